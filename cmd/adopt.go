@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/state"
+)
+
+// adoptCmd represents the adopt command
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Bring existing resources under configuration management",
+	Long: `Export resources that were created outside the CLI, such as in the PeerDB UI,
+to configuration files and record them as managed, so they can be changed with
+'config apply' from then on.`,
+}
+
+// adoptMirrorCmd represents the adopt mirror command
+var adoptMirrorCmd = &cobra.Command{
+	Use:   "mirror [mirror-name]",
+	Short: "Adopt an existing CDC mirror",
+	Long: `Export the live configuration of a CDC mirror to a YAML file and record it in
+the context's state (~/.mirror_cli/state/) as managed from that file. Applying
+the file right away shows no changes.`,
+	Example: `  mirror_cli adopt mirror orders_sync -o configs/mirrors/production/orders_sync.yaml`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return adoptMirror(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+	adoptCmd.AddCommand(adoptMirrorCmd)
+
+	adoptMirrorCmd.Flags().StringP("output", "o", "", "Output file path (default: configs/mirrors/<environment>/<name>.yaml)")
+	adoptMirrorCmd.Flags().String("environment", "", "Environment to set in metadata (default: the context's environment, or production)")
+	adoptMirrorCmd.Flags().Bool("force", false, "Overwrite an existing file and adopt a mirror that is already managed")
+}
+
+func adoptMirror(cmd *cobra.Command, mirrorName string) error {
+	output, _ := cmd.Flags().GetString("output")
+	environment := exportEnvironment(cmd)
+	force, _ := cmd.Flags().GetBool("force")
+
+	if output == "" {
+		output = fmt.Sprintf("configs/mirrors/%s/%s.yaml", environment, mirrorName)
+	}
+
+	st, path, err := loadState()
+	if err != nil {
+		return err
+	}
+	if managed, ok := st.Get("Mirror", mirrorName); ok && !force {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("mirror '%s' is already managed from %s (use --force to adopt it again)", mirrorName, managed.File)
+	}
+	if _, err := os.Stat(output); err == nil && !force {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", output)
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Printf("Adopting mirror '%s' into %s...\n", mirrorName, output)
+
+	status, err := client.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' is not a CDC mirror; only CDC mirrors can be adopted", mirrorName)
+	}
+
+	fileConfig := config.FromMirrorProto(status.CdcStatus.Config, environment)
+	if err := config.SaveConfigFileAs(fileConfig, output, config.FormatYAML); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	st.Manage(state.Resource{Kind: "Mirror", Name: mirrorName, File: absPath(output), Adopted: true})
+	if err := state.Save(st, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Mirror '%s' adopted; it is now managed from %s\n", mirrorName, output)
+	fmt.Printf("💡 Commit the file and change the mirror with 'mirror_cli config apply -f %s'\n", output)
+
+	return nil
+}
+
+// statePath returns the current context's state file
+func statePath() (string, error) {
+	cfg := GetConfig()
+	return state.Path(cfg.CurrentContext, cfg.Endpoints())
+}
+
+// loadState reads the current context's state and returns it with its path
+func loadState() (*state.State, string, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, "", err
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg := GetConfig()
+	st.Context = cfg.CurrentContext
+	st.Endpoint = strings.Join(cfg.Endpoints(), ",")
+	return st, path, nil
+}
+
+// recordManaged records applied configurations as managed in the context's
+// state. Failing to save it only warns, as the changes were made.
+func recordManaged(configs []*config.FileConfig) {
+	if len(configs) == 0 || GetConfig().Explain != nil {
+		return
+	}
+	st, path, err := loadState()
+	if err == nil {
+		now := time.Now().UTC()
+		for _, cfg := range configs {
+			r := state.Resource{Kind: cfg.Kind, Name: cfg.Metadata.Name, LastApplied: &now}
+			if cfg.Path != "" && cfg.Path != config.StdinPath {
+				r.File = absPath(cfg.Path)
+			}
+			if cfg.Kind == "Peer" {
+				r.SecretHash = peerSecretHash(cfg)
+			}
+			st.Manage(r)
+		}
+		err = state.Save(st, path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record managed resources: %v\n", err)
+	}
+}
+
+// peerSecretHash returns the secret hash of a peer config, or "" if it
+// can't be converted
+func peerSecretHash(cfg *config.FileConfig) string {
+	peer, err := cfg.ToPeerProto()
+	if err != nil {
+		return ""
+	}
+	hash, err := config.SecretHash(peer)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// absPath returns path made absolute, or path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
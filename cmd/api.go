@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/janakos/mirror_cli/internal/client"
+)
+
+// apiCmd represents the api command
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Call PeerDB RPCs directly",
+	Long:  "Low-level access to the PeerDB FlowService, for server features that don't have a dedicated command yet.",
+}
+
+// apiCallCmd represents the api call command
+var apiCallCmd = &cobra.Command{
+	Use:   "call <FlowService/Method>",
+	Short: "Invoke a FlowService RPC with a JSON request",
+	Long: `Invoke any FlowService RPC using the CLI's connection, TLS, and context settings.
+The request is given as JSON (protobuf JSON mapping) and the response is printed as JSON.
+Only methods in the protobuf definitions the CLI was built with can be called.`,
+	Example: `  mirror_cli api call FlowService/ListPeers
+  mirror_cli api call FlowService/MirrorStatus -d '{"flowJobName":"users_sync"}'
+  echo '{"flowJobName":"users_sync"}' | mirror_cli api call MirrorStatus -d @
+  mirror_cli api call GetPeerInfo -d @request.json`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, name := range client.MethodNames() {
+			names = append(names, "FlowService/"+name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return callAPI(cmd, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.AddCommand(apiCallCmd)
+
+	apiCallCmd.Flags().StringP("data", "d", "{}", "Request as JSON; @file reads it from a file and @ from stdin")
+	apiCallCmd.Flags().Bool("emit-defaults", false, "Include fields with default values in the response")
+	apiCallCmd.Flags().Duration("timeout", 0, "Timeout for the call (default: read_timeout for lookups, mutate_timeout for changes)")
+}
+
+func callAPI(cmd *cobra.Command, name string) error {
+	data, _ := cmd.Flags().GetString("data")
+	emitDefaults, _ := cmd.Flags().GetBool("emit-defaults")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	method, err := client.FindMethod(name)
+	if err != nil {
+		return err
+	}
+	fullMethod := client.FullMethodName(method)
+
+	payload, err := readRequestData(data)
+	if err != nil {
+		return err
+	}
+
+	req := dynamicpb.NewMessage(method.Input())
+	if err := protojson.Unmarshal(payload, req); err != nil {
+		return fmt.Errorf("invalid %s request: %w", method.Input().Name(), err)
+	}
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()), client.WithRPCTimeout(timeout))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Errors from here on are server responses, not usage mistakes
+	cmd.SilenceUsage = true
+
+	reply := dynamicpb.NewMessage(method.Output())
+	if err := client.Invoke(ctx, fullMethod, req, reply); err != nil {
+		return fmt.Errorf("%s failed: %w", method.Name(), err)
+	}
+
+	out, err := protojson.MarshalOptions{EmitUnpopulated: emitDefaults}.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	// protojson output has unstable whitespace
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", "  "); err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	fmt.Println(indented.String())
+	return nil
+}
+
+// readRequestData returns the request JSON given to -d, reading it from
+// stdin for "@" or from a file for "@path"
+func readRequestData(data string) ([]byte, error) {
+	if !strings.HasPrefix(data, "@") {
+		return []byte(data), nil
+	}
+
+	path := strings.TrimPrefix(data, "@")
+	if path == "" {
+		payload, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request from stdin: %w", err)
+		}
+		return payload, nil
+	}
+
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request file: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/provenance"
+)
+
+// configAppliedVersionCmd represents the config applied-version command
+var configAppliedVersionCmd = &cobra.Command{
+	Use:   "applied-version [mirror-name...]",
+	Short: "Show which git commit the live mirrors were applied from",
+	Long: `Show the git commit each mirror was applied from, as recorded in its
+provenance annotations by 'config apply' and 'reconcile', grouped by commit.
+Mirrors created without git, or before provenance was recorded, are listed as
+unknown. Only CDC mirrors carry annotations.`,
+	Example: `  mirror_cli config applied-version
+  mirror_cli config applied-version orders_mirror --mirrors`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showAppliedVersion(cmd, args)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configAppliedVersionCmd)
+
+	configAppliedVersionCmd.Flags().Bool("mirrors", false, "List every mirror instead of grouping by commit")
+	addAllPrefixesFlag(configAppliedVersionCmd)
+	addTableFlags(configAppliedVersionCmd)
+}
+
+// appliedRevision is the provenance of one mirror
+type appliedRevision struct {
+	mirror    string
+	repo      string
+	ref       string
+	sha       string
+	appliedAt time.Time
+}
+
+func showAppliedVersion(cmd *cobra.Command, names []string) error {
+	perMirror, _ := cmd.Flags().GetBool("mirrors")
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+	showNotes := tableOutput(cmd)
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for i, name := range names {
+		names[i] = resourceName(name)
+	}
+	revisions, hidden, err := appliedRevisions(ctx, client, names, ownedNames(cmd))
+	if err != nil {
+		return err
+	}
+	if showNotes {
+		printHidden(hidden, "mirror")
+		if len(revisions) == 0 {
+			fmt.Println("No CDC mirrors found")
+			return nil
+		}
+	}
+
+	if perMirror {
+		table := &printer.Table{Columns: []printer.Column{
+			{Header: "MIRROR", Key: "mirror"},
+			{Header: "COMMIT", Key: "commit", Format: shortSHAColumn},
+			{Header: "REF", Key: "ref"},
+			{Header: "APPLIED", Key: "applied_at", Format: timeColumn},
+			{Header: "REPOSITORY", Key: "repository"},
+		}}
+		for _, rev := range revisions {
+			table.AddRow(rev.mirror, orNil(rev.sha), orNil(rev.ref), timeOrNil(rev.appliedAt), orNil(rev.repo))
+		}
+		return printTable(cmd, table)
+	}
+
+	// Group by commit, most recently applied first
+	type group struct {
+		sha, repo string
+		mirrors   []string
+		latest    time.Time
+	}
+	groups := make(map[string]*group)
+	var order []*group
+	for _, rev := range revisions {
+		g, ok := groups[rev.sha]
+		if !ok {
+			g = &group{sha: rev.sha, repo: rev.repo}
+			groups[rev.sha] = g
+			order = append(order, g)
+		}
+		g.mirrors = append(g.mirrors, rev.mirror)
+		if rev.appliedAt.After(g.latest) {
+			g.latest = rev.appliedAt
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].latest.After(order[j].latest) })
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "COMMIT", Key: "commit", Format: shortSHAColumn},
+		{Header: "MIRRORS", Key: "mirror_count", Right: true},
+		{Header: "LAST APPLIED", Key: "last_applied_at", Format: timeColumn},
+		{Header: "REPOSITORY", Key: "repository"},
+		{Header: "NAMES", Key: "mirrors", Format: func(value interface{}) string {
+			return strings.Join(value.([]string), ", ")
+		}},
+	}}
+	known := 0
+	for _, g := range order {
+		var commit interface{} = "unknown"
+		if g.sha != "" {
+			commit = g.sha
+			known++
+		}
+		table.AddRow(commit, len(g.mirrors), timeOrNil(g.latest), orNil(g.repo), g.mirrors)
+	}
+	if err := printTable(cmd, table); err != nil {
+		return err
+	}
+	if !showNotes {
+		return nil
+	}
+
+	switch {
+	case known == 1 && len(order) == 1:
+		fmt.Printf("\n✅ All %d mirror(s) were applied from commit %s\n", len(revisions), order[0].sha)
+	case known > 1:
+		fmt.Printf("\n⚠ Mirrors were applied from %d different commits\n", known)
+	}
+	return nil
+}
+
+// appliedRevisions reads the provenance of the named mirrors, or of every
+// CDC mirror that passes owned, sorted by name. It also returns how many
+// mirrors owned hid.
+func appliedRevisions(ctx context.Context, grpcClient *client.Client, names []string, owned func(string) bool) ([]appliedRevision, int, error) {
+	list, err := grpcClient.ListMirrors(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list mirrors: %w", err)
+	}
+	mirrors, hidden := ownedMirrors(list.Mirrors, owned)
+	if len(names) > 0 {
+		hidden = 0
+		wanted := make(map[string]bool, len(names))
+		for _, name := range names {
+			wanted[name] = true
+		}
+		mirrors = nil
+		for _, mirror := range list.Mirrors {
+			if wanted[mirror.Name] {
+				mirrors = append(mirrors, mirror)
+				delete(wanted, mirror.Name)
+			}
+		}
+		for name := range wanted {
+			return nil, 0, fmt.Errorf("mirror '%s' not found", name)
+		}
+	}
+
+	var revisions []appliedRevision
+	for _, summary := range app.SummarizeMirrors(ctx, grpcClient, mirrors, time.Now()) {
+		if summary.Err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Skipping '%s': %v\n", summary.Name, summary.Err)
+			continue
+		}
+		if summary.Status.CdcStatus == nil || summary.Status.CdcStatus.Config == nil {
+			continue
+		}
+		env := summary.Status.CdcStatus.Config.Env
+		rev := appliedRevision{
+			mirror: summary.Name,
+			repo:   env[provenance.Prefix+"git_repo"],
+			ref:    env[provenance.Prefix+"git_ref"],
+			sha:    env[provenance.Prefix+"git_sha"],
+		}
+		rev.appliedAt, _ = time.Parse(time.RFC3339, env[provenance.Prefix+"applied_at"])
+		revisions = append(revisions, rev)
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].mirror < revisions[j].mirror })
+	return revisions, hidden, nil
+}
+
+func shortSHAColumn(value interface{}) string {
+	return shortSHA(value.(string))
+}
+
+// orNil returns nil for an empty string, so printers show it as unknown
+func orNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// timeOrNil returns nil for a zero time, so printers show it as unknown
+func timeOrNil(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// liveConfig returns the configuration cfg describes and that of the
+// existing resource, converted the same way so they can be compared. Both
+// are nil when the resource doesn't exist yet or can't be compared, such as
+// a QRep mirror.
+func liveConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig) (desired, actual *config.FileConfig, err error) {
+	switch {
+	case cfg.Kind == "Peer":
+		exists, err := grpcClient.PeerExists(ctx, cfg.Metadata.Name)
+		if err != nil || !exists {
+			return nil, nil, err
+		}
+		peer, err := cfg.ToPeerProto()
+		if err != nil {
+			return nil, nil, err
+		}
+		existing, err := grpcClient.GetPeer(ctx, cfg.Metadata.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if desired, err = config.FromPeerProto(peer, ""); err != nil {
+			return nil, nil, err
+		}
+		if actual, err = config.FromPeerProto(existing, ""); err != nil {
+			return nil, nil, err
+		}
+		return desired, actual, nil
+
+	case cfg.Kind == "Mirror" && !cfg.IsQRep():
+		exists, err := grpcClient.MirrorExists(ctx, cfg.Metadata.Name)
+		if err != nil || !exists {
+			return nil, nil, err
+		}
+		req, err := cfg.ToMirrorProto(GetConfig().Defaults())
+		if err != nil {
+			return nil, nil, err
+		}
+		status, err := grpcClient.GetMirrorStatus(ctx, cfg.Metadata.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+			return nil, nil, fmt.Errorf("mirror '%s' exists but is not a CDC mirror", cfg.Metadata.Name)
+		}
+		return config.FromMirrorProto(req.ConnectionConfigs, ""), config.FromMirrorProto(status.CdcStatus.Config, ""), nil
+	}
+	return nil, nil, nil
+}
+
+// printFieldDiffs prints changed fields as a unified diff, old values in
+// red and new ones in green. Lists show which items are removed and added.
+func printFieldDiffs(diffs []config.FieldDiff) {
+	for _, diff := range diffs {
+		fmt.Printf("    %s:\n", diff.Path)
+		oldItems, oldList := diff.Old.([]interface{})
+		newItems, newList := diff.New.([]interface{})
+		if !oldList || !newList {
+			if diff.Old != nil {
+				fmt.Println(red("  -     " + diffValue(diff.Old)))
+			}
+			fmt.Println(green("  +     " + diffValue(diff.New)))
+			continue
+		}
+
+		for _, item := range oldItems {
+			if !containsValue(newItems, item) {
+				fmt.Println(red("  -     " + diffValue(item)))
+			}
+		}
+		for _, item := range newItems {
+			if containsValue(oldItems, item) {
+				fmt.Println("        " + diffValue(item))
+			} else {
+				fmt.Println(green("  +     " + diffValue(item)))
+			}
+		}
+	}
+}
+
+// diffValue renders a field value on one line: scalars as they are and
+// lists or mappings as JSON
+func diffValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func containsValue(items []interface{}, value interface{}) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/cache"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local response cache",
+	Long:  "Commands for managing the local cache of list responses used by completion and list commands.",
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear the local response cache",
+	Long:  "Remove all cached responses for every PeerDB server.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return clearCache()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func clearCache() error {
+	if err := cache.ClearAll(); err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Cache cleared successfully")
+	return nil
+}
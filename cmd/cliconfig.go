@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// configExportCLICmd represents the config export-cli command
+var configExportCLICmd = &cobra.Command{
+	Use:   "export-cli",
+	Short: "Export the CLI's own configuration to share with a team",
+	Long: `Export ~/.mirror_cli/config.yaml, the CLI's contexts and settings, to share
+team-standard configuration. The current context is personal and left out.
+With --redact, passwords and secret headers (authorization, tokens, cookies)
+are left out too; otherwise the export warns about them.`,
+	Example: `  mirror_cli config export-cli --redact -o team.yaml
+  mirror_cli config export-cli --redact --context prod --context staging`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportCLIConfig(cmd)
+	},
+}
+
+// configImportCLICmd represents the config import-cli command
+var configImportCLICmd = &cobra.Command{
+	Use:   "import-cli <file>",
+	Short: "Merge a shared CLI configuration into your own",
+	Long: `Merge a configuration exported with 'config export-cli' into
+~/.mirror_cli/config.yaml. Contexts and settings you don't have are added.
+Settings you already have keep your value unless --overwrite is set, and
+empty imported values, such as redacted passwords, never replace yours.
+Comments in your file are kept. Hooks and credential helpers are commands
+the CLI runs, so an import that sets any is refused until you've reviewed
+them and pass --allow-exec.`,
+	Example: `  mirror_cli config import-cli team.yaml --dry-run
+  mirror_cli config import-cli team.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importCLIConfig(cmd, args[0])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configExportCLICmd)
+	configCmd.AddCommand(configImportCLICmd)
+
+	configExportCLICmd.Flags().Bool("redact", false, "Leave out passwords and secret headers")
+	configExportCLICmd.Flags().StringArray("context", nil, "Only export this context (repeatable)")
+	configExportCLICmd.Flags().StringP("output", "o", "", "Output file path (default: stdout)")
+
+	configImportCLICmd.Flags().Bool("overwrite", false, "Replace your values with the imported ones where they differ")
+	configImportCLICmd.Flags().Bool("dry-run", false, "Show what would change without writing the file")
+	configImportCLICmd.Flags().Bool("allow-exec", false, "Import hooks and credential helpers, which are commands the CLI runs")
+}
+
+func exportCLIConfig(cmd *cobra.Command) error {
+	redact, _ := cmd.Flags().GetBool("redact")
+	contexts, _ := cmd.Flags().GetStringArray("context")
+	output, _ := cmd.Flags().GetString("output")
+
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	data, secrets, err := config.ExportCLIConfig(path, contexts, redact)
+	if err != nil {
+		return fmt.Errorf("failed to export configuration: %w", err)
+	}
+
+	// Notes go to stderr when the export goes to stdout
+	notes := os.Stderr
+	if output == "" {
+		os.Stdout.Write(data)
+	} else {
+		if err := os.WriteFile(output, data, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		notes = os.Stdout
+		fmt.Printf("✅ CLI configuration exported to %s\n", output)
+	}
+
+	switch {
+	case len(secrets) > 0 && redact:
+		fmt.Fprintf(notes, "💡 Left out: %s\n", strings.Join(secrets, ", "))
+	case len(secrets) > 0:
+		fmt.Fprintf(notes, "⚠ The export contains secrets (%s); use --redact to leave them out\n", strings.Join(secrets, ", "))
+	}
+	return nil
+}
+
+func importCLIConfig(cmd *cobra.Command, file string) error {
+	cmd.SilenceUsage = true
+
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	allowExec, _ := cmd.Flags().GetBool("allow-exec")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+	commands, err := config.ExecSettings(data)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", file, err)
+	}
+	// A dry run writes nothing, so it lists the commands instead of refusing
+	merged, report, err := config.ImportCLIConfig(path, data, overwrite, allowExec || dryRun)
+	var execErr *config.ExecSettingsError
+	if errors.As(err, &execErr) {
+		return fmt.Errorf("%s sets commands the CLI would run (%s); review them in the file and pass --allow-exec to import them", file, strings.Join(execErr.Settings, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", file, err)
+	}
+
+	if len(report.Added) == 0 && len(report.Replaced) == 0 {
+		fmt.Printf("✓ Your configuration already has everything in %s\n", file)
+	} else if dryRun {
+		fmt.Printf("Importing %s would change %s:\n", file, path)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+		// The configuration can hold passwords. WriteFile keeps the mode of
+		// an existing file, so tighten it too.
+		if err := os.WriteFile(path, merged, 0600); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+		fmt.Printf("✅ Imported %s into %s\n", file, path)
+	}
+
+	if len(report.Added) > 0 {
+		fmt.Printf("  Added: %s\n", strings.Join(report.Added, ", "))
+	}
+	if len(report.Replaced) > 0 {
+		fmt.Printf("  Replaced: %s\n", strings.Join(report.Replaced, ", "))
+	}
+	if len(report.Kept) > 0 {
+		fmt.Printf("  Kept your value of: %s\n", strings.Join(report.Kept, ", "))
+		fmt.Println("💡 Use --overwrite to take the imported values instead")
+	}
+	if len(commands) > 0 {
+		fmt.Printf("⚠ Commands the CLI runs: %s\n", strings.Join(commands, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// completionInstallCmd represents the completion install command
+var completionInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the completion script for your shell",
+	Long: `Detect your shell from $SHELL and write its completion script where the
+shell loads completions from:
+
+  bash  $XDG_DATA_HOME/bash-completion/completions/mirror_cli
+  zsh   ~/.zfunc/_mirror_cli (add ~/.zfunc to fpath)
+  fish  $XDG_CONFIG_HOME/fish/completions/mirror_cli.fish
+
+Package managers such as Homebrew and Scoop install completions themselves;
+use this for binaries installed by hand.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return installCompletion(cmd)
+	},
+}
+
+// addCompletionInstallCmd attaches the install subcommand to cobra's
+// generated completion command
+func addCompletionInstallCmd() {
+	rootCmd.InitDefaultCompletionCmd()
+	for _, command := range rootCmd.Commands() {
+		if command.Name() == "completion" {
+			command.AddCommand(completionInstallCmd)
+			return
+		}
+	}
+}
+
+func init() {
+	completionInstallCmd.Flags().String("shell", "", "Shell to install for: bash, zsh, or fish (default: detected from $SHELL)")
+	completionInstallCmd.Flags().String("path", "", "Write the script to this file instead of the default location")
+}
+
+func installCompletion(cmd *cobra.Command) error {
+	shell, _ := cmd.Flags().GetString("shell")
+	path, _ := cmd.Flags().GetString("path")
+
+	if shell == "" {
+		shell = detectShell()
+		if shell == "" {
+			return fmt.Errorf("could not detect your shell from $SHELL; pass --shell bash, zsh, or fish")
+		}
+	}
+
+	var script bytes.Buffer
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(&script, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(&script)
+	case "fish":
+		err = rootCmd.GenFishCompletion(&script, true)
+	default:
+		return fmt.Errorf("unsupported shell: %s (expected bash, zsh, or fish); see 'mirror_cli completion --help' for others", shell)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s completion: %w", shell, err)
+	}
+
+	if path == "" {
+		path, err = completionPath(shell)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, script.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write completion script: %w", err)
+	}
+
+	fmt.Printf("✅ Installed %s completion to %s\n", shell, path)
+	switch shell {
+	case "bash":
+		fmt.Println("💡 Requires the bash-completion package; start a new shell to use it")
+	case "zsh":
+		fmt.Printf("💡 Make sure ~/.zshrc contains, before compinit:\n   fpath=(%s $fpath)\n   autoload -U compinit && compinit\n", filepath.Dir(path))
+	case "fish":
+		fmt.Println("💡 Start a new shell to use it")
+	}
+	return nil
+}
+
+// detectShell returns the name of the user's login shell
+func detectShell() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if shell == "." || shell == string(filepath.Separator) {
+		return ""
+	}
+	return strings.TrimSuffix(shell, ".exe")
+}
+
+// completionPath returns where a shell loads user completion scripts from
+func completionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	switch shell {
+	case "bash":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "bash-completion", "completions", "mirror_cli"), nil
+	case "zsh":
+		zdotdir := os.Getenv("ZDOTDIR")
+		if zdotdir == "" {
+			zdotdir = home
+		}
+		return filepath.Join(zdotdir, ".zfunc", "_mirror_cli"), nil
+	default:
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "fish", "completions", "mirror_cli.fish"), nil
+	}
+}
+
+// completionClient creates a client for shell completion, where the root
+// PersistentPreRunE hook has not loaded the configuration
+func completionClient() (*client.Client, error) {
+	cfg := GetConfig()
+	if cfg == nil {
+		var err error
+		cfg, err = config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return client.NewClient(commandContext(), client.WithConfig(cfg))
+}
+
+// completeMirrorNames completes the first argument with known mirror names
+func completeMirrorNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(commandContext(), 5*time.Second)
+	defer cancel()
+
+	client, err := completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer client.Close()
+
+	resp, err := client.ListMirrorNames(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return resp.Names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePeerNames completes the first argument with known peer names
+func completePeerNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(commandContext(), 5*time.Second)
+	defer cancel()
+
+	client, err := completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer client.Close()
+
+	resp, err := client.ListPeers(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := make([]string, 0, len(resp.Items))
+	for _, peer := range resp.Items {
+		names = append(names, peer.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
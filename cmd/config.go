@@ -2,14 +2,25 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/janakos/mirror_cli/internal/app"
 	"github.com/janakos/mirror_cli/internal/client"
 	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/gitsource"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
 )
 
 // configCmd represents the config command
@@ -25,7 +36,7 @@ var configShowCmd = &cobra.Command{
 	Short: "Show current configuration",
 	Long:  "Display the current CLI configuration settings.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return showConfig()
+		return showConfig(cmd)
 	},
 }
 
@@ -69,14 +80,24 @@ var configValidateCmd = &cobra.Command{
 	},
 }
 
+// configMigrateCmd represents the config migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate configuration files to the latest apiVersion",
+	Long:  "Rewrite apiVersion v1 configuration files in place using the v2 schema. Comments and unknown fields are preserved.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return migrateConfigs(cmd)
+	},
+}
+
 // configExportPeerCmd represents the config export-peer command
 var configExportPeerCmd = &cobra.Command{
 	Use:   "export-peer [peer-name]",
 	Short: "Export peer configuration to file",
-	Long:  "Export an existing peer configuration to a YAML file.",
+	Long:  "Export an existing peer configuration from PeerDB to a file. Use 'mirror_cli scaffold' for example templates.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return exportPeerConfig(cmd, args[0])
+		return exportPeerConfig(cmd, resourceName(args[0]))
 	},
 }
 
@@ -84,10 +105,31 @@ var configExportPeerCmd = &cobra.Command{
 var configExportMirrorCmd = &cobra.Command{
 	Use:   "export-mirror [mirror-name]",
 	Short: "Export mirror configuration to file",
-	Long:  "Export an existing mirror configuration to a YAML file.",
+	Long:  "Export an existing mirror configuration from PeerDB to a file. Use 'mirror_cli scaffold' for example templates.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return exportMirrorConfig(cmd, args[0])
+		return exportMirrorConfig(cmd, resourceName(args[0]))
+	},
+}
+
+// configImportContextCmd represents the config import-context command
+var configImportContextCmd = &cobra.Command{
+	Use:   "import-context",
+	Short: "Import a context from file",
+	Long:  "Import PeerDB server settings from a Context YAML file and make it the current context.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importContext(cmd)
+	},
+}
+
+// configUseContextCmd represents the config use-context command
+var configUseContextCmd = &cobra.Command{
+	Use:   "use-context [context-name]",
+	Short: "Switch the current context",
+	Long:  "Switch the current context to a previously imported one.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return useContext(args[0])
 	},
 }
 
@@ -98,8 +140,11 @@ func init() {
 	configCmd.AddCommand(configInitCmd)
 	configCmd.AddCommand(configApplyCmd)
 	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateCmd)
 	configCmd.AddCommand(configExportPeerCmd)
 	configCmd.AddCommand(configExportMirrorCmd)
+	configCmd.AddCommand(configImportContextCmd)
+	configCmd.AddCommand(configUseContextCmd)
 
 	// Set command flags
 	configSetCmd.Flags().String("host", "", "PeerDB server host")
@@ -107,41 +152,141 @@ func init() {
 	configSetCmd.Flags().Bool("tls", false, "Use TLS connection")
 	configSetCmd.Flags().String("username", "", "Username for authentication")
 	configSetCmd.Flags().String("password", "", "Password for authentication")
+	configSetCmd.Flags().String("drop-policy", "", "Whether mirror drops delete destination tables: keep-destination or drop-destination")
+	configSetCmd.Flags().String("environment", "", "Environment exports and applies default to (e.g. production, staging)")
+	configSetCmd.Flags().String("confirm-mode", "", "How destructive commands ask for confirmation: simple (y/N), typed (type the name), or off")
+	configSetCmd.Flags().String("name-prefix", "", "Prefix added to created peer and mirror names; list commands only show resources with it")
+	configSetCmd.Flags().String("peerdb-api", "", "FlowService API version to speak: auto, current, or legacy (PeerDB before v0.15)")
+	configSetCmd.Flags().String("default-destination-schema", "", "Schema that qualifies table mappings without a destination, e.g. ANALYTICS.PUBLIC")
+	configSetCmd.Flags().String("temporal-ui-url", "", "Temporal UI page of a mirror's workflow, with {workflow_id} in place of its ID")
+	configSetCmd.Flags().String("read-timeout", "", "Timeout of each lookup RPC, e.g. 30s (0 for no limit)")
+	configSetCmd.Flags().String("mutate-timeout", "", "Timeout of each RPC that changes server state, such as creating a mirror, e.g. 2m (0 for no limit)")
+	configSetCmd.Flags().String("wait-timeout", "", "Default limit on waits such as mirror create --wait and mirror cutover, e.g. 30m (0 for no limit)")
 
 	// Init command flags
 	configInitCmd.Flags().Bool("force", false, "Overwrite existing config file")
 
 	// Apply command flags
-	configApplyCmd.Flags().StringP("file", "f", "", "Configuration file or directory path")
+	configApplyCmd.Flags().StringP("file", "f", "", "Configuration file or directory path, or - to read YAML documents from stdin")
 	configApplyCmd.Flags().Bool("dry-run", false, "Show what would be applied without actually applying")
 	configApplyCmd.Flags().Bool("force", false, "Force apply even if resources already exist")
-	configApplyCmd.MarkFlagRequired("file")
+	addNormalizeFlag(configApplyCmd)
+	configApplyCmd.Flags().StringArray("annotate", []string{}, "Provenance annotation to stamp on created mirrors (key=value, repeatable)")
+	configApplyCmd.Flags().StringSlice("include", []string{}, "Only load files matching these glob patterns (relative to the directory, ** matches any path)")
+	configApplyCmd.Flags().StringSlice("exclude", []string{}, "Skip files matching these glob patterns")
+	configApplyCmd.Flags().String("policy", "", "Guardrail policy file to enforce (default: policy_file setting)")
+	configApplyCmd.Flags().StringArray("only", []string{}, "Only apply configs matching kind=<kind> or name=<glob> (repeatable)")
+	configApplyCmd.Flags().StringArray("skip", []string{}, "Skip configs matching kind=<kind> or name=<glob> (repeatable)")
+	configApplyCmd.Flags().Bool("no-diff", false, "Don't show the fields an update changes in existing peers and mirrors")
+	configApplyCmd.Flags().Bool("prune", false, "Drop peers and mirrors managed from files under --file that no longer define them")
+	configApplyCmd.Flags().Bool("sops", false, "Decrypt every file with sops, even without a sops metadata block (encrypted files are detected automatically)")
+	addVariableFlags(configApplyCmd)
+	addGitSourceFlags(configApplyCmd, "file")
 
 	// Validate command flags
-	configValidateCmd.Flags().StringP("file", "f", "", "Configuration file or directory path")
+	configValidateCmd.Flags().StringP("file", "f", "", "Configuration file or directory path, or - to read YAML documents from stdin")
+	configValidateCmd.Flags().StringSlice("include", []string{}, "Only validate files matching these glob patterns (relative to the directory, ** matches any path)")
+	configValidateCmd.Flags().StringSlice("exclude", []string{}, "Skip files matching these glob patterns")
+	configValidateCmd.Flags().String("policy", "", "Guardrail policy file to enforce (default: policy_file setting)")
+	configValidateCmd.Flags().Bool("sops", false, "Decrypt every file with sops, even without a sops metadata block (encrypted files are detected automatically)")
+	addVariableFlags(configValidateCmd)
 	configValidateCmd.MarkFlagRequired("file")
 
+	// Migrate command flags
+	configMigrateCmd.Flags().StringP("file", "f", "", "Configuration file or directory path")
+	configMigrateCmd.Flags().Bool("dry-run", false, "Print migrated files instead of rewriting them")
+	configMigrateCmd.Flags().StringSlice("include", []string{}, "Only migrate files matching these glob patterns (relative to the directory, ** matches any path)")
+	configMigrateCmd.Flags().StringSlice("exclude", []string{}, "Skip files matching these glob patterns")
+	configMigrateCmd.MarkFlagRequired("file")
+
 	// Export peer command flags
 	configExportPeerCmd.Flags().StringP("output", "o", "", "Output file path")
-	configExportPeerCmd.Flags().String("environment", "production", "Environment to set in metadata")
+	configExportPeerCmd.Flags().String("environment", "", "Environment to set in metadata (default: the context's environment, or production)")
+	configExportPeerCmd.Flags().String("format", config.FormatYAML, "Output format: yaml, json, or hcl")
 
 	// Export mirror command flags
 	configExportMirrorCmd.Flags().StringP("output", "o", "", "Output file path")
-	configExportMirrorCmd.Flags().String("environment", "production", "Environment to set in metadata")
+	configExportMirrorCmd.Flags().String("environment", "", "Environment to set in metadata (default: the context's environment, or production)")
+	configExportMirrorCmd.Flags().String("format", config.FormatYAML, "Output format: yaml, json, or hcl")
+
+	// Import context command flags
+	configImportContextCmd.Flags().StringP("file", "f", "", "Context configuration file path")
+	configImportContextCmd.Flags().Bool("no-switch", false, "Import the context without making it current")
+	addVariableFlags(configImportContextCmd)
+	configImportContextCmd.MarkFlagRequired("file")
 }
 
-func showConfig() error {
-	cfg, err := config.LoadConfig()
+func showConfig(cmd *cobra.Command) error {
+	output, err := reportOutput(cmd, printer.FormatJSON, printer.FormatYAML)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
+	}
+	cfg := GetConfig()
+	if output != printer.FormatText {
+		return printReport(output, newConfigReport(cfg))
 	}
 
 	fmt.Println("Current Configuration:")
+	if cfg.ContextSource != "" {
+		fmt.Printf("  Context:  %s (from %s)\n", cfg.CurrentContext, cfg.ContextSource)
+	} else if cfg.CurrentContext != "" {
+		fmt.Printf("  Context:  %s\n", cfg.CurrentContext)
+	}
 	fmt.Printf("  Host:     %s\n", cfg.PeerDBHost)
 	fmt.Printf("  Port:     %d\n", cfg.PeerDBPort)
 	fmt.Printf("  TLS:      %t\n", cfg.TLS)
 	fmt.Printf("  Username: %s\n", cfg.Username)
-	fmt.Printf("  Address:  %s\n", cfg.Address())
+	if endpoints := cfg.Endpoints(); len(endpoints) > 1 {
+		fmt.Printf("  Endpoints: %s (tried in order)\n", strings.Join(endpoints, ", "))
+	} else {
+		fmt.Printf("  Address:  %s\n", endpoints[0])
+	}
+	if cfg.DropPolicy != "" {
+		fmt.Printf("  Drop policy: %s\n", cfg.DropPolicy)
+	}
+	if cfg.Environment != "" {
+		fmt.Printf("  Environment: %s\n", cfg.Environment)
+	}
+	fmt.Printf("  Confirm mode: %s\n", cfg.Confirmation())
+	if cfg.NamePrefix != "" {
+		fmt.Printf("  Name prefix: %s\n", cfg.NamePrefix)
+	}
+	if cfg.RequireEnvironmentMatch {
+		fmt.Printf("  Require environment match: true\n")
+	}
+	if cfg.CredentialHelper != "" {
+		fmt.Printf("  Credential helper: %s\n", cfg.CredentialHelper)
+	}
+	if cfg.DefaultDestinationSchema != "" {
+		fmt.Printf("  Default destination schema: %s\n", cfg.DefaultDestinationSchema)
+	}
+	if cfg.TemporalUIURL != "" {
+		fmt.Printf("  Temporal UI URL: %s\n", cfg.TemporalUIURL)
+	}
+	if cfg.PeerDBAPI != "" {
+		fmt.Printf("  PeerDB API: %s\n", cfg.PeerDBAPI)
+	}
+	timeouts := cfg.Timeouts()
+	fmt.Printf("  Timeouts: read %s, mutate %s, wait %s\n", timeoutName(timeouts.Read), timeoutName(timeouts.Mutate), timeoutName(timeouts.Wait))
+	if cfg.ReadOnly {
+		fmt.Printf("  Read-only: true\n")
+	}
+	if len(cfg.ExtraHeaders) > 0 {
+		names := make([]string, 0, len(cfg.ExtraHeaders))
+		for name := range cfg.ExtraHeaders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("  Extra headers: %s\n", strings.Join(names, ", "))
+	}
+	if len(cfg.Hooks) > 0 {
+		names := make([]string, 0, len(cfg.Hooks))
+		for name := range cfg.Hooks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("  Hooks: %s\n", strings.Join(names, ", "))
+	}
 
 	if cfg.Password != "" {
 		fmt.Printf("  Password: [set]\n")
@@ -159,37 +304,122 @@ func setConfig(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	// Values apply to the current context when one is active, including
+	// one selected by MIRROR_CLI_CONTEXT or a .mirror_cli_context file
+	contextName := cfg.CurrentContext
+	if name, _, err := config.ContextOverride("."); err != nil {
+		return err
+	} else if name != "" {
+		contextName = strings.ToLower(name)
+	}
+	host, port, tls, username, password, dropPolicy, environment, confirmMode := &cfg.PeerDBHost, &cfg.PeerDBPort, &cfg.TLS, &cfg.Username, &cfg.Password, &cfg.DropPolicy, &cfg.Environment, &cfg.ConfirmMode
+	namePrefix, destinationSchema, peerdbAPI, temporalUI := &cfg.NamePrefix, &cfg.DefaultDestinationSchema, &cfg.PeerDBAPI, &cfg.TemporalUIURL
+	readTimeout, mutateTimeout, waitTimeout := &cfg.ReadTimeout, &cfg.MutateTimeout, &cfg.WaitTimeout
+	if contextName != "" {
+		ctx, ok := cfg.Contexts[strings.ToLower(contextName)]
+		if !ok {
+			return fmt.Errorf("current context %q not found in configuration", contextName)
+		}
+		host, port, tls, username, password, dropPolicy, environment, confirmMode = &ctx.PeerDBHost, &ctx.PeerDBPort, &ctx.TLS, &ctx.Username, &ctx.Password, &ctx.DropPolicy, &ctx.Environment, &ctx.ConfirmMode
+		namePrefix, destinationSchema, peerdbAPI, temporalUI = &ctx.NamePrefix, &ctx.DefaultDestinationSchema, &ctx.PeerDBAPI, &ctx.TemporalUIURL
+		readTimeout, mutateTimeout, waitTimeout = &ctx.ReadTimeout, &ctx.MutateTimeout, &ctx.WaitTimeout
+		fmt.Printf("Updating context: %s\n", contextName)
+	}
+
 	// Update values from flags
 	if cmd.Flags().Changed("host") {
-		host, _ := cmd.Flags().GetString("host")
-		cfg.PeerDBHost = host
-		fmt.Printf("Set host to: %s\n", host)
+		*host, _ = cmd.Flags().GetString("host")
+		fmt.Printf("Set host to: %s\n", *host)
 	}
 
 	if cmd.Flags().Changed("port") {
-		port, _ := cmd.Flags().GetInt("port")
-		cfg.PeerDBPort = port
-		fmt.Printf("Set port to: %d\n", port)
+		*port, _ = cmd.Flags().GetInt("port")
+		fmt.Printf("Set port to: %d\n", *port)
 	}
 
 	if cmd.Flags().Changed("tls") {
-		tls, _ := cmd.Flags().GetBool("tls")
-		cfg.TLS = tls
-		fmt.Printf("Set TLS to: %t\n", tls)
+		*tls, _ = cmd.Flags().GetBool("tls")
+		fmt.Printf("Set TLS to: %t\n", *tls)
 	}
 
 	if cmd.Flags().Changed("username") {
-		username, _ := cmd.Flags().GetString("username")
-		cfg.Username = username
-		fmt.Printf("Set username to: %s\n", username)
+		*username, _ = cmd.Flags().GetString("username")
+		fmt.Printf("Set username to: %s\n", *username)
 	}
 
 	if cmd.Flags().Changed("password") {
-		password, _ := cmd.Flags().GetString("password")
-		cfg.Password = password
+		*password, _ = cmd.Flags().GetString("password")
 		fmt.Println("Set password: [hidden]")
 	}
 
+	if cmd.Flags().Changed("drop-policy") {
+		*dropPolicy, _ = cmd.Flags().GetString("drop-policy")
+		if err := config.ValidateDropPolicy(*dropPolicy); err != nil {
+			return err
+		}
+		fmt.Printf("Set drop policy to: %s\n", *dropPolicy)
+	}
+
+	if cmd.Flags().Changed("environment") {
+		*environment, _ = cmd.Flags().GetString("environment")
+		fmt.Printf("Set environment to: %s\n", *environment)
+	}
+
+	if cmd.Flags().Changed("confirm-mode") {
+		*confirmMode, _ = cmd.Flags().GetString("confirm-mode")
+		if err := config.ValidateConfirmMode(*confirmMode); err != nil {
+			return err
+		}
+		fmt.Printf("Set confirm mode to: %s\n", *confirmMode)
+	}
+
+	if cmd.Flags().Changed("name-prefix") {
+		*namePrefix, _ = cmd.Flags().GetString("name-prefix")
+		if err := config.ValidateNamePrefix(*namePrefix); err != nil {
+			return err
+		}
+		fmt.Printf("Set name prefix to: %s\n", *namePrefix)
+	}
+
+	if cmd.Flags().Changed("peerdb-api") {
+		*peerdbAPI, _ = cmd.Flags().GetString("peerdb-api")
+		if err := config.ValidatePeerDBAPI(*peerdbAPI); err != nil {
+			return err
+		}
+		fmt.Printf("Set PeerDB API to: %s\n", *peerdbAPI)
+	}
+
+	if cmd.Flags().Changed("default-destination-schema") {
+		*destinationSchema, _ = cmd.Flags().GetString("default-destination-schema")
+		fmt.Printf("Set default destination schema to: %s\n", *destinationSchema)
+	}
+
+	if cmd.Flags().Changed("temporal-ui-url") {
+		*temporalUI, _ = cmd.Flags().GetString("temporal-ui-url")
+		if err := config.ValidateTemporalUIURL(*temporalUI); err != nil {
+			return err
+		}
+		fmt.Printf("Set Temporal UI URL to: %s\n", *temporalUI)
+	}
+
+	for _, timeout := range []struct {
+		flag, setting string
+		value         *string
+	}{
+		{"read-timeout", "read_timeout", readTimeout},
+		{"mutate-timeout", "mutate_timeout", mutateTimeout},
+		{"wait-timeout", "wait_timeout", waitTimeout},
+	} {
+		if !cmd.Flags().Changed(timeout.flag) {
+			continue
+		}
+		*timeout.value, _ = cmd.Flags().GetString(timeout.flag)
+		if err := config.ValidateTimeout(timeout.setting, *timeout.value); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s to: %s\n", timeout.setting, *timeout.value)
+	}
+
 	// Save the configuration
 	if err := config.SaveConfig(cfg); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
@@ -231,19 +461,61 @@ func applyConfigs(cmd *cobra.Command) error {
 	filePath, _ := cmd.Flags().GetString("file")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	force, _ := cmd.Flags().GetBool("force")
+	annotate, _ := cmd.Flags().GetStringArray("annotate")
+	onlySpecs, _ := cmd.Flags().GetStringArray("only")
+	skipSpecs, _ := cmd.Flags().GetStringArray("skip")
+	config.ForceSops, _ = cmd.Flags().GetBool("sops")
+	noDiff, _ := cmd.Flags().GetBool("no-diff")
+	prune, _ := cmd.Flags().GetBool("prune")
+	if err := applyVariableFlags(cmd); err != nil {
+		return err
+	}
+	if prune && (filePath == config.StdinPath || len(onlySpecs) > 0 || len(skipSpecs) > 0) {
+		return fmt.Errorf("--prune needs every configuration under --file, so it can't be combined with stdin, --only, or --skip")
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	// Check if path is a file or directory
-	fileInfo, err := os.Stat(filePath)
+	annotations, err := provenance.ParseAnnotations(annotate)
 	if err != nil {
-		return fmt.Errorf("failed to access path %s: %w", filePath, err)
+		return err
+	}
+	only, err := config.ParseFilters(onlySpecs)
+	if err != nil {
+		return err
+	}
+	skip, err := config.ParseFilters(skipSpecs)
+	if err != nil {
+		return err
+	}
+
+	if src := gitSource(cmd); src != nil {
+		if filePath == config.StdinPath {
+			return fmt.Errorf("--file - can't be combined with --git-repo")
+		}
+		cmd.SilenceUsage = true
+		var gitAnnotations map[string]string
+		var checkout *gitsource.Checkout
+		filePath, gitAnnotations, checkout, err = fetchGitSource(commandContext(), src, filePath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Fetched %s\n", gitRevisionLabel(src, checkout))
+		annotations = withGitAnnotations(gitAnnotations, annotations)
+	} else if filePath == "" {
+		return fmt.Errorf("--file or --git-repo is required")
 	}
 
+	ctx := commandContext()
+
 	var configs []*config.FileConfig
-	if fileInfo.IsDir() {
-		configs, err = config.LoadConfigsFromDirectory(filePath)
+	if filePath == config.StdinPath {
+		configs, err = config.LoadConfigStream(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to load configs from stdin: %w", err)
+		}
+	} else if fileInfo, err := os.Stat(filePath); err != nil {
+		return fmt.Errorf("failed to access path %s: %w", filePath, err)
+	} else if fileInfo.IsDir() {
+		configs, err = config.LoadConfigsFromDirectory(filePath, discoverOptions(cmd))
 		if err != nil {
 			return fmt.Errorf("failed to load configs from directory: %w", err)
 		}
@@ -255,35 +527,108 @@ func applyConfigs(cmd *cobra.Command) error {
 		configs = []*config.FileConfig{cfg}
 	}
 
-	if len(configs) == 0 {
+	configs, err = config.ResolveTemplates(configs)
+	if err != nil {
+		return err
+	}
+
+	if len(configs) == 0 && !prune {
 		fmt.Println("No configuration files found")
 		return nil
 	}
 
+	if len(only) > 0 || len(skip) > 0 {
+		total := len(configs)
+		configs = config.FilterConfigs(configs, only, skip)
+		fmt.Printf("Selected %d of %d configuration(s) with --only/--skip\n", len(configs), total)
+		if len(configs) == 0 {
+			return nil
+		}
+	}
+
+	if err := checkConfigNames(cmd, configs); err != nil {
+		return err
+	}
+
+	if GetConfig().RequireEnvironmentMatch && GetConfig().Environment == "" {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("require_environment_match is set, but no environment is configured; set one with 'mirror_cli config set --environment'")
+	}
+	if mismatched := checkEnvironments(configs); len(mismatched) > 0 && GetConfig().RequireEnvironmentMatch {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("refusing to apply %d config(s) for another environment: require_environment_match is set for %s ('%s')",
+			len(mismatched), contextLabel(GetConfig()), GetConfig().Environment)
+	}
+
 	// Create client for applying configurations
 	var grpcClient *client.Client
 	if !dryRun {
-		grpcClient, err = client.NewClient(GetConfig())
+		grpcClient, err = client.NewClient(commandContext(), client.WithConfig(GetConfig()))
 		if err != nil {
 			return fmt.Errorf("failed to create gRPC client: %w", err)
 		}
 		defer grpcClient.Close()
 	}
 
-	// Apply each configuration
+	// Check type mappings and enforce guardrails before anything is applied
+	resolve := peerTypeResolver(ctx, grpcClient, configs)
+	for _, cfg := range configs {
+		if err := checkDestination(cfg, resolve); err != nil {
+			cmd.SilenceUsage = true
+			return cfg.Locate(fmt.Errorf("%s '%s': %w", cfg.Kind, cfg.Metadata.Name, err))
+		}
+	}
+
+	pol, err := loadPolicy(cmd)
+	if err != nil {
+		return err
+	}
+	if pol != nil {
+		if err := checkPolicy(pol, configs, resolve); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+	}
+
+	// Apply each configuration, recording what was applied as managed
+	var applied []*config.FileConfig
+	defer func() { recordManaged(applied) }()
 	for _, cfg := range configs {
 		fmt.Printf("Processing %s '%s'...\n", cfg.Kind, cfg.Metadata.Name)
 
+		if cfg.Kind == "Context" {
+			fmt.Printf("  Skipped: use 'mirror_cli config import-context' to import contexts\n")
+			continue
+		}
+
 		if dryRun {
 			fmt.Printf("  [DRY-RUN] Would apply %s configuration\n", cfg.Kind)
 			continue
 		}
 
-		switch cfg.Kind {
-		case "Peer":
+		// Show what an update changes before applying it
+		var diffs []config.FieldDiff
+		var existing bool
+		desired, actual, err := liveConfig(ctx, grpcClient, cfg)
+		if err != nil {
+			fmt.Printf("  ⚠ Could not compare with the existing %s: %v\n", strings.ToLower(cfg.Kind), err)
+		} else if actual != nil {
+			existing = true
+			if diffs, err = config.DiffValues(desired, actual); err != nil {
+				return fmt.Errorf("failed to compare with existing %s: %w", strings.ToLower(cfg.Kind), err)
+			}
+			if !noDiff {
+				printFieldDiffs(diffs)
+			}
+		}
+
+		switch {
+		case cfg.Kind == "Peer":
 			err = applyPeerConfig(ctx, grpcClient, cfg, force)
-		case "Mirror":
-			err = applyMirrorConfig(ctx, grpcClient, cfg, force)
+		case cfg.Kind == "Mirror" && existing:
+			err = updateMirrorConfig(ctx, grpcClient, cfg, diffs, annotations)
+		case cfg.Kind == "Mirror":
+			err = applyMirrorConfig(ctx, grpcClient, cfg, annotations)
 		default:
 			err = fmt.Errorf("unsupported configuration kind: %s", cfg.Kind)
 		}
@@ -292,7 +637,17 @@ func applyConfigs(cmd *cobra.Command) error {
 			fmt.Printf("  ❌ Failed: %v\n", err)
 			return err
 		}
-		fmt.Printf("  ✅ Applied successfully\n")
+		applied = append(applied, cfg)
+		switch {
+		case !existing:
+			fmt.Printf("  ✅ Applied successfully\n")
+		case len(diffs) == 0 && cfg.Kind == "Peer":
+			fmt.Printf("  ✅ Updated; no field changes (secrets aren't compared)\n")
+		case len(diffs) == 0:
+			fmt.Printf("  ✅ Unchanged\n")
+		default:
+			fmt.Printf("  ✅ Updated %d field(s)\n", len(diffs))
+		}
 	}
 
 	if dryRun {
@@ -301,159 +656,287 @@ func applyConfigs(cmd *cobra.Command) error {
 		fmt.Printf("\n✅ Successfully applied %d configurations\n", len(configs))
 	}
 
+	if prune {
+		cmd.SilenceUsage = true
+		return pruneManaged(ctx, grpcClient, filePath, configs, dryRun)
+	}
 	return nil
 }
 
 func validateConfigs(cmd *cobra.Command) error {
 	filePath, _ := cmd.Flags().GetString("file")
+	config.ForceSops, _ = cmd.Flags().GetBool("sops")
 
-	// Check if path is a file or directory
-	fileInfo, err := os.Stat(filePath)
+	output, err := reportOutput(cmd, printer.FormatJSON)
 	if err != nil {
-		return fmt.Errorf("failed to access path %s: %w", filePath, err)
+		return err
+	}
+	if err := applyVariableFlags(cmd); err != nil {
+		return err
 	}
 
-	var configs []*config.FileConfig
-	if fileInfo.IsDir() {
-		configs, err = config.LoadConfigsFromDirectory(filePath)
+	var results []config.ValidationResult
+	if filePath == config.StdinPath {
+		results = config.ValidateStream(os.Stdin, GetConfig().Defaults())
+	} else {
+		files, err := config.FindConfigFiles(filePath, discoverOptions(cmd))
 		if err != nil {
-			return fmt.Errorf("failed to load configs from directory: %w", err)
+			return err
 		}
-	} else {
-		cfg, err := config.LoadConfigFile(filePath)
+
+		// Only directory scans skip YAML files that aren't CLI configurations
+		info, err := os.Stat(filePath)
 		if err != nil {
-			return fmt.Errorf("failed to load config file: %w", err)
+			return err
 		}
-		configs = []*config.FileConfig{cfg}
+
+		results = config.ValidateFiles(files, runtime.NumCPU(), info.IsDir(), GetConfig().Defaults())
 	}
 
-	if len(configs) == 0 {
+	if len(results) == 0 {
 		fmt.Println("No configuration files found")
 		return nil
 	}
 
-	allValid := true
-	for _, cfg := range configs {
-		fmt.Printf("Validating %s '%s'...\n", cfg.Kind, cfg.Metadata.Name)
-
-		var err error
-		switch cfg.Kind {
-		case "Peer":
-			_, err = cfg.ToPeerProto()
-		case "Mirror":
-			_, err = cfg.ToMirrorProto()
-		default:
-			err = fmt.Errorf("unsupported configuration kind: %s", cfg.Kind)
+	checkDestinationResults(results)
+
+	pol, err := loadPolicy(cmd)
+	if err != nil {
+		return err
+	}
+	if pol != nil {
+		applyPolicyToResults(pol, results)
+	}
+
+	invalid, skipped := 0, 0
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		} else if !result.Valid() {
+			invalid++
+		}
+	}
+
+	if output == "json" {
+		report := struct {
+			Valid   bool                      `json:"valid"`
+			Total   int                       `json:"total"`
+			Invalid int                       `json:"invalid"`
+			Skipped int                       `json:"skipped"`
+			Results []config.ValidationResult `json:"results"`
+		}{
+			Valid:   invalid == 0,
+			Total:   len(results) - skipped,
+			Invalid: invalid,
+			Skipped: skipped,
+			Results: results,
 		}
 
+		data, err := json.MarshalIndent(report, "", "  ")
 		if err != nil {
-			fmt.Printf("  ❌ Invalid: %v\n", err)
-			allValid = false
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, result := range results {
+			if result.Skipped {
+				continue
+			}
+
+			resource := "-"
+			if result.Kind != "" {
+				resource = fmt.Sprintf("%s '%s'", result.Kind, result.Name)
+			}
+
+			if result.Valid() {
+				fmt.Printf("✅ %s: %s\n", result.File, resource)
+				continue
+			}
+
+			location := result.File
+			switch {
+			case result.Column > 0:
+				location = fmt.Sprintf("%s:%d:%d", result.File, result.Line, result.Column)
+			case result.Line > 0:
+				location = fmt.Sprintf("%s:%d", result.File, result.Line)
+			}
+			fmt.Printf("❌ %s: %s\n", location, resource)
+			if result.Error != "" {
+				fmt.Printf("     %s\n", result.Error)
+			}
+			for _, violation := range result.Violations {
+				fmt.Printf("     policy %s\n", violation)
+			}
+		}
+
+		total := len(results) - skipped
+		if invalid == 0 {
+			fmt.Printf("\n✅ All %d configurations are valid\n", total)
 		} else {
-			fmt.Printf("  ✅ Valid\n")
+			fmt.Printf("\n❌ %d of %d configurations are invalid\n", invalid, total)
+		}
+		if skipped > 0 {
+			fmt.Printf("Skipped %d YAML files that are not mirror_cli configurations\n", skipped)
 		}
 	}
 
-	if allValid {
-		fmt.Printf("\n✅ All %d configurations are valid\n", len(configs))
-	} else {
-		fmt.Printf("\n❌ Some configurations are invalid\n")
+	if invalid > 0 {
+		cmd.SilenceUsage = true
 		return fmt.Errorf("validation failed")
 	}
 
 	return nil
 }
 
+func migrateConfigs(cmd *cobra.Command) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	files, err := config.FindConfigFiles(filePath, discoverOptions(cmd))
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		fmt.Println("No configuration files found")
+		return nil
+	}
+
+	migrated, failed := 0, 0
+	for _, file := range files {
+		// Templates only become YAML once rendered, so they can't be rewritten safely
+		if strings.HasSuffix(file, ".gotmpl") {
+			fmt.Printf("⚠ %s: skipped (templates must be migrated by hand)\n", file)
+			continue
+		}
+
+		data, changed, err := config.MigrateFile(file)
+		if err != nil {
+			if info.IsDir() && errors.Is(err, config.ErrUnknownKind) {
+				continue
+			}
+			fmt.Printf("❌ %s: %v\n", file, err)
+			failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("# %s\n%s\n", file, data)
+		} else if err := os.WriteFile(file, data, 0644); err != nil {
+			fmt.Printf("❌ %s: failed to write file: %v\n", file, err)
+			failed++
+			continue
+		} else {
+			fmt.Printf("✓ %s\n", file)
+		}
+		migrated++
+	}
+
+	if dryRun {
+		fmt.Printf("\n%d file(s) would be migrated to %s\n", migrated, config.APIVersionV2)
+	} else {
+		fmt.Printf("\n✅ Migrated %d file(s) to %s\n", migrated, config.APIVersionV2)
+	}
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d file(s) could not be migrated", failed)
+	}
+
+	return nil
+}
+
 func exportPeerConfig(cmd *cobra.Command, peerName string) error {
 	output, _ := cmd.Flags().GetString("output")
-	environment, _ := cmd.Flags().GetString("environment")
+	environment := exportEnvironment(cmd)
+	format, _ := cmd.Flags().GetString("format")
+
+	if err := config.ValidateFormat(format); err != nil {
+		return err
+	}
 
 	// Default output path if not specified
 	if output == "" {
-		output = fmt.Sprintf("configs/peers/%s/%s.yaml", environment, peerName)
+		output = fmt.Sprintf("configs/peers/%s/%s.%s", environment, peerName, config.FormatExtension(format))
 	}
 
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
 	fmt.Printf("Exporting peer '%s' to %s...\n", peerName, output)
-	
-	// TODO: Implement peer export by fetching from PeerDB
-	// For now, create a template
-	fileConfig := &config.FileConfig{
-		APIVersion: "v1",
-		Kind:       "Peer",
-		Metadata: config.Metadata{
-			Name:        peerName,
-			Environment: environment,
-			Description: fmt.Sprintf("Configuration for %s peer", peerName),
-		},
-		Spec: config.Spec{
-			Type: "postgres", // Default, should be detected
-			Config: config.PostgresConfig{
-				Host:     "localhost",
-				Port:     5432,
-				User:     "postgres",
-				Password: "${POSTGRES_PASSWORD}",
-				Database: "mydb",
-			},
-		},
-	}
-
-	if err := config.SaveConfigFile(fileConfig, output); err != nil {
+
+	peer, err := client.GetPeer(ctx, peerName)
+	if err != nil {
+		return fmt.Errorf("failed to get peer: %w", err)
+	}
+
+	fileConfig, err := config.FromPeerProto(peer, environment)
+	if err != nil {
+		return err
+	}
+
+	if err := config.SaveConfigFileAs(fileConfig, output, format); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
 	fmt.Printf("✅ Peer configuration exported to %s\n", output)
-	fmt.Printf("💡 Note: Update the configuration with actual values before applying\n")
+	fmt.Printf("💡 Note: Secrets were replaced with ${VAR} placeholders; set them before applying\n")
 
 	return nil
 }
 
 func exportMirrorConfig(cmd *cobra.Command, mirrorName string) error {
 	output, _ := cmd.Flags().GetString("output")
-	environment, _ := cmd.Flags().GetString("environment")
+	environment := exportEnvironment(cmd)
+	format, _ := cmd.Flags().GetString("format")
+
+	if err := config.ValidateFormat(format); err != nil {
+		return err
+	}
 
 	// Default output path if not specified
 	if output == "" {
-		output = fmt.Sprintf("configs/mirrors/%s/%s.yaml", environment, mirrorName)
+		output = fmt.Sprintf("configs/mirrors/%s/%s.%s", environment, mirrorName, config.FormatExtension(format))
 	}
 
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
 	fmt.Printf("Exporting mirror '%s' to %s...\n", mirrorName, output)
-	
-	// TODO: Implement mirror export by fetching from PeerDB
-	// For now, create a template
-	fileConfig := &config.FileConfig{
-		APIVersion: "v1",
-		Kind:       "Mirror",
-		Metadata: config.Metadata{
-			Name:        mirrorName,
-			Environment: environment,
-			Description: fmt.Sprintf("Configuration for %s mirror", mirrorName),
-		},
-		Spec: config.Spec{
-			Type:        "cdc",
-			Source:      "postgres_source",
-			Destination: "snowflake_warehouse",
-			Tables: []config.TableConfig{
-				{
-					Source:      "public.example_table",
-					Destination: "ANALYTICS_DB.PUBLIC.EXAMPLE_TABLE",
-				},
-			},
-			CDC: &config.CDCConfig{
-				BatchSize:           1000,
-				IdleTimeoutSeconds:  60,
-				InitialSnapshot:     true,
-				PublicationName:     "peerdb_pub",
-				ReplicationSlotName: "peerdb_slot",
-			},
-		},
-	}
-
-	if err := config.SaveConfigFile(fileConfig, output); err != nil {
+
+	status, err := client.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' has no CDC configuration to export", mirrorName)
+	}
+
+	fileConfig := config.FromMirrorProto(status.CdcStatus.Config, environment)
+
+	if err := config.SaveConfigFileAs(fileConfig, output, format); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
 	fmt.Printf("✅ Mirror configuration exported to %s\n", output)
-	fmt.Printf("💡 Note: Update the configuration with actual values before applying\n")
 
 	return nil
 }
@@ -461,19 +944,267 @@ func exportMirrorConfig(cmd *cobra.Command, mirrorName string) error {
 func applyPeerConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig, force bool) error {
 	peer, err := cfg.ToPeerProto()
 	if err != nil {
-		return fmt.Errorf("failed to convert config to peer: %w", err)
+		return cfg.Locate(fmt.Errorf("failed to convert config to peer: %w", err))
 	}
 
 	_, err = grpcClient.CreatePeer(ctx, peer, force)
 	return err
 }
 
-func applyMirrorConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig, force bool) error {
-	mirrorReq, err := cfg.ToMirrorProto()
+func applyMirrorConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig, annotations map[string]string) error {
+	if cfg.IsQRep() {
+		return applyQRepConfig(ctx, grpcClient, cfg, annotations)
+	}
+
+	mirrorReq, err := mirrorRequest(cfg, annotations)
+	if err != nil {
+		return err
+	}
+
+	checkReplicationNames(ctx, grpcClient, mirrorReq.ConnectionConfigs)
+
+	resp, err := grpcClient.CreateCDCMirror(ctx, mirrorReq)
+	if err != nil {
+		return err
+	}
+	recordWorkflow(cfg.Metadata.Name, resp.WorkflowId)
+	return nil
+}
+
+// mirrorRequest converts a CDC mirror config into the request that creates
+// it, recording where the mirror came from and the applied spec in its env
+func mirrorRequest(cfg *config.FileConfig, annotations map[string]string) (*pb.CreateCDCFlowRequest, error) {
+	mirrorReq, err := cfg.ToMirrorProto(GetConfig().Defaults())
 	if err != nil {
-		return fmt.Errorf("failed to convert config to mirror: %w", err)
+		return nil, cfg.Locate(fmt.Errorf("failed to convert config to mirror: %w", err))
 	}
 
-	_, err = grpcClient.CreateCDCMirror(ctx, mirrorReq)
-	return err
+	connectionConfigs := mirrorReq.ConnectionConfigs
+	connectionConfigs.Env = provenance.Merge(connectionConfigs.Env, provenance.Collect(filepath.Dir(cfg.Path), annotations))
+
+	// Record the applied spec, so status can tell if the mirror drifted
+	hash, err := config.SpecHash(connectionConfigs)
+	if err != nil {
+		return nil, err
+	}
+	connectionConfigs.Env[config.SpecHashKey] = hash
+	return mirrorReq, nil
+}
+
+// updateMirrorConfig applies the fields that differ on an existing CDC
+// mirror with a config update: its tables, batch size, and idle timeout.
+// PeerDB can only change other fields by resyncing the mirror, so those
+// fail the apply instead of being left out silently. An unchanged mirror
+// applied from another git commit is updated to record it, without being
+// paused.
+func updateMirrorConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig, diffs []config.FieldDiff, annotations map[string]string) error {
+	mirrorReq, err := mirrorRequest(cfg, annotations)
+	if err != nil {
+		return err
+	}
+	status, err := grpcClient.GetMirrorStatus(ctx, cfg.Metadata.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	desired, actual := mirrorReq.ConnectionConfigs, status.GetCdcStatus().GetConfig()
+	if actual == nil {
+		return fmt.Errorf("mirror '%s' exists but is not a CDC mirror", cfg.Metadata.Name)
+	}
+	if len(diffs) == 0 && !provenance.RevisionChanged(actual.Env, desired.Env) {
+		return nil
+	}
+
+	paths := make([]string, len(diffs))
+	for i, diff := range diffs {
+		paths[i] = diff.Path
+	}
+	edit, resync := mirrorChanges(desired, actual, paths)
+	if len(resync) > 0 {
+		return fmt.Errorf("PeerDB can't change %s on an existing mirror without a resync; drop the mirror and apply it again", strings.Join(resync, ", "))
+	}
+
+	// Keep the recorded provenance and spec current
+	edit.Env = changedEnv(desired.Env, actual.Env)
+	// Apply reports the update itself
+	service := &app.Mirrors{Client: grpcClient, Out: app.NewPrinter(io.Discard)}
+	return service.Edit(ctx, cfg.Metadata.Name, edit)
+}
+
+// changedEnv returns the entries of desired that differ in actual
+func changedEnv(desired, actual map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for key, value := range desired {
+		if actual[key] != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+func applyQRepConfig(ctx context.Context, grpcClient *client.Client, cfg *config.FileConfig, annotations map[string]string) error {
+	req, err := cfg.ToQRepProto()
+	if err != nil {
+		return cfg.Locate(fmt.Errorf("failed to convert config to mirror: %w", err))
+	}
+
+	// Record where the mirror came from
+	qrepConfig := req.QrepConfig
+	qrepConfig.Env = provenance.Merge(qrepConfig.Env, provenance.Collect(filepath.Dir(cfg.Path), annotations))
+
+	resp, err := grpcClient.CreateQRepMirror(ctx, req)
+	if err != nil {
+		return err
+	}
+	recordWorkflow(cfg.Metadata.Name, resp.WorkflowId)
+	return nil
+}
+
+func importContext(cmd *cobra.Command) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	noSwitch, _ := cmd.Flags().GetBool("no-switch")
+	if err := applyVariableFlags(cmd); err != nil {
+		return err
+	}
+
+	fileConfig, err := config.LoadConfigFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+
+	ctx, err := fileConfig.ToContext()
+	if err != nil {
+		return fmt.Errorf("invalid context configuration: %w", err)
+	}
+	// Keep secrets out of config.yaml; they are resolved when connecting
+	if err := config.KeepContextReferences(filePath, ctx); err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	name := strings.ToLower(fileConfig.Metadata.Name)
+	if name == "" {
+		return fmt.Errorf("context requires metadata.name")
+	}
+
+	cfg.SetContext(name, ctx)
+	if !noSwitch {
+		cfg.CurrentContext = name
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("✓ Context '%s' imported (%s:%d)\n", name, ctx.PeerDBHost, ctx.PeerDBPort)
+	if !noSwitch {
+		fmt.Printf("  Switched to context '%s'\n", name)
+	}
+
+	return nil
+}
+
+func useContext(name string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	name = strings.ToLower(name)
+	if _, ok := cfg.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found; import it with 'mirror_cli config import-context -f <file>'", name)
+	}
+
+	cfg.CurrentContext = name
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("✓ Switched to context '%s'\n", name)
+	if override, source, _ := config.ContextOverride("."); override != "" && !strings.EqualFold(override, name) {
+		fmt.Printf("⚠ Context '%s' from %s still takes precedence here\n", override, source)
+	}
+	return nil
+}
+
+// discoverOptions builds directory discovery options from --include/--exclude
+func discoverOptions(cmd *cobra.Command) config.DiscoverOptions {
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	return config.DiscoverOptions{Include: include, Exclude: exclude}
+}
+
+// configReport is the config show document. Secrets are reported as set
+// or not, and extra headers by name only.
+type configReport struct {
+	Context                  string         `json:"context,omitempty"`
+	ContextSource            string         `json:"context_source,omitempty"`
+	Host                     string         `json:"host"`
+	Port                     int            `json:"port"`
+	TLS                      bool           `json:"tls"`
+	Username                 string         `json:"username"`
+	PasswordSet              bool           `json:"password_set"`
+	Endpoints                []string       `json:"endpoints"`
+	DropPolicy               string         `json:"drop_policy,omitempty"`
+	Environment              string         `json:"environment,omitempty"`
+	ConfirmMode              string         `json:"confirm_mode"`
+	NamePrefix               string         `json:"name_prefix,omitempty"`
+	RequireEnvironmentMatch  bool           `json:"require_environment_match"`
+	CredentialHelper         string         `json:"credential_helper,omitempty"`
+	DefaultDestinationSchema string         `json:"default_destination_schema,omitempty"`
+	TemporalUIURL            string         `json:"temporal_ui_url,omitempty"`
+	PeerDBAPI                string         `json:"peerdb_api,omitempty"`
+	Timeouts                 timeoutsReport `json:"timeouts"`
+	ReadOnly                 bool           `json:"read_only"`
+	ExtraHeaders             []string       `json:"extra_headers,omitempty"`
+	Hooks                    []string       `json:"hooks,omitempty"`
+}
+
+// timeoutsReport lists the timeouts in effect, in seconds; 0 is no limit
+type timeoutsReport struct {
+	ReadSeconds   int64 `json:"read_seconds"`
+	MutateSeconds int64 `json:"mutate_seconds"`
+	WaitSeconds   int64 `json:"wait_seconds"`
+}
+
+// newConfigReport builds the config show document
+func newConfigReport(cfg *config.Config) configReport {
+	report := configReport{
+		Context:                  cfg.CurrentContext,
+		ContextSource:            cfg.ContextSource,
+		Host:                     cfg.PeerDBHost,
+		Port:                     cfg.PeerDBPort,
+		TLS:                      cfg.TLS,
+		Username:                 cfg.Username,
+		PasswordSet:              cfg.Password != "",
+		Endpoints:                cfg.Endpoints(),
+		DropPolicy:               cfg.DropPolicy,
+		Environment:              cfg.Environment,
+		ConfirmMode:              cfg.Confirmation(),
+		NamePrefix:               cfg.NamePrefix,
+		RequireEnvironmentMatch:  cfg.RequireEnvironmentMatch,
+		CredentialHelper:         cfg.CredentialHelper,
+		DefaultDestinationSchema: cfg.DefaultDestinationSchema,
+		TemporalUIURL:            cfg.TemporalUIURL,
+		PeerDBAPI:                cfg.PeerDBAPI,
+		ReadOnly:                 cfg.ReadOnly,
+	}
+	timeouts := cfg.Timeouts()
+	report.Timeouts = timeoutsReport{
+		ReadSeconds:   int64(timeouts.Read.Seconds()),
+		MutateSeconds: int64(timeouts.Mutate.Seconds()),
+		WaitSeconds:   int64(timeouts.Wait.Seconds()),
+	}
+	for name := range cfg.ExtraHeaders {
+		report.ExtraHeaders = append(report.ExtraHeaders, name)
+	}
+	sort.Strings(report.ExtraHeaders)
+	for name := range cfg.Hooks {
+		report.Hooks = append(report.Hooks, name)
+	}
+	sort.Strings(report.Hooks)
+	return report
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// stdin reads the answers to prompts. All of them share one reader, so a
+// line it buffers past the current answer is left for the next prompt
+// instead of being lost, as when answers are piped in.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readAnswer reads a line answering a prompt, without surrounding spaces.
+// It returns "" at the end of input.
+func readAnswer() string {
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// confirmDestructive asks before a destructive operation on a resource,
+// as set by the context's confirm_mode: a y/N question, typing the
+// resource's name, or nothing. typed forces the typed prompt regardless of
+// the mode unless confirmations are off.
+func confirmDestructive(kind, name, question string, typed bool) bool {
+	mode := GetConfig().Confirmation()
+	if typed && mode != config.ConfirmModeOff {
+		mode = config.ConfirmModeTyped
+	}
+
+	switch mode {
+	case config.ConfirmModeOff:
+		return true
+	case config.ConfirmModeTyped:
+		fmt.Printf("%s This action cannot be undone.\n", question)
+		return confirmByName(kind, name)
+	default:
+		fmt.Printf("%s This action cannot be undone. (y/N): ", question)
+		response := strings.ToLower(readAnswer())
+		return response == "y" || response == "yes"
+	}
+}
+
+// confirmByName asks the user to type a resource's name to confirm a
+// destructive operation, like deleting a GitHub repository
+func confirmByName(kind, name string) bool {
+	fmt.Printf("Type the %s name '%s' to confirm: ", kind, name)
+	return readAnswer() == name
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// TestConfirmSharesStdin answers a y/N prompt and then the typed prompt
+// for dropping an active mirror from one stdin, as when answers are piped
+func TestConfirmSharesStdin(t *testing.T) {
+	defer func(c *config.Config, r *bufio.Reader) { cfg, stdin = c, r }(cfg, stdin)
+	cfg = &config.Config{ConfirmMode: config.ConfirmModeSimple}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []bool
+	}{
+		{"both confirmed", "y\norders\n", []bool{true, true}},
+		{"spaces and case", " YES \r\n orders \r\n", []bool{true, true}},
+		{"no final newline", "y\norders", []bool{true, true}},
+		{"first declined", "n\norders\n", []bool{false, true}},
+		{"wrong name", "y\norder\n", []bool{true, false}},
+		{"answers run out", "y\n", []bool{true, false}},
+		{"empty", "", []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = bufio.NewReader(strings.NewReader(tt.input))
+			got := []bool{
+				confirmDestructive("peer", "pg_source", "Drop peer 'pg_source'?", false),
+				// mirror drop types the name for a mirror that appears active
+				confirmDestructive("mirror", "orders", "Drop mirror 'orders'?", true),
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
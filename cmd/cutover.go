@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/poller"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorCutoverCmd represents the mirror cutover command
+var mirrorCutoverCmd = &cobra.Command{
+	Use:   "cutover <mirror-name>",
+	Short: "Drain and pause a mirror for a database migration cutover",
+	Long: `Orchestrate the final steps of a migration that uses PeerDB:
+
+  1. Wait until the mirror's replication slot lag reaches --max-lag-mb
+  2. Pause the mirror and wait for it to stop
+  3. Check that synced row counts no longer change
+  4. Print a cutover summary with the final LSN and timestamps
+  5. With --drop, drop the mirror, keeping the destination tables
+
+Stop writes to the source database before running this command.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cutoverMirror(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorCutoverCmd)
+
+	mirrorCutoverCmd.Flags().Float64("max-lag-mb", 0, "Replication slot lag (MB) at which to pause")
+	mirrorCutoverCmd.Flags().Duration("timeout", 0, "Give up if the cutover takes longer than this, 0 for no limit (default: wait_timeout)")
+	mirrorCutoverCmd.Flags().Duration("poll-interval", 10*time.Second, "How often to check lag and state")
+	mirrorCutoverCmd.Flags().Duration("settle", 30*time.Second, "How long row counts must stay unchanged after pausing")
+	mirrorCutoverCmd.Flags().Bool("drop", false, "Drop the mirror after a successful cutover (destination tables are kept)")
+	mirrorCutoverCmd.Flags().Bool("force", false, "Drop without confirmation")
+}
+
+// cutoverReport records what happened during a cutover
+type cutoverReport struct {
+	started  time.Time
+	drained  time.Time
+	paused   time.Time
+	finalLSN string
+	counts   *pb.CDCTableTotalCountsResponse
+}
+
+func cutoverMirror(cmd *cobra.Command, mirrorName string) error {
+	maxLag, _ := cmd.Flags().GetFloat64("max-lag-mb")
+	timeout := waitTimeout(cmd, "timeout")
+	interval, _ := cmd.Flags().GetDuration("poll-interval")
+	settle, _ := cmd.Flags().GetDuration("settle")
+	drop, _ := cmd.Flags().GetBool("drop")
+	force, _ := cmd.Flags().GetBool("force")
+
+	cmd.SilenceUsage = true
+
+	ctx, cancel := withWaitTimeout(commandContext(), timeout)
+	defer cancel()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	report := cutoverReport{started: time.Now()}
+
+	status, err := client.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' is not a CDC mirror", mirrorName)
+	}
+	if status.CurrentFlowState != pb.FlowStatus_STATUS_RUNNING {
+		return fmt.Errorf("mirror '%s' is %s; it must be running to drain", mirrorName, stateName(status.CurrentFlowState))
+	}
+
+	source := status.CdcStatus.Config.SourceName
+	slot := status.CdcStatus.Config.ReplicationSlotName
+	if slot == "" {
+		slot = config.DefaultReplicationSlotName(mirrorName)
+	}
+
+	// 1. Drain
+	fmt.Printf("Waiting for slot '%s' on '%s' to drain to %.1f MB...\n", slot, source, maxLag)
+	err = pollUntil(ctx, interval, func() (bool, error) {
+		info, err := findSlot(ctx, client, source, slot)
+		if err != nil {
+			return false, err
+		}
+		if info.LagInMb <= float32(maxLag) {
+			return true, nil
+		}
+		fmt.Printf("  lag %.1f MB\n", info.LagInMb)
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("slot did not drain: %w", err)
+	}
+	report.drained = time.Now()
+	fmt.Println("✓ Replication slot drained")
+
+	// 2. Pause
+	if err := client.PauseMirror(ctx, mirrorName); err != nil {
+		return fmt.Errorf("failed to pause mirror: %w", err)
+	}
+	err = pollUntil(ctx, interval, func() (bool, error) {
+		status, err := client.GetMirrorStatus(ctx, mirrorName)
+		if err != nil {
+			return false, err
+		}
+		return status.CurrentFlowState == pb.FlowStatus_STATUS_PAUSED, nil
+	})
+	if err != nil {
+		return fmt.Errorf("mirror did not pause: %w", err)
+	}
+	report.paused = time.Now()
+	fmt.Println("✓ Mirror paused")
+
+	// 3. Verify row counts are final
+	before, err := client.GetTableRowCounts(ctx, mirrorName)
+	switch {
+	case skipUnsupported(err, "row count verification"):
+		fmt.Println("⚠ Row counts were not verified; compare source and destination before switching over")
+	case err != nil:
+		return fmt.Errorf("failed to get row counts: %w", err)
+	default:
+		fmt.Printf("Checking that row counts stay unchanged for %s...\n", settle)
+		select {
+		case <-time.After(settle):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		after, err := client.GetTableRowCounts(ctx, mirrorName)
+		if err != nil {
+			return fmt.Errorf("failed to get row counts: %w", err)
+		}
+		if changed := changedTables(before, after); len(changed) > 0 {
+			return fmt.Errorf("row counts changed after pausing (%s); the mirror is left paused, resume it with 'mirror_cli mirror resume %s'",
+				strings.Join(changed, ", "), mirrorName)
+		}
+		report.counts = after
+		fmt.Println("✓ Row counts are final")
+	}
+
+	// 4. Final position of the slot
+	if info, err := findSlot(ctx, client, source, slot); err != nil {
+		fmt.Printf("⚠ Could not read the final LSN: %v\n", err)
+	} else {
+		report.finalLSN = info.ConfirmedFlushLSN
+	}
+
+	printCutoverReport(mirrorName, report, drop)
+
+	// 5. Drop
+	if !drop {
+		return nil
+	}
+	if !force {
+		fmt.Println()
+		question := fmt.Sprintf("Drop mirror '%s'? Destination tables are kept.", mirrorName)
+		if !confirmDestructive("mirror", mirrorName, question, false) {
+			fmt.Println("Mirror left paused")
+			return nil
+		}
+	}
+	if err := client.DropMirror(ctx, mirrorName, true); err != nil {
+		return fmt.Errorf("failed to drop mirror: %w", err)
+	}
+	fmt.Printf("✓ Mirror '%s' dropped\n", mirrorName)
+	return nil
+}
+
+// pollUntil calls check about every interval, with jitter, until it returns
+// true, an error, or ctx is done
+func pollUntil(ctx context.Context, interval time.Duration, check func() (bool, error)) error {
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		if err := poller.Sleep(ctx, poller.Jitter(interval, poller.DefaultJitter)); err != nil {
+			return err
+		}
+	}
+}
+
+// findSlot returns the named replication slot on a peer
+func findSlot(ctx context.Context, grpcClient *client.Client, peer, slot string) (*pb.SlotInfo, error) {
+	slots, err := grpcClient.ListSlots(ctx, peer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replication slots: %w", err)
+	}
+	for _, info := range slots {
+		if info.SlotName == slot {
+			return info, nil
+		}
+	}
+	return nil, fmt.Errorf("replication slot '%s' not found on '%s'", slot, peer)
+}
+
+// changedTables returns the tables whose synced row counts differ
+func changedTables(before, after *pb.CDCTableTotalCountsResponse) []string {
+	counts := make(map[string]int64)
+	for _, table := range before.TablesData {
+		counts[table.TableName] = table.Counts.GetTotalCount()
+	}
+
+	var changed []string
+	for _, table := range after.TablesData {
+		if counts[table.TableName] != table.Counts.GetTotalCount() {
+			changed = append(changed, table.TableName)
+		}
+	}
+	if len(changed) == 0 && before.TotalData.GetTotalCount() != after.TotalData.GetTotalCount() {
+		changed = append(changed, "total")
+	}
+	return changed
+}
+
+func printCutoverReport(mirrorName string, report cutoverReport, dropping bool) {
+	fmt.Printf("\nCutover summary for '%s'\n", mirrorName)
+	fmt.Printf("  Started:        %s\n", formatTimestamp(report.started))
+	fmt.Printf("  Slot drained:   %s\n", formatTimestamp(report.drained))
+	fmt.Printf("  Paused:         %s\n", formatTimestamp(report.paused))
+	if report.finalLSN != "" {
+		fmt.Printf("  Final LSN:      %s\n", report.finalLSN)
+	}
+	// Servers without row counts leave them unverified
+	if report.counts != nil {
+		fmt.Printf("  Rows synced:    %s\n", formatCount(report.counts.TotalData.GetTotalCount()))
+		for _, table := range report.counts.TablesData {
+			fmt.Printf("    %-30s %s\n", table.TableName, formatCount(table.Counts.GetTotalCount()))
+		}
+	}
+
+	fmt.Println("\nChecklist:")
+	fmt.Println("  ✓ Source changes replicated and mirror paused")
+	if report.counts != nil {
+		fmt.Println("  - Compare row counts above with the source tables")
+	} else {
+		fmt.Println("  - Compare row counts of the source and destination tables")
+	}
+	fmt.Println("  - Reset sequences on the destination if it will take writes")
+	fmt.Println("  - Point applications at the destination")
+	if !dropping {
+		fmt.Printf("  - Drop the mirror when done: mirror_cli mirror drop %s --skip-destination-drop\n", mirrorName)
+	}
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/session"
+)
+
+// configDiffFilesCmd represents the config diff-files command
+var configDiffFilesCmd = &cobra.Command{
+	Use:   "diff-files <old> <new>",
+	Short: "Compare two configuration files or directories resource by resource",
+	Long: `Compare two versions of configuration files, e.g. a directory before and after
+a pull request, and show the peers and mirrors added, removed, or changed, with
+the fields that change. The comparison is semantic, not textual: formatting,
+field and table order, apiVersion v1 versus v2, and templates (which are
+resolved first) don't show up as changes. Nothing is sent to PeerDB.`,
+	Example: `  git worktree add /tmp/base main
+  mirror_cli config diff-files /tmp/base/configs configs
+  mirror_cli config diff-files old/users.yaml new/users.yaml --exit-code`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return diffConfigFiles(cmd, args[0], args[1])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configDiffFilesCmd)
+
+	configDiffFilesCmd.Flags().Bool("exit-code", false, "Exit with status 1 when there are differences, like diff")
+	configDiffFilesCmd.Flags().StringSlice("include", nil, "Only compare files matching these glob patterns (relative to the directory, ** matches any path)")
+	configDiffFilesCmd.Flags().StringSlice("exclude", nil, "Skip files matching these glob patterns")
+}
+
+// resourceDiff is how one resource differs between two sets of files
+type resourceDiff struct {
+	Kind    string         `json:"kind"`
+	Name    string         `json:"name"`
+	File    string         `json:"file"`
+	Change  string         `json:"change"`
+	Changes []fieldChanged `json:"fields,omitempty"`
+}
+
+type fieldChanged struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func diffConfigFiles(cmd *cobra.Command, oldPath, newPath string) error {
+	exitCode, _ := cmd.Flags().GetBool("exit-code")
+	output, err := reportOutput(cmd, printer.FormatJSON)
+	if err != nil {
+		return err
+	}
+
+	// Secrets are rarely set where files are compared, e.g. in CI, and
+	// unset ones compare equal
+	config.AllowMissingVariables = true
+
+	before, err := loadConfigsAt(cmd, oldPath)
+	if err != nil {
+		return err
+	}
+	after, err := loadConfigsAt(cmd, newPath)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	diffs, unchanged, err := diffResources(before, after)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		if diffs == nil {
+			diffs = []resourceDiff{}
+		}
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printResourceDiffs(diffs, unchanged)
+	}
+
+	if exitCode && len(diffs) > 0 {
+		return &ExitError{Code: 1, Message: fmt.Sprintf("%d resource(s) differ", len(diffs))}
+	}
+	return nil
+}
+
+// loadConfigsAt loads a configuration file, or every file in a directory,
+// with templates resolved. File paths are made relative to the directory.
+func loadConfigsAt(cmd *cobra.Command, path string) ([]*config.FileConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path %s: %w", path, err)
+	}
+
+	var configs []*config.FileConfig
+	if info.IsDir() {
+		configs, err = config.LoadConfigsFromDirectory(path, discoverOptions(cmd))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load configs from %s: %w", path, err)
+		}
+	} else {
+		cfg, err := config.LoadConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		configs = []*config.FileConfig{cfg}
+	}
+
+	configs, err = config.ResolveTemplates(configs)
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range configs {
+		if rel, err := filepath.Rel(path, cfg.Path); info.IsDir() && err == nil {
+			cfg.Path = rel
+		}
+	}
+	return configs, nil
+}
+
+// diffResources matches resources by kind and name and returns those
+// added, removed, or changed, sorted by kind and name, and how many are
+// unchanged
+func diffResources(before, after []*config.FileConfig) ([]resourceDiff, int, error) {
+	key := func(cfg *config.FileConfig) string { return cfg.Kind + "/" + cfg.Metadata.Name }
+	old := make(map[string]*config.FileConfig, len(before))
+	for _, cfg := range before {
+		old[key(cfg)] = cfg
+	}
+
+	var diffs []resourceDiff
+	unchanged := 0
+	seen := make(map[string]bool, len(after))
+	for _, cfg := range after {
+		seen[key(cfg)] = true
+		previous, ok := old[key(cfg)]
+		if !ok {
+			diffs = append(diffs, resourceDiff{Kind: cfg.Kind, Name: cfg.Metadata.Name, File: cfg.Path, Change: "added"})
+			continue
+		}
+
+		fields, err := config.DiffConfigs(previous, cfg)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to compare %s '%s': %w", cfg.Kind, cfg.Metadata.Name, err)
+		}
+		if len(fields) == 0 {
+			unchanged++
+			continue
+		}
+		diff := resourceDiff{Kind: cfg.Kind, Name: cfg.Metadata.Name, File: cfg.Path, Change: "changed"}
+		for _, field := range fields {
+			diff.Changes = append(diff.Changes, redactField(fieldChanged{Path: field.Path, Old: field.Old, New: field.New}))
+		}
+		diffs = append(diffs, diff)
+	}
+	for _, cfg := range before {
+		if !seen[key(cfg)] {
+			diffs = append(diffs, resourceDiff{Kind: cfg.Kind, Name: cfg.Metadata.Name, File: cfg.Path, Change: "removed"})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Kind != diffs[j].Kind {
+			return kindOrder(diffs[i].Kind) < kindOrder(diffs[j].Kind)
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs, unchanged, nil
+}
+
+func printResourceDiffs(diffs []resourceDiff, unchanged int) {
+	if len(diffs) == 0 {
+		fmt.Printf("No differences (%d resource(s) unchanged)\n", unchanged)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, diff := range diffs {
+		counts[diff.Change]++
+		label := fmt.Sprintf("%s '%s' (%s)", diff.Kind, diff.Name, diff.File)
+		switch diff.Change {
+		case "added":
+			fmt.Println(green("+ " + label))
+		case "removed":
+			fmt.Println(red("- " + label))
+		default:
+			fmt.Println(yellow("~ " + label))
+			for _, field := range diff.Changes {
+				printFieldChange(field)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d changed, %d added, %d removed, %d unchanged\n", counts["changed"], counts["added"], counts["removed"], unchanged)
+}
+
+// redactField hides the values of a secret field such as a password,
+// which are compared after ${VAR} expansion, leaving whether it was set
+func redactField(field fieldChanged) fieldChanged {
+	if !config.IsSecretField(field.Path) {
+		return field
+	}
+	if field.Old != nil {
+		field.Old = session.Redacted
+	}
+	if field.New != nil {
+		field.New = session.Redacted
+	}
+	return field
+}
+
+// printFieldChange prints one changed field on a line, or for lists such
+// as tables, one line per item added, removed, or changed. Table mappings
+// with the same source are shown as changed.
+func printFieldChange(field fieldChanged) {
+	oldItems, oldList := field.Old.([]interface{})
+	newItems, newList := field.New.([]interface{})
+	switch {
+	case oldList || newList:
+		var removed, added []interface{}
+		for _, item := range oldItems {
+			if !containsValue(newItems, item) {
+				removed = append(removed, item)
+			}
+		}
+		for _, item := range newItems {
+			if !containsValue(oldItems, item) {
+				added = append(added, item)
+			}
+		}
+		for _, item := range removed {
+			if i := indexBySource(added, item); i >= 0 {
+				fmt.Printf("    %s: changed %s: %s\n", field.Path, item.(map[string]interface{})["source"], mappingChanges(item, added[i]))
+				added = append(added[:i], added[i+1:]...)
+				continue
+			}
+			fmt.Printf("    %s: %s\n", field.Path, red("removed "+listItem(item)))
+		}
+		for _, item := range added {
+			fmt.Printf("    %s: %s\n", field.Path, green("added "+listItem(item)))
+		}
+	case field.Old == nil:
+		fmt.Printf("    %s: %s\n", field.Path, green("set to "+diffValue(field.New)))
+	case field.New == nil:
+		fmt.Printf("    %s: %s\n", field.Path, red("unset (was "+diffValue(field.Old)+")"))
+	default:
+		fmt.Printf("    %s: %s → %s\n", field.Path, diffValue(field.Old), diffValue(field.New))
+	}
+}
+
+// mappingChanges describes how two table mappings with the same source
+// differ, e.g. "destination a → b, exclude_columns set to [ssn]"
+func mappingChanges(before, after interface{}) string {
+	have, _ := before.(map[string]interface{})
+	want, _ := after.(map[string]interface{})
+	keys := make(map[string]bool)
+	for key := range have {
+		keys[key] = true
+	}
+	for key := range want {
+		keys[key] = true
+	}
+
+	var changes []string
+	for key := range keys {
+		old, hadKey := have[key]
+		value, hasKey := want[key]
+		switch {
+		case !hadKey:
+			changes = append(changes, key+" set to "+diffValue(value))
+		case !hasKey:
+			changes = append(changes, key+" unset (was "+diffValue(old)+")")
+		case !reflect.DeepEqual(old, value):
+			changes = append(changes, key+" "+diffValue(old)+" → "+diffValue(value))
+		}
+	}
+	sort.Strings(changes)
+	return strings.Join(changes, ", ")
+}
+
+// indexBySource returns the index of the table mapping in items with the
+// same source as item, or -1
+func indexBySource(items []interface{}, item interface{}) int {
+	mapping, _ := item.(map[string]interface{})
+	source, ok := mapping["source"].(string)
+	if !ok {
+		return -1
+	}
+	for i, other := range items {
+		if m, _ := other.(map[string]interface{}); m["source"] == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// listItem renders a list item, showing table mappings as
+// source -> destination with their other settings
+func listItem(item interface{}) string {
+	mapping, ok := item.(map[string]interface{})
+	if !ok {
+		return diffValue(item)
+	}
+	source, hasSource := mapping["source"].(string)
+	if !hasSource {
+		return diffValue(item)
+	}
+
+	label := source
+	if destination, ok := mapping["destination"].(string); ok {
+		label += " -> " + destination
+	}
+	var rest []string
+	for key, value := range mapping {
+		if key != "source" && key != "destination" {
+			rest = append(rest, key+"="+diffValue(value))
+		}
+	}
+	sort.Strings(rest)
+	if len(rest) > 0 {
+		label += " (" + strings.Join(rest, ", ") + ")"
+	}
+	return label
+}
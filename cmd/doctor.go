@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorDoctorCmd represents the mirror doctor command
+var mirrorDoctorCmd = &cobra.Command{
+	Use:   "doctor [mirror-name]",
+	Short: "Diagnose stuck mirrors and suggest or apply fixes",
+	Long: `Check a mirror, or every mirror when no name is given, for common stuck
+states and suggest how to fix each:
+
+  snapshot stalled      the initial snapshot made no progress during --sample
+                        and has run longer than --stall-after    → resync
+  slot invalidated      the replication slot lost required WAL   → resync
+  slot missing          the replication slot was dropped         → resync
+  publication missing   the publication was dropped              → resync, or
+                        recreate it by hand if it isn't PeerDB's
+  workflow failed       the mirror is FAILED                     → resume
+  workflow terminated   the mirror is TERMINATED but not dropped → resync
+
+With --fix, resyncs and resumes are run after confirmation; anything else is
+left to do by hand. A resync re-creates the mirror with a new replication slot
+and snapshots every table again, so it can take a long time on large tables.
+
+PeerDB doesn't report which tables a publication includes, so a table missing
+from an existing publication isn't detected. Check it on the source with
+SELECT * FROM pg_publication_tables WHERE pubname = '<publication>'.
+
+Exits with status 1 when problems are found and not all were fixed.`,
+	Example: `  mirror_cli mirror doctor orders_cdc
+  mirror_cli mirror doctor
+  mirror_cli mirror doctor orders_cdc --fix`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return mirrorDoctor(cmd, args)
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorDoctorCmd)
+
+	mirrorDoctorCmd.Flags().Bool("fix", false, "Apply the suggested resyncs and resumes")
+	mirrorDoctorCmd.Flags().Bool("force", false, "Fix without confirmation")
+	mirrorDoctorCmd.Flags().Duration("stall-after", 30*time.Minute, "How long a snapshot without progress must have run to be reported as stalled")
+	mirrorDoctorCmd.Flags().Duration("sample", 15*time.Second, "How long to watch a running snapshot for progress")
+	addAllPrefixesFlag(mirrorDoctorCmd)
+}
+
+// Problems found by mirror doctor
+const (
+	problemSnapshotStalled    = "snapshot_stalled"
+	problemSlotInvalidated    = "slot_invalidated"
+	problemSlotMissing        = "slot_missing"
+	problemPublicationMissing = "publication_missing"
+	problemWorkflowFailed     = "workflow_failed"
+	problemWorkflowTerminated = "workflow_terminated"
+	problemStatusUnavailable  = "status_unavailable"
+)
+
+// Remedies for the problems, from the least to the most disruptive
+const (
+	remedyManual = "manual"
+	remedyResume = "resume"
+	remedyResync = "resync"
+)
+
+// doctorFinding is one problem found on a mirror
+type doctorFinding struct {
+	Mirror     string `json:"mirror"`
+	Problem    string `json:"problem"`
+	Detail     string `json:"detail"`
+	Remedy     string `json:"remedy"`
+	Suggestion string `json:"suggestion"`
+	Fixed      bool   `json:"fixed,omitempty"`
+}
+
+// doctorCheck is what's needed to diagnose and fix one mirror
+type doctorCheck struct {
+	name     string
+	status   *pb.MirrorStatusResponse
+	findings []doctorFinding
+}
+
+func mirrorDoctor(cmd *cobra.Command, args []string) error {
+	fix, _ := cmd.Flags().GetBool("fix")
+	force, _ := cmd.Flags().GetBool("force")
+	stallAfter, _ := cmd.Flags().GetDuration("stall-after")
+	sample, _ := cmd.Flags().GetDuration("sample")
+	output, err := reportOutput(cmd, printer.FormatJSON)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	// Sampling and fixes wait on mirrors, so the wait timeout bounds the run
+	ctx, cancel := withWaitTimeout(commandContext(), GetConfig().Timeouts().Wait)
+	defer cancel()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var names []string
+	if len(args) == 1 {
+		names = []string{resourceName(args[0])}
+	} else {
+		resp, err := client.ListMirrors(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list mirrors: %w", err)
+		}
+		mirrors, _ := ownedMirrors(resp.Mirrors, ownedNames(cmd))
+		for _, mirror := range mirrors {
+			names = append(names, mirror.Name)
+		}
+	}
+
+	var checks []*doctorCheck
+	for _, name := range names {
+		check, err := diagnoseMirror(ctx, client, name, stallAfter, sample)
+		if err != nil {
+			if len(args) == 1 {
+				return err
+			}
+			check = &doctorCheck{name: name, findings: []doctorFinding{{
+				Mirror:     name,
+				Problem:    problemStatusUnavailable,
+				Detail:     err.Error(),
+				Remedy:     remedyManual,
+				Suggestion: "check that the mirror's peers are reachable and retry",
+			}}}
+		}
+		checks = append(checks, check)
+	}
+
+	if fix {
+		for _, check := range checks {
+			if err := fixMirror(ctx, client, check, force); err != nil {
+				return err
+			}
+		}
+	}
+
+	findings := []doctorFinding{}
+	unfixed := 0
+	for _, check := range checks {
+		for _, finding := range check.findings {
+			findings = append(findings, finding)
+			if !finding.Fixed {
+				unfixed++
+			}
+		}
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printDoctorFindings(checks, fix)
+	}
+
+	if unfixed > 0 {
+		return &ExitError{Code: 1, Message: fmt.Sprintf("%d problem(s) found", unfixed)}
+	}
+	return nil
+}
+
+// diagnoseMirror checks one mirror's state, snapshot progress, replication
+// slot, and publication
+func diagnoseMirror(ctx context.Context, grpcClient *client.Client, name string, stallAfter, sample time.Duration) (*doctorCheck, error) {
+	status, err := grpcClient.GetMirrorStatus(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	check := &doctorCheck{name: name, status: status}
+	add := func(problem, remedy, suggestion, format string, a ...interface{}) {
+		check.findings = append(check.findings, doctorFinding{
+			Mirror:     name,
+			Problem:    problem,
+			Detail:     fmt.Sprintf(format, a...),
+			Remedy:     remedy,
+			Suggestion: suggestion,
+		})
+	}
+
+	cdc := status.CdcStatus
+	switch status.CurrentFlowState {
+	case pb.FlowStatus_STATUS_FAILED:
+		add(problemWorkflowFailed, remedyResume, "resume the mirror to retry from where it stopped", "mirror is FAILED")
+	case pb.FlowStatus_STATUS_TERMINATED:
+		if cdc != nil {
+			add(problemWorkflowTerminated, remedyResync, "resync the mirror to restart it, or drop it if it's no longer needed", "mirror is TERMINATED but was not dropped")
+		} else {
+			add(problemWorkflowTerminated, remedyManual, "drop and re-create the mirror, or drop it if it's no longer needed", "mirror is TERMINATED but was not dropped")
+		}
+	case pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_SNAPSHOT, pb.FlowStatus_STATUS_RESYNC:
+		if cdc != nil {
+			stalled, detail, err := snapshotStalled(ctx, grpcClient, name, status, stallAfter, sample)
+			if err != nil {
+				return nil, err
+			}
+			if stalled {
+				add(problemSnapshotStalled, remedyResync, "resync the mirror to restart the snapshot", "%s", detail)
+			}
+		}
+	}
+
+	// Slots and publications only exist on PostgreSQL sources, and aren't
+	// created until the mirror is set up
+	if cdc == nil || cdc.Config == nil || cdc.SourceType != pb.DBType_POSTGRES || status.CurrentFlowState == pb.FlowStatus_STATUS_SETUP {
+		return check, nil
+	}
+	configs := cdc.Config
+
+	slotName := configs.ReplicationSlotName
+	if slotName == "" {
+		slotName = config.DefaultReplicationSlotName(name)
+	}
+	slots, err := grpcClient.ListSlots(ctx, configs.SourceName)
+	unsupported := skipUnsupported(err, fmt.Sprintf("replication slot check of '%s'", name))
+	if err != nil && !unsupported {
+		return nil, fmt.Errorf("failed to list replication slots: %w", err)
+	}
+	var slot *pb.SlotInfo
+	for _, info := range slots {
+		if info.SlotName == slotName {
+			slot = info
+		}
+	}
+	switch {
+	case unsupported:
+	case slot == nil:
+		add(problemSlotMissing, remedyResync, "resync the mirror to create a new slot and re-snapshot", "replication slot '%s' doesn't exist on '%s'", slotName, configs.SourceName)
+	case slot.WalStatus == "lost":
+		add(problemSlotInvalidated, remedyResync, "resync the mirror to create a new slot and re-snapshot; raise max_slot_wal_keep_size to avoid a repeat", "replication slot '%s' has lost required WAL", slotName)
+	}
+
+	publication := configs.PublicationName
+	if publication == "" {
+		publication = config.DefaultPublicationName(name)
+	}
+	publications, err := grpcClient.ListPublications(ctx, configs.SourceName)
+	if skipUnsupported(err, fmt.Sprintf("publication check of '%s'", name)) {
+		return check, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list publications: %w", err)
+	}
+	if !slices.Contains(publications, publication) {
+		// PeerDB re-creates publications it named; others were created by
+		// hand and must be again
+		if strings.HasPrefix(publication, config.PublicationPrefix) {
+			add(problemPublicationMissing, remedyResync, "resync the mirror to re-create the publication", "publication '%s' doesn't exist on '%s'", publication, configs.SourceName)
+		} else {
+			add(problemPublicationMissing, remedyManual, fmt.Sprintf("re-create it on '%s': %s, then resume the mirror", configs.SourceName, createPublicationSQL(publication, configs)), "publication '%s' doesn't exist on '%s'", publication, configs.SourceName)
+		}
+	}
+	return check, nil
+}
+
+// snapshotStalled reports whether a snapshot that has run longer than
+// stallAfter makes no progress during sample
+func snapshotStalled(ctx context.Context, grpcClient *client.Client, name string, status *pb.MirrorStatusResponse, stallAfter, sample time.Duration) (bool, string, error) {
+	started, _ := timestamps.FromProto(status.CreatedAt)
+	for _, clone := range status.CdcStatus.SnapshotStatus.GetClones() {
+		if t, ok := timestamps.FromProto(clone.StartTime); ok && (started.IsZero() || t.Before(started)) {
+			started = t
+		}
+	}
+	if started.IsZero() || time.Since(started) < stallAfter {
+		return false, "", nil
+	}
+
+	before := snapshotProgress(status)
+	select {
+	case <-ctx.Done():
+		return false, "", ctx.Err()
+	case <-time.After(sample):
+	}
+	after, err := grpcClient.GetMirrorStatus(ctx, name)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	switch after.CurrentFlowState {
+	case pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_SNAPSHOT, pb.FlowStatus_STATUS_RESYNC:
+	default:
+		// Finished or stopped while sampled
+		return false, "", nil
+	}
+	if snapshotProgress(after) != before {
+		return false, "", nil
+	}
+
+	pending := 0
+	for _, clone := range after.CdcStatus.SnapshotStatus.GetClones() {
+		if !clone.ConsolidateCompleted {
+			pending++
+		}
+	}
+	return true, fmt.Sprintf("%s for %s with %d table(s) pending and no progress in %s", stateName(after.CurrentFlowState), formatDuration(time.Since(started)), pending, sample), nil
+}
+
+// snapshotProgress summarizes the snapshot's progress, so two samples can
+// be compared
+func snapshotProgress(status *pb.MirrorStatusResponse) string {
+	var parts []string
+	for _, clone := range status.CdcStatus.GetSnapshotStatus().GetClones() {
+		parts = append(parts, fmt.Sprintf("%s:%d:%d:%t:%t", clone.TableName, clone.NumRowsSynced, clone.NumPartitionsCompleted, clone.FetchCompleted, clone.ConsolidateCompleted))
+	}
+	return strings.Join(parts, ",")
+}
+
+// createPublicationSQL returns the statement that re-creates a mirror's
+// publication
+func createPublicationSQL(publication string, configs *pb.FlowConnectionConfigs) string {
+	tables := make([]string, len(configs.TableMappings))
+	for i, mapping := range configs.TableMappings {
+		tables[i] = mapping.SourceTableIdentifier
+	}
+	return fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s;", publication, strings.Join(tables, ", "))
+}
+
+// fixMirror applies the most disruptive remedy a mirror needs, which also
+// covers the others: a resync drops the mirror and creates it again from
+// scratch. A lost slot or publication's name is cleared so PeerDB creates
+// a new one.
+func fixMirror(ctx context.Context, grpcClient *client.Client, check *doctorCheck, force bool) error {
+	remedy := ""
+	for _, finding := range check.findings {
+		switch {
+		case finding.Remedy == remedyResync:
+			remedy = remedyResync
+		case finding.Remedy == remedyResume && remedy == "":
+			remedy = remedyResume
+		}
+	}
+
+	switch remedy {
+	case remedyResync:
+		question := fmt.Sprintf("Resync mirror '%s' on %s? It is dropped, keeping its destination tables, and created again with a new replication slot; every table is snapshotted again.", check.name, contextLabel(GetConfig()))
+		if !force && !confirmDestructive("mirror", check.name, question, false) {
+			fmt.Printf("Skipped resyncing '%s'\n", check.name)
+			return nil
+		}
+		configs := proto.Clone(check.status.CdcStatus.Config).(*pb.FlowConnectionConfigs)
+		for _, finding := range check.findings {
+			switch finding.Problem {
+			case problemSlotMissing, problemSlotInvalidated:
+				configs.ReplicationSlotName = ""
+			case problemPublicationMissing:
+				configs.PublicationName = ""
+			}
+		}
+		if err := grpcClient.ResyncMirror(ctx, configs); err != nil {
+			return fmt.Errorf("failed to resync mirror '%s': %w", check.name, err)
+		}
+	case remedyResume:
+		if err := grpcClient.ResumeMirror(ctx, check.name); err != nil {
+			return fmt.Errorf("failed to resume mirror '%s': %w", check.name, err)
+		}
+	default:
+		return nil
+	}
+
+	for i := range check.findings {
+		if check.findings[i].Remedy != remedyManual {
+			check.findings[i].Fixed = true
+		}
+	}
+	return nil
+}
+
+func printDoctorFindings(checks []*doctorCheck, fix bool) {
+	if len(checks) == 0 {
+		fmt.Println("No mirrors found")
+		return
+	}
+
+	problems := 0
+	for _, check := range checks {
+		if len(check.findings) == 0 {
+			fmt.Printf("✓ %s: no problems found\n", check.name)
+			continue
+		}
+		problems += len(check.findings)
+		for _, finding := range check.findings {
+			fmt.Printf("❌ %s: %s: %s\n", finding.Mirror, strings.ReplaceAll(finding.Problem, "_", " "), finding.Detail)
+			switch {
+			case finding.Fixed:
+				fmt.Printf("   ✓ Fixed by %s\n", finding.Remedy)
+			case finding.Remedy != remedyManual && !fix:
+				fmt.Printf("   💡 %s (run with --fix)\n", finding.Suggestion)
+			default:
+				fmt.Printf("   💡 %s\n", finding.Suggestion)
+			}
+		}
+	}
+
+	if problems == 0 && len(checks) > 1 {
+		fmt.Printf("\n✅ No problems found in %d mirror(s)\n", len(checks))
+	}
+}
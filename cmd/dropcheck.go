@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorActivity returns why a mirror appears active: it is taking its
+// initial snapshot, or it synced rows within window. It returns "" for an
+// idle mirror.
+func mirrorActivity(ctx context.Context, grpcClient *client.Client, mirrorName string, window time.Duration) (string, error) {
+	status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get mirror status: %w", err)
+	}
+
+	switch status.CurrentFlowState {
+	case pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_SNAPSHOT, pb.FlowStatus_STATUS_RESYNC:
+		return fmt.Sprintf("it is in state %s", stateName(status.CurrentFlowState)), nil
+	}
+	if status.CdcStatus == nil {
+		return "", nil
+	}
+
+	since := time.Now().Add(-window)
+	var rows int64
+	var latest time.Time
+	for _, batch := range status.CdcStatus.CdcBatches {
+		ts, ok := timestamps.FromProto(batch.EndTime)
+		if !ok {
+			ts, ok = timestamps.FromProto(batch.StartTime)
+		}
+		if batch.NumRows == 0 || !ok || !ts.After(since) {
+			continue
+		}
+		rows += batch.NumRows
+		if ts.After(latest) {
+			latest = ts
+		}
+	}
+	if rows == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("it synced %s rows in the last %s, most recently %s", formatRows(rows), humanizeDuration(window), formatTime(latest)), nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/policy"
+)
+
+// editOption describes a mirror edit option and the destinations that
+// can't apply it. The server accepts these updates for any mirror but
+// ignores or rejects them later, so they are checked before sending.
+type editOption struct {
+	flags       []string
+	unsupported []string
+	reason      string
+}
+
+// editOptions lists the mirror edit options with destination restrictions
+var editOptions = []editOption{
+	{
+		flags:       []string{"add-tables", "pick-tables"},
+		unsupported: []string{"kafka", "pubsub", "eventhubs"},
+		reason:      "queue destinations have no tables to snapshot added tables into; create a new mirror for them instead",
+	},
+	{
+		flags:       []string{"remove-tables", "remap"},
+		unsupported: []string{"kafka", "pubsub", "eventhubs"},
+		reason:      "queue destinations have no tables to remove; create a new mirror without them instead",
+	},
+}
+
+// checkEditSupport returns an error if an edit option set on cmd isn't
+// supported by the mirror. Query replication mirrors can't be edited, and
+// CDC mirrors are checked against their destination's type.
+func checkEditSupport(ctx context.Context, cmd *cobra.Command, grpcClient *client.Client, mirrorName string) error {
+	status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' is not a CDC mirror; only CDC mirrors can be edited", mirrorName)
+	}
+
+	destination := status.CdcStatus.Config.DestinationName
+	peer, err := grpcClient.GetPeer(ctx, destination)
+	if err != nil {
+		return fmt.Errorf("failed to get destination peer '%s': %w", destination, err)
+	}
+	destinationType := policy.NormalizePeerType(peer.Type.String())
+
+	for _, option := range editOptions {
+		if !slices.Contains(option.unsupported, destinationType) {
+			continue
+		}
+		for _, flag := range option.flags {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s is not supported for mirror '%s': destination '%s' is a %s peer, and %s",
+					flag, mirrorName, destination, destinationType, option.reason)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// addVariableFlags registers --env-file and --allow-missing-env on a
+// command that loads config files with ${VAR} references
+func addVariableFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("env-file", nil, "Read ${VAR} values not set in the environment from this dotenv file (repeatable; later files win)")
+	cmd.Flags().Bool("allow-missing-env", false, "Expand unset ${VAR} references to empty values instead of failing")
+}
+
+// applyVariableFlags loads the --env-file files and sets whether unset
+// variables are allowed, before any config file is loaded
+func applyVariableFlags(cmd *cobra.Command) error {
+	config.AllowMissingVariables, _ = cmd.Flags().GetBool("allow-missing-env")
+
+	paths, _ := cmd.Flags().GetStringArray("env-file")
+	if len(paths) == 0 {
+		return nil
+	}
+	values, err := config.LoadEnvFiles(paths)
+	if err != nil {
+		return err
+	}
+	config.EnvFileValues = values
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// fallbackEnvironment is used when neither a flag nor the context sets one
+const fallbackEnvironment = "production"
+
+// exportEnvironment returns the --environment flag, or the current context's
+// environment when the flag isn't set
+func exportEnvironment(cmd *cobra.Command) string {
+	if cmd.Flags().Changed("environment") {
+		environment, _ := cmd.Flags().GetString("environment")
+		return environment
+	}
+	if environment := GetConfig().Environment; environment != "" {
+		return environment
+	}
+	return fallbackEnvironment
+}
+
+// checkEnvironments sets metadata.environment to the context's environment
+// on configs that don't have one, and warns about configs written for
+// another environment. It returns the mismatching configs.
+func checkEnvironments(configs []*config.FileConfig) []*config.FileConfig {
+	cfg := GetConfig()
+	if cfg.Environment == "" {
+		return nil
+	}
+
+	var mismatched []*config.FileConfig
+	for _, fc := range configs {
+		if fc.Kind == "Context" {
+			continue
+		}
+		switch fc.Metadata.Environment {
+		case "":
+			fc.Metadata.Environment = cfg.Environment
+		case cfg.Environment:
+		default:
+			mismatched = append(mismatched, fc)
+			fmt.Fprintf(os.Stderr, "⚠ %s '%s' is for environment '%s', but %s is '%s'\n",
+				fc.Kind, fc.Metadata.Name, fc.Metadata.Environment, contextLabel(cfg), cfg.Environment)
+		}
+	}
+	return mismatched
+}
+
+// contextLabel describes where the current environment comes from
+func contextLabel(cfg *config.Config) string {
+	if cfg.CurrentContext != "" {
+		return fmt.Sprintf("context '%s'", cfg.CurrentContext)
+	}
+	return "the configured environment"
+}
+
+// contextHeader names the current context, its server, and what selected
+// it when it isn't current_context
+func contextHeader(cfg *config.Config) string {
+	header := fmt.Sprintf("%s (%s", cfg.CurrentContext, strings.Join(cfg.Endpoints(), ", "))
+	if cfg.ContextSource != "" {
+		header += ", from " + cfg.ContextSource
+	}
+	return header + ")"
+}
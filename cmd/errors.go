@@ -0,0 +1,11 @@
+package cmd
+
+// ExitError is returned by commands that need a specific process exit code
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e *ExitError) Error() string {
+	return e.Message
+}
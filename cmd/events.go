@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/poller"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorEventsCmd represents the mirror events command
+var mirrorEventsCmd = &cobra.Command{
+	Use:   "events [mirror-name]",
+	Short: "Print mirror events",
+	Long: `Print mirror events (state changes, errors, completed batches, snapshot
+tables, and partitions) as one line each, so they can be filtered with tools
+like grep. With --follow, new events are printed as they happen.
+
+PeerDB has no event stream, so events are derived by polling mirror status
+every --interval. Events between polls are reported at the next poll.`,
+	Example: `  mirror_cli mirror events my_mirror
+  mirror_cli mirror events --all --follow | grep ERROR
+  mirror_cli mirror events my_mirror --follow -o json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return mirrorEvents(cmd, args)
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorEventsCmd)
+
+	mirrorEventsCmd.Flags().Bool("all", false, "Print events of all mirrors")
+	addAllPrefixesFlag(mirrorEventsCmd)
+	mirrorEventsCmd.Flags().BoolP("follow", "f", false, "Keep printing new events until interrupted")
+	mirrorEventsCmd.Flags().Duration("interval", 5*time.Second, "How often to poll mirror status with --follow")
+	mirrorEventsCmd.Flags().Int("concurrency", poller.DefaultConcurrency, "Mirror statuses to fetch at once with --all")
+}
+
+// Event types
+const (
+	eventState     = "STATE"
+	eventError     = "ERROR"
+	eventBatch     = "BATCH"
+	eventSnapshot  = "SNAPSHOT"
+	eventPartition = "PARTITION"
+	eventCreated   = "CREATED"
+	eventDropped   = "DROPPED"
+)
+
+// mirrorEvent is a single change observed on a mirror
+type mirrorEvent struct {
+	Time    time.Time `json:"time"`
+	Mirror  string    `json:"mirror"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+// mirrorSnapshot is what has already been reported for a mirror
+type mirrorSnapshot struct {
+	state      pb.FlowStatus
+	hasState   bool
+	err        string
+	batches    map[int64]bool
+	clones     map[string]bool
+	partitions map[string]string
+}
+
+func mirrorEvents(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	follow, _ := cmd.Flags().GetBool("follow")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	if all == (len(args) == 1) {
+		return fmt.Errorf("specify a mirror name or --all")
+	}
+	output, err := reportOutput(cmd, printer.FormatJSON)
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	printEvents := func(events []mirrorEvent) error {
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Time.Before(events[j].Time)
+		})
+		for _, event := range events {
+			if err := printEvent(event, output); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var name string
+	if !all {
+		name = resourceName(args[0])
+	}
+	owned := ownedNames(cmd)
+	p := poller.New(interval, poller.Options{Concurrency: concurrency})
+	snapshots := make(map[string]*mirrorSnapshot)
+	first := true
+	return pollUntil(ctx, interval, func() (bool, error) {
+		var events []mirrorEvent
+		if all {
+			events, err = pollAllMirrors(ctx, client, p, owned, snapshots, first)
+		} else {
+			events, err = pollMirror(ctx, client, name, snapshots, first)
+		}
+		if err != nil {
+			return false, err
+		}
+		first = false
+
+		if err := printEvents(events); err != nil {
+			return false, err
+		}
+
+		// A single mirror that was dropped has nothing more to report
+		if !all && snapshots[name] == nil {
+			return true, nil
+		}
+		return !follow, nil
+	})
+}
+
+// pollMirror reports the events of one mirror since the last poll. A mirror
+// that doesn't exist on the first poll is an error.
+func pollMirror(ctx context.Context, grpcClient *client.Client, name string, snapshots map[string]*mirrorSnapshot, first bool) ([]mirrorEvent, error) {
+	now := time.Now()
+	status, err := grpcClient.GetMirrorStatus(ctx, name)
+	if grpcstatus.Code(err) == codes.NotFound {
+		if first {
+			return nil, fmt.Errorf("failed to get mirror status: %w", err)
+		}
+		delete(snapshots, name)
+		return []mirrorEvent{{Time: now, Mirror: name, Type: eventDropped, Message: "mirror dropped"}}, nil
+	}
+	if first && err != nil {
+		return nil, fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	return mirrorStatusEvents(name, snapshots, status, err, now), nil
+}
+
+// pollAllMirrors reports the events of every mirror that passes owned
+// since the last poll, including mirrors that were created or dropped
+func pollAllMirrors(ctx context.Context, grpcClient *client.Client, p *poller.Poller, owned func(string) bool, snapshots map[string]*mirrorSnapshot, first bool) ([]mirrorEvent, error) {
+	now := time.Now()
+	resp, err := grpcClient.ListMirrors(ctx)
+	if err != nil {
+		if first {
+			return nil, fmt.Errorf("failed to list mirrors: %w", err)
+		}
+		return []mirrorEvent{{Time: now, Type: eventError, Message: fmt.Sprintf("failed to list mirrors: %v", err)}}, nil
+	}
+
+	mirrors, _ := ownedMirrors(resp.Mirrors, owned)
+	names := make([]string, len(mirrors))
+	for i, mirror := range mirrors {
+		names[i] = mirror.Name
+	}
+
+	// Statuses are fetched concurrently; mirrors whose status keeps
+	// failing are backed off and keep their last reported state
+	type fetched struct {
+		status *pb.MirrorStatusResponse
+		err    error
+	}
+	var mu sync.Mutex
+	statuses := make(map[string]fetched, len(names))
+	p.Poll(ctx, names, func(ctx context.Context, name string) error {
+		status, err := grpcClient.GetMirrorStatus(ctx, name)
+		mu.Lock()
+		statuses[name] = fetched{status, err}
+		mu.Unlock()
+		return err
+	})
+
+	var events []mirrorEvent
+	current := make(map[string]bool)
+	for _, mirror := range mirrors {
+		current[mirror.Name] = true
+		if _, known := snapshots[mirror.Name]; !known && !first {
+			events = append(events, mirrorEvent{Time: now, Mirror: mirror.Name, Type: eventCreated, Message: fmt.Sprintf("mirror created (%s -> %s)", mirror.SourceName, mirror.DestinationName)})
+		}
+
+		result, ok := statuses[mirror.Name]
+		if !ok || grpcstatus.Code(result.err) == codes.NotFound {
+			// Backing off, or dropped since it was listed and reported on
+			// the next poll
+			continue
+		}
+		events = append(events, mirrorStatusEvents(mirror.Name, snapshots, result.status, result.err, now)...)
+	}
+
+	for name := range snapshots {
+		if !current[name] {
+			delete(snapshots, name)
+			events = append(events, mirrorEvent{Time: now, Mirror: name, Type: eventDropped, Message: "mirror dropped"})
+		}
+	}
+	return events, nil
+}
+
+// mirrorStatusEvents compares a mirror's status with what was reported
+// before and returns the new events. On the first call for a mirror, its
+// current state and the batches, tables, and partitions PeerDB still
+// reports are returned as history.
+func mirrorStatusEvents(name string, snapshots map[string]*mirrorSnapshot, status *pb.MirrorStatusResponse, statusErr error, now time.Time) []mirrorEvent {
+	snapshot, known := snapshots[name]
+	if !known {
+		snapshot = &mirrorSnapshot{
+			batches:    make(map[int64]bool),
+			clones:     make(map[string]bool),
+			partitions: make(map[string]string),
+		}
+		snapshots[name] = snapshot
+	}
+
+	event := func(at time.Time, eventType, format string, a ...interface{}) mirrorEvent {
+		return mirrorEvent{Time: at, Mirror: name, Type: eventType, Message: fmt.Sprintf(format, a...)}
+	}
+
+	var events []mirrorEvent
+	if statusErr != nil {
+		// Report each distinct failure once
+		if msg := statusErr.Error(); msg != snapshot.err {
+			snapshot.err = msg
+			events = append(events, event(now, eventError, "failed to get mirror status: %s", msg))
+		}
+		return events
+	}
+	snapshot.err = ""
+
+	if !snapshot.hasState || status.CurrentFlowState != snapshot.state {
+		eventType, message := eventState, "state "+stateName(status.CurrentFlowState)
+		if snapshot.hasState {
+			message = fmt.Sprintf("state changed from %s to %s", stateName(snapshot.state), stateName(status.CurrentFlowState))
+		}
+		if status.CurrentFlowState == pb.FlowStatus_STATUS_FAILED {
+			eventType = eventError
+		}
+		events = append(events, event(now, eventType, "%s", message))
+		snapshot.state, snapshot.hasState = status.CurrentFlowState, true
+	}
+
+	if cdc := status.CdcStatus; cdc != nil {
+		for _, batch := range cdc.CdcBatches {
+			// Batches without an end time are still running
+			end, ended := timestamps.FromProto(batch.EndTime)
+			if !ended || snapshot.batches[batch.BatchId] {
+				continue
+			}
+			snapshot.batches[batch.BatchId] = true
+
+			duration := "-"
+			if start, ok := timestamps.FromProto(batch.StartTime); ok {
+				duration = formatDuration(end.Sub(start))
+			}
+			events = append(events, event(end, eventBatch, "batch %d synced %s rows in %s", batch.BatchId, formatRows(batch.NumRows), duration))
+		}
+
+		for _, clone := range cdc.SnapshotStatus.GetClones() {
+			if !clone.ConsolidateCompleted || snapshot.clones[clone.TableName] {
+				continue
+			}
+			snapshot.clones[clone.TableName] = true
+			events = append(events, event(now, eventSnapshot, "table %s snapshotted (%s rows)", clone.TableName, formatRows(clone.NumRowsSynced)))
+		}
+	}
+
+	if qrep := status.QrepStatus; qrep != nil {
+		for _, partition := range sortedPartitions(qrep.Partitions) {
+			state := partitionState(partition, status.CurrentFlowState)
+			if snapshot.partitions[partition.PartitionId] == state {
+				continue
+			}
+			snapshot.partitions[partition.PartitionId] = state
+
+			switch state {
+			case partitionCompleted:
+				end, ok := timestamps.FromProto(partition.EndTime)
+				if !ok {
+					end = now
+				}
+				events = append(events, event(end, eventPartition, "partition %s completed (%s rows)", partition.PartitionId, formatRows(partition.RowsSynced)))
+			case partitionFailed:
+				events = append(events, event(now, eventError, "partition %s failed", partition.PartitionId))
+			}
+		}
+	}
+
+	return events
+}
+
+// printEvent writes an event as a text line or a JSON object
+func printEvent(event mirrorEvent, output string) error {
+	if output == "json" {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	mirror := event.Mirror
+	if mirror == "" {
+		mirror = "-"
+	}
+	fmt.Printf("%s  %-20s  %-9s  %s\n", formatTimestamp(event.Time), mirror, event.Type, event.Message)
+	return nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// reportExisting prints the outcome of --if-not-exists for a resource that
+// already exists, warning when it differs from what was requested
+func reportExisting(desired, actual *config.FileConfig, hint string) error {
+	diffs, err := config.DiffFields(desired, actual)
+	if err != nil {
+		return fmt.Errorf("failed to compare with existing %s: %w", strings.ToLower(desired.Kind), err)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("✓ %s '%s' already exists and matches; nothing to do\n", desired.Kind, desired.Metadata.Name)
+		return nil
+	}
+
+	fmt.Println(yellow(fmt.Sprintf("⚠ %s '%s' already exists but differs from the requested configuration:", desired.Kind, desired.Metadata.Name)))
+	for _, diff := range diffs {
+		fmt.Printf("    %s\n", diff)
+	}
+	fmt.Printf("  Left unchanged; %s\n", hint)
+	return nil
+}
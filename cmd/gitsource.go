@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/gitsource"
+	"github.com/janakos/mirror_cli/internal/provenance"
+)
+
+// addGitSourceFlags registers the flags that load configs from a git
+// repository instead of the local filesystem
+func addGitSourceFlags(cmd *cobra.Command, pathFlag string) {
+	cmd.Flags().String("git-repo", "", fmt.Sprintf("Fetch configs from this git repository; --%s is then a path inside it (default: the repository root)", pathFlag))
+	cmd.Flags().String("git-ref", "", "Branch, tag, or commit to fetch with --git-repo (default: the remote's default branch)")
+}
+
+// gitSource returns the repository set with --git-repo, or nil
+func gitSource(cmd *cobra.Command) *gitsource.Source {
+	repo, _ := cmd.Flags().GetString("git-repo")
+	if repo == "" {
+		return nil
+	}
+	ref, _ := cmd.Flags().GetString("git-ref")
+	return &gitsource.Source{Repo: repo, Ref: ref}
+}
+
+// fetchGitSource fetches src and returns path resolved inside the checkout,
+// along with provenance annotations recording the repository and commit
+func fetchGitSource(ctx context.Context, src *gitsource.Source, path string) (string, map[string]string, *gitsource.Checkout, error) {
+	checkout, err := gitsource.Fetch(ctx, *src)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if path == "" {
+		path = "."
+	}
+	resolved, err := checkout.Path(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	annotations := map[string]string{
+		"git_repo": gitsource.Redact(src.Repo),
+		"git_sha":  checkout.SHA,
+	}
+	if src.Ref != "" {
+		annotations["git_ref"] = src.Ref
+	}
+	return resolved, annotations, checkout, nil
+}
+
+// shortSHA abbreviates a commit for output
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// gitRevisionLabel describes a fetched revision, e.g. "repo@main (abc123)"
+func gitRevisionLabel(src *gitsource.Source, checkout *gitsource.Checkout) string {
+	label := gitsource.Redact(src.Repo)
+	if src.Ref != "" {
+		label += "@" + src.Ref
+	}
+	return fmt.Sprintf("%s (%s)", label, shortSHA(checkout.SHA))
+}
+
+// withGitAnnotations returns explicit annotations layered over the git
+// ones, so --annotate can still override them
+func withGitAnnotations(gitAnnotations, annotations map[string]string) map[string]string {
+	if gitAnnotations == nil {
+		return annotations
+	}
+	return provenance.Merge(gitAnnotations, annotations)
+}
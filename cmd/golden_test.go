@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/golden"
+)
+
+// TestPeerCreateGoldens builds peer create requests from flags and compares
+// them with testdata/golden
+func TestPeerCreateGoldens(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"postgres", []string{
+			"--name", "pg_source", "--type", "postgres",
+			"--pg-host", "db.example.com", "--pg-port", "5433", "--pg-user", "peerdb",
+			"--pg-password", "secret", "--pg-database", "app", "--pg-require-tls",
+		}},
+		{"postgres_defaults", []string{
+			"--name", "pg_defaults", "--type", "postgresql",
+			"--pg-host", "localhost", "--pg-user", "postgres", "--pg-database", "postgres",
+		}},
+		{"bigquery", []string{
+			"--name", "bq_dest", "--type", "bigquery",
+			"--bq-project", "analytics", "--bq-dataset", "raw",
+			"--bq-client-email", "peerdb@analytics.iam.gserviceaccount.com",
+			"--bq-private-key", "key", "--bq-private-key-id", "key-id", "--bq-client-id", "123",
+		}},
+		{"snowflake", []string{
+			"--name", "sf_dest", "--type", "snowflake",
+			"--sf-account", "xy12345", "--sf-user", "peerdb", "--sf-password", "secret",
+			"--sf-database", "ANALYTICS", "--sf-warehouse", "COMPUTE_WH", "--sf-role", "PEERDB",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "create"}
+			addPeerCreateFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			name, _ := cmd.Flags().GetString("name")
+			peerType, _ := cmd.Flags().GetString("type")
+
+			peer, err := buildPeerFromFlags(cmd, name, peerType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "golden", "peer_create_"+tt.name+".textproto"), peer)
+		})
+	}
+}
+
+// TestMirrorCreateGoldens builds mirror create requests from flags and
+// compares them with testdata/golden
+func TestMirrorCreateGoldens(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"defaults", []string{
+			"--name", "orders_sync", "--source", "pg_source", "--destination", "sf_dest",
+			"--tables", "public.orders->public.orders",
+		}, nil},
+		{"all_flags", []string{
+			"--name", "orders_sync", "--source", "pg_source", "--destination", "sf_dest",
+			"--tables", "public.orders->analytics.orders,public.customers->analytics.customers",
+			"--batch-size", "5000", "--idle-timeout", "30", "--initial-snapshot=false",
+			"--publication", "orders_pub", "--replication-slot", "orders_slot",
+		}, map[string]string{"mirror_cli.team": "data"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "create"}
+			addMirrorCreateFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			name, _ := cmd.Flags().GetString("name")
+			source, _ := cmd.Flags().GetString("source")
+			destination, _ := cmd.Flags().GetString("destination")
+
+			req, err := buildMirrorFromFlags(cmd, name, source, destination, tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Assert(t, filepath.Join("testdata", "golden", "mirror_create_"+tt.name+".textproto"), req)
+		})
+	}
+}
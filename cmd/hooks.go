@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/hooks"
+	"github.com/janakos/mirror_cli/internal/provenance"
+)
+
+// hookOperations maps the commands that run hooks to their operation, the
+// suffix of their hook names. Mirror and peer commands share operations;
+// hooks tell them apart by the payload's command.
+var hookOperations map[*cobra.Command]string
+
+// hookFlags maps the commands that only change state with a flag set to
+// that flag
+var hookFlags map[*cobra.Command]string
+
+// hookCommand is the running command's operation, set by runPreHook for
+// runPostHook
+var hookCommand struct {
+	cmd       *cobra.Command
+	args      []string
+	operation string
+}
+
+func init() {
+	hookOperations = map[*cobra.Command]string{
+		configApplyCmd:   "apply",
+		reconcileCmd:     "reconcile",
+		mirrorCreateCmd:  "create",
+		peerCreateCmd:    "create",
+		mirrorEditCmd:    "edit",
+		mirrorPauseCmd:   "pause",
+		mirrorResumeCmd:  "resume",
+		mirrorDropCmd:    "drop",
+		peerDropCmd:      "drop",
+		mirrorCutoverCmd: "cutover",
+		mirrorDoctorCmd:  "fix",
+		selftestCmd:      "selftest",
+	}
+	hookFlags = map[*cobra.Command]string{
+		mirrorDoctorCmd: "fix",
+	}
+}
+
+// hookOperationNames returns the operations hooks can be configured for
+func hookOperationNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, operation := range hookOperations {
+		if !seen[operation] {
+			seen[operation] = true
+			names = append(names, operation)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runPreHook runs the pre hook of cmd's operation, if one is configured. An
+// error stops the command before it changes anything. Hook names are only
+// checked here, so a misspelled one doesn't break commands that run none.
+func runPreHook(cmd *cobra.Command, args []string, cfg *config.Config) error {
+	operation, ok := hookOperations[cmd]
+	if !ok || !changesState(cmd, cfg) {
+		return nil
+	}
+	if err := hooks.ValidateNames(cfg.Hooks, hookOperationNames()); err != nil {
+		return err
+	}
+	hookCommand.cmd, hookCommand.args, hookCommand.operation = cmd, args, operation
+
+	path := cfg.Hooks[hooks.Name(hooks.Pre, operation)]
+	if path == "" {
+		return nil
+	}
+	// Share the request ID with the post hook and the RPCs
+	if cfg.RequestID == "" {
+		cfg.RequestID = client.NewRequestID()
+	}
+	payload := hookPayload(hooks.Pre, cfg)
+	if err := hooks.Run(commandContext(), path, payload, os.Stderr); err != nil {
+		// The command never ran, so there's nothing for the post hook
+		hookCommand.cmd = nil
+		cmd.SilenceUsage = true
+		return fmt.Errorf("'%s' stopped by its %s hook: %w", cmd.CommandPath(), payload.Hook, err)
+	}
+	return nil
+}
+
+// runPostHook runs the post hook of the command runPreHook saw, with its
+// outcome. A failing post hook only warns, as the command already ran.
+func runPostHook(cmdErr error) {
+	cfg := GetConfig()
+	if hookCommand.cmd == nil || cfg == nil {
+		return
+	}
+	path := cfg.Hooks[hooks.Name(hooks.Post, hookCommand.operation)]
+	if path == "" {
+		return
+	}
+
+	// Flush the command's output, so the hook's comes after it
+	restoreStdout()
+
+	payload := hookPayload(hooks.Post, cfg)
+	succeeded := cmdErr == nil
+	payload.Succeeded = &succeeded
+	if cmdErr != nil {
+		payload.Error = cmdErr.Error()
+	}
+	if err := hooks.Run(commandContext(), path, payload, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ %s hook: %v\n", payload.Hook, err)
+	}
+}
+
+// changesState reports whether cmd will change server state, as opposed
+// to only showing what it would do
+func changesState(cmd *cobra.Command, cfg *config.Config) bool {
+	if cfg.Explain != nil {
+		return false
+	}
+	if flag, ok := hookFlags[cmd]; ok {
+		if set, _ := cmd.Flags().GetBool(flag); !set {
+			return false
+		}
+	}
+	for _, flag := range []string{"dry-run", "validate-only"} {
+		if set, _ := cmd.Flags().GetBool(flag); set {
+			return false
+		}
+	}
+	return true
+}
+
+func hookPayload(stage string, cfg *config.Config) hooks.Payload {
+	cmd := hookCommand.cmd
+	payload := hooks.Payload{
+		Hook:        hooks.Name(stage, hookCommand.operation),
+		Operation:   hookCommand.operation,
+		Command:     strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Args:        append([]string{}, hookCommand.args...),
+		Context:     cfg.CurrentContext,
+		Environment: cfg.Environment,
+		Endpoints:   cfg.Endpoints(),
+		RequestID:   cfg.RequestID,
+		User:        provenance.CurrentUser(),
+		Time:        time.Now().UTC().Format(time.RFC3339),
+	}
+	// Only the command's own flags; global ones may carry credentials
+	local := cmd.LocalFlags()
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if local.Lookup(flag.Name) == nil {
+			return
+		}
+		if payload.Flags == nil {
+			payload.Flags = make(map[string]string)
+		}
+		payload.Flags[flag.Name] = flag.Value.String()
+	})
+	return payload
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// configImportSQLCmd represents the config import-sql command
+var configImportSQLCmd = &cobra.Command{
+	Use:   "import-sql",
+	Short: "Convert PeerDB SQL statements to configuration files",
+	Long: `Convert the CREATE PEER and CREATE MIRROR statements of PeerDB's SQL
+interface into configuration files, to move from the SQL interface to the CLI.
+
+Files are written like exports, to <output-dir>/peers/<environment>/<name>.yaml
+and <output-dir>/mirrors/<environment>/<name>.yaml. Secrets become ${VAR}
+placeholders. Other statements, query replication mirrors, and unknown
+options are skipped with a warning.`,
+	Example: `  mirror_cli config import-sql -f create_mirrors.sql
+  mirror_cli config import-sql -f create_mirrors.sql --dry-run
+  cat legacy/*.sql | mirror_cli config import-sql -f - --output-dir configs --environment staging`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importSQL(cmd)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configImportSQLCmd)
+
+	configImportSQLCmd.Flags().StringP("file", "f", "", "SQL file to convert, or - to read from stdin")
+	configImportSQLCmd.Flags().String("output-dir", "configs", "Directory to write configuration files to")
+	configImportSQLCmd.Flags().String("environment", "production", "Environment to set in metadata and use in file paths")
+	configImportSQLCmd.Flags().Bool("dry-run", false, "Print the configurations instead of writing files")
+	configImportSQLCmd.Flags().Bool("force", false, "Overwrite existing files")
+	configImportSQLCmd.MarkFlagRequired("file")
+}
+
+func importSQL(cmd *cobra.Command) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	environment, _ := cmd.Flags().GetString("environment")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	cmd.SilenceUsage = true
+
+	var data []byte
+	var err error
+	if filePath == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filePath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read SQL: %w", err)
+	}
+
+	result, err := config.ImportSQL(string(data), environment)
+	if err != nil {
+		return fmt.Errorf("failed to parse SQL: %w", err)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+	}
+	if len(result.Configs) == 0 {
+		fmt.Println("No CREATE PEER or CREATE MIRROR statements found")
+		return nil
+	}
+
+	written, skipped := 0, 0
+	for _, fc := range result.Configs {
+		dir := "mirrors"
+		if fc.Kind == "Peer" {
+			dir = "peers"
+		}
+		path := filepath.Join(outputDir, dir, environment, fc.Metadata.Name+".yaml")
+
+		if dryRun {
+			out, err := config.MarshalCanonical(fc)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s '%s': %w", fc.Kind, fc.Metadata.Name, err)
+			}
+			fmt.Printf("# %s\n---\n%s\n", path, out)
+			continue
+		}
+
+		if _, err := os.Stat(path); err == nil && !force {
+			fmt.Printf("⚠ %s: skipped (file exists; use --force to overwrite)\n", path)
+			skipped++
+			continue
+		}
+		if err := config.SaveConfigFile(fc, path); err != nil {
+			return fmt.Errorf("failed to save %s '%s': %w", fc.Kind, fc.Metadata.Name, err)
+		}
+		fmt.Printf("✓ %s '%s' -> %s\n", fc.Kind, fc.Metadata.Name, path)
+		written++
+	}
+
+	if dryRun {
+		fmt.Printf("%d configuration(s) would be written\n", len(result.Configs))
+		return nil
+	}
+
+	fmt.Printf("\n✅ Wrote %d configuration(s)", written)
+	if skipped > 0 {
+		fmt.Printf(", skipped %d existing file(s)", skipped)
+	}
+	fmt.Println()
+	fmt.Printf("💡 Note: Secrets were replaced with ${VAR} placeholders; set them before applying\n")
+	fmt.Printf("💡 Check the result with: mirror_cli config validate -f %s\n", outputDir)
+	return nil
+}
@@ -3,12 +3,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/janakos/mirror_cli/internal/app"
 	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/provenance"
+	"github.com/janakos/mirror_cli/internal/timestamps"
 	pb "github.com/janakos/mirror_cli/proto/gen"
 )
 
@@ -23,7 +29,13 @@ var mirrorCmd = &cobra.Command{
 var mirrorCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new mirror",
-	Long:  "Create a new CDC mirror between source and destination peers.",
+	Long: `Create a new CDC mirror between source and destination peers.
+
+List table mappings with --tables, or mirror every table in a source schema
+with --schema. Tables without a primary key or REPLICA IDENTITY FULL are
+left out of a schema with a warning.`,
+	Example: `  mirror_cli mirror create --name users_sync --source pg --destination sf --tables "public.users->PUBLIC.USERS"
+  mirror_cli mirror create --name app_sync --source pg --destination sf --schema public`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return createMirror(cmd)
 	},
@@ -33,7 +45,9 @@ var mirrorCreateCmd = &cobra.Command{
 var mirrorListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all mirrors",
-	Long:  "List all configured mirrors with their status.",
+	Long:  "List all configured mirrors with their state, rows synced, and time since the last batch. Use --fast to skip the per-mirror status lookups, and --group-by to show one section per source, destination, or state.",
+	Example: `  mirror_cli mirror list
+  mirror_cli mirror list --group-by source`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return listMirrors(cmd)
 	},
@@ -43,10 +57,16 @@ var mirrorListCmd = &cobra.Command{
 var mirrorStatusCmd = &cobra.Command{
 	Use:   "status [mirror-name]",
 	Short: "Get mirror status",
-	Long:  "Get detailed status information for a specific mirror.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Get detailed status information for a specific mirror.
+
+With --watch, the state, rows synced (with rows per second since the previous
+refresh), and latest CDC batch are redrawn every --interval until Ctrl-C.`,
+	Example: `  mirror_cli mirror status orders_sync
+  mirror_cli mirror status orders_sync --watch --interval 5s`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return getMirrorStatus(cmd, args[0])
+		return getMirrorStatus(cmd, resourceName(args[0]))
 	},
 }
 
@@ -54,43 +74,51 @@ var mirrorStatusCmd = &cobra.Command{
 var mirrorPauseCmd = &cobra.Command{
 	Use:   "pause [mirror-name]",
 	Short: "Pause a mirror",
-	Long:  "Pause a running mirror to temporarily stop replication.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Pause a running mirror to temporarily stop replication. With --reason, the
+reason, who paused the mirror, and when are recorded with a CDC mirror and
+shown by 'mirror status' and 'mirror list' until it is resumed.`,
+	Example: `  mirror_cli mirror pause orders_mirror
+  mirror_cli mirror pause orders_mirror --reason "maintenance window INC-1234"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return pauseMirror(cmd, args[0])
+		return pauseMirror(cmd, resourceName(args[0]))
 	},
 }
 
 // mirrorResumeCmd represents the mirror resume command
 var mirrorResumeCmd = &cobra.Command{
-	Use:   "resume [mirror-name]",
-	Short: "Resume a mirror",
-	Long:  "Resume a paused mirror to restart replication.",
-	Args:  cobra.ExactArgs(1),
+	Use:               "resume [mirror-name]",
+	Short:             "Resume a mirror",
+	Long:              "Resume a paused mirror to restart replication.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return resumeMirror(cmd, args[0])
+		return resumeMirror(cmd, resourceName(args[0]))
 	},
 }
 
 // mirrorDropCmd represents the mirror drop command
 var mirrorDropCmd = &cobra.Command{
-	Use:   "drop [mirror-name]",
-	Short: "Drop a mirror",
-	Long:  "Terminate and drop a mirror permanently.",
-	Args:  cobra.ExactArgs(1),
+	Use:               "drop [mirror-name]",
+	Short:             "Drop a mirror",
+	Long:              "Terminate and drop a mirror permanently.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return dropMirror(cmd, args[0])
+		return dropMirror(cmd, resourceName(args[0]))
 	},
 }
 
 // mirrorEditCmd represents the mirror edit command
 var mirrorEditCmd = &cobra.Command{
-	Use:   "edit [mirror-name]",
-	Short: "Edit mirror configuration",
-	Long:  "Update configuration for an existing mirror.",
-	Args:  cobra.ExactArgs(1),
+	Use:               "edit [mirror-name]",
+	Short:             "Edit mirror configuration",
+	Long:              "Update configuration for an existing mirror.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return editMirror(cmd, args[0])
+		return editMirror(cmd, resourceName(args[0]))
 	},
 }
 
@@ -105,40 +133,71 @@ func init() {
 	mirrorCmd.AddCommand(mirrorEditCmd)
 
 	// Create command flags
-	mirrorCreateCmd.Flags().String("name", "", "Mirror name (required)")
-	mirrorCreateCmd.Flags().String("source", "", "Source peer name (required)")
-	mirrorCreateCmd.Flags().String("destination", "", "Destination peer name (required)")
-	mirrorCreateCmd.Flags().StringSlice("tables", []string{}, "Table mappings in format 'source_table->dest_table'")
-	mirrorCreateCmd.Flags().Uint32("batch-size", 1000, "Maximum batch size")
-	mirrorCreateCmd.Flags().Uint64("idle-timeout", 60, "Idle timeout in seconds")
-	mirrorCreateCmd.Flags().Bool("initial-snapshot", true, "Perform initial snapshot")
-	mirrorCreateCmd.Flags().String("publication", "", "PostgreSQL publication name")
-	mirrorCreateCmd.Flags().String("replication-slot", "", "PostgreSQL replication slot name")
+	addMirrorCreateFlags(mirrorCreateCmd)
+	mirrorCreateCmd.Flags().Bool("if-not-exists", false, "Do nothing if the mirror already exists (warns if it differs)")
+	mirrorCreateCmd.Flags().Bool("validate-only", false, "Validate the mirror with the server (peers, tables) without creating it")
+	addNormalizeFlag(mirrorCreateCmd)
+	mirrorCreateCmd.MarkFlagsMutuallyExclusive("if-not-exists", "validate-only")
+	mirrorCreateCmd.Flags().Bool("wait-for-snapshot", false, "Wait for the initial snapshot to complete and print a per-table report")
+	mirrorCreateCmd.Flags().Duration("snapshot-timeout", 0, "Give up waiting for the snapshot after this long, 0 for no limit (default: wait_timeout if set, otherwise no limit)")
+	mirrorCreateCmd.Flags().Duration("poll-interval", 10*time.Second, "How often to check snapshot progress with --wait-for-snapshot")
+	mirrorCreateCmd.MarkFlagsMutuallyExclusive("validate-only", "wait-for-snapshot")
+
+	mirrorCreateCmd.Flags().StringArray("annotate", []string{}, "Provenance annotation to stamp on the mirror (key=value, repeatable)")
+	mirrorCreateCmd.Flags().StringSlice("schema", []string{}, "Mirror every table in these source schemas that has a primary key or REPLICA IDENTITY FULL")
 
 	mirrorCreateCmd.MarkFlagRequired("name")
 	mirrorCreateCmd.MarkFlagRequired("source")
 	mirrorCreateCmd.MarkFlagRequired("destination")
-	mirrorCreateCmd.MarkFlagRequired("tables")
+	mirrorCreateCmd.MarkFlagsOneRequired("tables", "schema")
+
+	// List command flags
+	mirrorListCmd.Flags().Bool("fast", false, "Skip fetching each mirror's state, rows synced, and last batch time")
+	mirrorListCmd.Flags().String("group-by", "", "Group mirrors into sections with subtotals: "+strings.Join(mirrorGroupings, ", "))
+	addAllPrefixesFlag(mirrorListCmd)
+	addTableFlags(mirrorListCmd)
+
+	// Status command flags
+	mirrorStatusCmd.Flags().Duration("stale-after", 30*time.Minute, "Warn when a running mirror has not synced a batch within this window")
+	mirrorStatusCmd.Flags().BoolP("watch", "w", false, "Refresh the state, rows synced, and latest CDC batch until interrupted")
+	mirrorStatusCmd.Flags().Duration("interval", 5*time.Second, "How often to refresh with --watch")
+	mirrorStatusCmd.Flags().Bool("check", false, "Exit 0 only if the mirror is running and synced within --stale-after: 1 paused or not running, 2 failed or terminated, 3 lagging, 4 status unavailable")
+	mirrorStatusCmd.MarkFlagsMutuallyExclusive("watch", "check")
+
+	// Pause command flags
+	mirrorPauseCmd.Flags().String("reason", "", "Why the mirror is paused, e.g. a maintenance window or incident; shown by status and list until resumed (CDC mirrors)")
 
 	// Drop command flags
-	mirrorDropCmd.Flags().Bool("skip-destination-drop", false, "Skip dropping tables in destination")
+	mirrorDropCmd.Flags().Bool("skip-destination-drop", false, "Skip dropping tables in destination (always on with drop_policy keep-destination)")
 	mirrorDropCmd.Flags().Bool("force", false, "Force drop without confirmation")
+	mirrorDropCmd.Flags().Duration("active-window", 15*time.Minute, "Require typing the mirror name if it synced rows within this window or is snapshotting (0 to skip the check)")
 
 	// Edit command flags
 	mirrorEditCmd.Flags().StringSlice("add-tables", []string{}, "Add table mappings")
 	mirrorEditCmd.Flags().StringSlice("remove-tables", []string{}, "Remove table mappings")
+	mirrorEditCmd.Flags().StringSlice("remap", []string{}, "Change the destination of tables in the mirror (source->new_destination); each is removed and re-added, re-snapshotting it")
+	mirrorEditCmd.Flags().Bool("pick-tables", false, "Interactively select source tables to add")
+	mirrorEditCmd.Flags().StringSlice("schema", []string{}, "Source schemas to pick tables from with --pick-tables (default: schemas already in the mirror)")
 	mirrorEditCmd.Flags().Uint32("batch-size", 0, "Update batch size")
 	mirrorEditCmd.Flags().Uint64("idle-timeout", 0, "Update idle timeout")
 }
 
-func createMirror(cmd *cobra.Command) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// addMirrorCreateFlags registers the flags the mirror request is built from
+func addMirrorCreateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Mirror name (required)")
+	cmd.Flags().String("source", "", "Source peer name (required)")
+	cmd.Flags().String("destination", "", "Destination peer name (required)")
+	cmd.Flags().StringSlice("tables", []string{}, "Table mappings in format 'source_table->dest_table'")
+	cmd.Flags().Uint32("batch-size", 1000, "Maximum batch size")
+	cmd.Flags().Uint64("idle-timeout", 60, "Idle timeout in seconds")
+	cmd.Flags().Bool("initial-snapshot", true, "Perform initial snapshot")
+	cmd.Flags().String("publication", "", "PostgreSQL publication name (default: generated from the mirror name)")
+	cmd.Flags().String("replication-slot", "", "PostgreSQL replication slot name (default: generated from the mirror name)")
+}
 
-	// Get flags
-	name, _ := cmd.Flags().GetString("name")
-	source, _ := cmd.Flags().GetString("source")
-	destination, _ := cmd.Flags().GetString("destination")
+// buildMirrorFromFlags builds a CDC mirror request from mirror create's
+// flags. The publication and slot are named later, with the source peer.
+func buildMirrorFromFlags(cmd *cobra.Command, name, source, destination string, env map[string]string) (*pb.CreateCDCFlowRequest, error) {
 	tables, _ := cmd.Flags().GetStringSlice("tables")
 	batchSize, _ := cmd.Flags().GetUint32("batch-size")
 	idleTimeout, _ := cmd.Flags().GetUint64("idle-timeout")
@@ -146,28 +205,12 @@ func createMirror(cmd *cobra.Command) error {
 	publication, _ := cmd.Flags().GetString("publication")
 	replicationSlot, _ := cmd.Flags().GetString("replication-slot")
 
-	// Parse table mappings
-	tableMappings := make([]*pb.TableMapping, 0, len(tables))
-	for _, table := range tables {
-		parts := strings.Split(table, "->")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid table mapping format: %s (expected: source->destination)", table)
-		}
-		tableMappings = append(tableMappings, &pb.TableMapping{
-			SourceTableIdentifier:      strings.TrimSpace(parts[0]),
-			DestinationTableIdentifier: strings.TrimSpace(parts[1]),
-		})
-	}
-
-	// Create client
-	client, err := client.NewClient(GetConfig())
+	tableMappings, err := app.ParseTableMappings(tables)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer client.Close()
 
-	// Create mirror request
-	req := &pb.CreateCDCFlowRequest{
+	return &pb.CreateCDCFlowRequest{
 		ConnectionConfigs: &pb.FlowConnectionConfigs{
 			FlowJobName:         name,
 			SourceName:          source,
@@ -178,7 +221,93 @@ func createMirror(cmd *cobra.Command) error {
 			DoInitialSnapshot:   initialSnapshot,
 			PublicationName:     publication,
 			ReplicationSlotName: replicationSlot,
+			Env:                 env,
 		},
+	}, nil
+}
+
+func createMirror(cmd *cobra.Command) error {
+	ctx := commandContext()
+
+	// Get flags
+	name, _ := cmd.Flags().GetString("name")
+	source, _ := cmd.Flags().GetString("source")
+	destination, _ := cmd.Flags().GetString("destination")
+	initialSnapshot, _ := cmd.Flags().GetBool("initial-snapshot")
+	annotate, _ := cmd.Flags().GetStringArray("annotate")
+	ifNotExists, _ := cmd.Flags().GetBool("if-not-exists")
+	validateOnly, _ := cmd.Flags().GetBool("validate-only")
+	wait, _ := cmd.Flags().GetBool("wait-for-snapshot")
+	snapshotTimeout := unboundedWaitTimeout(cmd, "snapshot-timeout")
+	pollInterval, _ := cmd.Flags().GetDuration("poll-interval")
+	schemas, _ := cmd.Flags().GetStringSlice("schema")
+
+	if wait && !initialSnapshot {
+		return fmt.Errorf("--wait-for-snapshot needs --initial-snapshot")
+	}
+
+	name, err := checkName(cmd, "mirror", name)
+	if err != nil {
+		return err
+	}
+	if source, err = checkName(cmd, "source peer", source); err != nil {
+		return err
+	}
+	if destination, err = checkName(cmd, "destination peer", destination); err != nil {
+		return err
+	}
+
+	annotations, err := provenance.ParseAnnotations(annotate)
+	if err != nil {
+		return err
+	}
+
+	// Create mirror request
+	req, err := buildMirrorFromFlags(cmd, name, source, destination, provenance.Collect(".", annotations))
+	if err != nil {
+		return err
+	}
+	tableMappings := req.ConnectionConfigs.TableMappings
+
+	// Create client
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ifNotExists {
+		exists, err := client.MirrorExists(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing mirror: %w", err)
+		}
+		if exists {
+			return reportExistingMirror(ctx, client, req.ConnectionConfigs)
+		}
+	}
+
+	if len(schemas) > 0 {
+		req.ConnectionConfigs.TableMappings, err = schemaTableMappings(ctx, client, source, schemas, tableMappings)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		tableMappings = req.ConnectionConfigs.TableMappings
+	}
+
+	// Show which publication and slot the mirror will use
+	checkReplicationNames(ctx, client, req.ConnectionConfigs)
+
+	if validateOnly {
+		cmd.SilenceUsage = true
+		if err := client.ValidateCDCMirror(ctx, req); err != nil {
+			return fmt.Errorf("mirror validation failed: %w", err)
+		}
+		fmt.Printf("✅ Mirror '%s' is valid (not created)\n", name)
+		fmt.Printf("  Source: %s\n", source)
+		fmt.Printf("  Destination: %s\n", destination)
+		fmt.Printf("  Tables: %d\n", len(tableMappings))
+		return nil
 	}
 
 	// Create the mirror
@@ -187,21 +316,53 @@ func createMirror(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to create mirror: %w", err)
 	}
 
+	recordWorkflow(name, resp.WorkflowId)
+
 	fmt.Printf("✓ Mirror '%s' created successfully\n", name)
 	fmt.Printf("  Workflow ID: %s\n", resp.WorkflowId)
 	fmt.Printf("  Source: %s\n", source)
 	fmt.Printf("  Destination: %s\n", destination)
 	fmt.Printf("  Tables: %d\n", len(tableMappings))
 
+	if wait {
+		cmd.SilenceUsage = true
+		waitCtx, cancelWait := withWaitTimeout(commandContext(), snapshotTimeout)
+		defer cancelWait()
+		return waitForSnapshot(waitCtx, client, name, pollInterval)
+	}
+
 	return nil
 }
 
+// reportExistingMirror compares a requested mirror with the existing one
+func reportExistingMirror(ctx context.Context, grpcClient *client.Client, configs *pb.FlowConnectionConfigs) error {
+	status, err := grpcClient.GetMirrorStatus(ctx, configs.FlowJobName)
+	if err != nil {
+		return fmt.Errorf("failed to get existing mirror: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' exists but is not a CDC mirror", configs.FlowJobName)
+	}
+
+	desired := config.FromMirrorProto(configs, "")
+	actual := config.FromMirrorProto(status.CdcStatus.Config, "")
+	return reportExisting(desired, actual, "drop and recreate it, or use 'mirror edit' to change it")
+}
+
 func listMirrors(cmd *cobra.Command) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+	fast, _ := cmd.Flags().GetBool("fast")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	if err := validateGroupBy(groupBy, fast); err != nil {
+		return err
+	}
+
+	ctx := commandContext()
 
 	// Create client
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
@@ -213,42 +374,171 @@ func listMirrors(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to list mirrors: %w", err)
 	}
 
-	if len(resp.Mirrors) == 0 {
-		fmt.Println("No mirrors found")
-		return nil
+	var hidden int
+	resp.Mirrors, hidden = ownedMirrors(resp.Mirrors, ownedNames(cmd))
+	if tableOutput(cmd) {
+		if len(resp.Mirrors) == 0 {
+			fmt.Println("No mirrors found")
+			printHidden(hidden, "mirror")
+			return nil
+		}
+		defer printHidden(hidden, "mirror")
 	}
 
-	// Print header
-	fmt.Printf("%-20s %-15s %-15s %-10s %-12s\n", "NAME", "SOURCE", "DESTINATION", "TYPE", "CREATED")
-	fmt.Println(strings.Repeat("-", 80))
+	var summaries []app.MirrorSummary
+	if !fast {
+		summaries = app.SummarizeMirrors(ctx, client, resp.Mirrors, time.Now())
+	}
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "NAME", Key: "name"},
+		{Header: "SOURCE", Key: "source"},
+		{Header: "DESTINATION", Key: "destination"},
+		{Header: "TYPE", Key: "type"},
+		{Header: "CREATED", Key: "created_at", Format: timeColumn},
+		{Header: "AGE", Key: "age_seconds", Right: true, Format: ageColumn},
+	}}
+	if !fast {
+		table.Columns = append(table.Columns,
+			printer.Column{Header: "STATE", Key: "state", Aliases: []string{"status"}},
+			printer.Column{Header: "ROWS SYNCED", Key: "rows_synced", Right: true, Format: rowsColumn},
+			printer.Column{Header: "LAST BATCH", Key: "last_batch", Format: timeColumn},
+			printer.Column{Header: "PAUSE REASON", Key: "pause_reason"},
+		)
+	}
 
-	// Print mirrors
-	for _, mirror := range resp.Mirrors {
+	now := time.Now()
+	var recomputed int
+	for i, mirror := range resp.Mirrors {
 		mirrorType := "QRep"
 		if mirror.IsCdc {
 			mirrorType = "CDC"
 		}
 
-		createdAt := time.Unix(int64(mirror.CreatedAt), 0).Format("2006-01-02")
+		var status *pb.MirrorStatusResponse
+		if !fast {
+			status = summaries[i].Status
+		}
+		created, fixed := mirrorCreatedAt(mirror, status)
+		if fixed {
+			recomputed++
+		}
+		var createdAt, age interface{}
+		if !created.IsZero() {
+			createdAt, age = created, int64(now.Sub(created).Seconds())
+		}
 
-		fmt.Printf("%-20s %-15s %-15s %-10s %-12s\n",
+		row := []interface{}{
 			mirror.Name,
 			mirror.SourceName,
 			mirror.DestinationName,
 			mirrorType,
 			createdAt,
-		)
+			age,
+		}
+		if !fast {
+			var state, rows, lastBatch, reason interface{} = "UNAVAILABLE", nil, nil, nil
+			if summary := summaries[i]; summary.Err == nil {
+				state = stateName(summary.State)
+				if mirror.IsCdc {
+					rows = summary.RowsSynced
+				}
+				// Empty for mirrors that haven't synced a batch yet
+				if last := app.LastSyncActivity(summary.Status.GetCdcStatus().GetCdcBatches()); !last.IsZero() {
+					lastBatch = last
+				}
+				if note, ok := pauseNote(summary.Status); ok {
+					reason = note.Reason
+				}
+			}
+			row = append(row, state, rows, lastBatch, reason)
+		}
+		table.AddRow(row...)
 	}
 
-	return nil
+	if recomputed > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ The server's mirror list reported a wrong creation time for %d mirror(s); showing the time from their status instead\n", recomputed)
+	}
+
+	if groupBy != "" {
+		return printGroupedMirrors(cmd, table, groupBy)
+	}
+	return printTable(cmd, table)
+}
+
+// printSpecDrift reports whether a mirror's live configuration still
+// matches the spec it was last applied from. Mirrors that weren't applied
+// from a file have no recorded hash and are skipped.
+func printSpecDrift(cfg *pb.FlowConnectionConfigs) {
+	drift, ok := specDrift(cfg)
+	switch {
+	case !ok:
+	case drift.Err != nil:
+		fmt.Printf("Spec: UNKNOWN (%v)\n", drift.Err)
+	case drift.State == specClean:
+		fmt.Printf("Spec: CLEAN (matches the last applied spec %s)\n", shortHash(drift.Applied))
+	default:
+		fmt.Printf("Spec: %s (live config differs from the last applied spec %s)\n", yellow("DRIFTED"), shortHash(drift.Applied))
+		fmt.Printf("💡 Export the live config to compare: mirror_cli config export-mirror %s\n", cfg.FlowJobName)
+	}
+}
+
+// Spec drift states
+const (
+	specClean   = "CLEAN"
+	specDrifted = "DRIFTED"
+	specUnknown = "UNKNOWN"
+)
+
+// mirrorSpecDrift is whether a mirror's live configuration matches the
+// spec it was last applied from
+type mirrorSpecDrift struct {
+	State   string
+	Applied string
+	Err     error
+}
+
+// specDrift compares a mirror's live configuration with the spec it was
+// last applied from, or returns false if it wasn't applied from a file
+func specDrift(cfg *pb.FlowConnectionConfigs) (mirrorSpecDrift, bool) {
+	applied, ok := cfg.Env[config.SpecHashKey]
+	if !ok {
+		return mirrorSpecDrift{}, false
+	}
+	live, err := config.SpecHash(cfg)
+	switch {
+	case err != nil:
+		return mirrorSpecDrift{State: specUnknown, Applied: applied, Err: err}, true
+	case live == applied:
+		return mirrorSpecDrift{State: specClean, Applied: applied}, true
+	default:
+		return mirrorSpecDrift{State: specDrifted, Applied: applied}, true
+	}
+}
+
+// shortHash abbreviates a hash for display
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 12)]
 }
 
 func getMirrorStatus(cmd *cobra.Command, mirrorName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
+
+	staleAfter, _ := cmd.Flags().GetDuration("stale-after")
+	check, _ := cmd.Flags().GetBool("check")
+	output, err := reportOutput(cmd, printer.FormatJSON, printer.FormatYAML)
+	if err != nil {
+		return err
+	}
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		if output != printer.FormatText {
+			return fmt.Errorf("--watch redraws text for a terminal; drop -o %s", output)
+		}
+		return watchMirrorStatus(cmd, mirrorName)
+	}
 
 	// Create client
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
@@ -257,19 +547,67 @@ func getMirrorStatus(cmd *cobra.Command, mirrorName string) error {
 	// Get mirror status
 	resp, err := client.GetMirrorStatus(ctx, mirrorName)
 	if err != nil {
+		if check {
+			cmd.SilenceUsage = true
+			return &ExitError{Code: checkExitUnknown, Message: fmt.Sprintf("failed to get mirror status: %v", err)}
+		}
 		return fmt.Errorf("failed to get mirror status: %w", err)
 	}
 
+	if output != printer.FormatText {
+		report := newMirrorStatusReport(resp, staleAfter)
+		if err := printReport(output, report); err != nil {
+			return err
+		}
+		if check {
+			if err := checkMirrorHealth(mirrorName, resp.CurrentFlowState, mirrorStale(resp, staleAfter), staleAfter); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+		return nil
+	}
+
 	// Print status
 	fmt.Printf("Mirror: %s\n", resp.FlowJobName)
 	fmt.Printf("Status: %s\n", resp.CurrentFlowState.String())
+	if note, ok := pauseNote(resp); ok {
+		fmt.Printf("Pause Reason: %s\n", formatPauseNote(note))
+	}
 
-	if resp.CreatedAt != nil {
-		fmt.Printf("Created: %s\n", resp.CreatedAt.AsTime().Format(time.RFC3339))
+	if created, ok := timestamps.FromProto(resp.CreatedAt); ok {
+		fmt.Printf("Created: %s\n", formatTime(created))
 	}
 
+	if resp.QrepStatus != nil {
+		completed := 0
+		for _, partition := range resp.QrepStatus.Partitions {
+			if partition.EndTime != nil {
+				completed++
+			}
+		}
+		fmt.Printf("Partitions: %d of %d completed (see 'mirror_cli mirror partitions %s')\n",
+			completed, len(resp.QrepStatus.Partitions), mirrorName)
+
+		if qrepConfig := resp.QrepStatus.Config; qrepConfig != nil {
+			fmt.Printf("Table: %s -> %s\n", qrepConfig.WatermarkTable, qrepConfig.DestinationTableIdentifier)
+			writeMode := config.WriteModeName(qrepConfig.WriteMode)
+			if keys := qrepConfig.WriteMode.GetUpsertKeyColumns(); len(keys) > 0 {
+				writeMode += fmt.Sprintf(" (unique key: %s)", strings.Join(keys, ", "))
+			}
+			fmt.Printf("Write Mode: %s\n", writeMode)
+			if annotations := provenance.Extract(qrepConfig.Env); len(annotations) > 0 {
+				fmt.Println("Annotations:")
+				for _, annotation := range annotations {
+					fmt.Printf("  %s\n", annotation)
+				}
+			}
+		}
+	}
+
+	stale := false
 	if resp.CdcStatus != nil {
-		fmt.Printf("Rows Synced: %d\n", resp.CdcStatus.RowsSynced)
+		fmt.Printf("Rows Synced: %s\n", formatRows(resp.CdcStatus.RowsSynced))
 		fmt.Printf("Source Type: %s\n", resp.CdcStatus.SourceType.String())
 		fmt.Printf("Destination Type: %s\n", resp.CdcStatus.DestinationType.String())
 
@@ -278,141 +616,303 @@ func getMirrorStatus(cmd *cobra.Command, mirrorName string) error {
 		}
 
 		fmt.Printf("CDC Batches: %d\n", len(resp.CdcStatus.CdcBatches))
+
+		if resp.CdcStatus.Config != nil {
+			if annotations := provenance.Extract(resp.CdcStatus.Config.Env); len(annotations) > 0 {
+				fmt.Println("Annotations:")
+				for _, annotation := range annotations {
+					fmt.Printf("  %s\n", annotation)
+				}
+			}
+			printSpecDrift(resp.CdcStatus.Config)
+		}
+
+		lastActivity := app.LastSyncActivity(resp.CdcStatus.CdcBatches)
+		if lastActivity.IsZero() {
+			fmt.Println("Last Sync Activity: never")
+		} else {
+			fmt.Printf("Last Sync Activity: %s\n", formatTime(lastActivity))
+		}
+
+		if stale = mirrorStale(resp, staleAfter); stale {
+			fmt.Println(yellow(fmt.Sprintf("⚠ Mirror is running but has not synced a batch in over %s", staleAfter)))
+		}
 	}
 
+	if check {
+		if err := checkMirrorHealth(mirrorName, resp.CurrentFlowState, stale, staleAfter); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+	}
 	return nil
 }
 
+// mirrorStale reports whether a running CDC mirror has not synced a batch
+// within staleAfter. New mirrors get a grace period from their creation.
+func mirrorStale(resp *pb.MirrorStatusResponse, staleAfter time.Duration) bool {
+	if resp.CdcStatus == nil || resp.CurrentFlowState != pb.FlowStatus_STATUS_RUNNING {
+		return false
+	}
+	lastActivity := app.LastSyncActivity(resp.CdcStatus.CdcBatches)
+	if created, ok := timestamps.FromProto(resp.CreatedAt); lastActivity.IsZero() && ok {
+		lastActivity = created
+	}
+	return time.Since(lastActivity) > staleAfter
+}
+
+// mirror status --check exit codes
+const (
+	checkExitNotRunning = 1
+	checkExitFailed     = 2
+	checkExitLagging    = 3
+	checkExitUnknown    = 4
+)
+
+// checkMirrorHealth returns an ExitError with the --check exit code of a
+// mirror that isn't healthy, or nil if it is running and not stale
+func checkMirrorHealth(mirrorName string, state pb.FlowStatus, stale bool, staleAfter time.Duration) error {
+	switch state {
+	case pb.FlowStatus_STATUS_RUNNING:
+		if stale {
+			return &ExitError{Code: checkExitLagging, Message: fmt.Sprintf("mirror '%s' has not synced a batch in over %s", mirrorName, staleAfter)}
+		}
+		return nil
+	case pb.FlowStatus_STATUS_FAILED, pb.FlowStatus_STATUS_TERMINATING, pb.FlowStatus_STATUS_TERMINATED:
+		return &ExitError{Code: checkExitFailed, Message: fmt.Sprintf("mirror '%s' is %s", mirrorName, stateName(state))}
+	default:
+		return &ExitError{Code: checkExitNotRunning, Message: fmt.Sprintf("mirror '%s' is %s, not RUNNING", mirrorName, stateName(state))}
+	}
+}
+
 func pauseMirror(cmd *cobra.Command, mirrorName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
 
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	if err := client.PauseMirror(ctx, mirrorName); err != nil {
-		return fmt.Errorf("failed to pause mirror: %w", err)
-	}
-
-	fmt.Printf("✓ Mirror '%s' paused successfully\n", mirrorName)
-	return nil
+	reason, _ := cmd.Flags().GetString("reason")
+	return mirrorService(client).Pause(ctx, mirrorName, strings.TrimSpace(reason))
 }
 
 func resumeMirror(cmd *cobra.Command, mirrorName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
 
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	if err := client.ResumeMirror(ctx, mirrorName); err != nil {
-		return fmt.Errorf("failed to resume mirror: %w", err)
-	}
-
-	fmt.Printf("✓ Mirror '%s' resumed successfully\n", mirrorName)
-	return nil
+	return mirrorService(client).Resume(ctx, mirrorName)
 }
 
 func dropMirror(cmd *cobra.Command, mirrorName string) error {
 	skipDestinationDrop, _ := cmd.Flags().GetBool("skip-destination-drop")
 	force, _ := cmd.Flags().GetBool("force")
+	activeWindow, _ := cmd.Flags().GetDuration("active-window")
 
-	// Confirmation unless forced
-	if !force {
-		fmt.Printf("Are you sure you want to drop mirror '%s'? This action cannot be undone. (y/N): ", mirrorName)
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
-			fmt.Println("Operation cancelled")
-			return nil
+	// The drop policy overrides the flag default and can't be overridden
+	if GetConfig().KeepDestination() {
+		if cmd.Flags().Changed("skip-destination-drop") && !skipDestinationDrop {
+			return fmt.Errorf("drop_policy is %s; destination tables can't be dropped", config.DropPolicyKeepDestination)
 		}
+		skipDestinationDrop = true
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	if err := client.DropMirror(ctx, mirrorName, skipDestinationDrop); err != nil {
-		return fmt.Errorf("failed to drop mirror: %w", err)
+	// Warn before dropping a mirror that is still moving data
+	activity := ""
+	if activeWindow > 0 {
+		activity, err = mirrorActivity(commandContext(), client, mirrorName, activeWindow)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not check whether mirror '%s' is active: %v\n", mirrorName, err)
+		}
+	}
+	if activity != "" {
+		fmt.Printf("⚠ Mirror '%s' appears active: %s\n", mirrorName, activity)
 	}
 
-	fmt.Printf("✓ Mirror '%s' dropped successfully\n", mirrorName)
-	return nil
+	// Confirmation unless forced
+	if !force {
+		if skipDestinationDrop {
+			fmt.Println("Destination tables will be kept.")
+		}
+		question := fmt.Sprintf("Are you sure you want to drop mirror '%s'?", mirrorName)
+		if !confirmDestructive("mirror", mirrorName, question, activity != "") {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	ctx := commandContext()
+
+	return mirrorService(client).Drop(ctx, mirrorName, skipDestinationDrop)
 }
 
 func editMirror(cmd *cobra.Command, mirrorName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	addTables, _ := cmd.Flags().GetStringSlice("add-tables")
 	removeTables, _ := cmd.Flags().GetStringSlice("remove-tables")
+	remapTables, _ := cmd.Flags().GetStringSlice("remap")
+	pick, _ := cmd.Flags().GetBool("pick-tables")
+	schemas, _ := cmd.Flags().GetStringSlice("schema")
 	batchSize, _ := cmd.Flags().GetUint32("batch-size")
 	idleTimeout, _ := cmd.Flags().GetUint64("idle-timeout")
 
-	// Parse additional tables
-	additionalTables := make([]*pb.TableMapping, 0, len(addTables))
-	for _, table := range addTables {
-		parts := strings.Split(table, "->")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid table mapping format: %s", table)
-		}
-		additionalTables = append(additionalTables, &pb.TableMapping{
-			SourceTableIdentifier:      strings.TrimSpace(parts[0]),
-			DestinationTableIdentifier: strings.TrimSpace(parts[1]),
-		})
+	edit := app.MirrorEdit{BatchSize: batchSize, IdleTimeout: idleTimeout}
+	var err error
+	if edit.AddTables, err = app.ParseTableMappings(addTables); err != nil {
+		return err
 	}
-
-	// Parse tables to remove
-	removedTables := make([]*pb.TableMapping, 0, len(removeTables))
-	for _, table := range removeTables {
-		parts := strings.Split(table, "->")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid table mapping format: %s", table)
-		}
-		removedTables = append(removedTables, &pb.TableMapping{
-			SourceTableIdentifier:      strings.TrimSpace(parts[0]),
-			DestinationTableIdentifier: strings.TrimSpace(parts[1]),
-		})
+	if edit.RemoveTables, err = app.ParseTableMappings(removeTables); err != nil {
+		return err
+	}
+	if edit.RemapTables, err = app.ParseTableMappings(remapTables); err != nil {
+		return err
 	}
 
-	// Build update request
-	cdcUpdate := &pb.CDCFlowConfigUpdate{
-		AdditionalTables: additionalTables,
-		RemovedTables:    removedTables,
+	if len(schemas) > 0 && !pick {
+		return fmt.Errorf("--schema can only be used with --pick-tables")
 	}
 
-	if batchSize > 0 {
-		cdcUpdate.BatchSize = batchSize
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
 	}
-	if idleTimeout > 0 {
-		cdcUpdate.IdleTimeout = idleTimeout
+	defer client.Close()
+
+	cmd.SilenceUsage = true
+
+	if err := checkEditSupport(commandContext(), cmd, client, mirrorName); err != nil {
+		return err
 	}
 
-	update := &pb.FlowConfigUpdate{
-		CdcFlowConfigUpdate: cdcUpdate,
+	if pick {
+		picked, err := pickTables(commandContext(), client, mirrorName, schemas)
+		if err != nil {
+			return err
+		}
+		if len(picked) == 0 && len(edit.AddTables) == 0 && len(edit.RemoveTables) == 0 && len(edit.RemapTables) == 0 && batchSize == 0 && idleTimeout == 0 {
+			fmt.Println("No tables selected; mirror unchanged")
+			return nil
+		}
+		for _, mapping := range picked {
+			fmt.Printf("  + %s -> %s\n", mapping.SourceTableIdentifier, mapping.DestinationTableIdentifier)
+		}
+		edit.AddTables = append(edit.AddTables, picked...)
 	}
 
-	client, err := client.NewClient(GetConfig())
-	if err != nil {
-		return err
+	return mirrorService(client).Edit(commandContext(), mirrorName, edit)
+}
+
+// mirrorService returns the mirror service for a client, printing to stdout
+func mirrorService(grpcClient *client.Client) *app.Mirrors {
+	return &app.Mirrors{Client: grpcClient, Out: app.NewPrinter(os.Stdout)}
+}
+
+// mirrorStatusReport is the mirror status document
+type mirrorStatusReport struct {
+	Name        string            `json:"name"`
+	State       string            `json:"state"`
+	Type        string            `json:"type"`
+	CreatedAt   *time.Time        `json:"created_at"`
+	Pause       *pauseReport      `json:"pause,omitempty"`
+	Annotations []string          `json:"annotations,omitempty"`
+	CDC         *cdcStatusReport  `json:"cdc,omitempty"`
+	QRep        *qrepStatusReport `json:"qrep,omitempty"`
+	Spec        *specDriftReport  `json:"spec,omitempty"`
+}
+
+// pauseReport is the reason recorded with mirror pause --reason
+type pauseReport struct {
+	Reason string     `json:"reason"`
+	By     string     `json:"by,omitempty"`
+	At     *time.Time `json:"at,omitempty"`
+}
+
+type cdcStatusReport struct {
+	RowsSynced       int64      `json:"rows_synced"`
+	SourceType       string     `json:"source_type"`
+	DestinationType  string     `json:"destination_type"`
+	SnapshotTables   int        `json:"snapshot_tables"`
+	CDCBatches       int        `json:"cdc_batches"`
+	LastSyncActivity *time.Time `json:"last_sync_activity"`
+	// Stale is set for a running mirror without a batch within
+	// --stale-after
+	Stale bool `json:"stale"`
+}
+
+type qrepStatusReport struct {
+	SourceTable         string   `json:"source_table"`
+	DestinationTable    string   `json:"destination_table"`
+	WriteMode           string   `json:"write_mode"`
+	UpsertKeyColumns    []string `json:"upsert_key_columns,omitempty"`
+	PartitionsCompleted int      `json:"partitions_completed"`
+	PartitionsTotal     int      `json:"partitions_total"`
+}
+
+type specDriftReport struct {
+	State       string `json:"state"`
+	AppliedHash string `json:"applied_hash"`
+	Error       string `json:"error,omitempty"`
+}
+
+// newMirrorStatusReport builds the mirror status document
+func newMirrorStatusReport(resp *pb.MirrorStatusResponse, staleAfter time.Duration) mirrorStatusReport {
+	report := mirrorStatusReport{
+		Name:      resp.FlowJobName,
+		State:     stateName(resp.CurrentFlowState),
+		CreatedAt: optionalTime(timestamps.FromProto(resp.CreatedAt)),
+	}
+	if note, ok := pauseNote(resp); ok {
+		report.Pause = &pauseReport{Reason: note.Reason, By: note.By, At: optionalTime(note.At, !note.At.IsZero())}
 	}
-	defer client.Close()
 
-	if err := client.UpdateMirror(ctx, mirrorName, update); err != nil {
-		return fmt.Errorf("failed to update mirror: %w", err)
+	if cdc := resp.CdcStatus; cdc != nil {
+		report.Type = "CDC"
+		report.CDC = &cdcStatusReport{
+			RowsSynced:       cdc.RowsSynced,
+			SourceType:       cdc.SourceType.String(),
+			DestinationType:  cdc.DestinationType.String(),
+			SnapshotTables:   len(cdc.SnapshotStatus.GetClones()),
+			CDCBatches:       len(cdc.CdcBatches),
+			LastSyncActivity: optionalTime(app.LastSyncActivity(cdc.CdcBatches), len(cdc.CdcBatches) > 0),
+			Stale:            mirrorStale(resp, staleAfter),
+		}
+		if cfg := cdc.Config; cfg != nil {
+			report.Annotations = provenance.Extract(cfg.Env)
+			if drift, ok := specDrift(cfg); ok {
+				report.Spec = &specDriftReport{State: drift.State, AppliedHash: drift.Applied}
+				if drift.Err != nil {
+					report.Spec.Error = drift.Err.Error()
+				}
+			}
+		}
 	}
 
-	fmt.Printf("✓ Mirror '%s' updated successfully\n", mirrorName)
-	return nil
+	if qrep := resp.QrepStatus; qrep != nil {
+		report.Type = "QRep"
+		report.QRep = &qrepStatusReport{PartitionsTotal: len(qrep.Partitions)}
+		for _, partition := range qrep.Partitions {
+			if partition.EndTime != nil {
+				report.QRep.PartitionsCompleted++
+			}
+		}
+		if cfg := qrep.Config; cfg != nil {
+			report.QRep.SourceTable = cfg.WatermarkTable
+			report.QRep.DestinationTable = cfg.DestinationTableIdentifier
+			report.QRep.WriteMode = config.WriteModeName(cfg.WriteMode)
+			report.QRep.UpsertKeyColumns = cfg.WriteMode.GetUpsertKeyColumns()
+			report.Annotations = provenance.Extract(cfg.Env)
+		}
+	}
+	return report
 }
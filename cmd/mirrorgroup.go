@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/printer"
+)
+
+// mirrorGroupings are the columns mirror list --group-by accepts
+var mirrorGroupings = []string{"source", "destination", "state"}
+
+// validateGroupBy checks --group-by before contacting PeerDB
+func validateGroupBy(groupBy string, fast bool) error {
+	if groupBy == "" {
+		return nil
+	}
+	if !slices.Contains(mirrorGroupings, groupBy) {
+		return fmt.Errorf("unsupported --group-by: %s (expected %s)", groupBy, strings.Join(mirrorGroupings, ", "))
+	}
+	if groupBy == "state" && fast {
+		return fmt.Errorf("--group-by state needs each mirror's state; drop --fast")
+	}
+	return nil
+}
+
+// printGroupedMirrors prints the mirror list in one section per value of
+// the column key, each with a subtotal. Other output formats print the
+// rows sorted by that column.
+func printGroupedMirrors(cmd *cobra.Command, table *printer.Table, key string) error {
+	col := slices.IndexFunc(table.Columns, func(c printer.Column) bool { return c.Key == key })
+	sort.SliceStable(table.Rows, func(i, j int) bool {
+		return fmt.Sprint(table.Rows[i][col]) < fmt.Sprint(table.Rows[j][col])
+	})
+	if !tableOutput(cmd) {
+		return printTable(cmd, table)
+	}
+
+	if _, err := selectColumns(cmd, table); err != nil {
+		return err
+	}
+	// Sections leave out the grouped column, which is in their heading
+	selected, _ := cmd.Flags().GetStringSlice("columns")
+	selected = slices.DeleteFunc(slices.Clone(selected), table.Columns[col].Matches)
+
+	rowsCol := slices.IndexFunc(table.Columns, func(c printer.Column) bool { return c.Key == "rows_synced" })
+	columns := slices.Delete(slices.Clone(table.Columns), col, col+1)
+
+	groups := 0
+	for start := 0; start < len(table.Rows); {
+		value := fmt.Sprint(table.Rows[start][col])
+		end := start
+		section := &printer.Table{Columns: columns}
+		var rows int64
+		for ; end < len(table.Rows) && fmt.Sprint(table.Rows[end][col]) == value; end++ {
+			row := table.Rows[end]
+			section.AddRow(slices.Delete(slices.Clone(row), col, col+1)...)
+			if rowsCol >= 0 && row[rowsCol] != nil {
+				rows += row[rowsCol].(int64)
+			}
+		}
+
+		if groups > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s: %s\n", table.Columns[col].Header[:1]+strings.ToLower(table.Columns[col].Header[1:]), value)
+		if len(selected) > 0 {
+			var err error
+			if section, err = section.Select(selected); err != nil {
+				return err
+			}
+		}
+		if err := (printer.TablePrinter{}).Print(os.Stdout, section); err != nil {
+			return err
+		}
+		subtotal := fmt.Sprintf("%d mirror(s)", end-start)
+		if rowsCol >= 0 {
+			subtotal += fmt.Sprintf(", %s rows synced", formatRows(rows))
+		}
+		fmt.Printf("Subtotal: %s\n", subtotal)
+
+		groups++
+		start = end
+	}
+	fmt.Printf("\nTotal: %d mirror(s) across %d %s(s)\n", len(table.Rows), groups, key)
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorWatch is what mirror status --watch remembers between refreshes
+type mirrorWatch struct {
+	rows  rowCounter
+	state pb.FlowStatus
+	seen  bool
+}
+
+// watchMirrorStatus redraws the state, rows synced, and latest CDC batch of
+// a mirror every interval until interrupted
+func watchMirrorStatus(cmd *cobra.Command, mirrorName string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	staleAfter, _ := cmd.Flags().GetDuration("stale-after")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	watch := &mirrorWatch{}
+	return watchLoop(ctx, interval, func(now time.Time) (func(), error) {
+		resp, err := client.GetMirrorStatus(ctx, mirrorName)
+		if err != nil {
+			// Keep the previous rows, so the rate spans the outage
+			return nil, fmt.Errorf("failed to get mirror status: %w", err)
+		}
+		return func() { watch.print(resp, now, staleAfter) }, nil
+	})
+}
+
+// print prints a refresh of the mirror's status and remembers it
+func (w *mirrorWatch) print(resp *pb.MirrorStatusResponse, now time.Time, staleAfter time.Duration) {
+	fmt.Printf("Mirror: %s\n", resp.FlowJobName)
+	state := stateName(resp.CurrentFlowState)
+	if w.seen && resp.CurrentFlowState != w.state {
+		state += yellow(fmt.Sprintf(" (was %s)", stateName(w.state)))
+	}
+	fmt.Printf("Status: %s\n", state)
+	if note, ok := pauseNote(resp); ok {
+		fmt.Printf("Pause Reason: %s\n", formatPauseNote(note))
+	}
+
+	if qrep := resp.QrepStatus; qrep != nil {
+		completed, failed := 0, 0
+		for _, partition := range qrep.Partitions {
+			switch partitionState(partition, resp.CurrentFlowState) {
+			case partitionCompleted:
+				completed++
+			case partitionFailed:
+				failed++
+			}
+		}
+		line := fmt.Sprintf("Partitions: %d of %d completed", completed, len(qrep.Partitions))
+		if failed > 0 {
+			line += red(fmt.Sprintf(", %d failed", failed))
+		}
+		fmt.Println(line)
+	}
+
+	if cdc := resp.CdcStatus; cdc != nil {
+		line := "Rows Synced: " + formatRows(cdc.RowsSynced)
+		if delta, rate, ok := w.rows.update(cdc.RowsSynced, now); ok {
+			line += fmt.Sprintf(" (+%s, %s rows/sec)", formatRows(delta), formatRate(rate))
+		}
+		fmt.Println(line)
+		printLatestBatch(cdc.CdcBatches)
+
+		if mirrorStale(resp, staleAfter) {
+			fmt.Println(yellow(fmt.Sprintf("⚠ Mirror is running but has not synced a batch in over %s", staleAfter)))
+		}
+	}
+
+	w.state, w.seen = resp.CurrentFlowState, true
+}
+
+// printLatestBatch prints the most recent CDC batch: its rows, LSN range,
+// and when it ended, or when it started if it is still running
+func printLatestBatch(batches []*pb.CDCBatch) {
+	var latest *pb.CDCBatch
+	for _, batch := range batches {
+		if latest == nil || batch.BatchId > latest.BatchId {
+			latest = batch
+		}
+	}
+	if latest == nil {
+		fmt.Println("Latest CDC Batch: none yet")
+		return
+	}
+
+	line := fmt.Sprintf("Latest CDC Batch: #%d, %s rows, LSN %d-%d", latest.BatchId, formatRows(latest.NumRows), latest.StartLsn, latest.EndLsn)
+	start, started := timestamps.FromProto(latest.StartTime)
+	if end, ok := timestamps.FromProto(latest.EndTime); ok {
+		line += ", ended " + formatTime(end)
+		if started {
+			line += fmt.Sprintf(" (took %s)", formatDuration(end.Sub(start)))
+		}
+	} else if started {
+		line += ", started " + formatTime(start) + ", still running"
+	}
+	fmt.Println(line)
+}
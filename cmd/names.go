@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// addNormalizeFlag registers --normalize on a command that creates peers or
+// mirrors
+func addNormalizeFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("normalize", false, "Fix names PeerDB would reject (lowercase, dashes to underscores) instead of failing")
+}
+
+// checkName validates a peer or mirror name given on the command line, so
+// invalid names fail before anything is sent to the server. With
+// --normalize the name is fixed instead and the change is reported. The
+// context's name_prefix is added if the name doesn't have it yet.
+func checkName(cmd *cobra.Command, kind, name string) (string, error) {
+	if normalize, _ := cmd.Flags().GetBool("normalize"); normalize {
+		if normalized := config.NormalizeResourceName(name); normalized != name {
+			fmt.Printf("✓ Normalized %s name '%s' -> '%s'\n", kind, name, normalized)
+			name = normalized
+		}
+	}
+	if prefixed := resourceName(name); prefixed != name {
+		fmt.Printf("✓ Prefixed %s name '%s' -> '%s' (name_prefix)\n", kind, name, prefixed)
+		name = prefixed
+	}
+	if err := config.ValidateResourceName(kind, name); err != nil {
+		return "", fmt.Errorf("%w (use --normalize to fix it)", err)
+	}
+	return name, nil
+}
+
+// resourceName returns a peer or mirror name with the context's
+// name_prefix, added if the name doesn't have it yet. Every command that
+// takes a name resolves it here, so resources are found by the name they
+// were created with.
+func resourceName(name string) string {
+	return config.PrefixName(GetConfig().NamePrefix, name)
+}
+
+// checkConfigNames validates the peer and mirror names of configs, fixing
+// them with --normalize and adding the context's name_prefix
+func checkConfigNames(cmd *cobra.Command, configs []*config.FileConfig) error {
+	normalize, _ := cmd.Flags().GetBool("normalize")
+	prefix := GetConfig().NamePrefix
+	prefixed := 0
+	for _, cfg := range configs {
+		if normalize {
+			for _, change := range cfg.NormalizeNames() {
+				fmt.Printf("✓ Normalized %s\n", change)
+			}
+		}
+		if len(cfg.PrefixNames(prefix)) > 0 {
+			prefixed++
+		}
+		if err := cfg.CheckNames(); err != nil {
+			return cfg.Locate(fmt.Errorf("%s '%s': %w (use --normalize to fix it)", cfg.Kind, cfg.Metadata.Name, err))
+		}
+	}
+	if prefixed > 0 {
+		fmt.Printf("✓ Prefixed names in %d configuration(s) with name_prefix '%s'\n", prefixed, prefix)
+	}
+	return nil
+}
+
+// addAllPrefixesFlag registers --all-prefixes on a command that lists
+// resources
+func addAllPrefixesFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("all-prefixes", false, "Show resources of every team, not only those with the context's name_prefix")
+}
+
+// ownedNames returns a filter for list commands that keeps the names with
+// the context's name_prefix, or everything with --all-prefixes or without
+// a prefix
+func ownedNames(cmd *cobra.Command) func(name string) bool {
+	prefix := GetConfig().NamePrefix
+	if all, _ := cmd.Flags().GetBool("all-prefixes"); all || prefix == "" {
+		return func(string) bool { return true }
+	}
+	return func(name string) bool { return strings.HasPrefix(name, prefix) }
+}
+
+// ownedMirrors keeps the mirrors that pass owned, returning how many were
+// hidden
+func ownedMirrors(mirrors []*pb.ListMirrorsItem, owned func(string) bool) ([]*pb.ListMirrorsItem, int) {
+	kept := make([]*pb.ListMirrorsItem, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		if owned(mirror.Name) {
+			kept = append(kept, mirror)
+		}
+	}
+	return kept, len(mirrors) - len(kept)
+}
+
+// ownedPeers keeps the peers that pass owned, returning how many were
+// hidden
+func ownedPeers(peers []*pb.PeerListItem, owned func(string) bool) ([]*pb.PeerListItem, int) {
+	kept := make([]*pb.PeerListItem, 0, len(peers))
+	for _, peer := range peers {
+		if owned(peer.Name) {
+			kept = append(kept, peer)
+		}
+	}
+	return kept, len(peers) - len(kept)
+}
+
+// printHidden notes resources hidden by the context's name_prefix
+func printHidden(hidden int, kind string) {
+	if hidden > 0 {
+		fmt.Printf("💡 %d %s(s) without name_prefix '%s' hidden; use --all-prefixes to show them\n", hidden, kind, GetConfig().NamePrefix)
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/printer"
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+var (
+	// stdoutIsTerminal is captured at startup, before stdout may be redirected
+	stdoutIsTerminal = isTerminal(os.Stdout)
+
+	// plainOutput disables colors and replaces emoji and box drawing symbols
+	plainOutput bool
+
+	restoreStdout = func() {}
+)
+
+// plainReplacer maps the symbols used in command output to ASCII
+var plainReplacer = strings.NewReplacer(
+	"✓", "[OK]",
+	"✅", "[OK]",
+	"❌", "[FAIL]",
+	"💡", "[NOTE]",
+	"⚠", "[WARN]",
+	"→", "->",
+	"─", "-",
+	"━", "-",
+	"│", "|",
+	"├", "+",
+	"└", "+",
+	"┌", "+",
+	"┐", "+",
+	"┘", "+",
+	"┤", "+",
+	"┬", "+",
+	"┴", "+",
+	"┼", "+",
+	"\ufe0f", "",
+)
+
+// dataOutputAnnotation marks commands that print data for other programs
+// to stdout, such as a file's contents, unless --output names a file
+const dataOutputAnnotation = "mirror_cli/data-output"
+
+func init() {
+	for _, cmd := range []*cobra.Command{apiCallCmd, scaffoldCmd, configExportCLICmd} {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[dataOutputAnnotation] = "true"
+	}
+}
+
+// machineOutput reports whether cmd prints data for other programs rather
+// than decorated text, e.g. with -o json. Plain output leaves it alone.
+func machineOutput(cmd *cobra.Command) bool {
+	output, _ := cmd.Flags().GetString("output")
+	if cmd.Annotations[dataOutputAnnotation] != "" && output == "" {
+		return true
+	}
+	switch strings.ToLower(output) {
+	case printer.FormatJSON, printer.FormatYAML, printer.FormatCSV, printer.FormatTemplate:
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// usePlainOutput reports whether plain output should be used, based on the
+// --plain flag, the NO_COLOR convention, and whether stdout is a terminal
+func usePlainOutput(plainFlag bool) bool {
+	if plainFlag {
+		return true
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return true
+	}
+	return !stdoutIsTerminal
+}
+
+// enablePlainOutput routes stdout through a filter that replaces symbols
+// with ASCII equivalents until restoreStdout is called. Only use it for
+// decorated text, never for machine output.
+func enablePlainOutput() error {
+	plainOutput = true
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to set up plain output: %w", err)
+	}
+
+	original := os.Stdout
+	os.Stdout = w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		copyPlain(original, r)
+	}()
+
+	restoreStdout = func() {
+		w.Close()
+		<-done
+		os.Stdout = original
+		restoreStdout = func() {}
+	}
+	return nil
+}
+
+// copyPlain copies r to w, translating symbols and dropping other emoji.
+// Incomplete UTF-8 sequences at the end of a read are carried over.
+func copyPlain(w io.Writer, r io.Reader) {
+	buf := make([]byte, 4096)
+	var pending []byte
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			data := append(pending, buf[:n]...)
+
+			cut := len(data)
+			for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+				if utf8.RuneStart(data[i]) {
+					if !utf8.FullRune(data[i:]) {
+						cut = i
+					}
+					break
+				}
+			}
+
+			io.WriteString(w, toPlain(string(data[:cut])))
+			pending = append([]byte(nil), data[cut:]...)
+		}
+		if err != nil {
+			if len(pending) > 0 {
+				io.WriteString(w, toPlain(string(pending)))
+			}
+			return
+		}
+	}
+}
+
+// toPlain replaces known symbols and removes any remaining emoji
+func toPlain(s string) string {
+	s = plainReplacer.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII && unicode.Is(unicode.So, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// yellow wraps s in ANSI yellow unless output is plain or not a terminal
+func yellow(s string) string {
+	return colorize(ansiYellow, s)
+}
+
+// red wraps s in ANSI red unless output is plain or not a terminal
+func red(s string) string {
+	return colorize(ansiRed, s)
+}
+
+// green wraps s in ANSI green unless output is plain or not a terminal
+func green(s string) string {
+	return colorize(ansiGreen, s)
+}
+
+func colorize(color, s string) string {
+	if plainOutput || !stdoutIsTerminal {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// humanizeDuration formats d as a short human readable age, e.g. "5 minutes"
+func humanizeDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%d seconds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
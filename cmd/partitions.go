@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorPartitionsCmd represents the mirror partitions command
+var mirrorPartitionsCmd = &cobra.Command{
+	Use:   "partitions <mirror-name>",
+	Short: "Show partition progress of a QRep mirror",
+	Long: `List the partitions of a QRep mirror with their status, rows, and duration.
+A partition is failed when it started but did not finish and the mirror is no
+longer running.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPartitions(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorPartitionsCmd)
+
+	mirrorPartitionsCmd.Flags().Bool("failed-only", false, "Only show failed partitions")
+	addTableFlags(mirrorPartitionsCmd)
+}
+
+// Partition states
+const (
+	partitionPending   = "pending"
+	partitionRunning   = "running"
+	partitionPaused    = "paused"
+	partitionFailed    = "failed"
+	partitionCompleted = "completed"
+)
+
+func listPartitions(cmd *cobra.Command, mirrorName string) error {
+	failedOnly, _ := cmd.Flags().GetBool("failed-only")
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+	showNotes := tableOutput(cmd)
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.QrepStatus == nil {
+		if status.CdcStatus != nil {
+			return fmt.Errorf("mirror '%s' is a CDC mirror; use 'mirror_cli mirror status %s' for snapshot progress", mirrorName, mirrorName)
+		}
+		return fmt.Errorf("mirror '%s' has no partition status", mirrorName)
+	}
+
+	partitions := sortedPartitions(status.QrepStatus.Partitions)
+	now := time.Now()
+
+	counts := make(map[string]int)
+	var shown []*pb.PartitionStatus
+	for _, partition := range partitions {
+		state := partitionState(partition, status.CurrentFlowState)
+		counts[state]++
+		if !failedOnly || state == partitionFailed {
+			shown = append(shown, partition)
+		}
+	}
+
+	if len(shown) == 0 && showNotes {
+		if failedOnly {
+			fmt.Printf("No failed partitions in %d partition(s)\n", len(partitions))
+		} else {
+			fmt.Println("No partitions found")
+		}
+		return nil
+	}
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "PARTITION", Key: "partition_id"},
+		{Header: "STATUS", Key: "status"},
+		{Header: "ROWS SYNCED", Key: "rows_synced", Right: true, Format: rowsColumn},
+		{Header: "ROWS TOTAL", Key: "rows_total", Right: true, Format: rowsColumn},
+		{Header: "DURATION", Key: "duration_seconds", Format: func(value interface{}) string {
+			return formatDuration(time.Duration(value.(float64) * float64(time.Second)))
+		}},
+	}}
+	for _, partition := range shown {
+		var total, duration interface{}
+		if partition.RowsInPartition > 0 {
+			total = partition.RowsInPartition
+		}
+		if start, ok := timestamps.FromProto(partition.StartTime); ok {
+			end, ended := timestamps.FromProto(partition.EndTime)
+			if !ended {
+				end = now
+			}
+			duration = end.Sub(start).Round(time.Second).Seconds()
+		}
+		table.AddRow(
+			partition.PartitionId,
+			partitionState(partition, status.CurrentFlowState),
+			partition.RowsSynced,
+			total,
+			duration,
+		)
+	}
+	if err := printTable(cmd, table); err != nil {
+		return err
+	}
+	if !showNotes {
+		return nil
+	}
+
+	var summary []string
+	for _, state := range []string{partitionCompleted, partitionRunning, partitionPending, partitionPaused, partitionFailed} {
+		if counts[state] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	fmt.Printf("\n%d partition(s): %s\n", len(partitions), strings.Join(summary, ", "))
+
+	if counts[partitionFailed] > 0 {
+		fmt.Println("💡 PeerDB can't re-run single partitions; to copy the table again, drop the mirror and apply it again")
+	}
+
+	return nil
+}
+
+// partitionState derives a partition's state from its timestamps and the
+// state of its mirror
+func partitionState(partition *pb.PartitionStatus, mirrorState pb.FlowStatus) string {
+	switch {
+	case partition.EndTime != nil:
+		return partitionCompleted
+	case partition.StartTime == nil:
+		return partitionPending
+	}
+
+	switch mirrorState {
+	case pb.FlowStatus_STATUS_RUNNING, pb.FlowStatus_STATUS_SNAPSHOT, pb.FlowStatus_STATUS_SETUP:
+		return partitionRunning
+	case pb.FlowStatus_STATUS_PAUSED, pb.FlowStatus_STATUS_PAUSING:
+		return partitionPaused
+	default:
+		return partitionFailed
+	}
+}
+
+// sortedPartitions orders partitions by start time, with partitions that
+// haven't started last
+func sortedPartitions(partitions []*pb.PartitionStatus) []*pb.PartitionStatus {
+	sorted := append([]*pb.PartitionStatus(nil), partitions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aStarted := timestamps.FromProto(sorted[i].StartTime)
+		b, bStarted := timestamps.FromProto(sorted[j].StartTime)
+		switch {
+		case !aStarted || !bStarted:
+			return aStarted && !bStarted
+		case !a.Equal(b):
+			return a.Before(b)
+		default:
+			return sorted[i].PartitionId < sorted[j].PartitionId
+		}
+	})
+	return sorted
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// pauseNote returns the note 'mirror pause --reason' recorded for a paused
+// mirror. Notes left on mirrors resumed outside the CLI are ignored.
+func pauseNote(status *pb.MirrorStatusResponse) (provenance.PauseNote, bool) {
+	if status.GetCurrentFlowState() != pb.FlowStatus_STATUS_PAUSED {
+		return provenance.PauseNote{}, false
+	}
+	return provenance.ExtractPauseNote(status.GetCdcStatus().GetConfig().GetEnv())
+}
+
+// formatPauseNote renders a pause note as "reason (by who, when)"
+func formatPauseNote(note provenance.PauseNote) string {
+	var details []string
+	if note.By != "" {
+		details = append(details, "by "+note.By)
+	}
+	if !note.At.IsZero() {
+		details = append(details, formatTime(note.At))
+	}
+	if len(details) == 0 {
+		return note.Reason
+	}
+	return note.Reason + " (" + strings.Join(details, ", ") + ")"
+}
@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/printer"
 	pb "github.com/janakos/mirror_cli/proto/gen"
 )
 
@@ -41,12 +45,13 @@ var peerCreateCmd = &cobra.Command{
 
 // peerDropCmd represents the peer drop command
 var peerDropCmd = &cobra.Command{
-	Use:   "drop [peer-name]",
-	Short: "Drop a peer",
-	Long:  "Drop a peer connection.",
-	Args:  cobra.ExactArgs(1),
+	Use:               "drop [peer-name]",
+	Short:             "Drop a peer",
+	Long:              "Drop a peer connection.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePeerNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return dropPeer(cmd, args[0])
+		return dropPeer(cmd, resourceName(args[0]))
 	},
 }
 
@@ -67,12 +72,17 @@ func init() {
 	peerCmd.AddCommand(peerDropCmd)
 	peerCmd.AddCommand(peerValidateCmd)
 
+	addAllPrefixesFlag(peerListCmd)
+	addTableFlags(peerListCmd)
+
 	// Create command flags
 	addPeerCreateFlags(peerCreateCmd)
 	addPeerCreateFlags(peerValidateCmd)
 
 	// Create command specific flags
 	peerCreateCmd.Flags().Bool("allow-update", false, "Allow updating existing peer")
+	peerCreateCmd.Flags().Bool("if-not-exists", false, "Do nothing if the peer already exists (warns if it differs)")
+	addNormalizeFlag(peerCreateCmd)
 
 	// Drop command flags
 	peerDropCmd.Flags().Bool("force", false, "Force drop without confirmation")
@@ -90,6 +100,8 @@ func addPeerCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("pg-database", "", "PostgreSQL database")
 	cmd.Flags().String("pg-tls-host", "", "PostgreSQL TLS host")
 	cmd.Flags().String("pg-metadata-schema", "_peerdb_internal", "PostgreSQL metadata schema")
+	cmd.Flags().Bool("pg-require-tls", false, "Require TLS for PostgreSQL connections")
+	cmd.Flags().String("pg-root-ca-file", "", "Path to a PEM root CA certificate used to verify the PostgreSQL server")
 
 	// BigQuery flags
 	cmd.Flags().String("bq-project", "", "BigQuery project ID")
@@ -99,27 +111,35 @@ func addPeerCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("bq-private-key-id", "", "BigQuery private key ID")
 	cmd.Flags().String("bq-client-email", "", "BigQuery client email")
 	cmd.Flags().String("bq-client-id", "", "BigQuery client ID")
+	cmd.Flags().String("bq-credentials-file", "", "Path to a GCP service account JSON key file")
 
 	// Snowflake flags
 	cmd.Flags().String("sf-account", "", "Snowflake account ID")
 	cmd.Flags().String("sf-user", "", "Snowflake username")
 	cmd.Flags().String("sf-password", "", "Snowflake password")
 	cmd.Flags().String("sf-private-key", "", "Snowflake private key")
+	cmd.Flags().String("sf-private-key-file", "", "Path to a Snowflake private key PEM file (.p8)")
+	cmd.Flags().Bool("sf-private-key-passphrase-stdin", false, "Read the private key passphrase from stdin")
 	cmd.Flags().String("sf-database", "", "Snowflake database")
 	cmd.Flags().String("sf-warehouse", "", "Snowflake warehouse")
 	cmd.Flags().String("sf-role", "", "Snowflake role")
 	cmd.Flags().String("sf-metadata-schema", "_PEERDB_INTERNAL", "Snowflake metadata schema")
+	cmd.Flags().Uint64("sf-query-timeout", 300, "Snowflake query timeout in seconds")
+	cmd.Flags().String("sf-s3-integration", "", "Snowflake storage integration for S3 staging")
 
 	cmd.MarkFlagRequired("name")
 	cmd.MarkFlagRequired("type")
 }
 
 func listPeers(cmd *cobra.Command) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+
+	ctx := commandContext()
 
 	// Create client
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
@@ -131,47 +151,62 @@ func listPeers(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to list peers: %w", err)
 	}
 
-	if len(resp.Items) == 0 {
-		fmt.Println("No peers found")
-		return nil
+	owned := ownedNames(cmd)
+	var hidden int
+	resp.Items, hidden = ownedPeers(resp.Items, owned)
+	resp.SourceItems, _ = ownedPeers(resp.SourceItems, owned)
+	resp.DestinationItems, _ = ownedPeers(resp.DestinationItems, owned)
+	if tableOutput(cmd) {
+		if len(resp.Items) == 0 {
+			fmt.Println("No peers found")
+			printHidden(hidden, "peer")
+			return nil
+		}
+		defer printHidden(hidden, "peer")
 	}
 
-	// Print header
-	fmt.Printf("%-20s %-15s %-10s\n", "NAME", "TYPE", "CATEGORY")
-	fmt.Println(strings.Repeat("-", 50))
-
-	// Print all peers
-	for _, peer := range resp.Items {
-		category := "General"
-		fmt.Printf("%-20s %-15s %-10s\n", peer.Name, peer.Type.String(), category)
+	// Peers that can only be a source or only a destination are marked as
+	// such; the rest are general
+	sources := make(map[string]bool)
+	for _, peer := range resp.SourceItems {
+		sources[peer.Name] = true
 	}
-
-	// Print source peers if different
-	if len(resp.SourceItems) > 0 && len(resp.SourceItems) != len(resp.Items) {
-		fmt.Println("\nSource Peers:")
-		for _, peer := range resp.SourceItems {
-			fmt.Printf("%-20s %-15s %-10s\n", peer.Name, peer.Type.String(), "Source")
-		}
+	destinations := make(map[string]bool)
+	for _, peer := range resp.DestinationItems {
+		destinations[peer.Name] = true
 	}
 
-	// Print destination peers if different
-	if len(resp.DestinationItems) > 0 && len(resp.DestinationItems) != len(resp.Items) {
-		fmt.Println("\nDestination Peers:")
-		for _, peer := range resp.DestinationItems {
-			fmt.Printf("%-20s %-15s %-10s\n", peer.Name, peer.Type.String(), "Destination")
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "NAME", Key: "name"},
+		{Header: "TYPE", Key: "type"},
+		{Header: "CATEGORY", Key: "category"},
+	}}
+	for _, peer := range resp.Items {
+		category := "General"
+		switch {
+		case sources[peer.Name] && !destinations[peer.Name]:
+			category = "Source"
+		case destinations[peer.Name] && !sources[peer.Name]:
+			category = "Destination"
 		}
+		table.AddRow(peer.Name, peer.Type.String(), category)
 	}
 
-	return nil
+	return printTable(cmd, table)
 }
 
 func createPeer(cmd *cobra.Command) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
 
 	name, _ := cmd.Flags().GetString("name")
 	peerType, _ := cmd.Flags().GetString("type")
 	allowUpdate, _ := cmd.Flags().GetBool("allow-update")
+	ifNotExists, _ := cmd.Flags().GetBool("if-not-exists")
+
+	name, err := checkName(cmd, "peer", name)
+	if err != nil {
+		return err
+	}
 
 	// Create peer based on type
 	peer, err := buildPeerFromFlags(cmd, name, peerType)
@@ -180,12 +215,22 @@ func createPeer(cmd *cobra.Command) error {
 	}
 
 	// Create client
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
+	if ifNotExists && !allowUpdate {
+		exists, err := client.PeerExists(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing peer: %w", err)
+		}
+		if exists {
+			return reportExistingPeer(ctx, client, peer)
+		}
+	}
+
 	// Create the peer
 	resp, err := client.CreatePeer(ctx, peer, allowUpdate)
 	if err != nil {
@@ -205,9 +250,28 @@ func createPeer(cmd *cobra.Command) error {
 	return nil
 }
 
+// reportExistingPeer compares a requested peer with the existing one. Secrets
+// can't be compared, so they are masked on both sides.
+func reportExistingPeer(ctx context.Context, grpcClient *client.Client, peer *pb.Peer) error {
+	existing, err := grpcClient.GetPeer(ctx, peer.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get existing peer: %w", err)
+	}
+
+	desired, err := config.FromPeerProto(peer, "")
+	if err != nil {
+		return err
+	}
+	actual, err := config.FromPeerProto(existing, "")
+	if err != nil {
+		return err
+	}
+
+	return reportExisting(desired, actual, "use --allow-update to change it")
+}
+
 func validatePeer(cmd *cobra.Command) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
 
 	name, _ := cmd.Flags().GetString("name")
 	peerType, _ := cmd.Flags().GetString("type")
@@ -219,7 +283,7 @@ func validatePeer(cmd *cobra.Command) error {
 	}
 
 	// Create client
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
@@ -249,19 +313,16 @@ func dropPeer(cmd *cobra.Command, peerName string) error {
 
 	// Confirmation unless forced
 	if !force {
-		fmt.Printf("Are you sure you want to drop peer '%s'? This action cannot be undone. (y/N): ", peerName)
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+		question := fmt.Sprintf("Are you sure you want to drop peer '%s'?", peerName)
+		if !confirmDestructive("peer", peerName, question, false) {
 			fmt.Println("Operation cancelled")
 			return nil
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	ctx := commandContext()
 
-	client, err := client.NewClient(GetConfig())
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
 	if err != nil {
 		return err
 	}
@@ -320,24 +381,36 @@ func buildPostgresConfig(cmd *cobra.Command) (*pb.PostgresConfig, error) {
 	database, _ := cmd.Flags().GetString("pg-database")
 	tlsHost, _ := cmd.Flags().GetString("pg-tls-host")
 	metadataSchema, _ := cmd.Flags().GetString("pg-metadata-schema")
+	requireTLS, _ := cmd.Flags().GetBool("pg-require-tls")
+	rootCAFile, _ := cmd.Flags().GetString("pg-root-ca-file")
 
 	if host == "" || user == "" || database == "" {
 		return nil, fmt.Errorf("postgres peer requires host, user, and database")
 	}
 
 	config := &pb.PostgresConfig{
-		Host:     host,
-		Port:     uint32(port),
-		User:     user,
-		Password: password,
-		Database: database,
-		TlsHost:  tlsHost,
+		Host:       host,
+		Port:       uint32(port),
+		User:       user,
+		Password:   password,
+		Database:   database,
+		TlsHost:    tlsHost,
+		RequireTls: requireTLS,
 	}
 
 	if metadataSchema != "" {
 		config.MetadataSchema = &metadataSchema
 	}
 
+	if rootCAFile != "" {
+		data, err := os.ReadFile(rootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CA file: %w", err)
+		}
+		rootCA := string(data)
+		config.RootCa = &rootCA
+	}
+
 	return config, nil
 }
 
@@ -349,6 +422,30 @@ func buildBigQueryConfig(cmd *cobra.Command) (*pb.BigqueryConfig, error) {
 	privateKeyId, _ := cmd.Flags().GetString("bq-private-key-id")
 	clientEmail, _ := cmd.Flags().GetString("bq-client-email")
 	clientId, _ := cmd.Flags().GetString("bq-client-id")
+	credentialsFile, _ := cmd.Flags().GetString("bq-credentials-file")
+
+	// Populate unset fields from the service account key file
+	if credentialsFile != "" {
+		key, err := config.LoadServiceAccountKey(credentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		if projectId == "" {
+			projectId = key.ProjectID
+		}
+		if privateKey == "" {
+			privateKey = key.PrivateKey
+		}
+		if privateKeyId == "" {
+			privateKeyId = key.PrivateKeyID
+		}
+		if clientEmail == "" {
+			clientEmail = key.ClientEmail
+		}
+		if clientId == "" {
+			clientId = key.ClientID
+		}
+	}
 
 	if projectId == "" || datasetId == "" {
 		return nil, fmt.Errorf("bigquery peer requires project and dataset")
@@ -377,6 +474,31 @@ func buildSnowflakeConfig(cmd *cobra.Command) (*pb.SnowflakeConfig, error) {
 	warehouse, _ := cmd.Flags().GetString("sf-warehouse")
 	role, _ := cmd.Flags().GetString("sf-role")
 	metadataSchema, _ := cmd.Flags().GetString("sf-metadata-schema")
+	privateKeyFile, _ := cmd.Flags().GetString("sf-private-key-file")
+	passphraseStdin, _ := cmd.Flags().GetBool("sf-private-key-passphrase-stdin")
+	queryTimeout, _ := cmd.Flags().GetUint64("sf-query-timeout")
+	s3Integration, _ := cmd.Flags().GetString("sf-s3-integration")
+
+	if privateKeyFile != "" {
+		if privateKey != "" {
+			return nil, fmt.Errorf("--sf-private-key and --sf-private-key-file are mutually exclusive")
+		}
+
+		var passphrase []byte
+		if passphraseStdin {
+			var err error
+			passphrase, err = readPassphrase(stdin)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		privateKey, err = config.LoadPrivateKeyFile(privateKeyFile, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if accountId == "" || username == "" || database == "" || warehouse == "" {
 		return nil, fmt.Errorf("snowflake peer requires account, username, database, and warehouse")
@@ -387,12 +509,13 @@ func buildSnowflakeConfig(cmd *cobra.Command) (*pb.SnowflakeConfig, error) {
 	}
 
 	config := &pb.SnowflakeConfig{
-		AccountId:    accountId,
-		Username:     username,
-		Database:     database,
-		Warehouse:    warehouse,
-		Role:         role,
-		QueryTimeout: 300, // 5 minutes default
+		AccountId:     accountId,
+		Username:      username,
+		Database:      database,
+		Warehouse:     warehouse,
+		Role:          role,
+		QueryTimeout:  queryTimeout,
+		S3Integration: s3Integration,
 	}
 
 	if password != "" {
@@ -407,3 +530,12 @@ func buildSnowflakeConfig(cmd *cobra.Command) (*pb.SnowflakeConfig, error) {
 
 	return config, nil
 }
+
+// readPassphrase reads a single line from r, without the trailing newline
+func readPassphrase(r io.Reader) ([]byte, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// peerMirrorsCmd represents the peer mirrors command
+var peerMirrorsCmd = &cobra.Command{
+	Use:               "mirrors <peer-name>",
+	Short:             "List the mirrors that use a peer",
+	Long:              "List every mirror that uses a peer as its source or destination, with its state and time since its last batch, to see what is affected by changes to that database.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePeerNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listPeerMirrors(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	peerCmd.AddCommand(peerMirrorsCmd)
+	addTableFlags(peerMirrorsCmd)
+}
+
+func listPeerMirrors(cmd *cobra.Command, peerName string) error {
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, err := client.GetPeer(ctx, peerName); err != nil {
+		return fmt.Errorf("failed to get peer: %w", err)
+	}
+
+	resp, err := client.ListMirrors(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list mirrors: %w", err)
+	}
+
+	var mirrors []*pb.ListMirrorsItem
+	for _, mirror := range resp.Mirrors {
+		if mirror.SourceName == peerName || mirror.DestinationName == peerName {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	if len(mirrors) == 0 && tableOutput(cmd) {
+		fmt.Printf("No mirrors use peer '%s'\n", peerName)
+		return nil
+	}
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].Name < mirrors[j].Name
+	})
+
+	summaries := app.SummarizeMirrors(ctx, client, mirrors, time.Now())
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "NAME", Key: "name"},
+		{Header: "ROLE", Key: "role"},
+		{Header: "OTHER PEER", Key: "other_peer"},
+		{Header: "TYPE", Key: "type"},
+		{Header: "STATE", Key: "state", Aliases: []string{"status"}},
+		{Header: "LAST BATCH", Key: "last_batch", Format: timeColumn},
+	}}
+	for i, mirror := range mirrors {
+		role, other := "source", mirror.DestinationName
+		switch {
+		case mirror.SourceName == peerName && mirror.DestinationName == peerName:
+			role, other = "both", "-"
+		case mirror.DestinationName == peerName:
+			role, other = "destination", mirror.SourceName
+		}
+
+		mirrorType := "QRep"
+		if mirror.IsCdc {
+			mirrorType = "CDC"
+		}
+
+		var state, lastBatch interface{} = "UNAVAILABLE", nil
+		if summary := summaries[i]; summary.Err == nil {
+			state = stateName(summary.State)
+			// Empty for mirrors that haven't synced a batch yet
+			if last := app.LastSyncActivity(summary.Status.GetCdcStatus().GetCdcBatches()); !last.IsZero() {
+				lastBatch = last
+			}
+		}
+
+		table.AddRow(mirror.Name, role, other, mirrorType, state, lastBatch)
+	}
+
+	if err := printTable(cmd, table); err != nil {
+		return err
+	}
+	if tableOutput(cmd) {
+		fmt.Printf("\n%d mirror(s) use peer '%s'\n", len(mirrors), peerName)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/tuning"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// peerStatsCmd represents the peer stats command
+var peerStatsCmd = &cobra.Command{
+	Use:   "stats <peer-name>",
+	Short: "Show the size, largest tables, and replication slots of a peer",
+	Long: `Show the schemas and tables of a peer with their total size, its largest
+tables, and for PostgreSQL peers the replication slots and the WAL they retain.
+Use it to size initial snapshots and to spot risky sources, such as inactive
+slots holding back WAL, before mirroring.`,
+	Example: `  mirror_cli peer stats prod_postgres
+  mirror_cli peer stats prod_postgres --top 25 -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePeerNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showPeerStats(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	peerCmd.AddCommand(peerStatsCmd)
+
+	peerStatsCmd.Flags().Int("top", 10, "Number of largest tables to show")
+}
+
+// peerStats is the report of peer stats
+type peerStats struct {
+	Peer    string `json:"peer"`
+	Type    string `json:"type"`
+	Schemas int    `json:"schemas"`
+	Tables  int    `json:"tables"`
+	// TablesSizeBytes is the total size of the tables, including indexes
+	TablesSizeBytes int64           `json:"tables_size_bytes"`
+	LargestTables   []tableSizeStat `json:"largest_tables"`
+	// ReplicationSlots is only reported for PostgreSQL peers
+	ReplicationSlots *slotStats `json:"replication_slots,omitempty"`
+	// NotSupported lists the parts the server is too old to report
+	NotSupported []string `json:"not_supported,omitempty"`
+}
+
+type tableSizeStat struct {
+	Table     string `json:"table"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+type slotStats struct {
+	Count  int `json:"count"`
+	Active int `json:"active"`
+	// WALRetainedBytes is the most WAL any slot holds back
+	WALRetainedBytes int64      `json:"wal_retained_bytes"`
+	Slots            []slotStat `json:"slots"`
+}
+
+type slotStat struct {
+	Name             string `json:"name"`
+	Active           bool   `json:"active"`
+	WALRetainedBytes int64  `json:"wal_retained_bytes"`
+	WALStatus        string `json:"wal_status,omitempty"`
+}
+
+func showPeerStats(cmd *cobra.Command, peerName string) error {
+	top, _ := cmd.Flags().GetInt("top")
+	output, err := reportOutput(cmd, printer.FormatJSON)
+	if err != nil {
+		return err
+	}
+	if top < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	peer, err := client.GetPeer(ctx, peerName)
+	if err != nil {
+		return fmt.Errorf("failed to get peer: %w", err)
+	}
+
+	stats := peerStats{
+		Peer:          peerName,
+		Type:          peer.Type.String(),
+		LargestTables: []tableSizeStat{},
+	}
+
+	schemas, tables, err := peerTableSizes(ctx, client, peerName)
+	switch {
+	case skipUnsupported(err, "schemas and table sizes"):
+		stats.NotSupported = append(stats.NotSupported, "table_sizes")
+	case err != nil:
+		return err
+	default:
+		stats.Schemas, stats.Tables = schemas, len(tables)
+		for _, t := range tables {
+			stats.TablesSizeBytes += t.SizeBytes
+		}
+		sort.SliceStable(tables, func(i, j int) bool { return tables[i].SizeBytes > tables[j].SizeBytes })
+		stats.LargestTables = append(stats.LargestTables, tables[:min(top, len(tables))]...)
+	}
+
+	// Only PostgreSQL sources have replication slots
+	if peer.Type == pb.DBType_POSTGRES {
+		slots, err := client.ListSlots(ctx, peerName)
+		switch {
+		case skipUnsupported(err, "replication slots"):
+			stats.NotSupported = append(stats.NotSupported, "replication_slots")
+		case err != nil:
+			return fmt.Errorf("failed to list replication slots: %w", err)
+		default:
+			stats.ReplicationSlots = summarizeSlots(slots)
+		}
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return printPeerStats(stats)
+}
+
+// peerTableSizes lists the tables in every schema of a peer with their
+// sizes, and returns the number of schemas. Sizes PeerDB reports in a form
+// that can't be read count as 0.
+func peerTableSizes(ctx context.Context, grpcClient *client.Client, peerName string) (int, []tableSizeStat, error) {
+	schemas, err := grpcClient.ListSchemas(ctx, peerName)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+
+	var tables []tableSizeStat
+	for _, schema := range schemas {
+		listed, err := grpcClient.ListTables(ctx, peerName, schema, false)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to list tables in %s: %w", schema, err)
+		}
+		for _, t := range listed {
+			size, _ := tuning.ParseSize(t.TableSize)
+			tables = append(tables, tableSizeStat{Table: schema + "." + t.TableName, SizeBytes: size})
+		}
+	}
+	return len(schemas), tables, nil
+}
+
+// summarizeSlots counts slots and the WAL each retains, reported by PeerDB
+// as the slot's lag in MB
+func summarizeSlots(slots []*pb.SlotInfo) *slotStats {
+	summary := &slotStats{Count: len(slots), Slots: []slotStat{}}
+	for _, slot := range slots {
+		retained := int64(slot.LagInMb * 1024 * 1024)
+		if slot.Active {
+			summary.Active++
+		}
+		summary.WALRetainedBytes = max(summary.WALRetainedBytes, retained)
+		summary.Slots = append(summary.Slots, slotStat{
+			Name:             slot.SlotName,
+			Active:           slot.Active,
+			WALRetainedBytes: retained,
+			WALStatus:        slot.WalStatus,
+		})
+	}
+	return summary
+}
+
+func printPeerStats(stats peerStats) error {
+	tableSizes := !slices.Contains(stats.NotSupported, "table_sizes")
+
+	fmt.Printf("Peer:              %s (%s)\n", stats.Peer, stats.Type)
+	if tableSizes {
+		fmt.Printf("Tables:            %d in %d schema(s), %s\n", stats.Tables, stats.Schemas, formatBytes(stats.TablesSizeBytes))
+	}
+
+	if slots := stats.ReplicationSlots; slots != nil {
+		fmt.Printf("Replication slots: %d (%d active)\n", slots.Count, slots.Active)
+		if slots.Count > 0 {
+			fmt.Printf("WAL retained:      %s (largest slot)\n", formatBytes(slots.WALRetainedBytes))
+		}
+		for _, slot := range slots.Slots {
+			switch {
+			case slot.WALStatus == "lost":
+				fmt.Printf("⚠ Slot '%s' has lost required WAL and can't be used\n", slot.Name)
+			case slot.WALStatus == "unreserved":
+				fmt.Printf("⚠ Slot '%s' retains more WAL than max_slot_wal_keep_size and will lose it soon\n", slot.Name)
+			case !slot.Active && slot.WALRetainedBytes > 0:
+				fmt.Printf("⚠ Slot '%s' is inactive and retains %s of WAL\n", slot.Name, formatBytes(slot.WALRetainedBytes))
+			}
+		}
+	}
+
+	if !tableSizes {
+		return nil
+	}
+	fmt.Println()
+	if len(stats.LargestTables) == 0 {
+		fmt.Println("No tables found")
+		return nil
+	}
+	fmt.Println("Largest tables:")
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "TABLE", Key: "table"},
+		{Header: "SIZE", Key: "size_bytes", Right: true, Format: bytesColumn},
+	}}
+	for _, t := range stats.LargestTables {
+		table.AddRow(t.Table, t.SizeBytes)
+	}
+	return printer.TablePrinter{}.Print(os.Stdout, table)
+}
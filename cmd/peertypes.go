@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/policy"
+)
+
+// filePeerTypes maps the names of peers defined in configs to their types
+func filePeerTypes(configs []*config.FileConfig) map[string]string {
+	types := make(map[string]string)
+	for _, fc := range configs {
+		if fc.Kind == "Peer" {
+			types[fc.Metadata.Name] = policy.NormalizePeerType(fc.Spec.Type)
+		}
+	}
+	return types
+}
+
+// peerTypeResolver resolves peer types from the given configs first, then
+// from the server when a client is available
+func peerTypeResolver(ctx context.Context, grpcClient *client.Client, configs []*config.FileConfig) policy.PeerTypeResolver {
+	types := filePeerTypes(configs)
+	return func(name string) (string, bool) {
+		if peerType, ok := types[name]; ok {
+			return peerType, true
+		}
+		if grpcClient == nil {
+			return "", false
+		}
+		peer, err := grpcClient.GetPeer(ctx, name)
+		if err != nil {
+			return "", false
+		}
+		types[name] = policy.NormalizePeerType(peer.Type.String())
+		return types[name], true
+	}
+}
+
+// resultPeerTypeResolver resolves peer types from validated files only
+func resultPeerTypeResolver(results []config.ValidationResult) policy.PeerTypeResolver {
+	var configs []*config.FileConfig
+	for _, result := range results {
+		if result.Config != nil && !result.Skipped {
+			configs = append(configs, result.Config)
+		}
+	}
+
+	types := filePeerTypes(configs)
+	return func(name string) (string, bool) {
+		peerType, ok := types[name]
+		return peerType, ok
+	}
+}
+
+// checkDestination returns an error if a mirror's type_mapping,
+// destination_options, or QRep write mode isn't supported by its
+// destination. Destinations that can't
+// be resolved aren't checked.
+func checkDestination(fc *config.FileConfig, resolve policy.PeerTypeResolver) error {
+	if fc.Kind != "Mirror" || (fc.Spec.TypeMapping == nil && fc.Spec.QRep == nil && !hasDestinationOptions(fc)) {
+		return nil
+	}
+
+	destinationType, ok := resolve(fc.Spec.Destination)
+	if !ok {
+		return nil
+	}
+	if fc.Spec.TypeMapping != nil {
+		if err := fc.Spec.TypeMapping.CheckDestination(destinationType); err != nil {
+			return fmt.Errorf("destination %q: %w", fc.Spec.Destination, err)
+		}
+	}
+	if err := fc.CheckDestinationOptions(destinationType); err != nil {
+		return fmt.Errorf("destination %q: %w", fc.Spec.Destination, err)
+	}
+	if fc.Spec.QRep != nil {
+		if err := fc.Spec.QRep.CheckDestination(destinationType); err != nil {
+			return fmt.Errorf("destination %q: %w", fc.Spec.Destination, err)
+		}
+	}
+	return nil
+}
+
+// hasDestinationOptions reports whether a mirror or any of its tables sets
+// destination_options
+func hasDestinationOptions(fc *config.FileConfig) bool {
+	if fc.Spec.DestinationOptions != nil {
+		return true
+	}
+	for _, table := range fc.Spec.Tables {
+		if table.DestinationOptions != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDestinationResults records destination errors on validation results
+func checkDestinationResults(results []config.ValidationResult) {
+	resolve := resultPeerTypeResolver(results)
+	for i := range results {
+		result := &results[i]
+		if result.Config == nil || result.Skipped || result.Error != "" {
+			continue
+		}
+		if err := checkDestination(result.Config, resolve); err != nil {
+			result.SetError(err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/policy"
+	"github.com/janakos/mirror_cli/internal/poller"
+	"github.com/janakos/mirror_cli/internal/printer"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// peerValidateAllCmd represents the peer validate-all command
+var peerValidateAllCmd = &cobra.Command{
+	Use:   "validate-all",
+	Short: "Validate every peer on the server",
+	Long: `Validate the stored configuration of every peer on the server concurrently
+and report the peers whose credentials or connectivity are broken, e.g. after
+a network or credential change. Exits with an error if any peer is invalid.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return validateAllPeers(cmd)
+	},
+}
+
+func init() {
+	peerCmd.AddCommand(peerValidateAllCmd)
+
+	peerValidateAllCmd.Flags().Int("concurrency", 8, "Number of peers to validate at once")
+	peerValidateAllCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for validating each peer")
+	addAllPrefixesFlag(peerValidateAllCmd)
+	addTableFlags(peerValidateAllCmd)
+}
+
+// peerValidation is the result of validating one peer
+type peerValidation struct {
+	name     string
+	peerType string
+	valid    bool
+	message  string
+	err      error
+}
+
+func validateAllPeers(cmd *cobra.Command) error {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+	showNotes := tableOutput(cmd)
+
+	cmd.SilenceUsage = true
+
+	// --timeout bounds each peer's validation rather than read_timeout
+	client, err := client.NewClient(commandContext(), client.WithConfig(GetConfig()), client.WithRPCTimeout(timeout))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	peers, err := client.ListPeers(commandContext())
+	if err != nil {
+		return fmt.Errorf("failed to list peers: %w", err)
+	}
+	var hidden int
+	peers.Items, hidden = ownedPeers(peers.Items, ownedNames(cmd))
+	if showNotes {
+		printHidden(hidden, "peer")
+		if len(peers.Items) == 0 {
+			fmt.Println("No peers found")
+			return nil
+		}
+		fmt.Printf("Validating %d peer(s)...\n\n", len(peers.Items))
+	}
+
+	results := validatePeers(commandContext(), client, peers.Items, concurrency, timeout)
+	sort.Slice(results, func(i, j int) bool { return results[i].name < results[j].name })
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "NAME", Key: "name"},
+		{Header: "TYPE", Key: "type"},
+		{Header: "STATUS", Key: "status"},
+		{Header: "MESSAGE", Key: "message"},
+	}}
+	failed := 0
+	for _, result := range results {
+		status, message := "valid", result.message
+		switch {
+		case result.err != nil:
+			status, message = "error", result.err.Error()
+			failed++
+		case !result.valid:
+			status = "invalid"
+			failed++
+		}
+		table.AddRow(result.name, result.peerType, status, message)
+	}
+	if err := printTable(cmd, table); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d peer(s) failed validation", failed, len(results))
+	}
+	if showNotes {
+		fmt.Printf("\n✅ All %d peer(s) are valid\n", len(results))
+	}
+	return nil
+}
+
+// validatePeers fetches and validates each peer's stored configuration,
+// running up to concurrency validations at once
+func validatePeers(ctx context.Context, grpcClient *client.Client, peers []*pb.PeerListItem, concurrency int, timeout time.Duration) []peerValidation {
+	return poller.Map(ctx, peers, concurrency, func(ctx context.Context, peer *pb.PeerListItem) peerValidation {
+		return validateStoredPeer(ctx, grpcClient, peer, timeout)
+	})
+}
+
+func validateStoredPeer(ctx context.Context, grpcClient *client.Client, item *pb.PeerListItem, timeout time.Duration) peerValidation {
+	result := peerValidation{name: item.Name, peerType: policy.NormalizePeerType(item.Type.String())}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	peer, err := grpcClient.GetPeer(ctx, item.Name)
+	if err != nil {
+		result.err = fmt.Errorf("failed to get peer: %w", err)
+		return result
+	}
+	resp, err := grpcClient.ValidatePeer(ctx, peer)
+	if err != nil {
+		result.err = fmt.Errorf("failed to validate peer: %w", err)
+		return result
+	}
+	result.valid = resp.Status == pb.ValidatePeerStatus_VALID
+	result.message = resp.Message
+	return result
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// pickerItem is one selectable entry of a multi-select list
+type pickerItem struct {
+	Label  string
+	Detail string
+}
+
+// pickItems shows a searchable multi-select list on out and reads commands
+// from in until the user finishes. It returns the indexes of the selected
+// items in list order, or nil if the user cancelled.
+//
+// Commands: numbers or ranges (e.g. "1 3-5") toggle items of the shown list,
+// "/text" filters the list, "/" clears the filter, "a" toggles all shown
+// items, an empty line or "done" finishes, and "q" cancels.
+func pickItems(in io.Reader, out io.Writer, title string, items []pickerItem) ([]int, error) {
+	selected := make([]bool, len(items))
+	filter := ""
+	reader := bufio.NewReader(in)
+
+	for {
+		shown := make([]int, 0, len(items))
+		for i, item := range items {
+			if filter == "" || strings.Contains(strings.ToLower(item.Label), filter) {
+				shown = append(shown, i)
+			}
+		}
+
+		count := 0
+		for _, s := range selected {
+			if s {
+				count++
+			}
+		}
+
+		fmt.Fprintf(out, "\n%s (%d selected)\n", title, count)
+		if filter != "" {
+			fmt.Fprintf(out, "Filter: %q (%d of %d shown)\n", filter, len(shown), len(items))
+		}
+		if len(shown) == 0 {
+			fmt.Fprintln(out, "  No matching tables")
+		}
+		width := len(strconv.Itoa(len(shown)))
+		for n, i := range shown {
+			mark := " "
+			if selected[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "  [%s] %*d  %-50s %s\n", mark, width, n+1, items[i].Label, items[i].Detail)
+		}
+		fmt.Fprint(out, "Toggle (e.g. 1 3-5), /search, a = all shown, Enter = done, q = cancel: ")
+
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read selection: %w", err)
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || line == "done":
+			var result []int
+			for i, s := range selected {
+				if s {
+					result = append(result, i)
+				}
+			}
+			return result, nil
+		case line == "q" || line == "quit":
+			return nil, nil
+		case strings.HasPrefix(line, "/"):
+			filter = strings.ToLower(strings.TrimSpace(line[1:]))
+		case line == "a":
+			all := true
+			for _, i := range shown {
+				all = all && selected[i]
+			}
+			for _, i := range shown {
+				selected[i] = !all
+			}
+		default:
+			indexes, err := parseSelection(line, len(shown))
+			if err != nil {
+				fmt.Fprintf(out, "⚠ %v\n", err)
+				continue
+			}
+			for _, n := range indexes {
+				selected[shown[n]] = !selected[shown[n]]
+			}
+		}
+	}
+}
+
+// parseSelection parses space or comma separated numbers and ranges
+// (1-based) into zero-based indexes below size
+func parseSelection(line string, size int) ([]int, error) {
+	var indexes []int
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection '%s'", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection '%s'", field)
+			}
+		}
+		if start < 1 || end > size || start > end {
+			return nil, fmt.Errorf("selection '%s' is out of range 1-%d", field, size)
+		}
+		for n := start; n <= end; n++ {
+			indexes = append(indexes, n-1)
+		}
+	}
+	return indexes, nil
+}
+
+// pickTables lists the tables of the given schemas on the mirror's source
+// peer that aren't mirrored yet, lets the user select some, and returns
+// mappings for them named after the mirror's existing mappings
+func pickTables(ctx context.Context, grpcClient *client.Client, mirrorName string, schemas []string) ([]*pb.TableMapping, error) {
+	if !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("--pick-tables needs an interactive terminal; use --add-tables instead")
+	}
+
+	status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return nil, fmt.Errorf("mirror '%s' is not a CDC mirror; tables can only be added to CDC mirrors", mirrorName)
+	}
+	cfg := status.CdcStatus.Config
+
+	mapped := make(map[string]bool, len(cfg.TableMappings))
+	for _, mapping := range cfg.TableMappings {
+		mapped[mapping.SourceTableIdentifier] = true
+	}
+
+	if len(schemas) == 0 {
+		schemas = sourceSchemas(cfg.TableMappings)
+	}
+
+	var candidates []string
+	var items []pickerItem
+	for _, schema := range schemas {
+		tables, err := grpcClient.ListTables(ctx, cfg.SourceName, schema, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables in %s on '%s': %w", schema, cfg.SourceName, err)
+		}
+		sort.Slice(tables, func(i, j int) bool { return tables[i].TableName < tables[j].TableName })
+		for _, table := range tables {
+			name := schema + "." + table.TableName
+			if mapped[name] || !table.CanMirror {
+				continue
+			}
+			candidates = append(candidates, name)
+			items = append(items, pickerItem{Label: name, Detail: table.TableSize})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no tables left to add in schema(s) %s on '%s'", strings.Join(schemas, ", "), cfg.SourceName)
+	}
+
+	title := fmt.Sprintf("Tables on '%s' not yet in mirror '%s'", cfg.SourceName, mirrorName)
+	picked, err := pickItems(stdin, os.Stdout, title, items)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]*pb.TableMapping, 0, len(picked))
+	for _, i := range picked {
+		mappings = append(mappings, &pb.TableMapping{
+			SourceTableIdentifier:      candidates[i],
+			DestinationTableIdentifier: destinationTableName(cfg.TableMappings, candidates[i]),
+		})
+	}
+	return mappings, nil
+}
+
+// sourceSchemas returns the schemas of the mapped source tables, or public
+// if there are none
+func sourceSchemas(mappings []*pb.TableMapping) []string {
+	seen := make(map[string]bool)
+	var schemas []string
+	for _, mapping := range mappings {
+		schema, _ := splitTableName(mapping.SourceTableIdentifier)
+		if !seen[schema] {
+			seen[schema] = true
+			schemas = append(schemas, schema)
+		}
+	}
+	if len(schemas) == 0 {
+		return []string{"public"}
+	}
+	sort.Strings(schemas)
+	return schemas
+}
+
+// destinationTableName names the destination of a new source table like the
+// mirror's existing mappings: tables from the same source schema (or, if
+// there are none, any schema) land in the same destination schema, with the
+// same table name prefix and suffix. Without a usable mapping the source
+// name is kept.
+func destinationTableName(mappings []*pb.TableMapping, source string) string {
+	schema, table := splitTableName(source)
+
+	var rule *pb.TableMapping
+	for _, mapping := range mappings {
+		if _, _, ok := tableAffixes(mapping); !ok {
+			continue
+		}
+		mappingSchema, _ := splitTableName(mapping.SourceTableIdentifier)
+		if mappingSchema == schema {
+			rule = mapping
+			break
+		}
+		if rule == nil {
+			rule = mapping
+		}
+	}
+	if rule == nil {
+		return source
+	}
+
+	prefix, suffix, _ := tableAffixes(rule)
+	if !strings.Contains(rule.DestinationTableIdentifier, ".") {
+		return prefix + table + suffix
+	}
+	destSchema, _ := splitTableName(rule.DestinationTableIdentifier)
+	if ruleSchema, _ := splitTableName(rule.SourceTableIdentifier); ruleSchema != schema && ruleSchema == destSchema {
+		// The rule keeps schemas as they are, so keep this one too
+		destSchema = schema
+	}
+	return destSchema + "." + prefix + table + suffix
+}
+
+// tableAffixes returns the prefix and suffix a mapping adds around the
+// source table name, and whether the destination is built that way at all
+func tableAffixes(mapping *pb.TableMapping) (string, string, bool) {
+	_, source := splitTableName(mapping.SourceTableIdentifier)
+	_, dest := splitTableName(mapping.DestinationTableIdentifier)
+	i := strings.Index(dest, source)
+	if i < 0 {
+		return "", "", false
+	}
+	return dest[:i], dest[i+len(source):], true
+}
+
+// splitTableName splits a schema-qualified table name, defaulting the
+// schema to public
+func splitTableName(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "public", name
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/policy"
+)
+
+// loadPolicy loads the guardrail policy from --policy or the policy_file
+// setting, returning nil when neither is set
+func loadPolicy(cmd *cobra.Command) (*policy.Policy, error) {
+	path, _ := cmd.Flags().GetString("policy")
+	if path == "" {
+		path = GetConfig().PolicyFile
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return policy.Load(path)
+}
+
+// checkPolicy returns an error listing every policy violation in configs
+func checkPolicy(pol *policy.Policy, configs []*config.FileConfig, resolve policy.PeerTypeResolver) error {
+	var lines []string
+	for _, fc := range configs {
+		for _, violation := range pol.Check(fc, resolve) {
+			lines = append(lines, fmt.Sprintf("  %s '%s': %s", fc.Kind, fc.Metadata.Name, violation))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	fmt.Println("❌ Policy violations:")
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return fmt.Errorf("%d policy violation(s); nothing was applied", len(lines))
+}
+
+// applyPolicyToResults records policy violations on validation results.
+// Destination peers are resolved from the validated files only.
+func applyPolicyToResults(pol *policy.Policy, results []config.ValidationResult) {
+	resolve := resultPeerTypeResolver(results)
+
+	for i := range results {
+		result := &results[i]
+		if result.Config == nil || result.Skipped || result.Error != "" {
+			continue
+		}
+		for _, violation := range pol.Check(result.Config, resolve) {
+			result.Violations = append(result.Violations, violation.String())
+		}
+	}
+}
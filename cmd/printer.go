@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/printer"
+)
+
+// addOutputFlag adds the persistent -o/--output every command that prints
+// a table or a report reads. Commands whose -o names a file define their
+// own, which hides it.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("output", "o", "", "Output format: "+strings.Join(printer.Formats, ", ")+" for tables; "+printer.FormatText+", json, or yaml for reports (default: table or text). custom-columns takes HEADER:.key pairs, e.g. custom-columns=NAME:.name,STATE:.state")
+}
+
+// addTableFlags adds --template and --columns to a command that prints a
+// table
+func addTableFlags(cmd *cobra.Command) {
+	cmd.Flags().String("template", "", "Go template for -o template, run per row with the JSON field names, e.g. '{{.name}}'")
+	cmd.Flags().StringSlice("columns", nil, "Columns to show, in order, by JSON field name or header, e.g. name,state,last_batch (default: all)")
+}
+
+// outputPrinter returns the printer selected by --output. Call it before
+// contacting PeerDB, so an invalid format fails fast.
+func outputPrinter(cmd *cobra.Command) (printer.Printer, error) {
+	format, _ := cmd.Flags().GetString("output")
+	text, _ := cmd.Flags().GetString("template")
+	p, err := printer.New(format, text)
+	if err != nil {
+		return nil, err
+	}
+	// Custom columns already choose and order the columns
+	if _, ok := p.(printer.CustomColumnsPrinter); ok && cmd.Flags().Changed("columns") {
+		return nil, fmt.Errorf("--columns can't be combined with -o custom-columns")
+	}
+	return p, nil
+}
+
+// tableOutput reports whether --output is the human-readable table, which
+// notes and hints may accompany; other formats print only the data
+func tableOutput(cmd *cobra.Command) bool {
+	_, ok := mustPrinter(cmd).(printer.TablePrinter)
+	return ok
+}
+
+// printTable prints t to stdout with the printer selected by --output,
+// limited to the columns selected by --columns
+func printTable(cmd *cobra.Command, t *printer.Table) error {
+	t, err := selectColumns(cmd, t)
+	if err != nil {
+		return err
+	}
+	return mustPrinter(cmd).Print(os.Stdout, t)
+}
+
+// selectColumns returns t with only the columns selected by --columns, or
+// t itself without the flag
+func selectColumns(cmd *cobra.Command, t *printer.Table) (*printer.Table, error) {
+	names, _ := cmd.Flags().GetStringSlice("columns")
+	if len(names) == 0 {
+		return t, nil
+	}
+	selected, err := t.Select(names)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --columns: %w", err)
+	}
+	return selected, nil
+}
+
+// mustPrinter returns the printer selected by --output, falling back to a
+// table; commands validate the format with outputPrinter first
+func mustPrinter(cmd *cobra.Command) printer.Printer {
+	p, err := outputPrinter(cmd)
+	if err != nil {
+		return printer.TablePrinter{}
+	}
+	return p
+}
+
+// reportOutput returns the report format selected by --output, text or
+// one of formats. Call it before contacting PeerDB, so an invalid format
+// fails fast. Reports are the documents commands whose text output isn't
+// a table print as JSON or YAML; field names follow the table columns,
+// e.g. rows_synced, and unknown values are null.
+func reportOutput(cmd *cobra.Command, formats ...string) (string, error) {
+	output, _ := cmd.Flags().GetString("output")
+	return printer.ReportFormat(output, formats...)
+}
+
+// printReport prints a report to stdout in a format reportOutput returned
+// other than text
+func printReport(format string, v interface{}) error {
+	return printer.PrintReport(os.Stdout, format, v)
+}
+
+// optionalTime returns t, or nil when ok is false, for times that may be
+// unknown
+func optionalTime(t time.Time, ok bool) *time.Time {
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// Column formats for values shown with the CLI's display settings
+
+func rowsColumn(value interface{}) string {
+	return formatRows(value.(int64))
+}
+
+func bytesColumn(value interface{}) string {
+	return formatBytes(value.(int64))
+}
+
+func timeColumn(value interface{}) string {
+	return formatTime(value.(time.Time))
+}
+
+// ageColumn formats an age in seconds like kubectl's AGE, e.g. "3d4h"
+func ageColumn(value interface{}) string {
+	return formatDuration(time.Duration(value.(int64)) * time.Second)
+}
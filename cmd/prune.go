@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/state"
+)
+
+// staleResources returns the resources recorded as managed from files
+// under root that configs no longer define. Resources created by hand or
+// managed from files elsewhere are never stale. Mirrors come first, so the
+// peers they use can be dropped after them.
+func staleResources(st *state.State, root string, configs []*config.FileConfig) []state.Resource {
+	root = absPath(root)
+	defined := make(map[string]bool)
+	for _, cfg := range configs {
+		defined[cfg.Kind+"/"+cfg.Metadata.Name] = true
+	}
+
+	var stale []state.Resource
+	for _, r := range st.Resources {
+		if r.File == "" || !withinPath(root, r.File) || defined[r.Kind+"/"+r.Name] {
+			continue
+		}
+		stale = append(stale, r)
+	}
+	slices.SortStableFunc(stale, func(a, b state.Resource) int {
+		return kindOrder(b.Kind) - kindOrder(a.Kind)
+	})
+	return stale
+}
+
+// withinPath reports whether path is root or inside it
+func withinPath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// pruneManaged drops the stale resources under root, asking for each as
+// confirm_mode says, and forgets them. Resources already gone from the
+// server are only forgotten.
+func pruneManaged(ctx context.Context, grpcClient *client.Client, root string, configs []*config.FileConfig, dryRun bool) error {
+	st, path, err := loadState()
+	if err != nil {
+		return err
+	}
+	stale := staleResources(st, root, configs)
+	if len(stale) == 0 {
+		fmt.Println("\nNo managed resources to prune")
+		return nil
+	}
+
+	fmt.Printf("\nPruning %d managed resource(s) no longer defined in %s...\n", len(stale), root)
+	forgotten := 0
+	for _, r := range stale {
+		if dryRun {
+			fmt.Printf("  [DRY-RUN] Would drop %s '%s' (managed from %s)\n", r.Kind, r.Name, r.File)
+			continue
+		}
+
+		exists, err := managedExists(ctx, grpcClient, r)
+		if err != nil {
+			return savePruned(st, path, forgotten, err)
+		}
+		if exists {
+			question := fmt.Sprintf("%s '%s' was managed from %s, which no longer defines it. Drop it?", r.Kind, r.Name, r.File)
+			if !confirmDestructive(strings.ToLower(r.Kind), r.Name, question, false) {
+				fmt.Printf("  Skipped %s '%s'\n", r.Kind, r.Name)
+				continue
+			}
+			if err := dropManaged(ctx, grpcClient, r); err != nil {
+				return savePruned(st, path, forgotten, err)
+			}
+		} else {
+			fmt.Printf("  %s '%s' no longer exists; forgetting it\n", r.Kind, r.Name)
+		}
+		st.Forget(r.Kind, r.Name)
+		forgotten++
+	}
+	return savePruned(st, path, forgotten, nil)
+}
+
+// savePruned saves the state when resources were forgotten and returns
+// err, or the error saving it
+func savePruned(st *state.State, path string, forgotten int, err error) error {
+	if forgotten == 0 || GetConfig().Explain != nil {
+		return err
+	}
+	if saveErr := state.Save(st, path); saveErr != nil && err == nil {
+		return saveErr
+	}
+	return err
+}
+
+// managedExists reports whether a managed resource still exists
+func managedExists(ctx context.Context, grpcClient *client.Client, r state.Resource) (bool, error) {
+	switch r.Kind {
+	case "Peer":
+		return grpcClient.PeerExists(ctx, r.Name)
+	case "Mirror":
+		return grpcClient.MirrorExists(ctx, r.Name)
+	default:
+		return false, fmt.Errorf("unsupported configuration kind: %s", r.Kind)
+	}
+}
+
+// dropManaged drops a managed peer or mirror. Mirrors keep their
+// destination tables only under drop_policy keep-destination, as with
+// mirror drop.
+func dropManaged(ctx context.Context, grpcClient *client.Client, r state.Resource) error {
+	if r.Kind == "Mirror" {
+		return mirrorService(grpcClient).Drop(ctx, r.Name, GetConfig().KeepDestination())
+	}
+	if err := grpcClient.DropPeer(ctx, r.Name); err != nil {
+		return fmt.Errorf("failed to drop peer: %w", err)
+	}
+	fmt.Printf("✓ Peer '%s' dropped successfully\n", r.Name)
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// mutatingAnnotation marks commands that change server state
+const mutatingAnnotation = "mirror_cli/mutating"
+
+func init() {
+	// Commands that always change server state are refused up front in
+	// read-only mode. Others, such as config apply and api call, are
+	// stopped by the client when they send a mutating RPC.
+	for _, cmd := range []*cobra.Command{
+		mirrorCreateCmd, mirrorPauseCmd, mirrorResumeCmd, mirrorDropCmd, mirrorEditCmd, mirrorCutoverCmd,
+		peerCreateCmd, peerDropCmd,
+	} {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[mutatingAnnotation] = "true"
+	}
+}
+
+// checkReadOnly returns an error if cmd changes server state and the
+// configuration is read-only
+func checkReadOnly(cmd *cobra.Command, cfg *config.Config) error {
+	// --explain never sends changes, so it's allowed
+	if !cfg.ReadOnly || cfg.Explain != nil || cmd.Annotations[mutatingAnnotation] == "" {
+		return nil
+	}
+	// Validation only checks a change without making it
+	if validateOnly, _ := cmd.Flags().GetBool("validate-only"); validateOnly {
+		return nil
+	}
+	if cfg.CurrentContext != "" && !cmd.Flags().Changed("read-only") {
+		return fmt.Errorf("'%s' is not allowed: context '%s' is read-only", cmd.CommandPath(), cfg.CurrentContext)
+	}
+	return fmt.Errorf("'%s' is not allowed in read-only mode", cmd.CommandPath())
+}
@@ -0,0 +1,589 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/gitsource"
+	"github.com/janakos/mirror_cli/internal/policy"
+	"github.com/janakos/mirror_cli/internal/poller"
+	"github.com/janakos/mirror_cli/internal/provenance"
+	"github.com/janakos/mirror_cli/internal/reconcile"
+	"github.com/janakos/mirror_cli/internal/state"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// reconcileCmd represents the reconcile command
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Continuously apply a config directory to PeerDB",
+	Long: `Run as a long-lived process that periodically compares the peer and mirror
+configs in a directory with the server and applies the differences: missing
+peers and mirrors are created, changed peers are updated, and tables, batch
+size, and idle timeout of existing mirrors are edited in place. Other mirror
+changes need a drop and recreate, so they are only reported. Nothing is ever
+dropped.
+
+Run several replicas safely with --lock-file (a lease file on a shared volume)
+or --lease (a Kubernetes Lease, when running in a cluster); only the holder
+reconciles. With --metrics-addr, Prometheus metrics are served on /metrics.
+
+With --git-repo, configs are fetched from a git repository at the start of
+every pass, and created mirrors record the commit they were applied from.`,
+	Example: `  mirror_cli reconcile --dir configs/ --interval 5m
+  mirror_cli reconcile --dir configs/ --lease mirror-cli-reconcile --metrics-addr :9090
+  mirror_cli reconcile --dir configs/ --once
+  mirror_cli reconcile --git-repo https://github.com/acme/peerdb-config.git --git-ref main --dir prod/`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReconcile(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().String("dir", "", "Directory of peer and mirror configs (required unless --git-repo is set)")
+	reconcileCmd.Flags().Duration("interval", 5*time.Minute, "Time between reconcile passes")
+	reconcileCmd.Flags().Bool("once", false, "Run a single pass and exit, non-zero if anything failed")
+	reconcileCmd.Flags().StringSlice("include", []string{}, "Only load files matching these glob patterns (relative to the directory, ** matches any path)")
+	reconcileCmd.Flags().StringSlice("exclude", []string{}, "Skip files matching these glob patterns")
+	reconcileCmd.Flags().String("policy", "", "Guardrail policy file to enforce (default: policy_file setting)")
+	reconcileCmd.Flags().String("lock-file", "", "Elect a leader through a lease file, e.g. on a shared volume")
+	reconcileCmd.Flags().String("lease", "", "Elect a leader through this Kubernetes Lease (in-cluster only)")
+	reconcileCmd.Flags().String("lease-namespace", "", "Namespace of the Kubernetes Lease (default: the pod's namespace)")
+	reconcileCmd.Flags().Duration("lock-ttl", time.Minute, "How long the lock is held without renewal before another process takes over")
+	addVariableFlags(reconcileCmd)
+	reconcileCmd.Flags().String("metrics-addr", "", "Serve Prometheus metrics and /healthz on this address, e.g. :9090")
+
+	addGitSourceFlags(reconcileCmd, "dir")
+
+	reconcileCmd.MarkFlagsMutuallyExclusive("lock-file", "lease")
+}
+
+func runReconcile(cmd *cobra.Command) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	once, _ := cmd.Flags().GetBool("once")
+	lockFile, _ := cmd.Flags().GetString("lock-file")
+	leaseName, _ := cmd.Flags().GetString("lease")
+	leaseNamespace, _ := cmd.Flags().GetString("lease-namespace")
+	ttl, _ := cmd.Flags().GetDuration("lock-ttl")
+	metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if ttl < 3*time.Second {
+		return fmt.Errorf("--lock-ttl must be at least 3s")
+	}
+	if err := applyVariableFlags(cmd); err != nil {
+		return err
+	}
+	repo := gitSource(cmd)
+	if repo == nil {
+		if dir == "" {
+			return fmt.Errorf("--dir or --git-repo is required")
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("--dir %s is not a directory", dir)
+		}
+	}
+
+	pol, err := loadPolicy(cmd)
+	if err != nil {
+		return err
+	}
+
+	identity := reconcile.Identity()
+	var lock reconcile.Lock = reconcile.NoLock{}
+	switch {
+	case lockFile != "":
+		lock = &reconcile.FileLock{Path: lockFile, Identity: identity, TTL: ttl}
+	case leaseName != "":
+		if lock, err = reconcile.NewKubeLease(leaseName, leaseNamespace, identity, ttl); err != nil {
+			return err
+		}
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx, stop := signal.NotifyContext(commandContext(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	metrics := reconcile.NewMetrics()
+	if metricsAddr != "" {
+		server := &http.Server{Addr: metricsAddr, Handler: metrics.Handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logf("⚠ Metrics server stopped: %v", err)
+			}
+		}()
+		defer server.Shutdown(context.Background())
+		logf("Serving metrics on %s/metrics", metricsAddr)
+	}
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := lock.Release(releaseCtx); err != nil {
+			logf("⚠ Failed to release lock: %v", err)
+		}
+	}()
+
+	source := dir
+	if repo != nil {
+		source = gitsource.Redact(repo.Repo)
+		if repo.Ref != "" {
+			source += "@" + repo.Ref
+		}
+		if dir != "" {
+			source += ":" + dir
+		}
+	}
+	logf("Reconciling %s every %s as %s", source, interval, identity)
+
+	// The lock is renewed well within its TTL while passes run in the
+	// background, so a pass is cancelled as soon as leadership is lost
+	renewEvery := ttl / 3
+	leader := false
+	var nextRun time.Time
+	var cancelRun context.CancelFunc
+	done := make(chan reconcile.RunResult, 1)
+
+	for {
+		held, err := lock.Acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			logf("⚠ Failed to acquire lock: %v", err)
+		}
+		if held != leader {
+			leader = held
+			metrics.SetLeader(leader)
+			if _, ok := lock.(reconcile.NoLock); ok {
+				// Without a lock there is nothing to report
+			} else if leader {
+				logf("Acquired lock; this process is the leader")
+			} else {
+				logf("Lost lock; waiting to become leader")
+			}
+		}
+		if !leader && cancelRun != nil {
+			cancelRun()
+		}
+		if !leader && once {
+			return fmt.Errorf("another process holds the reconcile lock")
+		}
+
+		if leader && cancelRun == nil && !time.Now().Before(nextRun) {
+			var runCtx context.Context
+			runCtx, cancelRun = context.WithCancel(ctx)
+			go func() {
+				done <- reconcilePass(runCtx, cmd, client, repo, dir, pol, metrics)
+			}()
+		}
+
+		wait := renewEvery
+		if leader && cancelRun == nil {
+			wait = min(wait, time.Until(nextRun))
+		}
+
+		select {
+		case <-ctx.Done():
+			if cancelRun != nil {
+				cancelRun()
+				<-done
+			}
+			logf("Stopped")
+			return nil
+		case result := <-done:
+			cancelRun()
+			cancelRun = nil
+			metrics.Run(result)
+			logReconcileResult(result)
+			// Jittered, so reconcilers of several repositories drift apart
+			nextRun = result.Started.Add(poller.Jitter(interval, poller.DefaultJitter))
+			if once {
+				if result.Err != nil {
+					return result.Err
+				}
+				if result.Failed > 0 {
+					return fmt.Errorf("%d config(s) failed to apply", result.Failed)
+				}
+				return nil
+			}
+		case <-time.After(wait):
+		}
+	}
+}
+
+// logf prints a timestamped line, since reconcile output usually ends up in
+// a log collector
+func logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+func logReconcileResult(result reconcile.RunResult) {
+	switch {
+	case result.Err != nil:
+		logf("❌ Reconcile failed after %s: %v", formatDuration(result.Duration), result.Err)
+	case result.Failed > 0:
+		logf("❌ Reconciled %d config(s) in %s; %d failed", result.Configs, formatDuration(result.Duration), result.Failed)
+	default:
+		logf("✅ Reconciled %d config(s) in %s", result.Configs, formatDuration(result.Duration))
+	}
+	if result.Drifted > 0 {
+		logf("⚠ %d mirror(s) differ from their config in ways that need a drop and recreate", result.Drifted)
+	}
+}
+
+// reconcilePass loads the directory, fetching it from repo first if set,
+// and applies each config that differs from the server
+func reconcilePass(ctx context.Context, cmd *cobra.Command, grpcClient *client.Client, repo *gitsource.Source, dir string, pol *policy.Policy, metrics *reconcile.Metrics) reconcile.RunResult {
+	result := reconcile.RunResult{Started: time.Now()}
+
+	var annotations map[string]string
+	if repo != nil {
+		var checkout *gitsource.Checkout
+		var err error
+		dir, annotations, checkout, err = fetchGitSource(ctx, repo, dir)
+		if err != nil {
+			result.Err = err
+			result.Duration = time.Since(result.Started)
+			return result
+		}
+		if metrics.SetRevision(gitsource.Redact(repo.Repo), checkout.SHA) {
+			logf("Fetched %s", gitRevisionLabel(repo, checkout))
+		}
+	}
+
+	configs, err := config.LoadConfigsFromDirectory(dir, discoverOptions(cmd))
+	if err == nil {
+		configs, err = config.ResolveTemplates(configs)
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("failed to load configs: %w", err)
+		result.Duration = time.Since(result.Started)
+		return result
+	}
+	configs = slices.DeleteFunc(configs, func(fc *config.FileConfig) bool {
+		return fc.Kind != "Peer" && fc.Kind != "Mirror"
+	})
+	// Peers first, so new mirrors can use new peers
+	slices.SortStableFunc(configs, func(a, b *config.FileConfig) int {
+		return kindOrder(a.Kind) - kindOrder(b.Kind)
+	})
+	result.Configs = len(configs)
+	for _, fc := range configs {
+		fc.PrefixNames(GetConfig().NamePrefix)
+	}
+
+	// Peer secrets are compared by the hash recorded in the context's state,
+	// which also records what the directory manages
+	st, statePath, err := loadState()
+	if err != nil {
+		logf("⚠ Could not load state, so peers are updated to be sure their secrets match: %v", err)
+		st, statePath = &state.State{}, ""
+	}
+	recorded := false
+
+	// Configs for another environment are skipped when the gate is on
+	mismatched := checkEnvironments(configs)
+	resolve := peerTypeResolver(ctx, grpcClient, configs)
+
+	for _, fc := range configs {
+		if ctx.Err() != nil {
+			result.Err = ctx.Err()
+			break
+		}
+
+		err := fc.CheckNames()
+		if err == nil && GetConfig().RequireEnvironmentMatch && slices.Contains(mismatched, fc) {
+			err = fmt.Errorf("environment '%s' doesn't match require_environment_match", fc.Metadata.Environment)
+		}
+		if err == nil {
+			err = checkDestination(fc, resolve)
+		}
+		if err == nil && pol != nil {
+			err = checkPolicy(pol, []*config.FileConfig{fc}, resolve)
+		}
+		if err == nil {
+			switch fc.Kind {
+			case "Peer":
+				var changed bool
+				changed, err = reconcilePeer(ctx, grpcClient, fc, st, metrics)
+				recorded = recorded || changed
+			case "Mirror":
+				var drifted bool
+				drifted, err = reconcileMirror(ctx, grpcClient, fc, annotations, metrics)
+				if drifted {
+					result.Drifted++
+				}
+			}
+		}
+		if err != nil {
+			result.Failed++
+			logf("❌ %s '%s': %v", fc.Kind, fc.Metadata.Name, fc.Locate(err))
+			continue
+		}
+		recorded = manageReconciled(st, fc) || recorded
+	}
+
+	// Reconcile never drops anything; point out what a prune would
+	if ctx.Err() == nil {
+		for _, r := range staleResources(st, dir, configs) {
+			logf("⚠ %s '%s' was managed from %s, which no longer defines it; drop it with 'config apply --prune'", r.Kind, r.Name, r.File)
+		}
+	}
+
+	if recorded && statePath != "" {
+		if err := state.Save(st, statePath); err != nil {
+			logf("⚠ Could not record managed resources: %v", err)
+		}
+	}
+
+	result.Duration = time.Since(result.Started)
+	return result
+}
+
+func kindOrder(kind string) int {
+	if kind == "Peer" {
+		return 0
+	}
+	return 1
+}
+
+// reconcilePeer creates a missing peer or updates one that differs,
+// recording the hash of its secrets in st. It reports whether it did.
+func reconcilePeer(ctx context.Context, grpcClient *client.Client, fc *config.FileConfig, st *state.State, metrics *reconcile.Metrics) (bool, error) {
+	desired, err := fc.ToPeerProto()
+	if err != nil {
+		return false, fmt.Errorf("failed to convert config to peer: %w", err)
+	}
+	hash, err := config.SecretHash(desired)
+	if err != nil {
+		return false, err
+	}
+
+	exists, err := grpcClient.PeerExists(ctx, desired.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for existing peer: %w", err)
+	}
+	if !exists {
+		if _, err := grpcClient.CreatePeer(ctx, desired, false); err != nil {
+			return false, fmt.Errorf("failed to create peer: %w", err)
+		}
+		recordSecretHash(st, desired.Name, hash)
+		metrics.Change("Peer", "create")
+		logf("✓ Created peer '%s'", desired.Name)
+		return true, nil
+	}
+
+	existing, err := grpcClient.GetPeer(ctx, desired.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get peer: %w", err)
+	}
+	diffs, err := peerDiffs(desired, existing)
+	if err != nil {
+		return false, err
+	}
+	// Secrets are masked when read back, so they differ when their hash
+	// doesn't match the one last applied, or none was recorded
+	if managed, ok := st.Get("Peer", desired.Name); !ok || managed.SecretHash != hash {
+		diffs = append(diffs, "secrets")
+	}
+	if len(diffs) == 0 {
+		return false, nil
+	}
+	if _, err := grpcClient.CreatePeer(ctx, desired, true); err != nil {
+		return false, fmt.Errorf("failed to update peer: %w", err)
+	}
+	recordSecretHash(st, desired.Name, hash)
+	metrics.Change("Peer", "update")
+	logf("✓ Updated peer '%s' (%v)", desired.Name, diffs)
+	return true, nil
+}
+
+// manageReconciled records a reconciled config as managed from its file,
+// reporting whether that changed the state
+func manageReconciled(st *state.State, fc *config.FileConfig) bool {
+	file := absPath(fc.Path)
+	if managed, ok := st.Get(fc.Kind, fc.Metadata.Name); ok && managed.File == file {
+		return false
+	}
+	st.Manage(state.Resource{Kind: fc.Kind, Name: fc.Metadata.Name, File: file})
+	return true
+}
+
+// recordSecretHash records the secret hash of a peer just applied
+func recordSecretHash(st *state.State, name, hash string) {
+	now := time.Now().UTC()
+	st.Manage(state.Resource{Kind: "Peer", Name: name, LastApplied: &now, SecretHash: hash})
+}
+
+// peerDiffs lists the fields of desired that differ from existing. Secrets
+// are masked on both sides; reconcilePeer compares them by hash.
+func peerDiffs(desired, existing *pb.Peer) ([]string, error) {
+	want, err := config.FromPeerProto(desired, "")
+	if err != nil {
+		return nil, err
+	}
+	have, err := config.FromPeerProto(existing, "")
+	if err != nil {
+		return nil, err
+	}
+	return config.DiffFields(want, have)
+}
+
+// reconcileMirror creates a missing mirror, stamped with annotations, or
+// edits the tables, batch size, and idle timeout of one that differs. It
+// reports whether other fields differ, which can't be changed in place.
+func reconcileMirror(ctx context.Context, grpcClient *client.Client, fc *config.FileConfig, annotations map[string]string, metrics *reconcile.Metrics) (bool, error) {
+	exists, err := grpcClient.MirrorExists(ctx, fc.Metadata.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for existing mirror: %w", err)
+	}
+	if !exists {
+		if err := applyMirrorConfig(ctx, grpcClient, fc, annotations); err != nil {
+			return false, fmt.Errorf("failed to create mirror: %w", err)
+		}
+		metrics.Change("Mirror", "create")
+		logf("✓ Created mirror '%s'", fc.Metadata.Name)
+		return false, nil
+	}
+	if fc.IsQRep() {
+		// Query replication mirrors can't be edited, so existing ones are
+		// left alone
+		return false, nil
+	}
+
+	req, err := mirrorRequest(fc, annotations)
+	if err != nil {
+		return false, err
+	}
+	status, err := grpcClient.GetMirrorStatus(ctx, fc.Metadata.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return false, fmt.Errorf("mirror exists but is not a CDC mirror")
+	}
+	desired, actual := req.ConnectionConfigs, status.CdcStatus.Config
+
+	diffs, err := config.DiffFields(config.FromMirrorProto(desired, ""), config.FromMirrorProto(actual, ""))
+	if err != nil {
+		return false, err
+	}
+	revision := provenance.RevisionChanged(actual.Env, desired.Env)
+	if len(diffs) == 0 && !revision {
+		return false, nil
+	}
+
+	edit, drift := mirrorChanges(desired, actual, diffs)
+	if len(drift) == 0 {
+		// Record the commit and spec the mirror now matches
+		edit.Env = changedEnv(desired.Env, actual.Env)
+	}
+	if mirrorEdits(edit) || (revision && len(drift) == 0) {
+		service := &app.Mirrors{Client: grpcClient, Out: app.NewPrinter(os.Stdout)}
+		if err := service.Edit(ctx, fc.Metadata.Name, edit); err != nil {
+			return len(drift) > 0, err
+		}
+		if mirrorEdits(edit) {
+			metrics.Change("Mirror", "edit")
+			logf("✓ Edited mirror '%s': %d table(s) added, %d removed", fc.Metadata.Name, len(edit.AddTables), len(edit.RemoveTables))
+		} else {
+			logf("✓ Recorded commit %s on mirror '%s'", desired.Env[provenance.Prefix+"git_sha"], fc.Metadata.Name)
+		}
+	}
+	if len(drift) > 0 {
+		logf("⚠ Mirror '%s' differs in %v; drop and recreate it to apply", fc.Metadata.Name, drift)
+	}
+	return len(drift) > 0, nil
+}
+
+// mirrorChanges splits the fields that differ between desired and actual
+// into the edit PeerDB applies to an existing mirror and the fields it
+// can't change without a resync. A table already in the mirror whose
+// mapping changed needs a resync too, since tables are matched by source.
+func mirrorChanges(desired, actual *pb.FlowConnectionConfigs, diffs []string) (app.MirrorEdit, []string) {
+	edit := mirrorEditFor(desired, actual)
+	var resync []string
+	for _, diff := range diffs {
+		switch {
+		case diff == "spec.tables" && !remappedTables(desired, actual):
+		case diff == "spec.cdc.batch_size", diff == "spec.cdc.idle_timeout_seconds":
+		default:
+			resync = append(resync, diff)
+		}
+	}
+	return edit, resync
+}
+
+// mirrorEdits reports whether an edit changes anything
+func mirrorEdits(edit app.MirrorEdit) bool {
+	return len(edit.AddTables) > 0 || len(edit.RemoveTables) > 0 || edit.BatchSize != 0 || edit.IdleTimeout != 0
+}
+
+// remappedTables reports whether a table in both desired and actual is
+// mapped differently
+func remappedTables(desired, actual *pb.FlowConnectionConfigs) bool {
+	current := make(map[string]*pb.TableMapping, len(actual.TableMappings))
+	for _, mapping := range actual.TableMappings {
+		current[mapping.SourceTableIdentifier] = mapping
+	}
+	for _, mapping := range desired.TableMappings {
+		if old, ok := current[mapping.SourceTableIdentifier]; ok && !proto.Equal(old, mapping) {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorEditFor returns the edit that brings actual's tables, batch size,
+// and idle timeout in line with desired. Tables are matched by source.
+func mirrorEditFor(desired, actual *pb.FlowConnectionConfigs) app.MirrorEdit {
+	var edit app.MirrorEdit
+	current := make(map[string]bool, len(actual.TableMappings))
+	for _, mapping := range actual.TableMappings {
+		current[mapping.SourceTableIdentifier] = true
+	}
+	wanted := make(map[string]bool, len(desired.TableMappings))
+	for _, mapping := range desired.TableMappings {
+		wanted[mapping.SourceTableIdentifier] = true
+		if !current[mapping.SourceTableIdentifier] {
+			edit.AddTables = append(edit.AddTables, mapping)
+		}
+	}
+	for _, mapping := range actual.TableMappings {
+		if !wanted[mapping.SourceTableIdentifier] {
+			edit.RemoveTables = append(edit.RemoveTables, mapping)
+		}
+	}
+
+	if desired.MaxBatchSize != 0 && desired.MaxBatchSize != actual.MaxBatchSize {
+		edit.BatchSize = desired.MaxBatchSize
+	}
+	if desired.IdleTimeoutSeconds != 0 && desired.IdleTimeoutSeconds != actual.IdleTimeoutSeconds {
+		edit.IdleTimeout = desired.IdleTimeoutSeconds
+	}
+	return edit
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// checkReplicationNames prints the publication and replication slot a new
+// mirror will use. PeerDB creates them when their names are empty and
+// expects named ones to already exist, so names are only passed as set:
+// omitted ones are checked against what PeerDB will create, set ones
+// against what exists on the source. Only PostgreSQL sources have them.
+func checkReplicationNames(ctx context.Context, grpcClient *client.Client, configs *pb.FlowConnectionConfigs) {
+	source, err := grpcClient.GetPeer(ctx, configs.SourceName)
+	if err != nil {
+		fmt.Printf("  ⚠ Could not look up source peer '%s', skipping publication and slot checks: %v\n", configs.SourceName, err)
+		return
+	}
+	if source.Type != pb.DBType_POSTGRES {
+		return
+	}
+
+	publications, err := grpcClient.ListPublications(ctx, configs.SourceName)
+	if err != nil {
+		fmt.Printf("  ⚠ Could not list publications on '%s', skipping publication check: %v\n", configs.SourceName, err)
+	} else {
+		defaultName := config.DefaultPublicationName(configs.FlowJobName)
+		name := configs.PublicationName
+		if name == "" {
+			name = defaultName
+		}
+		printReplicationName("Publication", configs.SourceName, configs.PublicationName, defaultName, slices.Contains(publications, name))
+	}
+
+	slots, err := grpcClient.ListSlots(ctx, configs.SourceName)
+	if err != nil {
+		fmt.Printf("  ⚠ Could not list replication slots on '%s', skipping slot check: %v\n", configs.SourceName, err)
+		return
+	}
+	defaultName := config.DefaultReplicationSlotName(configs.FlowJobName)
+	name := configs.ReplicationSlotName
+	if name == "" {
+		name = defaultName
+	}
+	exists := slices.ContainsFunc(slots, func(slot *pb.SlotInfo) bool { return slot.SlotName == name })
+	printReplicationName("Replication slot", configs.SourceName, configs.ReplicationSlotName, defaultName, exists)
+}
+
+// printReplicationName prints the publication or slot a mirror will use.
+// name is the one set for the mirror, or empty for PeerDB's default, and
+// exists whether the name in use is already on the source.
+func printReplicationName(label, source, name, defaultName string, exists bool) {
+	switch {
+	case name == "" && exists:
+		fmt.Printf("  ⚠ %s: %s already exists on '%s'; drop it if an earlier mirror of the same name left it behind\n", label, defaultName, source)
+	case name == "":
+		fmt.Printf("  %s: %s (created by PeerDB)\n", label, defaultName)
+	case !exists:
+		fmt.Printf("  ⚠ %s: %s doesn't exist on '%s'; PeerDB only creates ones it names, so create it or leave the name out\n", label, name, source)
+	default:
+		fmt.Printf("  %s: %s (existing)\n", label, name)
+	}
+}
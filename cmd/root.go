@@ -1,18 +1,30 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/janakos/mirror_cli/internal/client"
 	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/credhelper"
+	"github.com/janakos/mirror_cli/internal/telemetry"
 )
 
 var (
 	cfgFile string
 	cfg     *config.Config
+
+	// commandCtx carries the running command's trace span
+	commandCtx  context.Context
+	commandSpan trace.Span
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -24,18 +36,123 @@ var rootCmd = &cobra.Command{
 It provides commands to create, list, pause, resume, drop, and monitor mirrors,
 as well as manage peer connections.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		cfg, err = config.LoadConfig()
+		var target string
+		if len(args) > 0 {
+			target = args[0]
+		}
+		commandCtx, commandSpan = telemetry.StartCommand(context.Background(), cmd.CommandPath(), target)
+		startRecording(cmd)
+
+		// Explanations own stdout; regular output moves to stderr
+		explain, _ := cmd.Flags().GetBool("explain")
+		var explainOut *os.File
+		if explain {
+			explainOut = os.Stdout
+			os.Stdout = os.Stderr
+		}
+
+		// Rewriting symbols would corrupt JSON, YAML, and other data
+		plain, _ := cmd.Flags().GetBool("plain")
+		if usePlainOutput(plain) && !machineOutput(cmd) {
+			if err := enablePlainOutput(); err != nil {
+				return err
+			}
+		}
+
+		if err := applyTimeFlags(cmd); err != nil {
+			return err
+		}
+
+		loaded, err := config.LoadConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
-		return nil
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if err := loaded.ApplyContextOverride(wd); err != nil {
+			// Still allow importing or switching to the missing context
+			if cmd != configImportContextCmd && cmd != configUseContextCmd {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+		}
+
+		cfg, err = loaded.ResolveContext()
+		if err != nil {
+			return err
+		}
+
+		applyFlagOverrides(cmd, cfg)
+		if err := config.ValidatePeerDBAPI(cfg.PeerDBAPI); err != nil {
+			return err
+		}
+		if err := applyHeaderFlags(cmd, cfg); err != nil {
+			return err
+		}
+		if cfg.CurrentContext != "" {
+			fmt.Fprintln(os.Stderr, "Using context:", contextHeader(cfg))
+		}
+		cfg.Warnings = os.Stderr
+		useCredentialHelper(cfg)
+		if explainOut != nil {
+			cfg.Explain = explainOut
+		}
+		if recorder != nil {
+			cfg.Record = recorder.Record
+		}
+		if err := checkReadOnly(cmd, cfg); err != nil {
+			return err
+		}
+		return runPreHook(cmd, args, cfg)
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	defer func() { restoreStdout() }()
+
+	// Tracing is best-effort and must never stop the CLI from running
+	shutdown, err := telemetry.Init(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: tracing disabled: %v\n", err)
+		shutdown = func(context.Context) error { return nil }
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(commandContext(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to flush traces: %v\n", err)
+		}
+	}()
+
+	addCompletionInstallCmd()
+	err = rootCmd.Execute()
+
+	finishRecording(err)
+	runPostHook(err)
+
+	// Point to the server logs of the failing RPC
+	var requestErr *client.RequestError
+	if errors.As(err, &requestErr) {
+		fmt.Fprintf(os.Stderr, "Request ID: %s\n", requestErr.RequestID)
+	}
+
+	if commandSpan != nil {
+		telemetry.EndCommand(commandSpan, err)
+	}
+	return err
+}
+
+// commandContext returns the context commands derive their RPC contexts
+// from, so gRPC spans are recorded under the command's span
+func commandContext() context.Context {
+	if commandCtx == nil {
+		return context.Background()
+	}
+	return commandCtx
 }
 
 func init() {
@@ -48,6 +165,17 @@ func init() {
 	rootCmd.PersistentFlags().Bool("tls", false, "Use TLS connection")
 	rootCmd.PersistentFlags().String("username", "", "Username for authentication")
 	rootCmd.PersistentFlags().String("password", "", "Password for authentication")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Bypass the local response cache")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Refuse commands that change server state")
+	rootCmd.PersistentFlags().Bool("explain", false, "Print the gRPC method and JSON request of each change instead of sending it; lookups are still sent")
+	rootCmd.PersistentFlags().String("peerdb-api", "", "FlowService API version to speak: auto (from the server's version), current, or legacy (PeerDB before v0.15)")
+	rootCmd.PersistentFlags().StringArray("header", nil, "Extra gRPC metadata to send with every request, as key=value (repeatable)")
+	rootCmd.PersistentFlags().String("time-format", timeFormatRelative, "How to print timestamps: relative, rfc3339, or unix")
+	rootCmd.PersistentFlags().String("timezone", "local", "Timezone for absolute timestamps: UTC, local, or an IANA name")
+	rootCmd.PersistentFlags().BoolVar(&rawNumbers, "raw", false, "Print exact row counts, sizes, and durations instead of rounded ones (e.g. 1234567 instead of 1.2M)")
+	rootCmd.PersistentFlags().String("record", "", "Append this command, its arguments, and the RPCs it sends with their responses to a session file for review or 'replay'")
+	addOutputFlag(rootCmd)
+	rootCmd.PersistentFlags().Bool("plain", false, "Plain output without colors or emoji (default when stdout is not a terminal or NO_COLOR is set)")
 
 	// Bind flags to viper
 	viper.BindPFlag("peerdb_host", rootCmd.PersistentFlags().Lookup("host"))
@@ -55,6 +183,7 @@ func init() {
 	viper.BindPFlag("tls", rootCmd.PersistentFlags().Lookup("tls"))
 	viper.BindPFlag("username", rootCmd.PersistentFlags().Lookup("username"))
 	viper.BindPFlag("password", rootCmd.PersistentFlags().Lookup("password"))
+	viper.BindPFlag("no_cache", rootCmd.PersistentFlags().Lookup("no-cache"))
 }
 
 // loadConfigFile reads in config file and ENV variables if set.
@@ -82,7 +211,77 @@ func loadConfigFile() {
 	}
 }
 
+// applyFlagOverrides re-applies explicitly passed connection flags so they
+// take precedence over the values from the current context
+func applyFlagOverrides(cmd *cobra.Command, cfg *config.Config) {
+	flags := cmd.Flags()
+
+	// An explicit --host replaces any configured peerdb_hosts
+	if flags.Changed("host") {
+		cfg.PeerDBHost, _ = flags.GetString("host")
+		cfg.PeerDBHosts = nil
+	}
+	if flags.Changed("port") {
+		cfg.PeerDBPort, _ = flags.GetInt("port")
+	}
+	if flags.Changed("tls") {
+		cfg.TLS, _ = flags.GetBool("tls")
+	}
+	if flags.Changed("username") {
+		cfg.Username, _ = flags.GetString("username")
+	}
+	if flags.Changed("password") {
+		cfg.Password, _ = flags.GetString("password")
+	}
+	if flags.Changed("peerdb-api") {
+		cfg.PeerDBAPI, _ = flags.GetString("peerdb-api")
+	}
+	// --read-only can only make the configuration stricter
+	if readOnly, _ := flags.GetBool("read-only"); readOnly {
+		cfg.ReadOnly = true
+	}
+}
+
+// applyHeaderFlags adds --header key=value pairs to the configured extra
+// headers, overriding headers with the same name
+func applyHeaderFlags(cmd *cobra.Command, cfg *config.Config) error {
+	headers, _ := cmd.Flags().GetStringArray("header")
+	if len(headers) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(cfg.ExtraHeaders)+len(headers))
+	for name, value := range cfg.ExtraHeaders {
+		merged[strings.ToLower(name)] = value
+	}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, "=")
+		if !ok {
+			return fmt.Errorf("invalid --header %q (expected key=value)", header)
+		}
+		merged[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+	cfg.ExtraHeaders = merged
+	return nil
+}
+
 // GetConfig returns the loaded configuration
 func GetConfig() *config.Config {
 	return cfg
 }
+
+// useCredentialHelper resolves ${VAR} references in config files that
+// aren't set in the environment with the configured credential helper
+func useCredentialHelper(cfg *config.Config) {
+	if cfg.CredentialHelper == "" {
+		return
+	}
+	helper := credhelper.New(cfg.CredentialHelper)
+	config.SecretSource = func(name string) (string, bool) {
+		value, ok, err := helper.Secret(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not look up ${%s}: %v\n", name, err)
+		}
+		return value, ok
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// scaffoldCmd represents the scaffold command
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold [kind] [type]",
+	Short: "Generate an example configuration file",
+	Long: `Print a fully commented example YAML configuration for the requested kind and type.
+
+Examples:
+  mirror_cli scaffold peer postgres
+  mirror_cli scaffold peer snowflake --name warehouse -o configs/peers/production/warehouse.yaml
+  mirror_cli scaffold mirror cdc
+  mirror_cli scaffold context --name prod`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return config.ScaffoldKinds(), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return config.ScaffoldTypes(args[0]), cobra.ShellCompDirectiveNoFileComp
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		typ := ""
+		if len(args) > 1 {
+			typ = args[1]
+		}
+		return scaffold(cmd, args[0], typ)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scaffoldCmd)
+
+	scaffoldCmd.Flags().String("name", "", "Resource name (defaults to a name derived from the type)")
+	scaffoldCmd.Flags().StringP("output", "o", "", "Write to a file instead of stdout")
+}
+
+func scaffold(cmd *cobra.Command, kind, typ string) error {
+	name, _ := cmd.Flags().GetString("name")
+	output, _ := cmd.Flags().GetString("output")
+
+	if name == "" {
+		name = fmt.Sprintf("my_%s", kind)
+		if typ != "" {
+			name = fmt.Sprintf("my_%s_%s", typ, kind)
+		}
+	}
+
+	content, err := config.Scaffold(kind, typ, name)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("✓ Example %s configuration written to %s\n", kind, output)
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// schemaTableMappings maps every table in the source schemas that PeerDB
+// can mirror, skipping tables already in mappings. Tables without a
+// primary key or REPLICA IDENTITY FULL are left out with a warning: CDC
+// can't replicate their updates and deletes. Destinations are in the
+// default destination schema, or named like the source without one.
+func schemaTableMappings(ctx context.Context, grpcClient *client.Client, source string, schemas []string, mappings []*pb.TableMapping) ([]*pb.TableMapping, error) {
+	mapped := make(map[string]bool, len(mappings))
+	for _, mapping := range mappings {
+		mapped[mapping.SourceTableIdentifier] = true
+	}
+
+	var added []*pb.TableMapping
+	var skipped []string
+	for _, schema := range schemas {
+		tables, err := grpcClient.ListTables(ctx, source, schema, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables in %s on '%s': %w", schema, source, err)
+		}
+		sort.Slice(tables, func(i, j int) bool { return tables[i].TableName < tables[j].TableName })
+
+		found := 0
+		for _, table := range tables {
+			name := schema + "." + table.TableName
+			if mapped[name] {
+				continue
+			}
+			if !table.CanMirror {
+				skipped = append(skipped, name)
+				continue
+			}
+			mapped[name] = true
+			found++
+
+			destination := name
+			if schema := GetConfig().DefaultDestinationSchema; schema != "" {
+				destination = config.DestinationTable(schema, name)
+			}
+			added = append(added, &pb.TableMapping{SourceTableIdentifier: name, DestinationTableIdentifier: destination})
+		}
+		fmt.Printf("✓ Found %d table(s) to mirror in schema %s on '%s'\n", found, schema, source)
+	}
+
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ Skipping %d table(s) without a primary key or REPLICA IDENTITY FULL, whose updates and deletes CDC can't replicate:\n", len(skipped))
+		for _, name := range skipped {
+			fmt.Fprintf(os.Stderr, "  %s\n", name)
+		}
+		fmt.Fprintln(os.Stderr, "💡 Add a primary key, or run ALTER TABLE ... REPLICA IDENTITY FULL, then add them with 'mirror edit --add-tables'")
+	}
+	if len(mappings)+len(added) == 0 {
+		return nil, fmt.Errorf("no tables to mirror in schema(s) %s on '%s'", strings.Join(schemas, ", "), source)
+	}
+	return append(mappings, added...), nil
+}
@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// selftestCmd represents the selftest command
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Check end to end that a PeerDB deployment replicates",
+	Long: `Verify that a PeerDB deployment is fully functional with a throwaway mirror:
+
+  1. Create a tiny table on the source peer and insert a row
+  2. Create a CDC mirror of it to the destination peer
+  3. Wait for the initial snapshot to copy the row
+  4. Insert another row and wait for it to replicate
+  5. Drop the mirror, its destination table, and the source table
+
+The source must be a PostgreSQL peer. PeerDB can't run SQL for the CLI, so
+the table is created with psql, connecting with the source peer's settings,
+or with --source-dsn when the CLI reaches the database at another address
+than PeerDB does. Without a password in the peer's settings, psql uses
+PGPASSWORD or ~/.pgpass.
+
+Everything is torn down even when a step fails, unless --keep is set.`,
+	Example: `  mirror_cli selftest --source pg_prod --destination sf_prod
+  mirror_cli selftest --source pg_prod --destination ch_prod --source-dsn "host=localhost port=5433 dbname=app user=me"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSelftest(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selftestCmd)
+
+	selftestCmd.Flags().String("source", "", "PostgreSQL source peer (required)")
+	selftestCmd.Flags().String("destination", "", "Destination peer (required)")
+	selftestCmd.Flags().String("schema", "public", "Source schema for the test table")
+	selftestCmd.Flags().String("destination-table", "", "Destination table (default: the source table, or its name in the default destination schema)")
+	selftestCmd.Flags().String("source-dsn", "", "psql connection string for the source (default: the source peer's settings)")
+	selftestCmd.Flags().String("psql", "psql", "psql program to run SQL on the source with")
+	selftestCmd.Flags().Duration("timeout", 0, "Give up if replication takes longer than this, 0 for no limit (default: wait_timeout)")
+	selftestCmd.Flags().Duration("poll-interval", 5*time.Second, "How often to check the mirror")
+	selftestCmd.Flags().Bool("keep", false, "Keep the mirror and tables for inspection instead of dropping them")
+	selftestCmd.MarkFlagRequired("source")
+	selftestCmd.MarkFlagRequired("destination")
+}
+
+// selftest is one run of the selftest command
+type selftest struct {
+	client      *client.Client
+	psql        *psqlRunner
+	source      string
+	destination string
+	table       string
+	destTable   string
+	mirror      string
+	interval    time.Duration
+
+	// Resources created so far, torn down in reverse
+	createdTable  bool
+	createdMirror bool
+}
+
+func runSelftest(cmd *cobra.Command) error {
+	source, _ := cmd.Flags().GetString("source")
+	destination, _ := cmd.Flags().GetString("destination")
+	schema, _ := cmd.Flags().GetString("schema")
+	destTable, _ := cmd.Flags().GetString("destination-table")
+	dsn, _ := cmd.Flags().GetString("source-dsn")
+	psql, _ := cmd.Flags().GetString("psql")
+	timeout := waitTimeout(cmd, "timeout")
+	interval, _ := cmd.Flags().GetDuration("poll-interval")
+	keep, _ := cmd.Flags().GetBool("keep")
+
+	cmd.SilenceUsage = true
+
+	ctx, cancel := withWaitTimeout(commandContext(), timeout)
+	defer cancel()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	suffix, err := selftestSuffix()
+	if err != nil {
+		return err
+	}
+	name := "mirror_cli_selftest_" + suffix
+	t := &selftest{
+		client:      client,
+		source:      resourceName(source),
+		destination: resourceName(destination),
+		table:       schema + "." + name,
+		destTable:   destTable,
+		mirror:      resourceName(name),
+		interval:    interval,
+	}
+
+	err = t.run(ctx, dsn, psql)
+	if keep {
+		t.printKept()
+	} else {
+		// Tear down even after a timeout or interrupt
+		teardownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		if teardownErr := t.teardown(teardownCtx); teardownErr != nil && err == nil {
+			err = teardownErr
+		}
+	}
+
+	if err != nil {
+		fmt.Printf("\n❌ Self-test of %s -> %s failed\n", source, destination)
+		return err
+	}
+	fmt.Printf("\n✅ Self-test passed: %s -> %s replicates snapshots and changes\n", source, destination)
+	return nil
+}
+
+// selftestSuffix returns a random suffix that keeps concurrent runs apart
+func selftestSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (t *selftest) run(ctx context.Context, dsn, psql string) error {
+	// 1. Peers and source table
+	sourcePeer, err := t.client.GetPeer(ctx, t.source)
+	if err != nil {
+		return fmt.Errorf("failed to get source peer: %w", err)
+	}
+	destinationPeer, err := t.client.GetPeer(ctx, t.destination)
+	if err != nil {
+		return fmt.Errorf("failed to get destination peer: %w", err)
+	}
+	if sourcePeer.Type != pb.DBType_POSTGRES {
+		return fmt.Errorf("source peer '%s' is %s; selftest needs a PostgreSQL source", t.source, sourcePeer.Type)
+	}
+	fmt.Printf("✓ Found peers '%s' (%s) and '%s' (%s)\n", t.source, sourcePeer.Type, t.destination, destinationPeer.Type)
+
+	t.psql, err = newPSQLRunner(psql, dsn, sourcePeer.GetPostgresConfig())
+	if err != nil {
+		return err
+	}
+	if err := t.psql.exec(ctx, fmt.Sprintf(
+		"CREATE TABLE %s (id bigint PRIMARY KEY, note text NOT NULL, created_at timestamptz NOT NULL DEFAULT now()); "+
+			"INSERT INTO %s (id, note) VALUES (1, 'snapshot')", t.table, t.table)); err != nil {
+		return fmt.Errorf("failed to create source table %s: %w", t.table, err)
+	}
+	t.createdTable = true
+	fmt.Printf("✓ Created source table %s with 1 row\n", t.table)
+
+	// 2. Mirror
+	if err := t.createMirror(ctx); err != nil {
+		return err
+	}
+	t.createdMirror = true
+	fmt.Printf("✓ Created mirror '%s' (%s -> %s)\n", t.mirror, t.table, t.destTable)
+
+	// 3. Snapshot
+	started := time.Now()
+	baseline, err := t.waitForSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Initial snapshot copied the row in %s\n", formatDuration(time.Since(started)))
+
+	// 4. Change
+	inserted := time.Now()
+	if err := t.psql.exec(ctx, fmt.Sprintf("INSERT INTO %s (id, note) VALUES (2, 'cdc')", t.table)); err != nil {
+		return fmt.Errorf("failed to insert into %s: %w", t.table, err)
+	}
+	if err := t.waitForChange(ctx, baseline, inserted); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Inserted row replicated in %s\n", formatDuration(time.Since(inserted)))
+	return nil
+}
+
+// createMirror creates the throwaway mirror from a config, so the
+// destination table follows the default destination schema like any other
+func (t *selftest) createMirror(ctx context.Context) error {
+	fc := &config.FileConfig{
+		APIVersion: "v1",
+		Kind:       "Mirror",
+		Metadata:   config.Metadata{Name: t.mirror, Description: "mirror_cli selftest"},
+		Spec: config.Spec{
+			Type:        "cdc",
+			Source:      t.source,
+			Destination: t.destination,
+			Tables:      []config.TableConfig{{Source: t.table, Destination: t.destTable}},
+			CDC:         &config.CDCConfig{InitialSnapshot: true, IdleTimeoutSeconds: 5},
+		},
+	}
+	if t.destTable == "" && GetConfig().DefaultDestinationSchema == "" {
+		fc.Spec.Tables[0].Destination = t.table
+	}
+	req, err := fc.ToMirrorProto(GetConfig().Defaults())
+	if err != nil {
+		return err
+	}
+	t.destTable = req.ConnectionConfigs.TableMappings[0].DestinationTableIdentifier
+
+	if _, err := t.client.CreateCDCMirror(ctx, req); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+	return nil
+}
+
+// waitForSnapshot waits until the mirror runs with its snapshot done and
+// returns the rows synced so far
+func (t *selftest) waitForSnapshot(ctx context.Context) (int64, error) {
+	var rows int64
+	err := pollUntil(ctx, t.interval, func() (bool, error) {
+		status, err := t.client.GetMirrorStatus(ctx, t.mirror)
+		if err != nil {
+			return false, fmt.Errorf("failed to get mirror status: %w", err)
+		}
+		if err := selftestFailed(status.CurrentFlowState); err != nil {
+			return false, err
+		}
+		if status.CurrentFlowState != pb.FlowStatus_STATUS_RUNNING {
+			return false, nil
+		}
+		for _, clone := range status.GetCdcStatus().GetSnapshotStatus().GetClones() {
+			if !clone.ConsolidateCompleted {
+				return false, nil
+			}
+		}
+		rows = status.GetCdcStatus().GetRowsSynced()
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("initial snapshot didn't complete: %w", err)
+	}
+	return rows, nil
+}
+
+// waitForChange waits for a batch after the insert, or for the rows synced
+// to grow past baseline
+func (t *selftest) waitForChange(ctx context.Context, baseline int64, inserted time.Time) error {
+	err := pollUntil(ctx, t.interval, func() (bool, error) {
+		status, err := t.client.GetMirrorStatus(ctx, t.mirror)
+		if err != nil {
+			return false, fmt.Errorf("failed to get mirror status: %w", err)
+		}
+		if err := selftestFailed(status.CurrentFlowState); err != nil {
+			return false, err
+		}
+		if status.GetCdcStatus().GetRowsSynced() > baseline {
+			return true, nil
+		}
+		for _, batch := range status.GetCdcStatus().GetCdcBatches() {
+			if end, ok := timestamps.FromProto(batch.EndTime); ok && end.After(inserted) && batch.NumRows > 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("inserted row didn't replicate: %w", err)
+	}
+	return nil
+}
+
+// selftestFailed returns an error for states the mirror won't leave on its
+// own
+func selftestFailed(state pb.FlowStatus) error {
+	switch state {
+	case pb.FlowStatus_STATUS_FAILED, pb.FlowStatus_STATUS_TERMINATED, pb.FlowStatus_STATUS_PAUSED:
+		return fmt.Errorf("mirror is %s; see 'mirror_cli mirror events' before it's dropped, or rerun with --keep", stateName(state))
+	}
+	return nil
+}
+
+// teardown drops what the run created
+func (t *selftest) teardown(ctx context.Context) error {
+	var failed []string
+	if t.createdMirror {
+		if err := t.client.DropMirror(ctx, t.mirror, false); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to drop mirror '%s': %v\n", t.mirror, err)
+			failed = append(failed, "mirror '"+t.mirror+"'")
+		} else {
+			fmt.Printf("✓ Dropped mirror '%s' and its destination table\n", t.mirror)
+		}
+	}
+	if t.createdTable {
+		if err := t.psql.exec(ctx, "DROP TABLE IF EXISTS "+t.table); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to drop source table %s: %v\n", t.table, err)
+			failed = append(failed, "source table "+t.table)
+		} else {
+			fmt.Printf("✓ Dropped source table %s\n", t.table)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to clean up %s; drop them by hand", strings.Join(failed, " and "))
+	}
+	return nil
+}
+
+// printKept lists what --keep left behind
+func (t *selftest) printKept() {
+	if !t.createdTable && !t.createdMirror {
+		return
+	}
+	fmt.Println("\n💡 Kept for inspection; clean up with:")
+	if t.createdMirror {
+		fmt.Printf("  mirror_cli mirror drop %s\n", t.mirror)
+	}
+	if t.createdTable {
+		fmt.Printf("  DROP TABLE %s;  -- on the source\n", t.table)
+	}
+}
+
+// psqlRunner runs SQL on the source database with psql
+type psqlRunner struct {
+	program string
+	args    []string
+	env     []string
+}
+
+// newPSQLRunner connects with dsn, or with the source peer's settings
+func newPSQLRunner(program, dsn string, pg *pb.PostgresConfig) (*psqlRunner, error) {
+	if _, err := exec.LookPath(program); err != nil {
+		return nil, fmt.Errorf("selftest runs SQL on the source with psql, which wasn't found: %w", err)
+	}
+	runner := &psqlRunner{
+		program: program,
+		args:    []string{"-X", "-q", "-v", "ON_ERROR_STOP=1"},
+		env:     os.Environ(),
+	}
+	if dsn != "" {
+		runner.args = append(runner.args, "-d", dsn)
+		return runner, nil
+	}
+	if pg == nil {
+		return nil, fmt.Errorf("source peer has no PostgreSQL settings; pass --source-dsn")
+	}
+
+	runner.env = append(runner.env,
+		"PGHOST="+pg.Host,
+		"PGPORT="+strconv.Itoa(int(pg.Port)),
+		"PGUSER="+pg.User,
+		"PGDATABASE="+pg.Database,
+	)
+	if pg.Password != "" {
+		runner.env = append(runner.env, "PGPASSWORD="+pg.Password)
+	}
+	if pg.RequireTls {
+		runner.env = append(runner.env, "PGSSLMODE=require")
+	}
+	return runner, nil
+}
+
+// exec runs sql, returning psql's error output on failure
+func (r *psqlRunner) exec(ctx context.Context, sql string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.program, append(r.args, "-c", sql)...)
+	cmd.Env = r.env
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/session"
+)
+
+// recorder records the running command when --record is set
+var (
+	recorder   *session.Recorder
+	recordPath string
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay <session-file>",
+	Short: "Review or re-run the commands of a recorded session",
+	Long: `Re-run the commands recorded with --record, in order, stopping at the first
+that fails. Commands that failed when they were recorded are skipped, as are
+commands whose secrets were redacted; run those by hand.
+
+Each command must have been recorded against the context and endpoint the
+replay runs against, so a session isn't replayed somewhere else by mistake.
+To replay one elsewhere, e.g. a change rehearsed in staging, pass
+--force-target.
+
+With --dry-run, nothing is run: each command is listed with the changes it sent
+and the server's responses, e.g. for a change management approval.`,
+	Example: `  mirror_cli --record change-1234.yaml mirror pause orders_cdc
+  mirror_cli --record change-1234.yaml mirror edit orders_cdc --add-tables "public.refunds->analytics.refunds"
+  mirror_cli --record change-1234.yaml mirror resume orders_cdc
+  mirror_cli replay change-1234.yaml --dry-run
+  MIRROR_CLI_CONTEXT=prod mirror_cli replay change-1234.yaml --force-target`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return replaySession(cmd, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().Bool("dry-run", false, "List the recorded commands and their changes without running them")
+	replayCmd.Flags().Bool("force", false, "Replay without confirmation")
+	replayCmd.Flags().Bool("force-target", false, "Replay commands recorded against another context or endpoint")
+}
+
+// connectionFlags are the global flags that choose the server, passed on
+// to replayed commands so they run against the replay's target
+var connectionFlags = []string{"config", "host", "port", "tls", "username", "password", "header", "peerdb-api"}
+
+// startRecording starts recording the command if --record is set. Replays
+// aren't recorded, as their commands already are.
+func startRecording(cmd *cobra.Command) {
+	if path, _ := cmd.Flags().GetString("record"); path != "" && cmd != replayCmd {
+		recorder = session.NewRecorder(os.Args[1:], client.IsLookup)
+		recordPath = path
+	}
+}
+
+// finishRecording appends the recorded command to the session file.
+// Failing to save it only warns, as the command already ran.
+func finishRecording(err error) {
+	if recorder == nil {
+		return
+	}
+	var context, endpoint string
+	if cfg := GetConfig(); cfg != nil {
+		context, endpoint = sessionTarget(cfg)
+	}
+	if saveErr := recorder.Finish(recordPath, context, endpoint, err); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the command to %s: %v\n", recordPath, saveErr)
+	}
+}
+
+// sessionTarget returns the context and endpoint commands are recorded
+// against
+func sessionTarget(cfg *config.Config) (string, string) {
+	return cfg.CurrentContext, strings.Join(cfg.Endpoints(), ",")
+}
+
+// targetMismatch returns how the target a command was recorded against
+// differs from the replay's, or "" if it doesn't
+func targetMismatch(recorded session.Command, context, endpoint string) string {
+	switch {
+	case recorded.Context != context:
+		return fmt.Sprintf("recorded against %s, not %s", sessionContextLabel(recorded.Context), sessionContextLabel(context))
+	case recorded.Endpoint != endpoint:
+		return fmt.Sprintf("recorded against %s, not %s", recorded.Endpoint, endpoint)
+	}
+	return ""
+}
+
+func sessionContextLabel(context string) string {
+	if context == "" {
+		return "no context"
+	}
+	return fmt.Sprintf("context '%s'", context)
+}
+
+// replayArgs returns the arguments to replay a command with: the recorded
+// ones followed by the replay's connection flags
+func replayArgs(cmd *cobra.Command, recorded session.Command) []string {
+	args := append([]string{}, recorded.Args...)
+	flags := cmd.Root().PersistentFlags()
+	for _, name := range connectionFlags {
+		flag := flags.Lookup(name)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if values, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range values.GetSlice() {
+				args = append(args, "--"+name+"="+value)
+			}
+			continue
+		}
+		args = append(args, "--"+name+"="+flag.Value.String())
+	}
+	return args
+}
+
+func replaySession(cmd *cobra.Command, path string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	forceTarget, _ := cmd.Flags().GetBool("force-target")
+
+	s, err := session.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	if len(s.Commands) == 0 {
+		fmt.Printf("No commands recorded in %s\n", path)
+		return nil
+	}
+
+	cmd.SilenceUsage = true
+
+	context, endpoint := sessionTarget(GetConfig())
+	if dryRun {
+		reviewSession(s, context, endpoint)
+		return nil
+	}
+
+	if !forceTarget {
+		for i, recorded := range s.Commands {
+			if mismatch := targetMismatch(recorded, context, endpoint); mismatch != "" && skipReason(recorded) == "" {
+				return fmt.Errorf("command %d was %s; pass --force-target to replay it here", i+1, mismatch)
+			}
+		}
+	}
+
+	if !force && !confirmDestructive("session", path, fmt.Sprintf("Replay %d command(s) from %s against %s?", len(s.Commands), path, contextLabel(GetConfig())), false) {
+		fmt.Println("Replay cancelled")
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the mirror_cli executable: %w", err)
+	}
+
+	replayed, skipped := 0, 0
+	for i, recorded := range s.Commands {
+		fmt.Printf("[%d/%d] mirror_cli %s\n", i+1, len(s.Commands), quoteArgs(recorded.Args))
+		if reason := skipReason(recorded); reason != "" {
+			fmt.Printf("  Skipped: %s\n", reason)
+			skipped++
+			continue
+		}
+
+		run := exec.Command(executable, replayArgs(cmd, recorded)...)
+		run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := run.Run(); err != nil {
+			return fmt.Errorf("command %d failed, stopping the replay: %w", i+1, err)
+		}
+		replayed++
+	}
+
+	fmt.Printf("\n✅ Replayed %d command(s)", replayed)
+	if skipped > 0 {
+		fmt.Printf(", skipped %d", skipped)
+	}
+	fmt.Println()
+	return nil
+}
+
+// reviewSession prints each recorded command with the changes it sent,
+// flagging those recorded against another context or endpoint
+func reviewSession(s *session.Session, context, endpoint string) {
+	replayable := 0
+	for i, recorded := range s.Commands {
+		fmt.Printf("[%d] mirror_cli %s\n", i+1, quoteArgs(recorded.Args))
+		fmt.Printf("    Recorded %s", formatTime(recorded.Started))
+		if recorded.User != "" {
+			fmt.Printf(" by %s", recorded.User)
+		}
+		if recorded.Context != "" {
+			fmt.Printf(" against context '%s'", recorded.Context)
+		} else if recorded.Endpoint != "" {
+			fmt.Printf(" against %s", recorded.Endpoint)
+		}
+		fmt.Printf(", took %s\n", recorded.Duration)
+		if mismatch := targetMismatch(recorded, context, endpoint); mismatch != "" {
+			fmt.Printf("    ⚠ Needs --force-target to replay: %s\n", mismatch)
+		}
+
+		lookups := 0
+		for _, call := range recorded.Calls {
+			if !call.Mutating {
+				lookups++
+				continue
+			}
+			fmt.Printf("    → %s %s\n", call.Method, compactJSON(call.Request))
+			if call.Error != "" {
+				fmt.Printf("      ❌ %s\n", call.Error)
+			} else if response := compactJSON(call.Response); call.Response != nil && response != "{}" {
+				fmt.Printf("      ← %s\n", response)
+			}
+		}
+		if lookups > 0 {
+			fmt.Printf("    %d lookup(s)\n", lookups)
+		}
+
+		if reason := skipReason(recorded); reason != "" {
+			fmt.Printf("    Would be skipped: %s\n", reason)
+		} else {
+			replayable++
+		}
+	}
+	fmt.Printf("\n[DRY-RUN] %d of %d command(s) would be replayed\n", replayable, len(s.Commands))
+}
+
+// skipReason returns why a recorded command isn't replayed, or "" if it is
+func skipReason(recorded session.Command) string {
+	switch {
+	case recorded.Error != "":
+		return "it failed when recorded: " + recorded.Error
+	case recorded.Redacts():
+		return "its secrets were redacted; run it by hand"
+	}
+	return ""
+}
+
+// compactJSON renders a recorded message on one line
+func compactJSON(value interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// quoteArgs joins args for display, quoting those a shell would split
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"$`\\|&;<>(){}*?[]#~") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
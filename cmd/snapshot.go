@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/snapshot"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and view the last-known state of PeerDB",
+	Long: `Save the current peers, mirrors, and mirror statuses to a local file, and
+view them later without contacting PeerDB, e.g. while the API is down during
+an incident. Snapshots are kept per context (or server) in
+~/.mirror_cli/snapshots/.`,
+}
+
+// snapshotSaveCmd represents the snapshot save command
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save the current state of peers and mirrors",
+	Long:  "Fetch peers, mirrors, and mirror statuses and save them locally, replacing the previous snapshot. Run it periodically (e.g. from cron) to always have a recent snapshot.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return saveSnapshot(cmd)
+	},
+}
+
+// snapshotShowCmd represents the snapshot show command
+var snapshotShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the last saved state without contacting PeerDB",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showSnapshot(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd)
+	snapshotCmd.AddCommand(snapshotShowCmd)
+
+	snapshotSaveCmd.Flags().String("file", "", "Snapshot file (default: per context in ~/.mirror_cli/snapshots/)")
+	snapshotShowCmd.Flags().String("file", "", "Snapshot file (default: per context in ~/.mirror_cli/snapshots/)")
+}
+
+// snapshotPath returns the --file flag or the current context's snapshot file
+func snapshotPath(cmd *cobra.Command) (string, error) {
+	if path, _ := cmd.Flags().GetString("file"); path != "" {
+		return path, nil
+	}
+	cfg := GetConfig()
+	return snapshot.Path(cfg.CurrentContext, cfg.Endpoints())
+}
+
+func saveSnapshot(cmd *cobra.Command) error {
+	path, err := snapshotPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	peers, err := client.ListPeers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list peers: %w", err)
+	}
+	mirrors, err := client.ListMirrors(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list mirrors: %w", err)
+	}
+
+	now := time.Now()
+	snap := &snapshot.Snapshot{
+		SavedAt:  now,
+		Endpoint: client.Endpoint(),
+		Context:  GetConfig().CurrentContext,
+		Peers:    peers,
+		Mirrors:  mirrors,
+		Statuses: make(map[string]*pb.MirrorStatusResponse),
+	}
+	for _, summary := range app.SummarizeMirrors(ctx, client, mirrors.Mirrors, now) {
+		if summary.Err != nil {
+			if snap.Errors == nil {
+				snap.Errors = make(map[string]string)
+			}
+			snap.Errors[summary.Name] = summary.Err.Error()
+			continue
+		}
+		snap.Statuses[summary.Name] = summary.Status
+	}
+
+	if err := snapshot.Save(snap, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Saved snapshot of %d peer(s) and %d mirror(s) to %s\n", len(peers.Items), len(mirrors.Mirrors), path)
+	if len(snap.Errors) > 0 {
+		fmt.Printf("⚠ The status of %d mirror(s) could not be fetched and is missing from the snapshot\n", len(snap.Errors))
+	}
+	return nil
+}
+
+func showSnapshot(cmd *cobra.Command) error {
+	path, err := snapshotPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	snap, err := snapshot.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no snapshot found at %s; save one with 'mirror_cli snapshot save'", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	source := snap.Endpoint
+	if snap.Context != "" {
+		source = fmt.Sprintf("context '%s' (%s)", snap.Context, snap.Endpoint)
+	}
+	fmt.Printf("Snapshot of %s\n", source)
+	fmt.Printf("Saved %s (%s ago)\n", formatTimestamp(snap.SavedAt), humanizeDuration(time.Since(snap.SavedAt)))
+	fmt.Println("⚠ This is the last-known state, not live data; times are relative to when it was saved")
+
+	fmt.Printf("\nPeers (%d):\n", len(snap.Peers.Items))
+	if len(snap.Peers.Items) > 0 {
+		fmt.Printf("%-20s %-15s\n", "NAME", "TYPE")
+		fmt.Println(strings.Repeat("-", 40))
+		for _, peer := range snap.Peers.Items {
+			fmt.Printf("%-20s %-15s\n", peer.Name, peer.Type.String())
+		}
+	}
+
+	fmt.Printf("\nMirrors (%d):\n", len(snap.Mirrors.Mirrors))
+	if len(snap.Mirrors.Mirrors) == 0 {
+		return nil
+	}
+	fmt.Printf("%-20s %-15s %-15s %-6s %-12s %12s  %s\n", "NAME", "SOURCE", "DESTINATION", "TYPE", "STATE", "ROWS SYNCED", "LAST BATCH")
+	fmt.Println(strings.Repeat("-", 110))
+	for _, mirror := range snap.Mirrors.Mirrors {
+		mirrorType := "QRep"
+		if mirror.IsCdc {
+			mirrorType = "CDC"
+		}
+
+		state, rows, lag := "UNAVAILABLE", "-", "-"
+		if status, ok := snap.Statuses[mirror.Name]; ok {
+			summary := app.SummarizeStatus(mirror.Name, status, snap.SavedAt)
+			state = stateName(summary.State)
+			if mirror.IsCdc {
+				rows = formatRows(summary.RowsSynced)
+			}
+			if last := app.LastSyncActivity(status.GetCdcStatus().GetCdcBatches()); !last.IsZero() {
+				lag = formatDuration(snap.SavedAt.Sub(last)) + " before save"
+			}
+		}
+		fmt.Printf("%-20s %-15s %-15s %-6s %-12s %12s  %s\n", mirror.Name, mirror.SourceName, mirror.DestinationName, mirrorType, state, rows, lag)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// snapshotTable is the progress of one table's initial snapshot
+type snapshotTable struct {
+	name       string
+	rows       int64
+	partitions string
+	started    time.Time
+	// finished is when the table was first seen completed; the server
+	// doesn't report an end time
+	finished time.Time
+}
+
+// waitForSnapshot polls a new mirror until its initial snapshot completes,
+// then prints a per-table report. It fails if the mirror fails or stops
+// before the snapshot finishes.
+func waitForSnapshot(ctx context.Context, grpcClient *client.Client, mirrorName string, interval time.Duration) error {
+	fmt.Printf("Waiting for the initial snapshot of '%s' to complete...\n", mirrorName)
+
+	tables := make(map[string]*snapshotTable)
+	var state pb.FlowStatus
+	err := pollUntil(ctx, interval, func() (bool, error) {
+		status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+		if err != nil {
+			return false, fmt.Errorf("failed to get mirror status: %w", err)
+		}
+		if status.CdcStatus == nil {
+			return false, fmt.Errorf("mirror '%s' is not a CDC mirror", mirrorName)
+		}
+
+		now := time.Now()
+		state = status.CurrentFlowState
+		for _, clone := range status.CdcStatus.SnapshotStatus.GetClones() {
+			table, ok := tables[clone.TableName]
+			if !ok {
+				table = &snapshotTable{name: clone.TableName}
+				tables[clone.TableName] = table
+			}
+			table.rows = clone.NumRowsSynced
+			table.partitions = fmt.Sprintf("%d/%d", clone.NumPartitionsCompleted, clone.NumPartitionsTotal)
+			if started, ok := timestamps.FromProto(clone.StartTime); ok {
+				table.started = started
+			}
+			if clone.ConsolidateCompleted && table.finished.IsZero() {
+				table.finished = now
+				fmt.Printf("  ✓ %s (%s rows)\n", table.name, formatRows(table.rows))
+			}
+		}
+
+		switch state {
+		case pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_SNAPSHOT, pb.FlowStatus_STATUS_RESYNC, pb.FlowStatus_STATUS_UNKNOWN:
+			return false, nil
+		case pb.FlowStatus_STATUS_RUNNING, pb.FlowStatus_STATUS_COMPLETED:
+			// Clones are reported after the mirror starts, so don't stop
+			// while one is still in progress
+			return allSnapshotted(tables), nil
+		default:
+			return false, errSnapshotStopped
+		}
+	})
+
+	report := sortedSnapshotTables(tables)
+	switch {
+	case errors.Is(err, errSnapshotStopped):
+		printSnapshotReport(report)
+		return fmt.Errorf("mirror '%s' entered state %s before its initial snapshot completed%s", mirrorName, stateName(state), incompleteTables(report))
+	case errors.Is(err, context.DeadlineExceeded):
+		printSnapshotReport(report)
+		return fmt.Errorf("timed out waiting for the initial snapshot of '%s'%s; the snapshot goes on (--snapshot-timeout or wait_timeout sets how long to wait)", mirrorName, incompleteTables(report))
+	case err != nil:
+		return err
+	}
+
+	fmt.Printf("\n✅ Initial snapshot of '%s' completed\n", mirrorName)
+	printSnapshotReport(report)
+	return nil
+}
+
+// errSnapshotStopped reports a mirror leaving the snapshot for a state it
+// won't continue from on its own
+var errSnapshotStopped = errors.New("mirror stopped")
+
+func allSnapshotted(tables map[string]*snapshotTable) bool {
+	for _, table := range tables {
+		if table.finished.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedSnapshotTables(tables map[string]*snapshotTable) []*snapshotTable {
+	result := make([]*snapshotTable, 0, len(tables))
+	for _, table := range tables {
+		result = append(result, table)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// incompleteTables lists the tables whose snapshot didn't finish, for
+// error messages
+func incompleteTables(tables []*snapshotTable) string {
+	var names []string
+	for _, table := range tables {
+		if table.finished.IsZero() {
+			names = append(names, table.name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (incomplete: %s)", strings.Join(names, ", "))
+}
+
+// printSnapshotReport prints rows copied, duration, and throughput per table
+func printSnapshotReport(tables []*snapshotTable) {
+	if len(tables) == 0 {
+		fmt.Println("No tables were snapshotted")
+		return
+	}
+
+	fmt.Printf("\n%-40s %-10s %14s %10s %12s %12s\n", "TABLE", "STATUS", "ROWS", "PARTITIONS", "DURATION", "ROWS/SEC")
+	fmt.Println(strings.Repeat("-", 103))
+
+	var totalRows int64
+	var first, last time.Time
+	for _, table := range tables {
+		totalRows += table.rows
+		status, duration, throughput := "done", "-", "-"
+		if table.finished.IsZero() {
+			status = "incomplete"
+		} else if !table.started.IsZero() {
+			elapsed := table.finished.Sub(table.started)
+			duration = formatDuration(elapsed)
+			if elapsed > 0 {
+				throughput = formatRows(int64(float64(table.rows) / elapsed.Seconds()))
+			}
+			if first.IsZero() || table.started.Before(first) {
+				first = table.started
+			}
+			if table.finished.After(last) {
+				last = table.finished
+			}
+		}
+		fmt.Printf("%-40s %-10s %14s %10s %12s %12s\n", table.name, status, formatRows(table.rows), table.partitions, duration, throughput)
+	}
+
+	fmt.Printf("\nTotal: %s rows in %d table(s)", formatRows(totalRows), len(tables))
+	if !first.IsZero() {
+		fmt.Printf(" over %s", formatDuration(last.Sub(first)))
+	}
+	fmt.Println()
+	if !first.IsZero() {
+		fmt.Println("💡 Durations end when completion was observed, so they are accurate to the poll interval")
+	}
+}
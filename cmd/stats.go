@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+)
+
+// mirrorStatsCmd represents the mirror stats command
+var mirrorStatsCmd = &cobra.Command{
+	Use:   "stats <mirror-name>",
+	Short: "Show rows synced per table of a CDC mirror",
+	Long: `Show the inserts, updates, and deletes a CDC mirror has synced for each
+table, and the totals. Use -o csv to open them in a spreadsheet.`,
+	Example: `  mirror_cli mirror stats orders_cdc
+  mirror_cli mirror stats orders_cdc -o csv > orders_cdc.csv`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return mirrorStats(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorStatsCmd)
+	addTableFlags(mirrorStatsCmd)
+}
+
+func mirrorStats(cmd *cobra.Command, mirrorName string) error {
+	if _, err := outputPrinter(cmd); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	counts, err := client.GetTableRowCounts(ctx, mirrorName)
+	if err != nil {
+		return fmt.Errorf("failed to get row counts: %w", err)
+	}
+
+	tables := counts.TablesData
+	sort.Slice(tables, func(i, j int) bool { return tables[i].TableName < tables[j].TableName })
+
+	table := &printer.Table{Columns: []printer.Column{
+		{Header: "TABLE", Key: "table"},
+		{Header: "INSERTS", Key: "inserts", Right: true, Format: rowsColumn},
+		{Header: "UPDATES", Key: "updates", Right: true, Format: rowsColumn},
+		{Header: "DELETES", Key: "deletes", Right: true, Format: rowsColumn},
+		{Header: "TOTAL", Key: "total", Right: true, Format: rowsColumn},
+	}}
+	for _, t := range tables {
+		c := t.GetCounts()
+		table.AddRow(t.TableName, c.GetInsertsCount(), c.GetUpdatesCount(), c.GetDeletesCount(), c.GetTotalCount())
+	}
+
+	if !tableOutput(cmd) {
+		return printTable(cmd, table)
+	}
+	if len(tables) == 0 {
+		fmt.Printf("No rows synced by mirror '%s' yet\n", mirrorName)
+		return nil
+	}
+	if err := printTable(cmd, table); err != nil {
+		return err
+	}
+	total := counts.GetTotalData()
+	fmt.Printf("\nTotal: %s rows (%s inserts, %s updates, %s deletes) in %d table(s)\n",
+		formatRows(total.GetTotalCount()), formatRows(total.GetInsertsCount()),
+		formatRows(total.GetUpdatesCount()), formatRows(total.GetDeletesCount()), len(tables))
+	return nil
+}
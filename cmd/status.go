@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/printer"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// statusCmd represents the top-level status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a summary of all mirrors",
+	Long: `Show a one-screen summary of every mirror: counts by state, rows synced in
+the last hour, mirrors with errors, and the mirrors lagging the most.
+
+With --watch, the summary is redrawn every --interval as a lightweight
+monitoring session. It adds rows per second since the previous refresh and
+alerts, with the terminal bell or a desktop notification (--notify), when a
+running mirror syncs no rows for --stall-intervals refreshes in a row or a
+mirror's errors increase: it fails, its status can't be fetched, or QRep
+partitions fail. A mirror with no writes on its source stalls too, so pick
+--stall-intervals to cover its quiet periods.`,
+	Example: `  mirror_cli status
+  mirror_cli status --watch --interval 30s --stall-intervals 10 --notify desktop`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fleetStatus(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().Int("top", 5, "Number of laggiest and busiest mirrors to show")
+	statusCmd.Flags().BoolP("watch", "w", false, "Refresh the summary until interrupted, with throughput and alerts")
+	statusCmd.Flags().Duration("interval", 10*time.Second, "How often to refresh with --watch")
+	statusCmd.Flags().Int("stall-intervals", 3, "Alert when a running mirror syncs no rows for this many refreshes")
+	statusCmd.Flags().String("notify", "bell", "How --watch alerts: bell, desktop, or none")
+	addAllPrefixesFlag(statusCmd)
+}
+
+func fleetStatus(cmd *cobra.Command) error {
+	top, _ := cmd.Flags().GetInt("top")
+	output, err := reportOutput(cmd, printer.FormatJSON, printer.FormatYAML)
+	if err != nil {
+		return err
+	}
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		if output != printer.FormatText {
+			return fmt.Errorf("--watch redraws text for a terminal; drop -o %s", output)
+		}
+		return watchFleetStatus(cmd, top)
+	}
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	summaries, hidden, err := fleetSummaries(ctx, client, ownedNames(cmd))
+	if err != nil {
+		return err
+	}
+	if output != printer.FormatText {
+		return printReport(output, newFleetStatusReport(client.Endpoint(), summaries, hidden))
+	}
+	printFleetStatus(client.Endpoint(), summaries, hidden, top)
+	return nil
+}
+
+// fleetSummaries summarizes every mirror that passes owned, returning the
+// number of mirrors left out
+func fleetSummaries(ctx context.Context, grpcClient *client.Client, owned func(string) bool) ([]app.MirrorSummary, int, error) {
+	resp, err := grpcClient.ListMirrors(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list mirrors: %w", err)
+	}
+
+	mirrors, hidden := ownedMirrors(resp.Mirrors, owned)
+	if len(mirrors) == 0 {
+		return nil, hidden, nil
+	}
+	return app.SummarizeMirrors(ctx, grpcClient, mirrors, time.Now()), hidden, nil
+}
+
+// printFleetStatus prints the one-screen summary of the mirrors
+func printFleetStatus(endpoint string, summaries []app.MirrorSummary, hidden, top int) {
+	fmt.Printf("PeerDB %s: %d mirrors\n", endpoint, len(summaries))
+	printHidden(hidden, "mirror")
+	if len(summaries) == 0 {
+		return
+	}
+
+	// Counts by state
+	counts := make(map[string]int)
+	var rowsInHour int64
+	var failing, lagging []app.MirrorSummary
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			counts["UNAVAILABLE"]++
+			failing = append(failing, summary)
+			continue
+		}
+
+		counts[stateName(summary.State)]++
+		rowsInHour += summary.RowsInHour
+		if summary.State == pb.FlowStatus_STATUS_FAILED {
+			failing = append(failing, summary)
+		}
+		if summary.State == pb.FlowStatus_STATUS_RUNNING && summary.Lag > 0 {
+			lagging = append(lagging, summary)
+		}
+	}
+
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	fmt.Printf("\n%-14s %s\n", "STATE", "COUNT")
+	for _, state := range states {
+		fmt.Printf("%-14s %d\n", state, counts[state])
+	}
+
+	fmt.Printf("\nRows synced (last hour): %s\n", formatRows(rowsInHour))
+
+	if len(failing) == 0 {
+		fmt.Println("\n✅ No mirrors with errors")
+	} else {
+		fmt.Printf("\n❌ Mirrors with errors (%d):\n", len(failing))
+		for _, summary := range failing {
+			if summary.Err != nil {
+				fmt.Printf("  %s: %v\n", summary.Name, summary.Err)
+			} else {
+				fmt.Printf("  %s: %s\n", summary.Name, stateName(summary.State))
+			}
+		}
+	}
+
+	if len(lagging) > 0 && top > 0 {
+		sort.Slice(lagging, func(i, j int) bool {
+			return lagging[i].Lag > lagging[j].Lag
+		})
+		if len(lagging) > top {
+			lagging = lagging[:top]
+		}
+
+		fmt.Println("\nMost lagging (time since last batch):")
+		for _, summary := range lagging {
+			fmt.Printf("  %-30s %s\n", summary.Name, formatDuration(summary.Lag))
+		}
+	}
+}
+
+// stateName returns a flow state without its STATUS_ prefix
+func stateName(state pb.FlowStatus) string {
+	return strings.TrimPrefix(state.String(), "STATUS_")
+}
+
+// formatCount formats n with thousands separators
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// fleetStatusReport is the document of the status command
+type fleetStatusReport struct {
+	Endpoint           string              `json:"endpoint"`
+	Mirrors            int                 `json:"mirrors"`
+	Hidden             int                 `json:"hidden"`
+	States             map[string]int      `json:"states"`
+	RowsSyncedLastHour int64               `json:"rows_synced_last_hour"`
+	Errors             []fleetMirrorReport `json:"errors"`
+	Lagging            []fleetMirrorReport `json:"lagging"`
+}
+
+// fleetMirrorReport is a mirror listed in the status document
+type fleetMirrorReport struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	// LagSeconds is the time since the last batch
+	LagSeconds *int64 `json:"lag_seconds,omitempty"`
+}
+
+// newFleetStatusReport builds the status document, listing every lagging
+// mirror, most lagging first
+func newFleetStatusReport(endpoint string, summaries []app.MirrorSummary, hidden int) fleetStatusReport {
+	report := fleetStatusReport{
+		Endpoint: endpoint,
+		Mirrors:  len(summaries),
+		Hidden:   hidden,
+		States:   make(map[string]int),
+		Errors:   []fleetMirrorReport{},
+		Lagging:  []fleetMirrorReport{},
+	}
+
+	var lagging []app.MirrorSummary
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			report.States["UNAVAILABLE"]++
+			report.Errors = append(report.Errors, fleetMirrorReport{Name: summary.Name, State: "UNAVAILABLE", Error: summary.Err.Error()})
+			continue
+		}
+
+		report.States[stateName(summary.State)]++
+		report.RowsSyncedLastHour += summary.RowsInHour
+		if summary.State == pb.FlowStatus_STATUS_FAILED {
+			report.Errors = append(report.Errors, fleetMirrorReport{Name: summary.Name, State: stateName(summary.State)})
+		}
+		if summary.State == pb.FlowStatus_STATUS_RUNNING && summary.Lag > 0 {
+			lagging = append(lagging, summary)
+		}
+	}
+
+	sort.SliceStable(lagging, func(i, j int) bool {
+		return lagging[i].Lag > lagging[j].Lag
+	})
+	for _, summary := range lagging {
+		seconds := int64(summary.Lag.Seconds())
+		report.Lagging = append(report.Lagging, fleetMirrorReport{Name: summary.Name, State: stateName(summary.State), LagSeconds: &seconds})
+	}
+	return report
+}
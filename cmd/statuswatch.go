@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/app"
+	"github.com/janakos/mirror_cli/internal/client"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// maxRecentAlerts is how many alerts status --watch keeps on screen
+const maxRecentAlerts = 10
+
+// fleetWatch tracks mirrors between refreshes of status --watch
+type fleetWatch struct {
+	stallIntervals int
+	mirrors        map[string]*watchedMirror
+	last           time.Time
+	listFailed     bool
+	recent         []fleetAlert
+}
+
+// watchedMirror is what status --watch remembers about a mirror
+type watchedMirror struct {
+	rows    rowCounter
+	errors  int
+	rate    float64
+	hasRate bool
+	// idle counts the refreshes in a row a running mirror synced no rows,
+	// since idleSince
+	idle      int
+	idleSince time.Time
+}
+
+// fleetAlert is a change status --watch calls attention to
+type fleetAlert struct {
+	Time    time.Time
+	Mirror  string
+	Message string
+	Error   bool
+}
+
+func newFleetWatch(stallIntervals int) *fleetWatch {
+	return &fleetWatch{stallIntervals: stallIntervals, mirrors: make(map[string]*watchedMirror)}
+}
+
+// update records a refresh and returns the alerts it raises: a running
+// mirror that synced no rows for stallIntervals refreshes, or a mirror
+// with more errors than before. Mirrors seen for the first time only set
+// the baseline.
+func (w *fleetWatch) update(summaries []app.MirrorSummary, now time.Time) []fleetAlert {
+	w.last = now
+
+	var alerts []fleetAlert
+	seen := make(map[string]bool, len(summaries))
+	for _, summary := range summaries {
+		seen[summary.Name] = true
+		errors := mirrorErrorCount(summary)
+		mirror, known := w.mirrors[summary.Name]
+		if !known {
+			mirror = &watchedMirror{errors: errors, idleSince: now}
+			mirror.rows.update(summary.RowsSynced, now)
+			w.mirrors[summary.Name] = mirror
+			continue
+		}
+
+		if errors > mirror.errors {
+			alerts = append(alerts, fleetAlert{Time: now, Mirror: summary.Name, Message: mirrorErrorMessage(summary, errors-mirror.errors), Error: true})
+		}
+		mirror.errors = errors
+
+		if summary.Err != nil {
+			// Keep the last rows and rate until the status is back
+			continue
+		}
+
+		delta, rate, ok := mirror.rows.update(summary.RowsSynced, now)
+		mirror.rate, mirror.hasRate = rate, ok
+
+		if delta != 0 || summary.State != pb.FlowStatus_STATUS_RUNNING || summary.Status.GetCdcStatus() == nil {
+			mirror.idle, mirror.idleSince = 0, now
+			continue
+		}
+		mirror.idle++
+		if mirror.idle == w.stallIntervals {
+			alerts = append(alerts, fleetAlert{Time: now, Mirror: summary.Name, Message: fmt.Sprintf("throughput dropped to zero: no rows synced for %s", formatDuration(now.Sub(mirror.idleSince)))})
+		}
+	}
+
+	for name := range w.mirrors {
+		if !seen[name] {
+			delete(w.mirrors, name)
+		}
+	}
+
+	w.remember(alerts)
+	return alerts
+}
+
+// remember keeps alerts on screen, dropping the oldest
+func (w *fleetWatch) remember(alerts []fleetAlert) {
+	w.recent = append(w.recent, alerts...)
+	if len(w.recent) > maxRecentAlerts {
+		w.recent = w.recent[len(w.recent)-maxRecentAlerts:]
+	}
+}
+
+// listError records a failure to list mirrors, alerting once per outage
+func (w *fleetWatch) listError(err error, now time.Time) []fleetAlert {
+	if w.listFailed {
+		return nil
+	}
+	w.listFailed = true
+	alerts := []fleetAlert{{Time: now, Message: err.Error(), Error: true}}
+	w.remember(alerts)
+	return alerts
+}
+
+// stalled returns the mirrors that have synced no rows for stallIntervals
+// refreshes or more, longest first
+func (w *fleetWatch) stalled() []string {
+	var names []string
+	for name, mirror := range w.mirrors {
+		if mirror.idle >= w.stallIntervals {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := w.mirrors[names[i]], w.mirrors[names[j]]
+		if !a.idleSince.Equal(b.idleSince) {
+			return a.idleSince.Before(b.idleSince)
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// mirrorErrorCount counts the errors of a mirror: a failed state or status,
+// plus failed QRep partitions
+func mirrorErrorCount(summary app.MirrorSummary) int {
+	if summary.Err != nil || summary.State == pb.FlowStatus_STATUS_FAILED {
+		return 1 + failedPartitions(summary)
+	}
+	return failedPartitions(summary)
+}
+
+// failedPartitions counts the failed partitions of a QRep mirror
+func failedPartitions(summary app.MirrorSummary) int {
+	var failed int
+	for _, partition := range summary.Status.GetQrepStatus().GetPartitions() {
+		if partitionState(partition, summary.State) == partitionFailed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// mirrorErrorMessage describes new errors of a mirror
+func mirrorErrorMessage(summary app.MirrorSummary, added int) string {
+	switch {
+	case summary.Err != nil:
+		return summary.Err.Error()
+	case summary.State == pb.FlowStatus_STATUS_FAILED && failedPartitions(summary) == 0:
+		return "mirror failed"
+	default:
+		return fmt.Sprintf("%d more failed partition(s)", added)
+	}
+}
+
+// watchFleetStatus redraws the fleet summary every interval, adding rows
+// per second since the previous refresh and alerting on stalls and errors
+func watchFleetStatus(cmd *cobra.Command, top int) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	stallIntervals, _ := cmd.Flags().GetInt("stall-intervals")
+	notify, _ := cmd.Flags().GetString("notify")
+
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if stallIntervals < 1 {
+		return fmt.Errorf("--stall-intervals must be at least 1")
+	}
+	notifier, err := newAlertNotifier(notify)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	owned := ownedNames(cmd)
+	watch := newFleetWatch(stallIntervals)
+	return watchLoop(ctx, interval, func(now time.Time) (func(), error) {
+		summaries, hidden, err := fleetSummaries(ctx, client, owned)
+		if err != nil {
+			alerts := watch.listError(err, now)
+			return func() {
+				printAlerts(watch)
+				notifier.notify(alerts)
+			}, err
+		}
+		watch.listFailed = false
+		alerts := watch.update(summaries, now)
+		return func() {
+			printFleetStatus(client.Endpoint(), summaries, hidden, top)
+			printThroughput(watch, summaries, top)
+			printAlerts(watch)
+			notifier.notify(alerts)
+		}, nil
+	})
+}
+
+// printThroughput prints rows per second since the previous refresh and
+// the mirrors that stopped syncing
+func printThroughput(watch *fleetWatch, summaries []app.MirrorSummary, top int) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	var total float64
+	var busy []string
+	measured := false
+	for _, summary := range summaries {
+		mirror := watch.mirrors[summary.Name]
+		if mirror == nil || !mirror.hasRate {
+			continue
+		}
+		measured = true
+		total += mirror.rate
+		if mirror.rate > 0 {
+			busy = append(busy, summary.Name)
+		}
+	}
+	if !measured {
+		fmt.Println("\nThroughput: measured from the next refresh")
+		return
+	}
+
+	fmt.Printf("\nThroughput: %s rows/sec\n", formatRate(total))
+	sort.Slice(busy, func(i, j int) bool {
+		a, b := watch.mirrors[busy[i]].rate, watch.mirrors[busy[j]].rate
+		if a != b {
+			return a > b
+		}
+		return busy[i] < busy[j]
+	})
+	if len(busy) > top {
+		busy = busy[:top]
+	}
+	for _, name := range busy {
+		fmt.Printf("  %-30s %s rows/sec\n", name, formatRate(watch.mirrors[name].rate))
+	}
+
+	if stalled := watch.stalled(); len(stalled) > 0 {
+		fmt.Printf("\n%s\n", yellow(fmt.Sprintf("⚠ Running without syncing rows (%d):", len(stalled))))
+		for _, name := range stalled {
+			fmt.Printf("  %-30s %s\n", name, formatDuration(watch.last.Sub(watch.mirrors[name].idleSince)))
+		}
+	}
+}
+
+// printAlerts prints the most recent alerts, newest last
+func printAlerts(watch *fleetWatch) {
+	if len(watch.recent) == 0 {
+		return
+	}
+	fmt.Println("\nAlerts:")
+	for _, alert := range watch.recent {
+		line := alert.Message
+		if alert.Mirror != "" {
+			line = alert.Mirror + ": " + line
+		}
+		line = formatTimestamp(alert.Time) + "  " + line
+		if alert.Error {
+			fmt.Printf("  %s\n", red(line))
+		} else {
+			fmt.Printf("  %s\n", yellow(line))
+		}
+	}
+}
+
+// formatRate formats rows per second, e.g. "1.2K" or "0.5"
+func formatRate(rate float64) string {
+	if rate >= 1000 || rawNumbers {
+		return formatRows(int64(rate + 0.5))
+	}
+	return trimDecimal(rate)
+}
+
+// alertNotifier calls attention to alerts with the terminal bell or a
+// desktop notification
+type alertNotifier struct {
+	mode   string
+	warned bool
+}
+
+func newAlertNotifier(mode string) (*alertNotifier, error) {
+	switch mode {
+	case "bell", "desktop", "none":
+		return &alertNotifier{mode: mode}, nil
+	}
+	return nil, fmt.Errorf("unsupported --notify: %s (expected bell, desktop, or none)", mode)
+}
+
+// notify signals new alerts; a desktop notification that can't be shown
+// falls back to the bell
+func (n *alertNotifier) notify(alerts []fleetAlert) {
+	if len(alerts) == 0 || n.mode == "none" {
+		return
+	}
+	if n.mode == "desktop" {
+		message := alerts[0].Message
+		if alerts[0].Mirror != "" {
+			message = alerts[0].Mirror + ": " + message
+		}
+		if len(alerts) > 1 {
+			message += fmt.Sprintf(" (and %d more)", len(alerts)-1)
+		}
+		err := desktopNotification("mirror_cli", message)
+		if err == nil {
+			return
+		}
+		if !n.warned {
+			n.warned = true
+			fmt.Fprintf(os.Stderr, "⚠ Failed to show a desktop notification, ringing the bell instead: %v\n", err)
+		}
+	}
+	fmt.Print("\a")
+}
+
+// desktopNotification shows a notification with notify-send on Linux or
+// osascript on macOS
+func desktopNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("notify-send", title, message)
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	default:
+		return fmt.Errorf("desktop notifications aren't supported on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/buildinfo"
+	"github.com/janakos/mirror_cli/internal/bundle"
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/provenance"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// supportBundleCmd represents the support-bundle command
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle [mirror-name]",
+	Short: "Collect an encrypted diagnostics bundle for a support ticket",
+	Long: `Gather what PeerDB support usually asks for into one encrypted tar.gz:
+mirror statuses, recent errors, CDC batch history, peer configurations,
+and the CLI and server versions. Give a mirror name to collect just that
+mirror and its peers.
+
+Peer secrets are replaced with ${VAR} placeholders and mirror env values
+with <redacted>, but review the contents before sharing. The bundle is
+encrypted with the passphrase in $` + bundle.PassphraseEnv + `, or a random
+one that is printed; it can be decrypted with openssl alone.`,
+	Example: `  mirror_cli support-bundle
+  mirror_cli support-bundle orders_cdc --file /tmp/orders.tar.gz.enc`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return supportBundle(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(supportBundleCmd)
+
+	supportBundleCmd.Flags().String("file", "", "Bundle file (default: mirror_cli-support-<time>.tar.gz.enc)")
+	supportBundleCmd.Flags().Int("batches", 50, "Recent CDC batches to include per mirror")
+	addAllPrefixesFlag(supportBundleCmd)
+}
+
+// bundleManifest describes a support bundle and where it came from
+type bundleManifest struct {
+	CreatedAt     time.Time      `json:"created_at"`
+	CLI           buildinfo.Info `json:"cli"`
+	ServerVersion string         `json:"server_version"`
+	API           string         `json:"api,omitempty"`
+	Endpoint      string         `json:"endpoint"`
+	Context       string         `json:"context,omitempty"`
+	Mirrors       []string       `json:"mirrors"`
+	Peers         []string       `json:"peers"`
+}
+
+// bundleError is a problem found or hit while collecting a bundle
+type bundleError struct {
+	Mirror  string `json:"mirror,omitempty"`
+	Peer    string `json:"peer,omitempty"`
+	Message string `json:"message"`
+}
+
+// bundleBatch is one CDC batch in a bundle's batch history
+type bundleBatch struct {
+	BatchID   int64      `json:"batch_id"`
+	StartLSN  int64      `json:"start_lsn"`
+	EndLSN    int64      `json:"end_lsn"`
+	Rows      int64      `json:"rows"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
+func supportBundle(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	batchLimit, _ := cmd.Flags().GetInt("batches")
+
+	if batchLimit < 0 {
+		return fmt.Errorf("--batches can't be negative")
+	}
+
+	now := time.Now()
+	if path == "" {
+		path = fmt.Sprintf("mirror_cli-support-%s.tar.gz.enc", now.UTC().Format("20060102-150405"))
+	}
+
+	passphrase, generated := os.Getenv(bundle.PassphraseEnv), false
+	if passphrase == "" {
+		var err error
+		if passphrase, err = bundle.NewPassphrase(); err != nil {
+			return err
+		}
+		generated = true
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	mirrors, err := client.ListMirrors(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list mirrors: %w", err)
+	}
+
+	var selected []*pb.ListMirrorsItem
+	peerNames := make(map[string]bool)
+	if len(args) == 1 {
+		name := resourceName(args[0])
+		for _, mirror := range mirrors.Mirrors {
+			if mirror.Name == name {
+				selected = append(selected, mirror)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("mirror '%s' not found", name)
+		}
+		peerNames[selected[0].SourceName] = true
+		peerNames[selected[0].DestinationName] = true
+	} else {
+		selected, _ = ownedMirrors(mirrors.Mirrors, ownedNames(cmd))
+		peers, err := client.ListPeers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list peers: %w", err)
+		}
+		owned, _ := ownedPeers(peers.Items, ownedNames(cmd))
+		for _, peer := range owned {
+			peerNames[peer.Name] = true
+		}
+	}
+
+	fmt.Printf("Collecting diagnostics for %d mirror(s) and %d peer(s)...\n", len(selected), len(peerNames))
+
+	archive := bundle.NewArchive(now)
+	var problems []bundleError
+
+	manifest := bundleManifest{
+		CreatedAt: now.UTC(),
+		CLI:       buildinfo.Get(),
+		Endpoint:  client.Endpoint(),
+		Context:   GetConfig().CurrentContext,
+	}
+	manifest.ServerVersion, err = client.GetServerVersion(ctx)
+	switch {
+	case grpcstatus.Code(err) == codes.Unimplemented:
+		manifest.ServerVersion = "unknown (not reported by this server)"
+	case err != nil:
+		manifest.ServerVersion = "unknown"
+		problems = append(problems, bundleError{Message: fmt.Sprintf("failed to get server version: %v", err)})
+	}
+	manifest.API, _ = client.API(ctx)
+
+	for _, mirror := range selected {
+		manifest.Mirrors = append(manifest.Mirrors, mirror.Name)
+		mirrorProblems, err := addMirrorToBundle(ctx, archive, client, mirror.Name, batchLimit)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, mirrorProblems...)
+	}
+
+	for _, name := range sortedKeys(peerNames) {
+		manifest.Peers = append(manifest.Peers, name)
+		if problem := addPeerToBundle(ctx, archive, client, name); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	if err := archive.AddJSON("manifest.json", manifest); err != nil {
+		return err
+	}
+	if problems == nil {
+		problems = []bundleError{}
+	}
+	if err := archive.AddJSON("errors.json", problems); err != nil {
+		return err
+	}
+
+	data, err := archive.Bytes()
+	if err != nil {
+		return err
+	}
+	encrypted, err := bundle.Encrypt(data, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	if err := os.WriteFile(path, encrypted, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Printf("✅ Wrote support bundle to %s (%s)\n", path, formatBytes(int64(len(encrypted))))
+	if len(problems) > 0 {
+		fmt.Printf("⚠ Found %d error(s); see errors.json in the bundle\n", len(problems))
+	}
+	if generated {
+		fmt.Printf("Passphrase: %s\n", passphrase)
+		fmt.Println("💡 Share the passphrase separately from the bundle, e.g. not in the same ticket comment")
+	} else {
+		fmt.Printf("Encrypted with the passphrase in $%s\n", bundle.PassphraseEnv)
+	}
+	fmt.Printf("💡 Decrypt with: %s\n", bundle.DecryptCommand(path))
+	return nil
+}
+
+// addMirrorToBundle adds a mirror's status and batch history to the
+// bundle, returning the problems found. Only failures to write the bundle
+// are returned as errors.
+func addMirrorToBundle(ctx context.Context, archive *bundle.Archive, grpcClient *client.Client, name string, batchLimit int) ([]bundleError, error) {
+	status, err := grpcClient.GetMirrorStatus(ctx, name)
+	if err != nil {
+		return []bundleError{{Mirror: name, Message: fmt.Sprintf("failed to get mirror status: %v", err)}}, nil
+	}
+	status = proto.Clone(status).(*pb.MirrorStatusResponse)
+
+	var problems []bundleError
+	if status.CurrentFlowState == pb.FlowStatus_STATUS_FAILED {
+		problems = append(problems, bundleError{Mirror: name, Message: "mirror is in state FAILED"})
+	}
+
+	var batches []*pb.CDCBatch
+	if cdc := status.CdcStatus; cdc != nil {
+		if cdc.Config != nil {
+			cdc.Config.Env = redactEnv(cdc.Config.Env)
+		}
+		batches = recentBatches(cdc.CdcBatches, batchLimit)
+		cdc.CdcBatches = nil
+	}
+	if qrep := status.QrepStatus; qrep != nil {
+		if qrep.Config != nil {
+			qrep.Config.Env = redactEnv(qrep.Config.Env)
+		}
+		for _, partition := range qrep.Partitions {
+			if partitionState(partition, status.CurrentFlowState) == partitionFailed {
+				problems = append(problems, bundleError{Mirror: name, Message: fmt.Sprintf("partition %s failed", partition.PartitionId)})
+			}
+		}
+	}
+
+	dir := "mirrors/" + name + "/"
+	if err := archive.AddProto(dir+"status.json", status); err != nil {
+		return nil, err
+	}
+	if status.CdcStatus != nil {
+		history := make([]bundleBatch, 0, len(batches))
+		for _, batch := range batches {
+			history = append(history, bundleBatch{
+				BatchID:   batch.BatchId,
+				StartLSN:  batch.StartLsn,
+				EndLSN:    batch.EndLsn,
+				Rows:      batch.NumRows,
+				StartTime: optionalTime(timestamps.FromProto(batch.StartTime)),
+				EndTime:   optionalTime(timestamps.FromProto(batch.EndTime)),
+			})
+		}
+		if err := archive.AddJSON(dir+"batches.json", history); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+// addPeerToBundle adds a peer's configuration to the bundle, with secrets
+// replaced by placeholders, returning the problem if it can't
+func addPeerToBundle(ctx context.Context, archive *bundle.Archive, grpcClient *client.Client, name string) *bundleError {
+	peer, err := grpcClient.GetPeer(ctx, name)
+	if err != nil {
+		return &bundleError{Peer: name, Message: fmt.Sprintf("failed to get peer: %v", err)}
+	}
+	fc, err := config.FromPeerProto(peer, "")
+	if err != nil {
+		// Without a converter the secrets can't be told apart, so only
+		// the type is included
+		fc = &config.FileConfig{APIVersion: "v1", Kind: "Peer", Metadata: config.Metadata{Name: name}}
+		fc.Spec.Type = strings.ToLower(peer.Type.String())
+	}
+	data, err := config.MarshalFileConfig(fc, config.FormatYAML)
+	if err != nil {
+		return &bundleError{Peer: name, Message: fmt.Sprintf("failed to encode peer: %v", err)}
+	}
+	if err := archive.Add("peers/"+name+".yaml", data); err != nil {
+		return &bundleError{Peer: name, Message: err.Error()}
+	}
+	return nil
+}
+
+// redactEnv hides mirror env values, which may hold credentials, keeping
+// mirror_cli's provenance annotations
+func redactEnv(env map[string]string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for key, value := range env {
+		if !strings.HasPrefix(key, provenance.Prefix) {
+			value = "<redacted>"
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// recentBatches returns the latest limit batches, newest first
+func recentBatches(batches []*pb.CDCBatch, limit int) []*pb.CDCBatch {
+	sorted := append([]*pb.CDCBatch(nil), batches...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].BatchId > sorted[j].BatchId })
+	return sorted[:min(limit, len(sorted))]
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
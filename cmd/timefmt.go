@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// Timestamp formats accepted by --time-format
+const (
+	timeFormatRelative = "relative"
+	timeFormatRFC3339  = "rfc3339"
+	timeFormatUnix     = "unix"
+)
+
+var (
+	// timeFormat is how timestamps are printed, set by --time-format
+	timeFormat = timeFormatRelative
+
+	// timeLocation is the timezone absolute timestamps are printed in,
+	// set by --timezone
+	timeLocation = time.Local
+)
+
+// applyTimeFlags reads --time-format and --timezone
+func applyTimeFlags(cmd *cobra.Command) error {
+	format, _ := cmd.Flags().GetString("time-format")
+	switch format = strings.ToLower(format); format {
+	case timeFormatRelative, timeFormatRFC3339, timeFormatUnix:
+		timeFormat = format
+	default:
+		return fmt.Errorf("unsupported time format: %s (expected relative, rfc3339, or unix)", format)
+	}
+
+	zone, _ := cmd.Flags().GetString("timezone")
+	switch strings.ToLower(zone) {
+	case "local":
+		timeLocation = time.Local
+	case "utc":
+		timeLocation = time.UTC
+	default:
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return fmt.Errorf("unknown timezone: %s (expected UTC, local, or an IANA name such as Europe/Berlin)", zone)
+		}
+		timeLocation = loc
+	}
+	return nil
+}
+
+// formatTime formats a timestamp for human output according to
+// --time-format, e.g. "3 hours ago"
+func formatTime(t time.Time) string {
+	if timeFormat != timeFormatRelative {
+		return formatTimestamp(t)
+	}
+	d := time.Since(t)
+	if d < 0 {
+		return "in " + humanizeDuration(-d)
+	}
+	return humanizeDuration(d) + " ago"
+}
+
+// formatTimestamp formats a timestamp as an absolute time, for output such
+// as event logs where relative times would go stale. The relative format
+// falls back to RFC 3339.
+func formatTimestamp(t time.Time) string {
+	if timeFormat == timeFormatUnix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.In(timeLocation).Format(time.RFC3339)
+}
+
+// createdAtSkew is how far a mirror list's creation time may be from the
+// mirror status's before it counts as wrong, e.g. shifted by a timezone
+// offset
+const createdAtSkew = time.Minute
+
+// mirrorCreatedAt returns when a listed mirror was created, or the zero
+// time if unknown. The list reports a Unix time whose unit and timezone
+// vary between PeerDB versions, so the status's timestamp wins when
+// status is given; fixed reports whether it corrected the list's time.
+func mirrorCreatedAt(mirror *pb.ListMirrorsItem, status *pb.MirrorStatusResponse) (created time.Time, fixed bool) {
+	listed, ok := timestamps.FromUnix(mirror.CreatedAt)
+	fromStatus, statusOK := timestamps.FromProto(status.GetCreatedAt())
+	if !statusOK {
+		return listed, false
+	}
+	skew := listed.Sub(fromStatus)
+	if skew < 0 {
+		skew = -skew
+	}
+	return fromStatus, !ok || skew > createdAtSkew
+}
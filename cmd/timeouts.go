@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// waitTimeout returns the limit on a wait: the duration flag when given,
+// with 0 for no limit, or else the wait_timeout setting
+func waitTimeout(cmd *cobra.Command, flag string) time.Duration {
+	if cmd.Flags().Changed(flag) {
+		timeout, _ := cmd.Flags().GetDuration(flag)
+		return timeout
+	}
+	return GetConfig().Timeouts().Wait
+}
+
+// unboundedWaitTimeout is waitTimeout for waits that are unlimited unless
+// asked otherwise, such as a snapshot of large tables: the duration flag
+// when given, or else wait_timeout only when it is set
+func unboundedWaitTimeout(cmd *cobra.Command, flag string) time.Duration {
+	if cmd.Flags().Changed(flag) || GetConfig().WaitTimeout != "" {
+		return waitTimeout(cmd, flag)
+	}
+	return 0
+}
+
+// withWaitTimeout returns ctx bounded by timeout, or unbounded for 0
+func withWaitTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutName formats a timeout for display, e.g. "30s" or "no limit"
+func timeoutName(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "no limit"
+	}
+	return formatDuration(timeout)
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/tuning"
+)
+
+// mirrorTuneCmd represents the mirror tune command
+var mirrorTuneCmd = &cobra.Command{
+	Use:   "tune [mirror-name]",
+	Short: "Suggest snapshot and batch settings from source table sizes",
+	Long: `Look up the size of each source table and suggest snapshot partition size,
+parallel workers, tables in parallel, and CDC batch size for the mirror.
+Pass a mirror name to tune an existing mirror, or -f to tune a mirror
+configuration file; --write updates the file in place.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tuneMirror(cmd, args)
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorTuneCmd)
+
+	mirrorTuneCmd.Flags().StringP("file", "f", "", "Mirror configuration file to tune")
+	mirrorTuneCmd.Flags().Bool("write", false, "Write the suggested settings into the file given with -f")
+	mirrorTuneCmd.Flags().Int64("row-bytes", tuning.DefaultRowBytes, "Average row size in bytes, used to estimate row counts from table sizes")
+	addVariableFlags(mirrorTuneCmd)
+}
+
+// tuneTarget is the mirror being tuned
+type tuneTarget struct {
+	name    string
+	source  string
+	tables  []string
+	current tuning.Settings
+}
+
+func tuneMirror(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	write, _ := cmd.Flags().GetBool("write")
+	rowBytes, _ := cmd.Flags().GetInt64("row-bytes")
+
+	if (file == "") == (len(args) == 0) {
+		return fmt.Errorf("specify either a mirror name or -f <file>")
+	}
+	if write && file == "" {
+		return fmt.Errorf("--write requires -f <file>")
+	}
+	if err := applyVariableFlags(cmd); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var target *tuneTarget
+	if file != "" {
+		target, err = tuneTargetFromFile(file)
+	} else {
+		target, err = tuneTargetFromServer(ctx, client, resourceName(args[0]))
+	}
+	if err != nil {
+		return err
+	}
+
+	tables, err := sourceTableSizes(ctx, client, target.source, target.tables)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("none of the mirror's tables were found on source peer '%s'", target.source)
+	}
+
+	rec := tuning.Recommend(tables, rowBytes)
+
+	fmt.Printf("Mirror '%s' (source '%s')\n\n", target.name, target.source)
+	fmt.Printf("%-40s %12s\n", "TABLE", "SIZE")
+	for _, table := range tables {
+		fmt.Printf("%-40s %12s\n", table.Name, formatBytes(table.Bytes))
+	}
+
+	fmt.Printf("\n%-32s %-12s %-12s\n", "SETTING", "CURRENT", "SUGGESTED")
+	for _, row := range tuneRows(target.current, rec.Settings) {
+		fmt.Printf("%-32s %-12s %-12s\n", row.path, row.current, formatCount(int64(row.suggested)))
+	}
+
+	fmt.Println("\n💡 Based on:")
+	for _, reason := range rec.Reasons {
+		fmt.Printf("  %s\n", reason)
+	}
+
+	if !write {
+		if file != "" {
+			fmt.Println("\nRun with --write to update the file.")
+		}
+		return nil
+	}
+
+	values := make(map[string]interface{})
+	for _, row := range tuneRows(target.current, rec.Settings) {
+		values[row.path] = row.suggested
+	}
+	data, err := config.SetSpecValues(file, values)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	fmt.Printf("\n✅ Updated %s\n", file)
+	return nil
+}
+
+// tuneRow is one setting in the tune output
+type tuneRow struct {
+	path      string
+	current   string
+	suggested uint32
+}
+
+// tuneRows pairs current and suggested settings with their spec paths
+func tuneRows(current, suggested tuning.Settings) []tuneRow {
+	format := func(v uint32) string {
+		if v == 0 {
+			return "(default)"
+		}
+		return formatCount(int64(v))
+	}
+
+	return []tuneRow{
+		{"snapshot.num_rows_per_partition", format(current.NumRowsPerPartition), suggested.NumRowsPerPartition},
+		{"snapshot.max_parallel_workers", format(current.MaxParallelWorkers), suggested.MaxParallelWorkers},
+		{"snapshot.num_tables_in_parallel", format(current.NumTablesInParallel), suggested.NumTablesInParallel},
+		{"cdc.batch_size", format(current.BatchSize), suggested.BatchSize},
+	}
+}
+
+func tuneTargetFromFile(file string) (*tuneTarget, error) {
+	fc, err := config.LoadConfigFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if fc.Kind != "Mirror" {
+		return nil, fmt.Errorf("%s is a %s configuration, not a Mirror", file, fc.Kind)
+	}
+
+	target := &tuneTarget{name: fc.Metadata.Name, source: fc.Spec.Source}
+	for _, table := range fc.Spec.Tables {
+		target.tables = append(target.tables, table.Source)
+	}
+	if fc.Spec.Snapshot != nil {
+		target.current.NumRowsPerPartition = fc.Spec.Snapshot.NumRowsPerPartition
+		target.current.MaxParallelWorkers = fc.Spec.Snapshot.MaxParallelWorkers
+		target.current.NumTablesInParallel = fc.Spec.Snapshot.NumTablesInParallel
+	}
+	if fc.Spec.CDC != nil {
+		target.current.BatchSize = fc.Spec.CDC.BatchSize
+	}
+	return target, nil
+}
+
+func tuneTargetFromServer(ctx context.Context, grpcClient *client.Client, mirrorName string) (*tuneTarget, error) {
+	status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return nil, fmt.Errorf("mirror '%s' is not a CDC mirror", mirrorName)
+	}
+
+	cfg := status.CdcStatus.Config
+	target := &tuneTarget{
+		name:   mirrorName,
+		source: cfg.SourceName,
+		current: tuning.Settings{
+			NumRowsPerPartition: cfg.SnapshotNumRowsPerPartition,
+			MaxParallelWorkers:  cfg.SnapshotMaxParallelWorkers,
+			NumTablesInParallel: cfg.SnapshotNumTablesInParallel,
+			BatchSize:           cfg.MaxBatchSize,
+		},
+	}
+	for _, mapping := range cfg.TableMappings {
+		target.tables = append(target.tables, mapping.SourceTableIdentifier)
+	}
+	return target, nil
+}
+
+// sourceTableSizes looks up the size of each table on the source peer,
+// listing each schema once. Tables that aren't found are reported and
+// left out.
+func sourceTableSizes(ctx context.Context, grpcClient *client.Client, source string, tables []string) ([]tuning.Table, error) {
+	sizes := make(map[string]map[string]string)
+	var result []tuning.Table
+
+	for _, name := range tables {
+		schema, table := "public", name
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			schema, table = name[:i], name[i+1:]
+		}
+
+		if _, ok := sizes[schema]; !ok {
+			listed, err := grpcClient.ListTables(ctx, source, schema, false)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tables in %s on '%s': %w", schema, source, err)
+			}
+			sizes[schema] = make(map[string]string)
+			for _, t := range listed {
+				sizes[schema][t.TableName] = t.TableSize
+			}
+		}
+
+		size, ok := sizes[schema][table]
+		if !ok {
+			fmt.Printf("⚠ Table %s not found on source peer '%s'; skipping\n", name, source)
+			continue
+		}
+		bytes, err := tuning.ParseSize(size)
+		if err != nil {
+			fmt.Printf("⚠ Could not read the size of %s: %v; skipping\n", name, err)
+			continue
+		}
+		result = append(result, tuning.Table{Name: name, Bytes: bytes})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Bytes > result[j].Bytes
+	})
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rawNumbers prints exact row counts, byte sizes, and durations instead of
+// rounded ones, set by --raw
+var rawNumbers bool
+
+// formatRows formats a row count for display, e.g. "1.2M", or the exact
+// number with --raw
+func formatRows(n int64) string {
+	if rawNumbers {
+		return strconv.FormatInt(n, 10)
+	}
+	return humanizeCount(n)
+}
+
+// formatBytes formats a size in bytes for display using binary units, e.g.
+// "3.4 GiB", or the exact number of bytes with --raw
+func formatBytes(n int64) string {
+	if rawNumbers {
+		return strconv.FormatInt(n, 10)
+	}
+	units := []string{"KiB", "MiB", "GiB", "TiB", "PiB"}
+	if n < 1024 && n > -1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / 1024
+	unit := 0
+	for (value >= 1024 || value <= -1024) && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return trimDecimal(value) + " " + units[unit]
+}
+
+// formatDuration formats a duration for display with its two largest
+// units, e.g. "2h15m", or Go's exact duration syntax with --raw
+func formatDuration(d time.Duration) string {
+	if rawNumbers {
+		return d.String()
+	}
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	hours := d % (24 * time.Hour) / time.Hour
+	minutes := d % time.Hour / time.Minute
+	seconds := d % time.Minute / time.Second
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// humanizeCount abbreviates large counts with K, M, B, or T and one
+// decimal, e.g. 1234567 becomes "1.2M"
+func humanizeCount(n int64) string {
+	if n < 0 {
+		return "-" + humanizeCount(-n)
+	}
+	if n < 1000 {
+		return strconv.FormatInt(n, 10)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"K", "M", "B", "T"} {
+		value /= 1000
+		// Round first, so 999,950 becomes 1.0M rather than 1000.0K
+		if value < 999.95 || suffix == "T" {
+			return trimDecimal(value) + suffix
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// trimDecimal formats a value with one decimal, dropping a trailing ".0"
+func trimDecimal(value float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0")
+}
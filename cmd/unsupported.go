@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/janakos/mirror_cli/internal/client"
+)
+
+// skipUnsupported reports whether err means the server doesn't implement
+// an RPC a command uses for part of its output, and if so prints a notice
+// naming the part skipped. The command then goes on without it.
+func skipUnsupported(err error, what string) bool {
+	if !client.IsUnsupported(err) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "⚠ Skipping %s: not supported by this PeerDB version\n", what)
+	return true
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/buildinfo"
+	"github.com/janakos/mirror_cli/internal/printer"
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long:  "Print the version, git commit, and build date of mirror_cli. Release builds set these with -ldflags; other builds report what the Go toolchain recorded.",
+	Args:  cobra.NoArgs,
+	// Works without a CLI configuration, e.g. in package manager tests
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printVersion(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.Version = buildinfo.Get().Version
+	rootCmd.SetVersionTemplate("mirror_cli {{.Version}}\n")
+}
+
+func printVersion(cmd *cobra.Command) error {
+	output, err := reportOutput(cmd, printer.FormatJSON, printer.FormatYAML)
+	if err != nil {
+		return err
+	}
+
+	info := buildinfo.Get()
+	if output != printer.FormatText {
+		return printReport(output, info)
+	}
+	fmt.Printf("mirror_cli %s\n", info)
+	return nil
+}
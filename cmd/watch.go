@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// watchLoop redraws the screen every interval until Ctrl-C, for --watch.
+// poll fetches a refresh and returns a func that prints it. An error
+// before the first refresh ends the watch; later ones are shown in place
+// of the refresh, followed by draw if poll returned one, so an outage
+// doesn't end it.
+func watchLoop(ctx context.Context, interval time.Duration, poll func(now time.Time) (draw func(), err error)) error {
+	first := true
+	err := pollUntil(ctx, interval, func() (bool, error) {
+		now := time.Now()
+		draw, err := poll(now)
+		if ctx.Err() != nil {
+			return true, nil
+		}
+		if err != nil && first {
+			return false, err
+		}
+		first = false
+
+		clearScreen()
+		fmt.Printf("Every %s, until Ctrl-C: %s\n\n", formatDuration(interval), formatTimestamp(now))
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+		if draw != nil {
+			draw()
+		}
+		return false, nil
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// clearScreen clears the terminal before a redraw. Without a terminal,
+// refreshes are separated by a blank line instead.
+func clearScreen() {
+	if stdoutIsTerminal {
+		fmt.Print("\033[H\033[2J")
+	} else {
+		fmt.Println()
+	}
+}
+
+// rowCounter follows a rows synced count across the refreshes of a watch
+type rowCounter struct {
+	rows int64
+	at   time.Time
+	seen bool
+}
+
+// update records the count at now and returns the rows synced since the
+// previous one and their rate per second. ok is false for the first count,
+// and when the count went down, as it does when a mirror is resynced,
+// which starts over.
+func (c *rowCounter) update(rows int64, now time.Time) (delta int64, rate float64, ok bool) {
+	delta = rows - c.rows
+	elapsed := now.Sub(c.at).Seconds()
+	ok = c.seen && delta >= 0 && elapsed > 0
+	if ok {
+		rate = float64(delta) / elapsed
+	}
+	c.rows, c.at, c.seen = rows, now, true
+	return delta, rate, ok
+}
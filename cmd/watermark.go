@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// mirrorWatermarkCmd represents the mirror watermark command
+var mirrorWatermarkCmd = &cobra.Command{
+	Use:   "watermark",
+	Short: "Inspect the watermark of a QRep mirror",
+	Long: `A QRep mirror copies rows in ranges of its watermark column, and each run
+starts after the watermark: the end of the last range it copied. Show it to
+see how far a mirror got.`,
+}
+
+// mirrorWatermarkShowCmd represents the mirror watermark show command
+var mirrorWatermarkShowCmd = &cobra.Command{
+	Use:   "show <mirror-name>",
+	Short: "Show the watermark of a QRep mirror",
+	Long: `Show the watermark column of a QRep mirror and its most recently completed
+partition. PeerDB doesn't report the watermark value itself; the command
+prints a query that finds it on the destination.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showWatermark(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorWatermarkCmd)
+	mirrorWatermarkCmd.AddCommand(mirrorWatermarkShowCmd)
+}
+
+// qrepStatus returns the status of a QRep mirror
+func qrepStatus(ctx context.Context, grpcClient *client.Client, mirrorName string) (*pb.MirrorStatusResponse, error) {
+	status, err := grpcClient.GetMirrorStatus(ctx, mirrorName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror status: %w", err)
+	}
+	if status.QrepStatus == nil {
+		return nil, fmt.Errorf("mirror '%s' is not a QRep mirror; only QRep mirrors have a watermark", mirrorName)
+	}
+	return status, nil
+}
+
+// lastCompletedPartition returns the partition that finished last and when,
+// or nil when none has
+func lastCompletedPartition(partitions []*pb.PartitionStatus) (*pb.PartitionStatus, time.Time) {
+	var last *pb.PartitionStatus
+	var lastEnd time.Time
+	for _, partition := range partitions {
+		end, ok := timestamps.FromProto(partition.EndTime)
+		if ok && (last == nil || end.After(lastEnd)) {
+			last, lastEnd = partition, end
+		}
+	}
+	return last, lastEnd
+}
+
+func showWatermark(cmd *cobra.Command, mirrorName string) error {
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	client, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := qrepStatus(ctx, client, mirrorName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Mirror: %s\n", mirrorName)
+	cfg := status.QrepStatus.Config
+	if cfg != nil {
+		fmt.Printf("Watermark Column: %s.%s\n", cfg.WatermarkTable, cfg.WatermarkColumn)
+	}
+
+	last, end := lastCompletedPartition(status.QrepStatus.Partitions)
+	if last == nil {
+		fmt.Println("Last Completed Partition: none (no range has been copied yet)")
+		return nil
+	}
+	fmt.Printf("Last Completed Partition: %s, %s rows, %s ago\n", last.PartitionId, formatRows(last.RowsSynced), humanizeDuration(time.Since(end)))
+
+	if cfg != nil && cfg.DestinationTableIdentifier != "" {
+		fmt.Printf("💡 PeerDB doesn't report the watermark value; find it on the destination with: SELECT max(%s) FROM %s\n", cfg.WatermarkColumn, cfg.DestinationTableIdentifier)
+	}
+	return nil
+}
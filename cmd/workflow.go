@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/janakos/mirror_cli/internal/client"
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/printer"
+	"github.com/janakos/mirror_cli/internal/state"
+)
+
+// mirrorWorkflowCmd represents the mirror workflow command
+var mirrorWorkflowCmd = &cobra.Command{
+	Use:   "workflow [mirror-name]",
+	Short: "Show the Temporal workflow of a mirror",
+	Long: `Show the ID of the Temporal workflow that runs a mirror, and a link to it in
+the Temporal UI, to dig into retries and activity errors PeerDB doesn't surface.
+
+Workflow IDs of mirrors created with 'mirror create' or 'config apply' are
+recorded in the context's state (~/.mirror_cli/state/). PeerDB's own ID is
+preferred; the recorded one is shown when PeerDB can't be reached or no longer
+lists the mirror.
+
+The link follows the temporal_ui_url setting, with {workflow_id} in place of the
+ID. Without it, the link points at the Temporal UI of PeerDB's Docker Compose
+setup, on port 8085 of the PeerDB host.`,
+	Example: `  mirror_cli mirror workflow orders_sync
+  mirror_cli config set --temporal-ui-url "https://temporal.example.com/namespaces/peerdb/workflows/{workflow_id}"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeMirrorNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showWorkflow(cmd, resourceName(args[0]))
+	},
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirrorWorkflowCmd)
+}
+
+// recordWorkflow records the workflow PeerDB started for a created mirror
+// in the context's state
+func recordWorkflow(mirror, workflowID string) {
+	if workflowID == "" || GetConfig().Explain != nil {
+		return
+	}
+	st, path, err := loadState()
+	if err == nil {
+		st.RecordWorkflow(state.Workflow{Mirror: mirror, WorkflowID: workflowID})
+		err = state.Save(st, path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the workflow ID of '%s': %v\n", mirror, err)
+	}
+}
+
+// serverWorkflowID returns the workflow ID PeerDB lists for a mirror; found
+// is false when PeerDB doesn't list the mirror
+func serverWorkflowID(ctx context.Context, mirrorName string) (workflowID string, found bool, err error) {
+	grpcClient, err := client.NewClient(ctx, client.WithConfig(GetConfig()))
+	if err != nil {
+		return "", false, err
+	}
+	defer grpcClient.Close()
+
+	list, err := grpcClient.ListMirrors(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list mirrors: %w", err)
+	}
+	for _, mirror := range list.Mirrors {
+		if mirror.Name == mirrorName {
+			return mirror.WorkflowId, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func showWorkflow(cmd *cobra.Command, mirrorName string) error {
+	output, err := reportOutput(cmd, printer.FormatJSON, printer.FormatYAML)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx := commandContext()
+
+	st, path, err := loadState()
+	if err != nil {
+		return err
+	}
+	recorded, hasRecord := st.Workflow(mirrorName)
+
+	report := workflowReport{Mirror: mirrorName}
+	workflowID, found, serverErr := serverWorkflowID(ctx, mirrorName)
+	switch {
+	case serverErr == nil && found && workflowID != "":
+		report.WorkflowID, report.Source = workflowID, "peerdb"
+		// A mirror that was dropped and created again, e.g. from the PeerDB
+		// UI, runs in a new workflow
+		if !hasRecord || recorded.WorkflowID != workflowID {
+			st.RecordWorkflow(state.Workflow{Mirror: mirrorName, WorkflowID: workflowID})
+			if err := state.Save(st, path); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ Could not record the workflow ID of '%s': %v\n", mirrorName, err)
+			} else if hasRecord {
+				fmt.Fprintf(os.Stderr, "⚠ Replaced the recorded workflow ID %s; the mirror was created again or resynced\n", recorded.WorkflowID)
+			}
+		}
+	case hasRecord:
+		report.WorkflowID, report.Source = recorded.WorkflowID, "state"
+		report.RecordedAt = optionalTime(recorded.RecordedAt, !recorded.RecordedAt.IsZero())
+		if serverErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not ask PeerDB for the workflow ID, showing the recorded one: %v\n", serverErr)
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠ PeerDB doesn't list mirror '%s'; the recorded workflow may have ended\n", mirrorName)
+		}
+	case serverErr != nil:
+		return fmt.Errorf("failed to get the workflow ID of mirror '%s', and none is recorded: %w", mirrorName, serverErr)
+	case found:
+		return fmt.Errorf("PeerDB lists no workflow ID for mirror '%s', and none is recorded", mirrorName)
+	default:
+		return fmt.Errorf("mirror '%s' not found", mirrorName)
+	}
+
+	cfg := GetConfig()
+	report.TemporalUI, report.TemporalUIConfigured = cfg.WorkflowURL(report.WorkflowID)
+
+	if output != printer.FormatText {
+		return printReport(output, report)
+	}
+
+	fmt.Printf("Mirror: %s\n", report.Mirror)
+	fmt.Printf("  Workflow ID: %s\n", report.WorkflowID)
+	if report.Source == "peerdb" {
+		fmt.Println("  Source: PeerDB")
+	} else if report.RecordedAt != nil {
+		fmt.Printf("  Source: recorded %s\n", formatTimestamp(*report.RecordedAt))
+	} else {
+		fmt.Println("  Source: recorded")
+	}
+	fmt.Printf("  Temporal UI: %s\n", report.TemporalUI)
+	if !report.TemporalUIConfigured {
+		fmt.Printf("💡 This link assumes PeerDB's Docker Compose setup; set yours with 'mirror_cli config set --temporal-ui-url <url with %s>'\n", config.WorkflowIDPlaceholder)
+	}
+	return nil
+}
+
+// workflowReport is the mirror workflow document
+type workflowReport struct {
+	Mirror     string `json:"mirror"`
+	WorkflowID string `json:"workflow_id"`
+	// Source is peerdb, or state for an ID recorded when the mirror was
+	// created
+	Source     string     `json:"source"`
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`
+	TemporalUI string     `json:"temporal_ui"`
+	// TemporalUIConfigured is false when the link is a guess, without
+	// temporal_ui_url
+	TemporalUIConfigured bool `json:"temporal_ui_configured"`
+}
@@ -0,0 +1,48 @@
+// Package app holds the mirror lifecycle operations (pause, resume, drop,
+// and edit) and the per-mirror status summaries fleet views are built
+// from, independent of cobra and of where output goes. Commands in cmd
+// build a service with a client and a printer and call it; other frontends
+// and tests can supply their own implementations of both. The logic of
+// other commands, such as config apply, reconcile, and cutover, is still
+// in cmd.
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// Client is the subset of the PeerDB API the services use. It is
+// implemented by *client.Client.
+type Client interface {
+	GetMirrorStatus(ctx context.Context, mirrorName string) (*pb.MirrorStatusResponse, error)
+	PauseMirror(ctx context.Context, mirrorName string) error
+	ResumeMirror(ctx context.Context, mirrorName string) error
+	ResumeMirrorWithEnv(ctx context.Context, mirrorName string, env map[string]string) error
+	DropMirror(ctx context.Context, mirrorName string, skipDestinationDrop bool) error
+	UpdateMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error
+	UpdatePausedMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error
+	UpdatePausedMirrorEnv(ctx context.Context, mirrorName string, env map[string]string) error
+}
+
+// Printer receives the human-readable output of the services
+type Printer interface {
+	Printf(format string, args ...interface{})
+}
+
+// writerPrinter prints to an io.Writer
+type writerPrinter struct {
+	w io.Writer
+}
+
+// NewPrinter returns a Printer writing to w
+func NewPrinter(w io.Writer) Printer {
+	return writerPrinter{w: w}
+}
+
+func (p writerPrinter) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(p.w, format, args...)
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/poller"
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// Mirrors changes the state and configuration of mirrors
+type Mirrors struct {
+	Client Client
+	Out    Printer
+}
+
+// MirrorEdit is a change to a CDC mirror's configuration. Zero values are
+// left unchanged.
+type MirrorEdit struct {
+	AddTables    []*pb.TableMapping
+	RemoveTables []*pb.TableMapping
+	// RemapTables changes the destination of tables already in the mirror
+	RemapTables []*pb.TableMapping
+	BatchSize   uint32
+	IdleTimeout uint64
+	// Env sets entries of the mirror's env, such as its provenance
+	Env map[string]string
+}
+
+// Pause pauses a mirror. A reason, if given, is recorded in the env of a
+// CDC mirror, along with who paused it and when, until it is resumed.
+func (m *Mirrors) Pause(ctx context.Context, name, reason string) error {
+	if reason != "" {
+		status, err := m.Client.GetMirrorStatus(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get mirror: %w", err)
+		}
+		if status.CdcStatus == nil {
+			return fmt.Errorf("mirror '%s' is not a CDC mirror; pause reasons can only be recorded for CDC mirrors", name)
+		}
+	}
+
+	if err := m.Client.PauseMirror(ctx, name); err != nil {
+		return fmt.Errorf("failed to pause mirror: %w", err)
+	}
+	if reason == "" {
+		m.Out.Printf("✓ Mirror '%s' paused successfully\n", name)
+		return nil
+	}
+
+	if err := m.Client.UpdatePausedMirrorEnv(ctx, name, provenance.PauseAnnotations(reason, time.Now())); err != nil {
+		return fmt.Errorf("mirror paused, but failed to record the reason: %w", err)
+	}
+	m.Out.Printf("✓ Mirror '%s' paused: %s\n", name, reason)
+	return nil
+}
+
+// Resume resumes a paused mirror, clearing the reason it was paused for in
+// the same request, so a mirror that isn't paused is never paused by it
+func (m *Mirrors) Resume(ctx context.Context, name string) error {
+	// Best effort: a mirror whose status can't be read still resumes
+	var note provenance.PauseNote
+	var noted bool
+	if status, err := m.Client.GetMirrorStatus(ctx, name); err == nil {
+		note, noted = provenance.ExtractPauseNote(status.GetCdcStatus().GetConfig().GetEnv())
+	}
+
+	var err error
+	if noted {
+		err = m.Client.ResumeMirrorWithEnv(ctx, name, provenance.ClearedPauseAnnotations())
+	} else {
+		err = m.Client.ResumeMirror(ctx, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resume mirror: %w", err)
+	}
+	m.Out.Printf("✓ Mirror '%s' resumed successfully\n", name)
+	if noted {
+		m.Out.Printf("  It was paused: %s\n", note.Reason)
+	}
+	return nil
+}
+
+// Drop drops a mirror, keeping its destination tables if keepDestination
+// is set
+func (m *Mirrors) Drop(ctx context.Context, name string, keepDestination bool) error {
+	if err := m.Client.DropMirror(ctx, name, keepDestination); err != nil {
+		return fmt.Errorf("failed to drop mirror: %w", err)
+	}
+	m.Out.Printf("✓ Mirror '%s' dropped successfully\n", name)
+	return nil
+}
+
+// Edit applies a configuration change to a CDC mirror. A running mirror is
+// paused for the update and resumed; a paused one is updated in place and
+// left paused. An edit that only sets env entries, such as the provenance
+// config apply records, never pauses the mirror. Remapped tables take two
+// updates: PeerDB can't remove and add the same table in one, so they are
+// removed with the rest of the edit and, once the mirror runs again, added
+// back with their new destination.
+func (m *Mirrors) Edit(ctx context.Context, name string, edit MirrorEdit) error {
+	status, err := m.Client.GetMirrorStatus(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get mirror: %w", err)
+	}
+	if status.CdcStatus == nil || status.CdcStatus.Config == nil {
+		return fmt.Errorf("mirror '%s' is not a CDC mirror; only CDC mirrors can be edited", name)
+	}
+	state := status.CurrentFlowState
+	paused := state == pb.FlowStatus_STATUS_PAUSED
+	if !paused && state != pb.FlowStatus_STATUS_RUNNING {
+		return fmt.Errorf("mirror '%s' is %s; it can only be edited while running or paused", name, stateName(state))
+	}
+
+	if envOnly(edit) {
+		// Requesting the state the mirror is already in applies the env
+		// without pausing or resuming it
+		if paused {
+			err = m.Client.UpdatePausedMirrorEnv(ctx, name, edit.Env)
+		} else {
+			err = m.Client.ResumeMirrorWithEnv(ctx, name, edit.Env)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update mirror: %w", err)
+		}
+		m.Out.Printf("✓ Mirror '%s' updated successfully\n", name)
+		return nil
+	}
+
+	var remapped []*pb.TableMapping
+	if len(edit.RemapTables) > 0 {
+		if paused {
+			return fmt.Errorf("mirror '%s' is paused; resume it before remapping tables, which are added back once it runs", name)
+		}
+		removed, added, err := m.remapTables(status.CdcStatus.Config, name, edit)
+		if err != nil {
+			return err
+		}
+		edit.RemoveTables = append(edit.RemoveTables, removed...)
+		remapped = added
+	}
+
+	update := &pb.FlowConfigUpdate{
+		CdcFlowConfigUpdate: &pb.CDCFlowConfigUpdate{
+			AdditionalTables: edit.AddTables,
+			RemovedTables:    edit.RemoveTables,
+			BatchSize:        edit.BatchSize,
+			IdleTimeout:      edit.IdleTimeout,
+			UpdatedEnv:       edit.Env,
+		},
+	}
+
+	if paused {
+		err = m.Client.UpdatePausedMirror(ctx, name, update)
+	} else {
+		err = m.Client.UpdateMirror(ctx, name, update)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update mirror: %w", err)
+	}
+	if len(remapped) > 0 {
+		if err := m.addRemapped(ctx, name, remapped); err != nil {
+			return err
+		}
+	}
+	m.Out.Printf("✓ Mirror '%s' updated successfully\n", name)
+	return nil
+}
+
+// envOnly reports whether an edit only sets env entries
+func envOnly(edit MirrorEdit) bool {
+	return len(edit.AddTables) == 0 && len(edit.RemoveTables) == 0 && len(edit.RemapTables) == 0 &&
+		edit.BatchSize == 0 && edit.IdleTimeout == 0
+}
+
+// stateName returns a flow state as a lowercase word, such as "paused"
+func stateName(state pb.FlowStatus) string {
+	return strings.ToLower(strings.TrimPrefix(state.String(), "STATUS_"))
+}
+
+// remapWaitTimeout bounds how long Edit waits for a mirror to run again
+// before adding remapped tables back
+const remapWaitTimeout = 10 * time.Minute
+
+// remapPollInterval is how often Edit checks whether the mirror runs
+// again. It is a variable so tests can shorten it.
+var remapPollInterval = 2 * time.Second
+
+// addRemapped waits for a mirror whose remapped tables were removed to run
+// again, then adds them back with their new destinations
+func (m *Mirrors) addRemapped(ctx context.Context, name string, mappings []*pb.TableMapping) error {
+	m.Out.Printf("✓ Removed %d remapped table(s); waiting for the mirror to run again\n", len(mappings))
+	if err := m.waitRunning(ctx, name); err != nil {
+		return fmt.Errorf("remapped tables were removed but not added back: %w; add them with --add-tables %s", err, formatMappings(mappings))
+	}
+	update := &pb.FlowConfigUpdate{
+		CdcFlowConfigUpdate: &pb.CDCFlowConfigUpdate{AdditionalTables: mappings},
+	}
+	if err := m.Client.UpdateMirror(ctx, name, update); err != nil {
+		return fmt.Errorf("remapped tables were removed but not added back: %w; add them with --add-tables %s", err, formatMappings(mappings))
+	}
+	m.Out.Printf("✓ Added %d remapped table(s) with their new destination\n", len(mappings))
+	return nil
+}
+
+// waitRunning polls a mirror until it is running, failing if it fails or
+// doesn't within remapWaitTimeout
+func (m *Mirrors) waitRunning(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, remapWaitTimeout)
+	defer cancel()
+	for {
+		status, err := m.Client.GetMirrorStatus(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get mirror: %w", err)
+		}
+		switch status.CurrentFlowState {
+		case pb.FlowStatus_STATUS_RUNNING:
+			return nil
+		case pb.FlowStatus_STATUS_FAILED, pb.FlowStatus_STATUS_TERMINATED:
+			return fmt.Errorf("mirror '%s' is %s", name, stateName(status.CurrentFlowState))
+		}
+		if err := poller.Sleep(ctx, remapPollInterval); err != nil {
+			return fmt.Errorf("mirror '%s' didn't run again: %w", name, err)
+		}
+	}
+}
+
+// formatMappings formats table mappings as --add-tables takes them
+func formatMappings(mappings []*pb.TableMapping) string {
+	specs := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		specs[i] = mapping.SourceTableIdentifier + "->" + mapping.DestinationTableIdentifier
+	}
+	return "'" + strings.Join(specs, ",") + "'"
+}
+
+// remapTables turns destination changes to the tables of a mirror's config
+// into the mappings to remove and add back. The new mappings keep the
+// other settings of the old ones, such as excluded columns.
+func (m *Mirrors) remapTables(config *pb.FlowConnectionConfigs, name string, edit MirrorEdit) (removed, added []*pb.TableMapping, err error) {
+	current := make(map[string]*pb.TableMapping)
+	for _, mapping := range config.TableMappings {
+		current[mapping.SourceTableIdentifier] = mapping
+	}
+	edited := make(map[string]bool)
+	for _, mapping := range append(edit.AddTables, edit.RemoveTables...) {
+		edited[mapping.SourceTableIdentifier] = true
+	}
+
+	for _, remap := range edit.RemapTables {
+		source := remap.SourceTableIdentifier
+		old, ok := current[source]
+		switch {
+		case !ok:
+			return nil, nil, fmt.Errorf("can't remap '%s': mirror '%s' doesn't replicate it", source, name)
+		case edited[source]:
+			return nil, nil, fmt.Errorf("can't remap '%s': it is also added or removed by this edit", source)
+		case old.DestinationTableIdentifier == remap.DestinationTableIdentifier:
+			return nil, nil, fmt.Errorf("can't remap '%s': it already replicates to '%s'", source, old.DestinationTableIdentifier)
+		}
+		edited[source] = true
+
+		mapping := proto.Clone(old).(*pb.TableMapping)
+		mapping.DestinationTableIdentifier = remap.DestinationTableIdentifier
+		removed = append(removed, old)
+		added = append(added, mapping)
+		m.Out.Printf("  ~ %s: %s -> %s\n", source, old.DestinationTableIdentifier, remap.DestinationTableIdentifier)
+	}
+
+	m.Out.Printf("⚠ Remapped tables are removed and added again, which re-runs their initial snapshot into the new destination. The old destination tables are left in place.\n")
+	return removed, added, nil
+}
+
+// ParseTableMappings parses "source->destination" table mappings
+func ParseTableMappings(specs []string) ([]*pb.TableMapping, error) {
+	mappings := make([]*pb.TableMapping, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(spec, "->")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid table mapping format: %s (expected: source->destination)", spec)
+		}
+		mappings = append(mappings, &pb.TableMapping{
+			SourceTableIdentifier:      strings.TrimSpace(parts[0]),
+			DestinationTableIdentifier: strings.TrimSpace(parts[1]),
+		})
+	}
+	return mappings, nil
+}
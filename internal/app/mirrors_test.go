@@ -0,0 +1,431 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// fakeClient holds one CDC mirror in memory and records the calls made
+// to it. UpdateMirror pauses and resumes the mirror as *client.Client does.
+type fakeClient struct {
+	status *pb.MirrorStatusResponse
+	calls  []string
+	// afterResume lists the states reported in turn by the status lookups
+	// after the next resume, so a mirror can take a while to run again, or
+	// fail. settling holds those not reported yet.
+	afterResume []pb.FlowStatus
+	settling    []pb.FlowStatus
+}
+
+// newFakeClient returns a fake holding a CDC mirror named orders in state
+// that replicates public.orders
+func newFakeClient(state pb.FlowStatus, env map[string]string) *fakeClient {
+	return &fakeClient{status: &pb.MirrorStatusResponse{
+		FlowJobName:      "orders",
+		CurrentFlowState: state,
+		CdcStatus: &pb.CDCMirrorStatus{Config: &pb.FlowConnectionConfigs{
+			FlowJobName: "orders",
+			TableMappings: []*pb.TableMapping{
+				{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "analytics.orders", Exclude: []string{"notes"}},
+			},
+			Env: env,
+		}},
+	}}
+}
+
+func (f *fakeClient) GetMirrorStatus(ctx context.Context, mirrorName string) (*pb.MirrorStatusResponse, error) {
+	if len(f.settling) > 0 {
+		f.status.CurrentFlowState, f.settling = f.settling[0], f.settling[1:]
+	}
+	return proto.Clone(f.status).(*pb.MirrorStatusResponse), nil
+}
+
+func (f *fakeClient) PauseMirror(ctx context.Context, mirrorName string) error {
+	f.calls = append(f.calls, "pause")
+	f.status.CurrentFlowState = pb.FlowStatus_STATUS_PAUSED
+	return nil
+}
+
+func (f *fakeClient) ResumeMirror(ctx context.Context, mirrorName string) error {
+	f.calls = append(f.calls, "resume")
+	f.status.CurrentFlowState = pb.FlowStatus_STATUS_RUNNING
+	f.settling, f.afterResume = f.afterResume, nil
+	return nil
+}
+
+func (f *fakeClient) ResumeMirrorWithEnv(ctx context.Context, mirrorName string, env map[string]string) error {
+	f.calls = append(f.calls, "running with env")
+	f.setEnv(env)
+	f.status.CurrentFlowState = pb.FlowStatus_STATUS_RUNNING
+	return nil
+}
+
+func (f *fakeClient) DropMirror(ctx context.Context, mirrorName string, skipDestinationDrop bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("drop keep=%t", skipDestinationDrop))
+	f.status.CurrentFlowState = pb.FlowStatus_STATUS_TERMINATED
+	return nil
+}
+
+func (f *fakeClient) UpdateMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error {
+	f.PauseMirror(ctx, mirrorName)
+	if err := f.UpdatePausedMirror(ctx, mirrorName, update); err != nil {
+		return err
+	}
+	return f.ResumeMirror(ctx, mirrorName)
+}
+
+func (f *fakeClient) UpdatePausedMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error {
+	if f.status.CurrentFlowState != pb.FlowStatus_STATUS_PAUSED {
+		return fmt.Errorf("mirror is not paused")
+	}
+	cdc := update.CdcFlowConfigUpdate
+	f.calls = append(f.calls, fmt.Sprintf("update +%d -%d", len(cdc.AdditionalTables), len(cdc.RemovedTables)))
+
+	config := f.status.CdcStatus.Config
+	config.TableMappings = slices.DeleteFunc(config.TableMappings, func(mapping *pb.TableMapping) bool {
+		return slices.ContainsFunc(cdc.RemovedTables, func(removed *pb.TableMapping) bool {
+			return proto.Equal(mapping, removed)
+		})
+	})
+	config.TableMappings = append(config.TableMappings, cdc.AdditionalTables...)
+	if cdc.BatchSize != 0 {
+		config.MaxBatchSize = cdc.BatchSize
+	}
+	f.setEnv(cdc.UpdatedEnv)
+	return nil
+}
+
+func (f *fakeClient) UpdatePausedMirrorEnv(ctx context.Context, mirrorName string, env map[string]string) error {
+	return f.UpdatePausedMirror(ctx, mirrorName, &pb.FlowConfigUpdate{
+		CdcFlowConfigUpdate: &pb.CDCFlowConfigUpdate{UpdatedEnv: env},
+	})
+}
+
+func (f *fakeClient) setEnv(env map[string]string) {
+	config := f.status.CdcStatus.Config
+	for key, value := range env {
+		if config.Env == nil {
+			config.Env = make(map[string]string)
+		}
+		config.Env[key] = value
+	}
+}
+
+// TestEditKeepsPausedMirrorPaused covers the edits reconcile and config
+// apply make, including recording a new commit on an unchanged mirror: a
+// mirror paused on purpose stays paused, with its pause note.
+func TestEditKeepsPausedMirrorPaused(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      MirrorEdit
+		wantCalls []string
+	}{
+		{
+			name:      "provenance only",
+			edit:      MirrorEdit{Env: map[string]string{provenance.Prefix + "git_sha": "abc123"}},
+			wantCalls: []string{"update +0 -0"},
+		},
+		{
+			name: "tables and provenance",
+			edit: MirrorEdit{
+				AddTables: []*pb.TableMapping{{SourceTableIdentifier: "public.refunds", DestinationTableIdentifier: "analytics.refunds"}},
+				Env:       map[string]string{provenance.Prefix + "git_sha": "abc123"},
+			},
+			wantCalls: []string{"update +1 -0"},
+		},
+		{
+			name:      "batch size",
+			edit:      MirrorEdit{BatchSize: 5000},
+			wantCalls: []string{"update +0 -0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_PAUSED, provenance.PauseAnnotations("maintenance", time.Now()))
+			m := &Mirrors{Client: f, Out: NewPrinter(&bytes.Buffer{})}
+			if err := m.Edit(context.Background(), "orders", tt.edit); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(f.calls, tt.wantCalls) {
+				t.Errorf("got calls %v, want %v", f.calls, tt.wantCalls)
+			}
+			if state := f.status.CurrentFlowState; state != pb.FlowStatus_STATUS_PAUSED {
+				t.Errorf("got state %s, want %s", state, pb.FlowStatus_STATUS_PAUSED)
+			}
+			env := f.status.CdcStatus.Config.Env
+			if note, ok := provenance.ExtractPauseNote(env); !ok || note.Reason != "maintenance" {
+				t.Errorf("got pause note %+v, want reason maintenance", note)
+			}
+			for key, value := range tt.edit.Env {
+				if env[key] != value {
+					t.Errorf("got %s=%q, want %q", key, env[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestEditRunningMirror(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      MirrorEdit
+		wantCalls []string
+	}{
+		// Requests the running state it is already in, without pausing it
+		{"provenance only", MirrorEdit{Env: map[string]string{provenance.Prefix + "git_sha": "abc123"}}, []string{"running with env"}},
+		{"batch size", MirrorEdit{BatchSize: 5000}, []string{"pause", "update +0 -0", "resume"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_RUNNING, nil)
+			m := &Mirrors{Client: f, Out: NewPrinter(&bytes.Buffer{})}
+			if err := m.Edit(context.Background(), "orders", tt.edit); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(f.calls, tt.wantCalls) {
+				t.Errorf("got calls %v, want %v", f.calls, tt.wantCalls)
+			}
+			if state := f.status.CurrentFlowState; state != pb.FlowStatus_STATUS_RUNNING {
+				t.Errorf("got state %s, want %s", state, pb.FlowStatus_STATUS_RUNNING)
+			}
+		})
+	}
+}
+
+func TestEditRefusedStates(t *testing.T) {
+	tests := []struct {
+		state   pb.FlowStatus
+		edit    MirrorEdit
+		wantErr string
+	}{
+		{pb.FlowStatus_STATUS_SNAPSHOT, MirrorEdit{BatchSize: 5000}, "mirror 'orders' is snapshot; it can only be edited while running or paused"},
+		{pb.FlowStatus_STATUS_SETUP, MirrorEdit{Env: map[string]string{"k": "v"}}, "mirror 'orders' is setup; it can only be edited while running or paused"},
+		{pb.FlowStatus_STATUS_PAUSED, MirrorEdit{RemapTables: []*pb.TableMapping{{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "staging.orders"}}}, "mirror 'orders' is paused; resume it before remapping tables"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state.String(), func(t *testing.T) {
+			f := newFakeClient(tt.state, nil)
+			m := &Mirrors{Client: f, Out: NewPrinter(&bytes.Buffer{})}
+			err := m.Edit(context.Background(), "orders", tt.edit)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if len(f.calls) != 0 {
+				t.Errorf("got calls %v, want none", f.calls)
+			}
+			if f.status.CurrentFlowState != tt.state {
+				t.Errorf("got state %s, want %s", f.status.CurrentFlowState, tt.state)
+			}
+		})
+	}
+}
+
+func TestPause(t *testing.T) {
+	tests := []struct {
+		name       string
+		reason     string
+		qrep       bool
+		wantCalls  []string
+		wantOut    string
+		wantErr    string
+		wantReason string
+	}{
+		{name: "without reason", wantCalls: []string{"pause"}, wantOut: "✓ Mirror 'orders' paused successfully\n"},
+		{name: "with reason", reason: "maintenance", wantCalls: []string{"pause", "update +0 -0"}, wantOut: "✓ Mirror 'orders' paused: maintenance\n", wantReason: "maintenance"},
+		{name: "reason on QRep", reason: "maintenance", qrep: true, wantErr: "pause reasons can only be recorded for CDC mirrors"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_RUNNING, nil)
+			if tt.qrep {
+				f.status.CdcStatus = nil
+			}
+			var out bytes.Buffer
+			m := &Mirrors{Client: f, Out: NewPrinter(&out)}
+			err := m.Pause(context.Background(), "orders", tt.reason)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				if len(f.calls) != 0 {
+					t.Errorf("got calls %v, want none", f.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(f.calls, tt.wantCalls) {
+				t.Errorf("got calls %v, want %v", f.calls, tt.wantCalls)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("got output %q, want %q", out.String(), tt.wantOut)
+			}
+			if note, _ := provenance.ExtractPauseNote(f.status.CdcStatus.Config.Env); note.Reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", note.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantCalls []string
+		wantOut   string
+	}{
+		{"without note", nil, []string{"resume"}, "✓ Mirror 'orders' resumed successfully\n"},
+		{
+			"with note",
+			provenance.PauseAnnotations("maintenance", time.Now()),
+			[]string{"running with env"},
+			"✓ Mirror 'orders' resumed successfully\n  It was paused: maintenance\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_PAUSED, tt.env)
+			var out bytes.Buffer
+			m := &Mirrors{Client: f, Out: NewPrinter(&out)}
+			if err := m.Resume(context.Background(), "orders"); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(f.calls, tt.wantCalls) {
+				t.Errorf("got calls %v, want %v", f.calls, tt.wantCalls)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("got output %q, want %q", out.String(), tt.wantOut)
+			}
+			if f.status.CurrentFlowState != pb.FlowStatus_STATUS_RUNNING {
+				t.Errorf("got state %s, want %s", f.status.CurrentFlowState, pb.FlowStatus_STATUS_RUNNING)
+			}
+			if note, ok := provenance.ExtractPauseNote(f.status.CdcStatus.Config.Env); ok {
+				t.Errorf("pause note %+v was not cleared", note)
+			}
+		})
+	}
+}
+
+func TestDrop(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("keep destination %t", keep), func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_RUNNING, nil)
+			var out bytes.Buffer
+			m := &Mirrors{Client: f, Out: NewPrinter(&out)}
+			if err := m.Drop(context.Background(), "orders", keep); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := []string{fmt.Sprintf("drop keep=%t", keep)}; !slices.Equal(f.calls, want) {
+				t.Errorf("got calls %v, want %v", f.calls, want)
+			}
+			if want := "✓ Mirror 'orders' dropped successfully\n"; out.String() != want {
+				t.Errorf("got output %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestEditRemap(t *testing.T) {
+	defer func(interval time.Duration) { remapPollInterval = interval }(remapPollInterval)
+	remapPollInterval = time.Millisecond
+
+	remap := func(source, destination string) MirrorEdit {
+		return MirrorEdit{RemapTables: []*pb.TableMapping{{SourceTableIdentifier: source, DestinationTableIdentifier: destination}}}
+	}
+	tests := []struct {
+		name        string
+		edit        MirrorEdit
+		afterResume []pb.FlowStatus
+		wantCalls   []string
+		wantErr     string
+		wantTables  []string
+	}{
+		{
+			name:       "added back once running",
+			edit:       remap("public.orders", "staging.orders"),
+			wantCalls:  []string{"pause", "update +0 -1", "resume", "pause", "update +1 -0", "resume"},
+			wantTables: []string{"public.orders->staging.orders"},
+		},
+		{
+			name:        "added back after waiting",
+			edit:        remap("public.orders", "staging.orders"),
+			afterResume: []pb.FlowStatus{pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_RUNNING},
+			wantCalls:   []string{"pause", "update +0 -1", "resume", "pause", "update +1 -0", "resume"},
+			wantTables:  []string{"public.orders->staging.orders"},
+		},
+		{
+			name:        "mirror fails before running again",
+			edit:        remap("public.orders", "staging.orders"),
+			afterResume: []pb.FlowStatus{pb.FlowStatus_STATUS_SETUP, pb.FlowStatus_STATUS_FAILED},
+			wantCalls:   []string{"pause", "update +0 -1", "resume"},
+			wantErr:     "remapped tables were removed but not added back: mirror 'orders' is failed; add them with --add-tables 'public.orders->staging.orders'",
+			wantTables:  []string{},
+		},
+		{
+			name:       "table not in mirror",
+			edit:       remap("public.users", "staging.users"),
+			wantErr:    "can't remap 'public.users': mirror 'orders' doesn't replicate it",
+			wantTables: []string{"public.orders->analytics.orders"},
+		},
+		{
+			name:       "same destination",
+			edit:       remap("public.orders", "analytics.orders"),
+			wantErr:    "can't remap 'public.orders': it already replicates to 'analytics.orders'",
+			wantTables: []string{"public.orders->analytics.orders"},
+		},
+		{
+			name: "also removed",
+			edit: MirrorEdit{
+				RemoveTables: []*pb.TableMapping{{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "analytics.orders"}},
+				RemapTables:  []*pb.TableMapping{{SourceTableIdentifier: "public.orders", DestinationTableIdentifier: "staging.orders"}},
+			},
+			wantErr:    "can't remap 'public.orders': it is also added or removed by this edit",
+			wantTables: []string{"public.orders->analytics.orders"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeClient(pb.FlowStatus_STATUS_RUNNING, nil)
+			f.afterResume = tt.afterResume
+			m := &Mirrors{Client: f, Out: NewPrinter(&bytes.Buffer{})}
+			err := m.Edit(context.Background(), "orders", tt.edit)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(f.calls, tt.wantCalls) {
+				t.Errorf("got calls %v, want %v", f.calls, tt.wantCalls)
+			}
+			tables := []string{}
+			for _, mapping := range f.status.CdcStatus.Config.TableMappings {
+				tables = append(tables, mapping.SourceTableIdentifier+"->"+mapping.DestinationTableIdentifier)
+				// Remapped tables keep the other settings of their mapping
+				if !slices.Equal(mapping.Exclude, []string{"notes"}) {
+					t.Errorf("got excluded columns %v for %s, want [notes]", mapping.Exclude, mapping.SourceTableIdentifier)
+				}
+			}
+			if !slices.Equal(tables, tt.wantTables) {
+				t.Errorf("got tables %v, want %v", tables, tt.wantTables)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/janakos/mirror_cli/internal/poller"
+	"github.com/janakos/mirror_cli/internal/timestamps"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// statusWorkers bounds the number of concurrent MirrorStatus calls
+const statusWorkers = 8
+
+// MirrorSummary is the per-mirror data fleet views are built from
+type MirrorSummary struct {
+	Name       string
+	State      pb.FlowStatus
+	RowsSynced int64
+	RowsInHour int64
+	Lag        time.Duration
+	// LastActivity is the end of the last batch, or the creation time
+	LastActivity time.Time
+	Err          error
+
+	// Status is the response the summary was built from
+	Status *pb.MirrorStatusResponse
+}
+
+// SummarizeMirrors fetches the status of every mirror concurrently
+func SummarizeMirrors(ctx context.Context, c Client, mirrors []*pb.ListMirrorsItem, now time.Time) []MirrorSummary {
+	return poller.Map(ctx, mirrors, statusWorkers, func(ctx context.Context, mirror *pb.ListMirrorsItem) MirrorSummary {
+		return SummarizeMirror(ctx, c, mirror.Name, now)
+	})
+}
+
+// SummarizeMirror fetches and summarizes the status of one mirror
+func SummarizeMirror(ctx context.Context, c Client, name string, now time.Time) MirrorSummary {
+	resp, err := c.GetMirrorStatus(ctx, name)
+	if err != nil {
+		return MirrorSummary{Name: name, Err: fmt.Errorf("failed to get status: %w", err)}
+	}
+	return SummarizeStatus(name, resp, now)
+}
+
+// SummarizeStatus summarizes a mirror status response as of now
+func SummarizeStatus(name string, resp *pb.MirrorStatusResponse, now time.Time) MirrorSummary {
+	summary := MirrorSummary{Name: name, State: resp.CurrentFlowState, Status: resp}
+
+	if resp.CdcStatus == nil {
+		return summary
+	}
+	summary.RowsSynced = resp.CdcStatus.RowsSynced
+
+	hourAgo := now.Add(-time.Hour)
+	for _, batch := range resp.CdcStatus.CdcBatches {
+		if t, ok := batchTime(batch); ok && t.After(hourAgo) {
+			summary.RowsInHour += batch.NumRows
+		}
+	}
+
+	// Fall back to the creation time, as mirror status does
+	lastActivity := LastSyncActivity(resp.CdcStatus.CdcBatches)
+	if created, ok := timestamps.FromProto(resp.CreatedAt); lastActivity.IsZero() && ok {
+		lastActivity = created
+	}
+	if !lastActivity.IsZero() {
+		summary.LastActivity = lastActivity
+		summary.Lag = now.Sub(lastActivity)
+	}
+
+	return summary
+}
+
+// LastSyncActivity returns the end time of the most recent CDC batch
+func LastSyncActivity(batches []*pb.CDCBatch) time.Time {
+	var latest time.Time
+	for _, batch := range batches {
+		if t, ok := batchTime(batch); ok && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// batchTime returns when a batch ended, or started if it hasn't ended
+func batchTime(batch *pb.CDCBatch) (time.Time, bool) {
+	if t, ok := timestamps.FromProto(batch.EndTime); ok {
+		return t, true
+	}
+	return timestamps.FromProto(batch.StartTime)
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+func TestSummarizeStatus(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *timestamppb.Timestamp {
+		return timestamppb.New(now.Add(-ago))
+	}
+
+	tests := []struct {
+		name             string
+		resp             *pb.MirrorStatusResponse
+		wantRows         int64
+		wantRowsInHour   int64
+		wantLastActivity time.Time
+		wantLag          time.Duration
+	}{
+		{
+			name: "recent batches",
+			resp: &pb.MirrorStatusResponse{
+				CurrentFlowState: pb.FlowStatus_STATUS_RUNNING,
+				CreatedAt:        at(48 * time.Hour),
+				CdcStatus: &pb.CDCMirrorStatus{RowsSynced: 5000, CdcBatches: []*pb.CDCBatch{
+					{NumRows: 300, StartTime: at(2 * time.Hour), EndTime: at(2*time.Hour - time.Minute)},
+					{NumRows: 200, StartTime: at(30 * time.Minute), EndTime: at(29 * time.Minute)},
+					// Still running: counted by its start time
+					{NumRows: 100, StartTime: at(time.Minute)},
+				}},
+			},
+			wantRows:         5000,
+			wantRowsInHour:   300,
+			wantLastActivity: now.Add(-time.Minute),
+			wantLag:          time.Minute,
+		},
+		{
+			name: "stale",
+			resp: &pb.MirrorStatusResponse{
+				CurrentFlowState: pb.FlowStatus_STATUS_RUNNING,
+				CreatedAt:        at(48 * time.Hour),
+				CdcStatus: &pb.CDCMirrorStatus{RowsSynced: 300, CdcBatches: []*pb.CDCBatch{
+					{NumRows: 300, StartTime: at(3*time.Hour + time.Minute), EndTime: at(3 * time.Hour)},
+				}},
+			},
+			wantRows:         300,
+			wantLastActivity: now.Add(-3 * time.Hour),
+			wantLag:          3 * time.Hour,
+		},
+		{
+			name: "no batches",
+			resp: &pb.MirrorStatusResponse{
+				CurrentFlowState: pb.FlowStatus_STATUS_SNAPSHOT,
+				CreatedAt:        at(10 * time.Minute),
+				CdcStatus:        &pb.CDCMirrorStatus{},
+			},
+			wantLastActivity: now.Add(-10 * time.Minute),
+			wantLag:          10 * time.Minute,
+		},
+		{
+			name: "no batches or creation time",
+			resp: &pb.MirrorStatusResponse{
+				CurrentFlowState: pb.FlowStatus_STATUS_SETUP,
+				CdcStatus:        &pb.CDCMirrorStatus{},
+			},
+		},
+		{
+			name: "not CDC",
+			resp: &pb.MirrorStatusResponse{
+				CurrentFlowState: pb.FlowStatus_STATUS_RUNNING,
+				CreatedAt:        at(time.Hour),
+				QrepStatus:       &pb.QRepMirrorStatus{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummarizeStatus("orders", tt.resp, now)
+			if got.Name != "orders" || got.State != tt.resp.CurrentFlowState || got.Status != tt.resp {
+				t.Errorf("got name %q, state %s, want orders, %s, and the response", got.Name, got.State, tt.resp.CurrentFlowState)
+			}
+			if got.RowsSynced != tt.wantRows {
+				t.Errorf("got rows synced %d, want %d", got.RowsSynced, tt.wantRows)
+			}
+			if got.RowsInHour != tt.wantRowsInHour {
+				t.Errorf("got rows in hour %d, want %d", got.RowsInHour, tt.wantRowsInHour)
+			}
+			if !got.LastActivity.Equal(tt.wantLastActivity) {
+				t.Errorf("got last activity %v, want %v", got.LastActivity, tt.wantLastActivity)
+			}
+			if got.Lag != tt.wantLag {
+				t.Errorf("got lag %s, want %s", got.Lag, tt.wantLag)
+			}
+		})
+	}
+}
+
+func TestLastSyncActivity(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		batches []*pb.CDCBatch
+		want    time.Time
+	}{
+		{"none", nil, time.Time{}},
+		{"without times", []*pb.CDCBatch{{NumRows: 10}}, time.Time{}},
+		{"latest end", []*pb.CDCBatch{
+			{EndTime: timestamppb.New(now.Add(-time.Minute))},
+			{EndTime: timestamppb.New(now.Add(-time.Hour))},
+		}, now.Add(-time.Minute)},
+		{"running batch", []*pb.CDCBatch{
+			{EndTime: timestamppb.New(now.Add(-time.Hour))},
+			{StartTime: timestamppb.New(now.Add(-time.Second))},
+		}, now.Add(-time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LastSyncActivity(tt.batches); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// PassphraseEnv names the environment variable bundles are encrypted with;
+// without it a random passphrase is generated
+const PassphraseEnv = "MIRROR_CLI_BUNDLE_PASSPHRASE"
+
+// iterations is the PBKDF2 iteration count of the encryption key
+const iterations = 100000
+
+// Archive collects files into a gzipped tar archive in memory
+type Archive struct {
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+// NewArchive starts an empty archive whose files are dated modTime
+func NewArchive(modTime time.Time) *Archive {
+	// Whole seconds, so the files aren't dated after the bundle was made
+	a := &Archive{modTime: modTime.Truncate(time.Second)}
+	a.gz = gzip.NewWriter(&a.buf)
+	a.tw = tar.NewWriter(a.gz)
+	return a
+}
+
+// Add adds a file to the archive
+func (a *Archive) Add(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: a.modTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := a.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
+
+// AddJSON adds v to the archive as indented JSON
+func (a *Archive) AddJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return a.Add(name, append(data, '\n'))
+}
+
+// AddProto adds a protobuf message to the archive as indented JSON
+func (a *Archive) AddProto(name string, m proto.Message) error {
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return a.Add(name, append(data, '\n'))
+}
+
+// Bytes finishes the archive and returns its contents
+func (a *Archive) Bytes() ([]byte, error) {
+	if err := a.tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := a.gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return a.buf.Bytes(), nil
+}
+
+// NewPassphrase generates a random passphrase
+func NewPassphrase() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate passphrase: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Encrypt encrypts data with a passphrase in the format of
+// `openssl enc -aes-256-cbc -pbkdf2 -iter 100000 -md sha256`, so whoever
+// receives a bundle can decrypt it without mirror_cli
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	derived := pbkdf2.Key([]byte(passphrase), salt, iterations, 32+aes.BlockSize, sha256.New)
+	block, err := aes.NewCipher(derived[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7 padding, as openssl expects
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := make([]byte, len(data), len(data)+padding)
+	copy(plain, data)
+	plain = append(plain, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	out := make([]byte, 16+len(plain))
+	copy(out, "Salted__")
+	copy(out[8:], salt)
+	cipher.NewCBCEncrypter(block, derived[32:]).CryptBlocks(out[16:], plain)
+	return out, nil
+}
+
+// DecryptCommand returns the shell command that decrypts and unpacks the
+// bundle at path
+func DecryptCommand(path string) string {
+	return fmt.Sprintf("openssl enc -d -aes-256-cbc -pbkdf2 -iter %d -md sha256 -in %s | tar xz", iterations, path)
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultTTL is how long cached responses are considered fresh
+const DefaultTTL = 30 * time.Second
+
+// Cache stores short-lived RPC responses on disk, namespaced per server
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// Dir returns the root directory used for cached responses
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mirror_cli", "cache"), nil
+}
+
+// New creates a cache for the given namespace (typically the server address)
+func New(namespace string, ttl time.Duration) (*Cache, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	replacer := strings.NewReplacer(":", "_", "/", "_", "\\", "_")
+	return &Cache{
+		dir: filepath.Join(root, replacer.Replace(namespace)),
+		ttl: ttl,
+	}, nil
+}
+
+// Get loads a cached response into m, reporting whether a fresh entry was found
+func (c *Cache) Get(key string, m proto.Message) bool {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.ttl {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	return protojson.Unmarshal(data, m) == nil
+}
+
+// Put stores a response under the given key
+func (c *Cache) Put(key string, m proto.Message) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Invalidate removes all entries in this cache's namespace
+func (c *Cache) Invalidate() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+// ClearAll removes every cached response for every server
+func ClearAll() error {
+	root, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(root); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
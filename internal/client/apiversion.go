@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/janakos/mirror_cli/internal/config"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+	"github.com/janakos/mirror_cli/proto/gen/legacy"
+)
+
+// currentAPISince is the first PeerDB release that speaks the current
+// FlowService API; older servers get the messages in proto/legacy
+var currentAPISince = [3]int{0, 15, 0}
+
+// API returns the FlowService API version spoken to the server: the pinned
+// peerdb_api, or for auto, the one matching the server's version. Servers
+// too old to report their version get the legacy API. It is looked up once
+// per client.
+func (c *Client) API(ctx context.Context) (string, error) {
+	c.apiMu.Lock()
+	defer c.apiMu.Unlock()
+	if c.api != "" {
+		return c.api, nil
+	}
+
+	switch c.config.PeerDBAPI {
+	case config.PeerDBAPICurrent, config.PeerDBAPILegacy:
+		c.api = c.config.PeerDBAPI
+		return c.api, nil
+	}
+
+	version, err := c.GetServerVersion(ctx)
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		c.api = config.PeerDBAPILegacy
+	case err != nil:
+		return "", fmt.Errorf("failed to detect the PeerDB API version (set peerdb_api to skip detection): %w", err)
+	default:
+		c.api = apiForVersion(version)
+	}
+	return c.api, nil
+}
+
+// apiForVersion returns the API of a PeerDB server version such as
+// "v0.14.3". Versions that aren't releases, e.g. development builds, are
+// assumed to be current.
+func apiForVersion(version string) string {
+	parsed, ok := parseVersion(version)
+	if !ok {
+		return config.PeerDBAPICurrent
+	}
+	for i := range parsed {
+		if parsed[i] != currentAPISince[i] {
+			if parsed[i] < currentAPISince[i] {
+				return config.PeerDBAPILegacy
+			}
+			break
+		}
+	}
+	return config.PeerDBAPICurrent
+}
+
+// parseVersion parses the major, minor, and patch numbers of a version,
+// ignoring a leading v and anything after the patch number
+func parseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		// e.g. "3-rc1" or "3+build"
+		if end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
+
+// flowStateChange sends a state change in the API the server speaks
+func (c *Client) flowStateChange(ctx context.Context, req *pb.FlowStateChangeRequest) error {
+	api, err := c.API(ctx)
+	if err != nil {
+		return err
+	}
+	if api != config.PeerDBAPILegacy {
+		_, err := c.flowClient.FlowStateChange(ctx, req)
+		return err
+	}
+
+	legacyReq, err := c.legacyFlowStateChange(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.conn.Invoke(ctx, pb.FlowService_FlowStateChange_FullMethodName, legacyReq, &pb.FlowStateChangeResponse{})
+}
+
+// legacyFlowStateChange converts a state change to the legacy message,
+// which carries the mirror's source and destination peers
+func (c *Client) legacyFlowStateChange(ctx context.Context, req *pb.FlowStateChangeRequest) (*legacy.FlowStateChangeRequest, error) {
+	if req.SkipDestinationDrop {
+		return nil, fmt.Errorf("PeerDB servers older than v%d.%d.%d always drop destination tables with the mirror; use drop_policy drop-destination or upgrade the server", currentAPISince[0], currentAPISince[1], currentAPISince[2])
+	}
+
+	mirror, err := c.GetMirrorStatus(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror peers: %w", err)
+	}
+	var sourceName, destinationName string
+	switch {
+	case mirror.CdcStatus.GetConfig() != nil:
+		sourceName, destinationName = mirror.CdcStatus.Config.SourceName, mirror.CdcStatus.Config.DestinationName
+	case mirror.QrepStatus.GetConfig() != nil:
+		sourceName, destinationName = mirror.QrepStatus.Config.SourceName, mirror.QrepStatus.Config.DestinationName
+	default:
+		return nil, fmt.Errorf("failed to get mirror peers: the server didn't return the mirror's configuration")
+	}
+
+	source, err := c.GetPeer(ctx, sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source peer: %w", err)
+	}
+	destination, err := c.GetPeer(ctx, destinationName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination peer: %w", err)
+	}
+
+	return &legacy.FlowStateChangeRequest{
+		FlowJobName:        req.FlowJobName,
+		RequestedFlowState: req.RequestedFlowState,
+		SourcePeer:         source,
+		DestinationPeer:    destination,
+		FlowConfigUpdate:   req.FlowConfigUpdate,
+		DropMirrorStats:    req.DropMirrorStats,
+	}, nil
+}
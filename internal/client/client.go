@@ -4,63 +4,288 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/janakos/mirror_cli/internal/cache"
 	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/telemetry"
 	pb "github.com/janakos/mirror_cli/proto/gen"
 )
 
+// dropWaitTimeout bounds how long ResyncMirror waits for the drop to
+// finish
+const dropWaitTimeout = 5 * time.Minute
+
+// endpointDialTimeout bounds each connection attempt unless
+// WithDialTimeout sets another timeout
+const endpointDialTimeout = 5 * time.Second
+
 // Client wraps the gRPC client with convenience methods
 type Client struct {
-	conn       *grpc.ClientConn
+	conn       *connection
 	flowClient pb.FlowServiceClient
 	config     *config.Config
+	cache      *cache.Cache
+
+	// api is the FlowService API version, looked up on first use
+	apiMu sync.Mutex
+	api   string
 }
 
-// NewClient creates a new PeerDB gRPC client
-func NewClient(cfg *config.Config) (*Client, error) {
-	var opts []grpc.DialOption
+// NewClient creates a new PeerDB gRPC client. ctx bounds connecting to
+// the server, not the client's lifetime; with WithLazyDial the client
+// connects on its first RPC instead.
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	o := options{dialTimeout: endpointDialTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := o.config
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+
+	var dialOpts []grpc.DialOption
 
 	// Set up credentials
-	if cfg.TLS {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	useTLS := cfg.TLS || o.tlsConfig != nil
+	if useTLS {
+		tlsConfig := o.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	} else {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if o.perRPC != nil {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(o.perRPC))
+	}
+
+	// Bound each connection attempt
+	dialOpts = append(dialOpts, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           backoff.DefaultConfig,
+		MinConnectTimeout: o.dialTimeout,
+	}))
+
+	// Trace RPCs when OpenTelemetry is configured
+	if telemetry.Enabled() {
+		dialOpts = append(dialOpts, telemetry.DialOption())
 	}
 
-	// Add timeout
-	opts = append(opts, grpc.WithTimeout(30*time.Second))
+	c := &Client{config: cfg}
+
+	// Print mutating RPCs instead of sending them
+	if cfg.Explain != nil {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(explainInterceptor(cfg.Explain, c.Endpoint, useTLS)))
+	}
+
+	// Refuse mutating RPCs in read-only mode
+	if cfg.ReadOnly {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(readOnlyInterceptor()))
+	}
+
+	// Record the RPCs actually sent, after explanations and refusals
+	if cfg.Record != nil {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(recordInterceptor(cfg.Record)))
+	}
+
+	// Bound each RPC by the read or mutate timeout
+	timeouts := cfg.Timeouts()
+	read, mutate := rpcTimeout{timeouts.Read, "read_timeout"}, rpcTimeout{timeouts.Mutate, "mutate_timeout"}
+	if o.rpcTimeout > 0 {
+		read = rpcTimeout{o.rpcTimeout, "--timeout"}
+		mutate = read
+	}
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(timeoutInterceptor(read, mutate)))
+
+	// Suggest similar names when a mirror or peer doesn't exist
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(nameInterceptor()))
+
+	// Tell RPCs the server is too old for from other failures
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(unsupportedInterceptor()))
+
+	// Imported contexts reference their secrets as ${VAR}
+	if err := cfg.ExpandCredentials(); err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+
+	// Credentials from a helper fill in what isn't configured
+	if cfg.CredentialHelper != "" {
+		if err := applyCredentialHelper(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Tag every RPC with a request ID, so server logs can be matched to a
+	// failing invocation; an x-request-id extra header replaces the
+	// generated one
+	if err := ValidateHeaders(cfg.ExtraHeaders); err != nil {
+		return nil, err
+	}
+	for name, value := range cfg.ExtraHeaders {
+		if strings.ToLower(name) == RequestIDHeader && value != "" {
+			cfg.RequestID = value
+		}
+	}
+	if cfg.RequestID == "" {
+		cfg.RequestID = NewRequestID()
+	}
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(metadataInterceptor(cfg.RequestID, cfg.ExtraHeaders)))
+
+	// The caller's interceptors see what is actually sent
+	if len(o.interceptors) > 0 {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(o.interceptors...))
+	}
 
 	// Connect to PeerDB
-	conn, err := grpc.Dial(cfg.Address(), opts...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to PeerDB at %s: %w", cfg.Address(), err)
+	endpoints := cfg.Endpoints()
+	c.conn = &connection{
+		dial: func(ctx context.Context) (*grpc.ClientConn, string, error) {
+			return dial(ctx, endpoints, dialOpts, o.dialTimeout, cfg.Warnings)
+		},
+	}
+	if !o.lazy {
+		if _, err := c.conn.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+	c.flowClient = pb.NewFlowServiceClient(c.conn)
+
+	// The response cache is best-effort; run uncached if it can't be set up
+	if !cfg.NoCache {
+		namespace := strings.Join(endpoints, ",")
+		if cfg.CurrentContext != "" {
+			namespace = cfg.CurrentContext + "@" + namespace
+		}
+		if respCache, err := cache.New(namespace, cache.DefaultTTL); err == nil {
+			c.cache = respCache
+		}
+	}
+
+	return c, nil
+}
+
+// dial connects to PeerDB. A single endpoint is dialed lazily; with several,
+// each is tried in order for up to timeout and the first that accepts a
+// connection is used.
+func dial(ctx context.Context, endpoints []string, opts []grpc.DialOption, timeout time.Duration, warnings io.Writer) (*grpc.ClientConn, string, error) {
+	if len(endpoints) == 1 {
+		conn, err := grpc.Dial(endpoints[0], opts...)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to connect to PeerDB at %s: %w", endpoints[0], err)
+		}
+		return conn, endpoints[0], nil
 	}
 
-	return &Client{
-		conn:       conn,
-		flowClient: pb.NewFlowServiceClient(conn),
-		config:     cfg,
-	}, nil
+	blocking := append(opts, grpc.WithBlock(), grpc.WithReturnConnectionError())
+	var failures []string
+	for _, endpoint := range endpoints {
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		conn, err := grpc.DialContext(dialCtx, endpoint, blocking...)
+		cancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", endpoint, err))
+			continue
+		}
+
+		if len(failures) > 0 && warnings != nil {
+			fmt.Fprintf(warnings, "Warning: PeerDB unreachable at %s; using %s\n", strings.Join(endpoints[:len(failures)], ", "), endpoint)
+		}
+		return conn, endpoint, nil
+	}
+
+	return nil, "", fmt.Errorf("failed to connect to any PeerDB endpoint:\n  %s", strings.Join(failures, "\n  "))
+}
+
+// Endpoint returns the address of the PeerDB server the client is
+// connected to, or "" if a lazily-dialed client hasn't connected yet
+func (c *Client) Endpoint() string {
+	return c.conn.endpointName()
 }
 
 // Close closes the gRPC connection
 func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
+	return c.conn.close()
 }
 
 // CreateCDCMirror creates a new CDC mirror
 func (c *Client) CreateCDCMirror(ctx context.Context, req *pb.CreateCDCFlowRequest) (*pb.CreateCDCFlowResponse, error) {
+	defer c.invalidateCache()
 	return c.flowClient.CreateCDCFlow(ctx, req)
 }
 
+// ResyncMirror re-creates a CDC mirror from config with a fresh
+// replication slot and initial snapshot. The mirror is dropped first,
+// keeping its destination tables, then created again with resync set,
+// which swaps in the new destination tables when the snapshot completes.
+func (c *Client) ResyncMirror(ctx context.Context, configs *pb.FlowConnectionConfigs) error {
+	defer c.invalidateCache()
+	if err := c.DropMirror(ctx, configs.FlowJobName, true); err != nil {
+		return fmt.Errorf("failed to drop mirror: %w", err)
+	}
+	if err := c.waitDropped(ctx, configs.FlowJobName); err != nil {
+		return fmt.Errorf("mirror dropped, but failed to wait for the drop to finish: %w", err)
+	}
+
+	configs = proto.Clone(configs).(*pb.FlowConnectionConfigs)
+	configs.Resync = true
+	configs.DoInitialSnapshot = true
+	_, err := c.flowClient.CreateCDCFlow(ctx, &pb.CreateCDCFlowRequest{ConnectionConfigs: configs})
+	return err
+}
+
+// waitDropped waits until a dropped mirror is no longer listed, since
+// PeerDB finishes dropping in the background. The response cache is
+// skipped.
+func (c *Client) waitDropped(ctx context.Context, mirrorName string) error {
+	ctx, cancel := context.WithTimeout(ctx, dropWaitTimeout)
+	defer cancel()
+	for {
+		resp, err := c.flowClient.ListMirrorNames(ctx, &pb.ListMirrorNamesRequest{})
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(resp.Names, mirrorName) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// CreateQRepMirror creates a new query replication mirror
+func (c *Client) CreateQRepMirror(ctx context.Context, req *pb.CreateQRepFlowRequest) (*pb.CreateQRepFlowResponse, error) {
+	defer c.invalidateCache()
+	return c.flowClient.CreateQRepFlow(ctx, req)
+}
+
+// ValidateCDCMirror runs the server-side checks for a CDC mirror (peer
+// connectivity, table existence, ...) without creating it
+func (c *Client) ValidateCDCMirror(ctx context.Context, req *pb.CreateCDCFlowRequest) error {
+	resp, err := c.flowClient.ValidateCDCMirror(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return fmt.Errorf("server rejected the mirror")
+	}
+	return nil
+}
+
 // ListMirrors lists all mirrors
 func (c *Client) ListMirrors(ctx context.Context) (*pb.ListMirrorsResponse, error) {
 	return c.flowClient.ListMirrors(ctx, &pb.ListMirrorsRequest{})
@@ -68,7 +293,34 @@ func (c *Client) ListMirrors(ctx context.Context) (*pb.ListMirrorsResponse, erro
 
 // ListMirrorNames lists all mirror names
 func (c *Client) ListMirrorNames(ctx context.Context) (*pb.ListMirrorNamesResponse, error) {
-	return c.flowClient.ListMirrorNames(ctx, &pb.ListMirrorNamesRequest{})
+	resp := &pb.ListMirrorNamesResponse{}
+	if c.cache != nil && c.cache.Get("mirror_names", resp) {
+		return resp, nil
+	}
+
+	resp, err := c.flowClient.ListMirrorNames(ctx, &pb.ListMirrorNamesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		c.cache.Put("mirror_names", resp)
+	}
+	return resp, nil
+}
+
+// MirrorExists reports whether a mirror with the given name exists
+func (c *Client) MirrorExists(ctx context.Context, mirrorName string) (bool, error) {
+	resp, err := c.ListMirrorNames(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range resp.Names {
+		if name == mirrorName {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // GetMirrorStatus gets the status of a specific mirror
@@ -87,8 +339,7 @@ func (c *Client) PauseMirror(ctx context.Context, mirrorName string) error {
 		FlowJobName:        mirrorName,
 		RequestedFlowState: pb.FlowStatus_STATUS_PAUSED,
 	}
-	_, err := c.flowClient.FlowStateChange(ctx, req)
-	return err
+	return c.flowStateChange(ctx, req)
 }
 
 // ResumeMirror resumes a mirror
@@ -97,8 +348,39 @@ func (c *Client) ResumeMirror(ctx context.Context, mirrorName string) error {
 		FlowJobName:        mirrorName,
 		RequestedFlowState: pb.FlowStatus_STATUS_RUNNING,
 	}
-	_, err := c.flowClient.FlowStateChange(ctx, req)
-	return err
+	return c.flowStateChange(ctx, req)
+}
+
+// ResumeMirrorWithEnv resumes a mirror, setting env entries of a CDC mirror
+// in the same request
+func (c *Client) ResumeMirrorWithEnv(ctx context.Context, mirrorName string, env map[string]string) error {
+	req := &pb.FlowStateChangeRequest{
+		FlowJobName:        mirrorName,
+		RequestedFlowState: pb.FlowStatus_STATUS_RUNNING,
+		FlowConfigUpdate: &pb.FlowConfigUpdate{
+			CdcFlowConfigUpdate: &pb.CDCFlowConfigUpdate{UpdatedEnv: env},
+		},
+	}
+	return c.flowStateChange(ctx, req)
+}
+
+// UpdatePausedMirrorEnv sets env entries of a paused CDC mirror without
+// resuming it
+func (c *Client) UpdatePausedMirrorEnv(ctx context.Context, mirrorName string, env map[string]string) error {
+	return c.UpdatePausedMirror(ctx, mirrorName, &pb.FlowConfigUpdate{
+		CdcFlowConfigUpdate: &pb.CDCFlowConfigUpdate{UpdatedEnv: env},
+	})
+}
+
+// UpdatePausedMirror applies a configuration update to a paused mirror,
+// leaving it paused
+func (c *Client) UpdatePausedMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error {
+	req := &pb.FlowStateChangeRequest{
+		FlowJobName:        mirrorName,
+		RequestedFlowState: pb.FlowStatus_STATUS_PAUSED,
+		FlowConfigUpdate:   update,
+	}
+	return c.flowStateChange(ctx, req)
 }
 
 // DropMirror terminates and drops a mirror
@@ -109,11 +391,11 @@ func (c *Client) DropMirror(ctx context.Context, mirrorName string, skipDestinat
 		DropMirrorStats:     true,
 		SkipDestinationDrop: skipDestinationDrop,
 	}
-	_, err := c.flowClient.FlowStateChange(ctx, req)
-	return err
+	defer c.invalidateCache()
+	return c.flowStateChange(ctx, req)
 }
 
-// UpdateMirror updates mirror configuration
+// UpdateMirror pauses a mirror, updates its configuration, and resumes it
 func (c *Client) UpdateMirror(ctx context.Context, mirrorName string, update *pb.FlowConfigUpdate) error {
 	// First pause the mirror
 	if err := c.PauseMirror(ctx, mirrorName); err != nil {
@@ -121,13 +403,7 @@ func (c *Client) UpdateMirror(ctx context.Context, mirrorName string, update *pb
 	}
 
 	// Apply the update
-	req := &pb.FlowStateChangeRequest{
-		FlowJobName:        mirrorName,
-		RequestedFlowState: pb.FlowStatus_STATUS_PAUSED,
-		FlowConfigUpdate:   update,
-	}
-
-	if _, err := c.flowClient.FlowStateChange(ctx, req); err != nil {
+	if err := c.UpdatePausedMirror(ctx, mirrorName, update); err != nil {
 		return fmt.Errorf("failed to update mirror configuration: %w", err)
 	}
 
@@ -141,7 +417,96 @@ func (c *Client) UpdateMirror(ctx context.Context, mirrorName string, update *pb
 
 // ListPeers lists all peers
 func (c *Client) ListPeers(ctx context.Context) (*pb.ListPeersResponse, error) {
-	return c.flowClient.ListPeers(ctx, &pb.ListPeersRequest{})
+	resp := &pb.ListPeersResponse{}
+	if c.cache != nil && c.cache.Get("peers", resp) {
+		return resp, nil
+	}
+
+	resp, err := c.flowClient.ListPeers(ctx, &pb.ListPeersRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		c.cache.Put("peers", resp)
+	}
+	return resp, nil
+}
+
+// PeerExists reports whether a peer with the given name exists
+func (c *Client) PeerExists(ctx context.Context, peerName string) (bool, error) {
+	resp, err := c.ListPeers(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, peer := range resp.Items {
+		if peer.Name == peerName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetPeer gets the configuration of a specific peer
+func (c *Client) GetPeer(ctx context.Context, peerName string) (*pb.Peer, error) {
+	resp, err := c.flowClient.GetPeerInfo(ctx, &pb.PeerInfoRequest{PeerName: peerName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Peer, nil
+}
+
+// ListSlots lists the replication slots on a PostgreSQL peer
+func (c *Client) ListSlots(ctx context.Context, peerName string) ([]*pb.SlotInfo, error) {
+	resp, err := c.flowClient.GetSlotInfo(ctx, &pb.PostgresPeerActivityInfoRequest{PeerName: peerName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.SlotData, nil
+}
+
+// ListPublications lists the publications on a PostgreSQL peer
+func (c *Client) ListPublications(ctx context.Context, peerName string) ([]string, error) {
+	resp, err := c.flowClient.GetPublications(ctx, &pb.PostgresPeerActivityInfoRequest{PeerName: peerName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.PublicationNames, nil
+}
+
+// ListSchemas lists the schemas of a peer
+func (c *Client) ListSchemas(ctx context.Context, peerName string) ([]string, error) {
+	resp, err := c.flowClient.GetSchemas(ctx, &pb.PostgresPeerActivityInfoRequest{PeerName: peerName})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Schemas, nil
+}
+
+// ListTables lists the tables in a schema of a peer, with their sizes. With
+// cdcEnabled, CanMirror is only set on tables CDC can replicate: those with
+// a primary key or REPLICA IDENTITY FULL.
+func (c *Client) ListTables(ctx context.Context, peerName, schema string, cdcEnabled bool) ([]*pb.TableResponse, error) {
+	resp, err := c.flowClient.GetTablesInSchema(ctx, &pb.SchemaTablesRequest{PeerName: peerName, SchemaName: schema, CdcEnabled: cdcEnabled})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tables, nil
+}
+
+// GetTableRowCounts gets the number of rows a mirror has synced, in total
+// and per table
+func (c *Client) GetTableRowCounts(ctx context.Context, mirrorName string) (*pb.CDCTableTotalCountsResponse, error) {
+	return c.flowClient.CDCTableTotalCounts(ctx, &pb.CDCTableTotalCountsRequest{FlowJobName: mirrorName})
+}
+
+// GetServerVersion returns the version of the PeerDB server
+func (c *Client) GetServerVersion(ctx context.Context) (string, error) {
+	resp, err := c.flowClient.GetVersion(ctx, &pb.PeerDBVersionRequest{})
+	if err != nil {
+		return "", err
+	}
+	return resp.Version, nil
 }
 
 // CreatePeer creates a new peer
@@ -150,6 +515,7 @@ func (c *Client) CreatePeer(ctx context.Context, peer *pb.Peer, allowUpdate bool
 		Peer:        peer,
 		AllowUpdate: allowUpdate,
 	}
+	defer c.invalidateCache()
 	return c.flowClient.CreatePeer(ctx, req)
 }
 
@@ -158,6 +524,7 @@ func (c *Client) DropPeer(ctx context.Context, peerName string) error {
 	req := &pb.DropPeerRequest{
 		PeerName: peerName,
 	}
+	defer c.invalidateCache()
 	_, err := c.flowClient.DropPeer(ctx, req)
 	return err
 }
@@ -169,3 +536,19 @@ func (c *Client) ValidatePeer(ctx context.Context, peer *pb.Peer) (*pb.ValidateP
 	}
 	return c.flowClient.ValidatePeer(ctx, req)
 }
+
+// invalidateCache drops cached list responses after a mutating call
+func (c *Client) invalidateCache() {
+	if c.cache != nil {
+		c.cache.Invalidate()
+	}
+}
+
+// Invoke sends a request to a FlowService method by its full name, e.g.
+// "/peerdb_route.FlowService/ListPeers"
+func (c *Client) Invoke(ctx context.Context, method string, req, reply proto.Message) error {
+	if !IsLookup(method) {
+		defer c.invalidateCache()
+	}
+	return c.conn.Invoke(ctx, method, req, reply)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// connection is a gRPC connection dialed on first use. A failed dial is
+// retried by the next call.
+type connection struct {
+	dial func(ctx context.Context) (*grpc.ClientConn, string, error)
+
+	mu       sync.Mutex
+	conn     *grpc.ClientConn
+	endpoint string
+	closed   bool
+}
+
+// errClosed is returned by RPCs on a closed client
+var errClosed = errors.New("client is closed")
+
+// get returns the connection, dialing it if needed
+func (c *connection) get(ctx context.Context) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, errClosed
+	}
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	conn, endpoint, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.conn, c.endpoint = conn, endpoint
+	return conn, nil
+}
+
+// Invoke implements grpc.ClientConnInterface
+func (c *connection) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface
+func (c *connection) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
+
+// endpointName returns the endpoint connected to, or "" before dialing
+func (c *connection) endpointName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.endpoint
+}
+
+// close closes the connection if it was dialed
+func (c *connection) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
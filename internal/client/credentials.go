@@ -0,0 +1,37 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/config"
+	"github.com/janakos/mirror_cli/internal/credhelper"
+)
+
+// applyCredentialHelper asks the configured credential helper for server
+// credentials. Explicitly configured values take precedence, and headers
+// from the helper are sent with every RPC like extra headers.
+func applyCredentialHelper(cfg *config.Config) error {
+	resp, err := credhelper.New(cfg.CredentialHelper).Server(cfg.CurrentContext, cfg.Endpoints())
+	if err != nil {
+		return fmt.Errorf("failed to get server credentials: %w", err)
+	}
+
+	if cfg.Username == "" {
+		cfg.Username = resp.Username
+	}
+	if cfg.Password == "" {
+		cfg.Password = resp.Password
+	}
+	if len(resp.Headers) > 0 {
+		headers := make(map[string]string, len(resp.Headers)+len(cfg.ExtraHeaders))
+		for name, value := range resp.Headers {
+			headers[strings.ToLower(name)] = value
+		}
+		for name, value := range cfg.ExtraHeaders {
+			headers[strings.ToLower(name)] = value
+		}
+		cfg.ExtraHeaders = headers
+	}
+	return nil
+}
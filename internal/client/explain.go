@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExplainedCall describes an RPC that was printed instead of sent
+type ExplainedCall struct {
+	Method  string          `json:"method"`
+	Request json.RawMessage `json:"request"`
+	Grpcurl string          `json:"grpcurl"`
+}
+
+// explainInterceptor writes each mutating RPC to w as a JSON line and
+// returns an empty response without sending it. Lookups (Get*, List*,
+// MirrorStatus, ...) are still sent, since commands build their requests from
+// them.
+func explainInterceptor(w io.Writer, address func() string, useTLS bool) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if IsLookup(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return fmt.Errorf("cannot explain %s: request is not a protobuf message", method)
+		}
+
+		call, err := explainCall(method, msg, address(), useTLS)
+		if err != nil {
+			return err
+		}
+
+		line, err := json.Marshal(call)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", method, err)
+		}
+		_, err = fmt.Fprintln(w, string(line))
+		return err
+	}
+}
+
+// explainCall builds the description of an RPC, including an equivalent
+// grpcurl command
+func explainCall(method string, req proto.Message, address string, useTLS bool) (*ExplainedCall, error) {
+	data, err := protojson.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	// protojson output has unstable whitespace
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	args := []string{"grpcurl"}
+	if !useTLS {
+		args = append(args, "-plaintext")
+	}
+	args = append(args,
+		"-import-path", "proto", "-proto", "route.proto",
+		"-d", shellQuote(compact.String()),
+		address, strings.TrimPrefix(method, "/"),
+	)
+
+	return &ExplainedCall{
+		Method:  method,
+		Request: compact.Bytes(),
+		Grpcurl: strings.Join(args, " "),
+	}, nil
+}
+
+// readOnlyMethods are lookups whose names don't start with Get or List
+var readOnlyMethods = map[string]bool{
+	"MirrorStatus":        true,
+	"CDCTableTotalCounts": true,
+	"ValidateCDCMirror":   true,
+	"ValidatePeer":        true,
+}
+
+// IsLookup reports whether a full method name is a read-only RPC
+func IsLookup(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List") || readOnlyMethods[name]
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// flowService is the descriptor of the FlowService the CLI was built with
+var flowService = pb.File_route_proto.Services().ByName("FlowService")
+
+// FindMethod looks up a FlowService method. name may be the bare method
+// name or include the service, e.g. "FlowService/ListPeers" or
+// "/peerdb_route.FlowService/ListPeers".
+func FindMethod(name string) (protoreflect.MethodDescriptor, error) {
+	trimmed := strings.TrimPrefix(name, "/")
+	methodName := trimmed
+	if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+		service := trimmed[:i]
+		if service != string(flowService.Name()) && service != string(flowService.FullName()) {
+			return nil, fmt.Errorf("unknown service %q; only %s is supported", service, flowService.Name())
+		}
+		methodName = trimmed[i+1:]
+	}
+
+	method := flowService.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("unknown method %q; available methods: %s", methodName, strings.Join(MethodNames(), ", "))
+	}
+	return method, nil
+}
+
+// MethodNames returns the names of the FlowService methods, sorted
+func MethodNames() []string {
+	methods := flowService.Methods()
+	names := make([]string, 0, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		names = append(names, string(methods.Get(i).Name()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FullMethodName returns the gRPC path of a method, as used by Invoke
+func FullMethodName(method protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+}
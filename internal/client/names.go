@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// maxSuggestions is the number of similar names offered for an unknown name
+const maxSuggestions = 3
+
+// NameError is returned when an RPC fails because a mirror or peer name
+// doesn't exist or is already taken. Unknown names come with the closest
+// existing names as suggestions.
+type NameError struct {
+	Kind        string
+	Name        string
+	Code        codes.Code
+	Suggestions []string
+
+	err error
+}
+
+func (e *NameError) Error() string {
+	if e.Code == codes.AlreadyExists {
+		return fmt.Sprintf("%s '%s' already exists", e.Kind, e.Name)
+	}
+
+	msg := fmt.Sprintf("%s '%s' not found", e.Kind, e.Name)
+	if len(e.Suggestions) > 0 {
+		quoted := make([]string, len(e.Suggestions))
+		for i, name := range e.Suggestions {
+			quoted[i] = "'" + name + "'"
+		}
+		msg += fmt.Sprintf("; did you mean %s?", strings.Join(quoted, " or "))
+	}
+	return msg
+}
+
+func (e *NameError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus keeps status.Code working on wrapped errors
+func (e *NameError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// nameInterceptor turns NotFound and AlreadyExists errors of RPCs that name
+// a mirror or peer into NameErrors
+func nameInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		code := status.Code(err)
+		if code != codes.NotFound && code != codes.AlreadyExists {
+			return err
+		}
+
+		kind, name := requestName(req, code)
+		if name == "" {
+			return err
+		}
+
+		nameErr := &NameError{Kind: kind, Name: name, Code: code, err: err}
+		if code == codes.NotFound {
+			if names, listErr := listNames(ctx, kind, cc, invoker); listErr == nil {
+				nameErr.Suggestions = closestNames(name, names, maxSuggestions)
+			}
+		}
+		return nameErr
+	}
+}
+
+// requestName returns the kind and name of the resource a request refers
+// to. Creations only name the resource for AlreadyExists, since NotFound
+// there refers to a peer the mirror uses.
+func requestName(req interface{}, code codes.Code) (string, string) {
+	switch r := req.(type) {
+	case *pb.MirrorStatusRequest:
+		return "mirror", r.FlowJobName
+	case *pb.FlowStateChangeRequest:
+		return "mirror", r.FlowJobName
+	case *pb.CDCTableTotalCountsRequest:
+		return "mirror", r.FlowJobName
+	case *pb.CreateCDCFlowRequest:
+		if code == codes.AlreadyExists {
+			return "mirror", r.ConnectionConfigs.GetFlowJobName()
+		}
+	case *pb.PeerInfoRequest:
+		return "peer", r.PeerName
+	case *pb.DropPeerRequest:
+		return "peer", r.PeerName
+	case *pb.PostgresPeerActivityInfoRequest:
+		return "peer", r.PeerName
+	case *pb.SchemaTablesRequest:
+		return "peer", r.PeerName
+	case *pb.CreatePeerRequest:
+		if code == codes.AlreadyExists {
+			return "peer", r.Peer.GetName()
+		}
+	}
+	return "", ""
+}
+
+// listNames lists the existing mirror or peer names
+func listNames(ctx context.Context, kind string, cc *grpc.ClientConn, invoker grpc.UnaryInvoker) ([]string, error) {
+	if kind == "mirror" {
+		resp := &pb.ListMirrorNamesResponse{}
+		if err := invoker(ctx, pb.FlowService_ListMirrorNames_FullMethodName, &pb.ListMirrorNamesRequest{}, resp, cc); err != nil {
+			return nil, err
+		}
+		return resp.Names, nil
+	}
+
+	resp := &pb.ListPeersResponse{}
+	if err := invoker(ctx, pb.FlowService_ListPeers_FullMethodName, &pb.ListPeersRequest{}, resp, cc); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(resp.Items))
+	for _, peer := range resp.Items {
+		names = append(names, peer.Name)
+	}
+	return names, nil
+}
+
+// closestNames returns up to limit names similar to name: those within a
+// small edit distance, or containing it, closest first
+func closestNames(name string, names []string, limit int) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	target := strings.ToLower(name)
+	maxDistance := len(target) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var candidates []candidate
+	for _, existing := range names {
+		lower := strings.ToLower(existing)
+		distance := editDistance(target, lower)
+		if distance <= maxDistance || strings.Contains(lower, target) || strings.Contains(target, lower) {
+			candidates = append(candidates, candidate{existing, distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var result []string
+	for _, c := range candidates {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, c.name)
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
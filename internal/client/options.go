@@ -0,0 +1,84 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// Option configures a Client created by NewClient
+type Option func(*options)
+
+type options struct {
+	config       *config.Config
+	tlsConfig    *tls.Config
+	perRPC       credentials.PerRPCCredentials
+	dialTimeout  time.Duration
+	rpcTimeout   time.Duration
+	interceptors []grpc.UnaryClientInterceptor
+	lazy         bool
+}
+
+// WithConfig sets the CLI configuration the client connects with: the
+// server endpoints, TLS, headers, and behaviors such as read-only mode.
+// Without it the client uses config.DefaultConfig.
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithTLSConfig connects over TLS with tlsConfig, e.g. for client
+// certificates or a private CA, even if the configuration doesn't set tls
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithPerRPCCredentials attaches creds, such as OAuth tokens, to every RPC
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(o *options) {
+		o.perRPC = creds
+	}
+}
+
+// WithDialTimeout bounds each attempt to connect to an endpoint. It
+// defaults to 5 seconds.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithRPCTimeout bounds every RPC by timeout instead of the configured
+// read_timeout and mutate_timeout, for a command's --timeout flag; zero
+// keeps the configured ones
+func WithRPCTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.rpcTimeout = timeout
+	}
+}
+
+// WithInterceptors adds unary interceptors to every RPC. They run after
+// the client's own, so they see the request ID header and only RPCs that
+// are actually sent, e.g. not those refused in read-only mode.
+func WithInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// WithLazyDial defers connecting until the first RPC, so a client can be
+// created while the server is down, e.g. by a long-running process that
+// starts before PeerDB.
+// A failed connection is retried on the next RPC.
+func WithLazyDial() Option {
+	return func(o *options) {
+		o.lazy = true
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// ErrReadOnly is returned for mutating RPCs when the client is read-only
+var ErrReadOnly = errors.New("read-only mode")
+
+// readOnlyInterceptor refuses every RPC that isn't a lookup
+func readOnlyInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !IsLookup(method) {
+			return fmt.Errorf("%w: %s is not allowed", ErrReadOnly, method)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
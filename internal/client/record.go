@@ -0,0 +1,17 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// recordInterceptor passes every RPC sent, with its response or error, to
+// record
+func recordInterceptor(record func(method string, req, reply interface{}, err error)) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		record(method, req, reply, err)
+		return err
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the metadata key carrying the request ID
+const RequestIDHeader = "x-request-id"
+
+// RequestError is an RPC error annotated with the request ID that was sent,
+// so it can be found in server logs. Its message is the underlying error's.
+type RequestError struct {
+	RequestID string
+
+	err error
+}
+
+func (e *RequestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus keeps status.Code working on wrapped errors
+func (e *RequestError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// NewRequestID returns a random UUID (version 4)
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ValidateHeaders checks that extra headers can be sent as gRPC metadata
+func ValidateHeaders(headers map[string]string) error {
+	for name := range headers {
+		key := strings.ToLower(name)
+		switch {
+		case key == "":
+			return fmt.Errorf("invalid header: empty name")
+		case strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":"):
+			return fmt.Errorf("invalid header '%s': names starting with 'grpc-' or ':' are reserved", name)
+		case strings.IndexFunc(key, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+		}) >= 0:
+			return fmt.Errorf("invalid header '%s': names may only contain letters, digits, '-', '_', and '.'", name)
+		}
+	}
+	return nil
+}
+
+// metadataInterceptor sends the request ID and extra headers with every RPC
+// and annotates errors with the request ID
+func metadataInterceptor(requestID string, headers map[string]string) grpc.UnaryClientInterceptor {
+	pairs := []string{RequestIDHeader, requestID}
+	for name, value := range headers {
+		if key := strings.ToLower(name); key != RequestIDHeader {
+			pairs = append(pairs, key, value)
+		}
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return &RequestError{RequestID: requestID, err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// TimeoutError is returned for an RPC that ran out of its read or mutate
+// timeout, naming the setting that raises it
+type TimeoutError struct {
+	// Method is the RPC's name, e.g. CreateCDCFlow
+	Method  string
+	Timeout time.Duration
+	// Setting is read_timeout, mutate_timeout, or the command's --timeout
+	Setting string
+
+	err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s (raise %s to wait longer)", e.Method, e.Timeout, e.Setting)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus keeps status.Code working on wrapped errors
+func (e *TimeoutError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// rpcTimeout is the limit on a kind of RPC and the setting that changes it
+type rpcTimeout struct {
+	timeout time.Duration
+	setting string
+}
+
+// timeoutInterceptor bounds each lookup by read and every other RPC by
+// mutate; zero leaves the RPC to the caller's context
+func timeoutInterceptor(read, mutate rpcTimeout) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		timeout, setting := mutate.timeout, mutate.setting
+		if IsLookup(method) {
+			timeout, setting = read.timeout, read.setting
+		}
+		if timeout <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		rpcCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err := invoker(rpcCtx, method, req, reply, cc, opts...)
+		// Only blame the timeout when the caller's context is still live
+		if err != nil && ctx.Err() == nil && errors.Is(rpcCtx.Err(), context.DeadlineExceeded) {
+			return &TimeoutError{Method: method[strings.LastIndex(method, "/")+1:], Timeout: timeout, Setting: setting, err: err}
+		}
+		return err
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// TestTimeoutInterceptor checks that lookups get the read timeout and other
+// RPCs the mutate timeout, and that a TimeoutError is only returned when
+// the RPC's own timeout ran out
+func TestTimeoutInterceptor(t *testing.T) {
+	read := rpcTimeout{timeout: time.Minute, setting: "read_timeout"}
+	mutate := rpcTimeout{timeout: 2 * time.Hour, setting: "mutate_timeout"}
+
+	tests := []struct {
+		method  string
+		timeout time.Duration
+	}{
+		{"/peerdb_route.FlowService/ListMirrors", time.Minute},
+		{"/peerdb_route.FlowService/GetPeerInfo", time.Minute},
+		{"/peerdb_route.FlowService/MirrorStatus", time.Minute},
+		{"/peerdb_route.FlowService/CreateCDCFlow", 2 * time.Hour},
+		{"/peerdb_route.FlowService/FlowStateChange", 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var remaining time.Duration
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				deadline, ok := ctx.Deadline()
+				if !ok {
+					t.Fatal("RPC has no deadline")
+				}
+				remaining = time.Until(deadline)
+				return nil
+			}
+			if err := timeoutInterceptor(read, mutate)(context.Background(), tt.method, nil, nil, nil, invoker); err != nil {
+				t.Fatal(err)
+			}
+			if remaining > tt.timeout || remaining < tt.timeout-time.Second {
+				t.Errorf("deadline in %s, want %s", remaining, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestTimeoutInterceptorErrors(t *testing.T) {
+	short := rpcTimeout{timeout: 10 * time.Millisecond, setting: "read_timeout"}
+	waitForDeadline := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("rpc timeout", func(t *testing.T) {
+		err := timeoutInterceptor(short, short)(context.Background(), "/peerdb_route.FlowService/ListMirrors", nil, nil, nil, waitForDeadline)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("got %v, want a TimeoutError", err)
+		}
+		if timeoutErr.Method != "ListMirrors" || timeoutErr.Setting != "read_timeout" {
+			t.Errorf("got method %q and setting %q", timeoutErr.Method, timeoutErr.Setting)
+		}
+	})
+
+	t.Run("caller timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		long := rpcTimeout{timeout: time.Hour, setting: "read_timeout"}
+		err := timeoutInterceptor(long, long)(ctx, "/peerdb_route.FlowService/ListMirrors", nil, nil, nil, waitForDeadline)
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want the caller's deadline error", err)
+		}
+	})
+
+	t.Run("caller cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := timeoutInterceptor(short, short)(ctx, "/peerdb_route.FlowService/ListMirrors", nil, nil, nil, waitForDeadline)
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) || !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want the caller's cancellation", err)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		none := rpcTimeout{}
+		invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("RPC has a deadline")
+			}
+			return nil
+		}
+		if err := timeoutInterceptor(none, none)(context.Background(), "/peerdb_route.FlowService/CreateCDCFlow", nil, nil, nil, invoker); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
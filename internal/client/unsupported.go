@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnsupportedError is returned for RPCs the server doesn't implement,
+// usually because it predates them. Commands can leave out what needs
+// the RPC and show the rest.
+type UnsupportedError struct {
+	// Method is the RPC's name, e.g. GetSlotInfo
+	Method string
+
+	err error
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by this PeerDB version", e.Method)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus keeps status.Code working on wrapped errors
+func (e *UnsupportedError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// IsUnsupported reports whether err is from an RPC the server doesn't
+// implement
+func IsUnsupported(err error) bool {
+	var unsupported *UnsupportedError
+	return errors.As(err, &unsupported) || status.Code(err) == codes.Unimplemented
+}
+
+// unsupportedInterceptor turns Unimplemented errors into UnsupportedErrors
+func unsupportedInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) != codes.Unimplemented {
+			return err
+		}
+		return &UnsupportedError{Method: method[strings.LastIndex(method, "/")+1:], err: err}
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/janakos/mirror_cli/internal/provenance"
+)
+
+// secretKeys are the peer config fields that are always written as ${VAR}
+// placeholders
+var secretKeys = map[string]bool{
+	"password":    true,
+	"private_key": true,
+}
+
+// IsSecretField reports whether a dotted field path names a secret: a
+// secret peer config field such as spec.config.password, or a field whose
+// name suggests a credential, such as spec.env.API_TOKEN
+func IsSecretField(path string) bool {
+	name := path[strings.LastIndex(path, ".")+1:]
+	return secretKeys[name] || secretHeader(name)
+}
+
+// MarshalCanonical serializes a configuration as canonical YAML, so that
+// exporting the same resource twice produces identical files:
+//
+//   - fields are written in schema order and map keys are sorted
+//   - tables are sorted by source and excluded columns by name
+//   - provenance annotations (applied_at, applied_by, ...) are dropped
+//   - secrets are written as ${VAR} placeholders
+//   - strings are only quoted when they have to be, always with double
+//     quotes, and multi-line strings use literal blocks
+func MarshalCanonical(config *FileConfig) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return nil, err
+	}
+
+	if spec := mappingValue(&doc, "spec"); spec != nil {
+		canonicalizeSpec(spec, config.Metadata.Name)
+	}
+	canonicalizeScalars(&doc)
+
+	return encodeDocument(&doc)
+}
+
+// canonicalizeSpec normalizes the parts of a spec whose order or content
+// varies between exports of the same resource
+func canonicalizeSpec(spec *yaml.Node, name string) {
+	if tables := mappingValue(spec, "tables"); tables != nil && tables.Kind == yaml.SequenceNode {
+		sort.SliceStable(tables.Content, func(i, j int) bool {
+			return scalarValue(tables.Content[i], "source") < scalarValue(tables.Content[j], "source")
+		})
+		for _, table := range tables.Content {
+			if columns := mappingValue(table, "exclude_columns"); columns != nil && columns.Kind == yaml.SequenceNode {
+				sort.SliceStable(columns.Content, func(i, j int) bool {
+					return columns.Content[i].Value < columns.Content[j].Value
+				})
+			}
+		}
+	}
+
+	if env := mappingValue(spec, "env"); env != nil && env.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(env.Content); {
+			if strings.HasPrefix(env.Content[i].Value, provenance.Prefix) {
+				env.Content = append(env.Content[:i], env.Content[i+2:]...)
+				continue
+			}
+			i += 2
+		}
+		if len(env.Content) == 0 {
+			deleteKey(spec, "env")
+		}
+	}
+
+	if peerConfig := mappingValue(spec, "config"); peerConfig != nil && peerConfig.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(peerConfig.Content); i += 2 {
+			key, value := peerConfig.Content[i].Value, peerConfig.Content[i+1]
+			if secretKeys[key] && value.Kind == yaml.ScalarNode && value.Value != "" && !isPlaceholder(value.Value) {
+				value.Value = secretPlaceholder(name, strings.ToUpper(key))
+			}
+		}
+	}
+}
+
+// canonicalizeScalars applies one quoting style to every string value
+func canonicalizeScalars(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		switch {
+		case strings.Contains(node.Value, "\n"):
+			node.Style = yaml.LiteralStyle
+		case node.Style != 0 || isPlaceholder(node.Value):
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+	for _, child := range node.Content {
+		canonicalizeScalars(child)
+	}
+}
+
+// isPlaceholder reports whether a value is an environment variable
+// placeholder such as ${MY_PEER_PASSWORD}
+func isPlaceholder(value string) bool {
+	return strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}")
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// personalKeys are config.yaml settings that belong to one user and are
+// never exported
+var personalKeys = []string{"current_context"}
+
+// ConfigPath returns the path of the CLI's own configuration file
+func ConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mirror_cli", "config.yaml"), nil
+}
+
+// ExportCLIConfig returns the CLI configuration file at path for sharing
+// with a team, without personal settings such as the current context.
+// contexts, if given, limits the contexts exported. Secrets, i.e.
+// passwords and secret headers, are left out with redact and otherwise
+// kept; their dotted paths are returned either way.
+func ExportCLIConfig(path string, contexts []string, redact bool) ([]byte, []string, error) {
+	_, doc, err := readDocument(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	for _, key := range personalKeys {
+		deleteKey(root, key)
+	}
+
+	if len(contexts) > 0 {
+		all := mappingValue(root, "contexts")
+		wanted := make([]string, len(contexts))
+		for i, name := range contexts {
+			wanted[i] = strings.ToLower(name)
+			if mappingValue(all, wanted[i]) == nil {
+				return nil, nil, fmt.Errorf("context %q not found", name)
+			}
+		}
+		for i := 0; all != nil && i+1 < len(all.Content); {
+			if !slices.Contains(wanted, all.Content[i].Value) {
+				all.Content = append(all.Content[:i], all.Content[i+2:]...)
+				continue
+			}
+			i += 2
+		}
+	}
+
+	secrets := settingSecrets(root, "", redact)
+	if ctxs := mappingValue(root, "contexts"); ctxs != nil && ctxs.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(ctxs.Content); i += 2 {
+			secrets = append(secrets, settingSecrets(ctxs.Content[i+1], "contexts."+ctxs.Content[i].Value+".", redact)...)
+		}
+	}
+
+	data, err := encodeDocument(doc)
+	return data, secrets, err
+}
+
+// settingSecrets returns the paths of the password and secret headers in
+// a mapping of settings, removing them with redact
+func settingSecrets(settings *yaml.Node, prefix string, redact bool) []string {
+	var secrets []string
+	if password := mappingValue(settings, "password"); password != nil && password.Value != "" {
+		secrets = append(secrets, prefix+"password")
+		if redact {
+			deleteKey(settings, "password")
+		}
+	}
+	headers := mappingValue(settings, "extra_headers")
+	if headers == nil || headers.Kind != yaml.MappingNode {
+		return secrets
+	}
+	for i := 0; i+1 < len(headers.Content); {
+		name := headers.Content[i].Value
+		if secretHeader(name) {
+			secrets = append(secrets, prefix+"extra_headers."+name)
+			if redact {
+				headers.Content = append(headers.Content[:i], headers.Content[i+2:]...)
+				continue
+			}
+		}
+		i += 2
+	}
+	return secrets
+}
+
+// secretHeader reports whether a header name suggests a credential
+func secretHeader(name string) bool {
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	for _, word := range []string{"authorization", "cookie", "token", "secret", "password", "apikey"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeReport lists, by dotted path, what importing a CLI configuration
+// changed
+type MergeReport struct {
+	Added []string
+	// Kept are settings whose local value differs from the import and was
+	// kept
+	Kept []string
+	// Replaced are settings whose local value the import replaced
+	Replaced []string
+}
+
+// ExecSettingsError is returned when an imported configuration sets
+// commands the CLI runs, hooks and credential helpers, and importing them
+// wasn't allowed
+type ExecSettingsError struct {
+	// Settings are the dotted paths of the commands
+	Settings []string
+}
+
+func (e *ExecSettingsError) Error() string {
+	return fmt.Sprintf("the configuration sets commands the CLI would run: %s", strings.Join(e.Settings, ", "))
+}
+
+// ExecSettings returns the dotted paths of the commands a CLI configuration
+// sets, at the top level and in each context
+func ExecSettings(data []byte) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	settings := settingCommands(root, "")
+	if contexts := mappingValue(root, "contexts"); contexts != nil && contexts.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(contexts.Content); i += 2 {
+			settings = append(settings, settingCommands(contexts.Content[i+1], "contexts."+contexts.Content[i].Value+".")...)
+		}
+	}
+	return settings, nil
+}
+
+// settingCommands returns the paths of the credential helper and hooks in a
+// mapping of settings
+func settingCommands(settings *yaml.Node, prefix string) []string {
+	var commands []string
+	if helper := mappingValue(settings, "credential_helper"); helper != nil && helper.Value != "" {
+		commands = append(commands, prefix+"credential_helper")
+	}
+	hooks := mappingValue(settings, "hooks")
+	if hooks == nil || hooks.Kind != yaml.MappingNode {
+		return commands
+	}
+	for i := 0; i+1 < len(hooks.Content); i += 2 {
+		if hooks.Content[i+1].Value != "" {
+			commands = append(commands, prefix+"hooks."+hooks.Content[i].Value)
+		}
+	}
+	return commands
+}
+
+// ImportCLIConfig merges a shared CLI configuration into the file at path
+// and returns the new content. Settings missing locally are added; local
+// values win over imported ones unless overwrite is set, and empty
+// imported values never replace local ones. Comments in the local file are
+// kept. Imported hooks and credential helpers are commands the CLI runs, so
+// unless allowExec is set they fail the import with an *ExecSettingsError.
+func ImportCLIConfig(path string, data []byte, overwrite, allowExec bool) ([]byte, *MergeReport, error) {
+	var imported yaml.Node
+	if err := yaml.Unmarshal(data, &imported); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if imported.Kind != yaml.DocumentNode || len(imported.Content) == 0 || imported.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("imported configuration is not a YAML mapping")
+	}
+	if err := validateCLIConfig(data); err != nil {
+		return nil, nil, fmt.Errorf("invalid imported configuration: %w", err)
+	}
+	if !allowExec {
+		commands, err := ExecSettings(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(commands) > 0 {
+			return nil, nil, &ExecSettingsError{Settings: commands}
+		}
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	if _, err := os.Stat(path); err == nil {
+		if _, doc, err = readDocument(path); err != nil {
+			return nil, nil, err
+		}
+		if doc.Content[0].Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("%s is not a YAML mapping", path)
+		}
+	}
+
+	report := &MergeReport{}
+	mergeNode(doc.Content[0], imported.Content[0], "", overwrite, report)
+
+	merged, err := encodeDocument(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateCLIConfig(merged); err != nil {
+		return nil, nil, fmt.Errorf("merged configuration is invalid: %w", err)
+	}
+	return merged, report, nil
+}
+
+// mergeNode merges the mapping src into dst
+func mergeNode(dst, src *yaml.Node, prefix string, overwrite bool, report *MergeReport) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		path := prefix + key.Value
+		existing := mappingValue(dst, key.Value)
+		switch {
+		case existing == nil:
+			dst.Content = append(dst.Content, key, value)
+			report.Added = append(report.Added, path)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNode(existing, value, path+".", overwrite, report)
+		case sameNode(existing, value) || (value.Kind == yaml.ScalarNode && value.Value == ""):
+			// Nothing to merge
+		case overwrite:
+			head, line, foot := existing.HeadComment, existing.LineComment, existing.FootComment
+			*existing = *value
+			existing.HeadComment, existing.LineComment, existing.FootComment = head, line, foot
+			report.Replaced = append(report.Replaced, path)
+		default:
+			report.Kept = append(report.Kept, path)
+		}
+	}
+}
+
+// sameNode reports whether two nodes hold the same value
+func sameNode(a, b *yaml.Node) bool {
+	if a.Kind == yaml.ScalarNode && b.Kind == yaml.ScalarNode {
+		return a.Value == b.Value
+	}
+	encodedA, errA := yaml.Marshal(a)
+	encodedB, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// validateCLIConfig checks that data is a valid config.yaml, including
+// each of its contexts
+func validateCLIConfig(data []byte) error {
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+	for name := range cfg.Contexts {
+		withContext := *cfg
+		withContext.CurrentContext = name
+		if _, err := withContext.ResolveContext(); err != nil {
+			return err
+		}
+	}
+	_, err := cfg.ResolveContext()
+	return err
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TableColumns returns the soft-delete and synced-at columns for a table,
+// applying the table's overrides to the mirror-level spec.columns
+func (fc *FileConfig) TableColumns(table TableConfig) (ColumnsConfig, error) {
+	var columns ColumnsConfig
+	if fc.Spec.Columns != nil {
+		columns = *fc.Spec.Columns
+	}
+
+	if table.SoftDeleteColumn != "" {
+		columns.SoftDeleteColumn = table.SoftDeleteColumn
+	}
+	if table.SyncedAtColumn != "" {
+		columns.SyncedAtColumn = table.SyncedAtColumn
+	}
+
+	if table.SoftDelete != nil {
+		switch {
+		case !*table.SoftDelete && table.SoftDeleteColumn != "":
+			return columns, fmt.Errorf("table %s sets both soft_delete: false and soft_delete_column", table.Source)
+		case !*table.SoftDelete:
+			columns.SoftDeleteColumn = ""
+		case columns.SoftDeleteColumn == "":
+			return columns, fmt.Errorf("table %s sets soft_delete: true but no soft_delete_column is set for it or in spec.columns", table.Source)
+		}
+	}
+
+	return columns, nil
+}
+
+// EffectiveColumns resolves the column settings of every table. PeerDB
+// applies soft-delete and synced-at columns to a whole mirror, so per-table
+// overrides are only accepted when all tables end up with the same settings.
+func (fc *FileConfig) EffectiveColumns() (ColumnsConfig, error) {
+	if len(fc.Spec.Tables) == 0 {
+		if fc.Spec.Columns == nil {
+			return ColumnsConfig{}, nil
+		}
+		return *fc.Spec.Columns, nil
+	}
+
+	byColumns := make(map[ColumnsConfig][]string)
+	var first ColumnsConfig
+	for i, table := range fc.Spec.Tables {
+		columns, err := fc.TableColumns(table)
+		if err != nil {
+			return ColumnsConfig{}, &FieldError{Field: fmt.Sprintf("spec.tables[%d]", i), Err: err}
+		}
+		if i == 0 {
+			first = columns
+		}
+		byColumns[columns] = append(byColumns[columns], table.Source)
+	}
+	if len(byColumns) == 1 {
+		return first, nil
+	}
+
+	groups := make([]string, 0, len(byColumns))
+	for columns, tables := range byColumns {
+		groups = append(groups, fmt.Sprintf("%s: %s", strings.Join(tables, ", "), describeColumns(columns)))
+	}
+	sort.Strings(groups)
+	return ColumnsConfig{}, fmt.Errorf("tables use different column settings (%s); PeerDB applies them to the whole mirror, so split these tables into separate mirrors",
+		strings.Join(groups, "; "))
+}
+
+// describeColumns summarizes column settings for error messages
+func describeColumns(columns ColumnsConfig) string {
+	softDelete := "no soft delete"
+	if columns.SoftDeleteColumn != "" {
+		softDelete = "soft delete " + columns.SoftDeleteColumn
+	}
+	syncedAt := "no synced-at column"
+	if columns.SyncedAtColumn != "" {
+		syncedAt = "synced-at " + columns.SyncedAtColumn
+	}
+	return softDelete + ", " + syncedAt
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldDiff is a spec field whose desired value differs from the actual one
+type FieldDiff struct {
+	// Path is the dotted path of the field, e.g. spec.config.host
+	Path string
+	// Old is the actual value, or nil when the field is unset
+	Old interface{}
+	// New is the desired value
+	New interface{}
+}
+
+// DiffFields compares the fields set in desired against actual and returns
+// the dotted paths of the spec fields that differ, e.g. spec.config.host.
+// Fields left unset in desired are not compared, so server-side defaults
+// don't count as differences.
+func DiffFields(desired, actual *FileConfig) ([]string, error) {
+	diffs, err := DiffValues(desired, actual)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(diffs))
+	for i, diff := range diffs {
+		paths[i] = diff.Path
+	}
+	return paths, nil
+}
+
+// DiffValues is DiffFields with the old and new value of each field,
+// sorted by path
+func DiffValues(desired, actual *FileConfig) ([]FieldDiff, error) {
+	want, err := toGeneric(desired)
+	if err != nil {
+		return nil, err
+	}
+	have, err := toGeneric(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FieldDiff
+	if want["kind"] != have["kind"] {
+		diffs = append(diffs, FieldDiff{Path: "kind", Old: have["kind"], New: want["kind"]})
+	}
+	diffs = append(diffs, diffMaps("spec", asMap(want["spec"]), asMap(have["spec"]))...)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+func diffMaps(prefix string, want, have map[string]interface{}) []FieldDiff {
+	var diffs []FieldDiff
+	for key, value := range want {
+		path := prefix + "." + key
+		if nested, ok := value.(map[string]interface{}); ok {
+			diffs = append(diffs, diffMaps(path, nested, asMap(have[key]))...)
+			continue
+		}
+		if !reflect.DeepEqual(value, have[key]) {
+			diffs = append(diffs, FieldDiff{Path: path, Old: have[key], New: value})
+		}
+	}
+	return diffs
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+// DiffConfigs compares two versions of a configuration, such as the same
+// file before and after a change, field by field in metadata and spec.
+// Unlike DiffValues, fields removed in after are reported too, with a nil
+// New.
+func DiffConfigs(before, after *FileConfig) ([]FieldDiff, error) {
+	have, err := toGeneric(before)
+	if err != nil {
+		return nil, err
+	}
+	want, err := toGeneric(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FieldDiff
+	for _, section := range []string{"kind", "metadata", "spec"} {
+		diffs = append(diffs, diffBoth(section, want[section], have[section])...)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+// diffBoth compares want against have in both directions, descending into
+// mappings so each changed field is reported on its own
+func diffBoth(path string, want, have interface{}) []FieldDiff {
+	wantMap, wantIsMap := want.(map[string]interface{})
+	haveMap, haveIsMap := have.(map[string]interface{})
+	if wantIsMap && have == nil {
+		haveMap, haveIsMap = map[string]interface{}{}, true
+	}
+	if haveIsMap && want == nil {
+		wantMap, wantIsMap = map[string]interface{}{}, true
+	}
+	if !wantIsMap || !haveIsMap {
+		if reflect.DeepEqual(want, have) {
+			return nil
+		}
+		return []FieldDiff{{Path: path, Old: have, New: want}}
+	}
+
+	var diffs []FieldDiff
+	for key, value := range wantMap {
+		diffs = append(diffs, diffBoth(path+"."+key, value, haveMap[key])...)
+	}
+	for key, value := range haveMap {
+		if _, ok := wantMap[key]; !ok {
+			diffs = append(diffs, FieldDiff{Path: path + "." + key, Old: value})
+		}
+	}
+	return diffs
+}
@@ -2,20 +2,166 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
+// Drop policies decide whether dropping a mirror also drops its
+// destination tables
+const (
+	DropPolicyKeepDestination = "keep-destination"
+	DropPolicyDropDestination = "drop-destination"
+)
+
+// Confirm modes decide how destructive commands such as mirror drop ask
+// for confirmation
+const (
+	ConfirmModeSimple = "simple"
+	ConfirmModeTyped  = "typed"
+	ConfirmModeOff    = "off"
+)
+
+// PeerDB APIs decide which version of the FlowService messages the client
+// sends: the one matching the server's version, or a pinned one
+const (
+	PeerDBAPIAuto    = "auto"
+	PeerDBAPICurrent = "current"
+	PeerDBAPILegacy  = "legacy"
+)
+
 // Config represents the CLI configuration
 type Config struct {
-	PeerDBHost string `yaml:"peerdb_host" mapstructure:"peerdb_host"`
-	PeerDBPort int    `yaml:"peerdb_port" mapstructure:"peerdb_port"`
-	TLS        bool   `yaml:"tls" mapstructure:"tls"`
-	Username   string `yaml:"username" mapstructure:"username"`
-	Password   string `yaml:"password" mapstructure:"password"`
+	PeerDBHost  string   `yaml:"peerdb_host" mapstructure:"peerdb_host"`
+	PeerDBPort  int      `yaml:"peerdb_port" mapstructure:"peerdb_port"`
+	PeerDBHosts []string `yaml:"peerdb_hosts,omitempty" mapstructure:"peerdb_hosts"`
+	TLS         bool     `yaml:"tls" mapstructure:"tls"`
+	Username    string   `yaml:"username" mapstructure:"username"`
+	Password    string   `yaml:"password" mapstructure:"password"`
+	NoCache     bool     `yaml:"no_cache,omitempty" mapstructure:"no_cache"`
+	PolicyFile  string   `yaml:"policy_file,omitempty" mapstructure:"policy_file"`
+	DropPolicy  string   `yaml:"drop_policy,omitempty" mapstructure:"drop_policy"`
+	ReadOnly    bool     `yaml:"read_only,omitempty" mapstructure:"read_only"`
+
+	// ConfirmMode is simple (y/N), typed (type the resource name), or off;
+	// it defaults to typed in production and simple elsewhere
+	ConfirmMode string `yaml:"confirm_mode,omitempty" mapstructure:"confirm_mode"`
+
+	// PeerDBAPI pins the FlowService API version: auto (default) picks it
+	// from the server's version, current or legacy are used as is
+	PeerDBAPI string `yaml:"peerdb_api,omitempty" mapstructure:"peerdb_api"`
+
+	// NamePrefix is prepended to the names of created peers and mirrors,
+	// and list commands only show resources with it, so teams sharing a
+	// PeerDB instance stay apart
+	NamePrefix string `yaml:"name_prefix,omitempty" mapstructure:"name_prefix"`
+
+	// Environment is the deployment environment (e.g. production) exports
+	// and applies default to
+	Environment string `yaml:"environment,omitempty" mapstructure:"environment"`
+
+	// RequireEnvironmentMatch makes applying configs for another
+	// environment an error instead of a warning
+	RequireEnvironmentMatch bool `yaml:"require_environment_match,omitempty" mapstructure:"require_environment_match"`
+
+	// DefaultDestinationSchema qualifies the destination of table mappings
+	// that leave it out, for mirrors that don't set their own
+	DefaultDestinationSchema string `yaml:"default_destination_schema,omitempty" mapstructure:"default_destination_schema"`
+
+	// TemporalUIURL is the Temporal UI page of a mirror's workflow, with
+	// {workflow_id} in place of its ID
+	TemporalUIURL string `yaml:"temporal_ui_url,omitempty" mapstructure:"temporal_ui_url"`
+
+	// ReadTimeout, MutateTimeout, and WaitTimeout bound lookup RPCs, RPCs
+	// that change server state, and waits such as mirror create --wait,
+	// e.g. "30s"; see Timeouts for the defaults
+	ReadTimeout   string `yaml:"read_timeout,omitempty" mapstructure:"read_timeout"`
+	MutateTimeout string `yaml:"mutate_timeout,omitempty" mapstructure:"mutate_timeout"`
+	WaitTimeout   string `yaml:"wait_timeout,omitempty" mapstructure:"wait_timeout"`
+
+	// ExtraHeaders are sent as gRPC metadata with every RPC
+	ExtraHeaders map[string]string `yaml:"extra_headers,omitempty" mapstructure:"extra_headers"`
+
+	// CredentialHelper is a program that supplies server credentials and
+	// config file secrets; see internal/credhelper
+	CredentialHelper string `yaml:"credential_helper,omitempty" mapstructure:"credential_helper"`
+
+	// Hooks maps hook names such as pre_apply to programs run before and
+	// after commands that change server state; see internal/hooks
+	Hooks map[string]string `yaml:"hooks,omitempty" mapstructure:"hooks"`
+
+	// RequestID is sent with every RPC as x-request-id and printed on
+	// errors; it is generated when empty
+	RequestID string `yaml:"-" mapstructure:"-"`
+
+	// Explain, when set, receives a JSON description of each mutating RPC
+	// instead of the RPC being sent
+	Explain io.Writer `yaml:"-" mapstructure:"-"`
+
+	// Warnings, when set, receives notices such as failing over to another
+	// endpoint
+	Warnings io.Writer `yaml:"-" mapstructure:"-"`
+
+	// Record, when set, is called with every RPC sent and its response or
+	// error, for --record
+	Record func(method string, req, reply interface{}, err error) `yaml:"-" mapstructure:"-"`
+
+	CurrentContext string              `yaml:"current_context,omitempty" mapstructure:"current_context"`
+	Contexts       map[string]*Context `yaml:"contexts,omitempty" mapstructure:"contexts"`
+
+	// ContextSource is where CurrentContext was selected when it overrides
+	// current_context: $MIRROR_CLI_CONTEXT or a .mirror_cli_context path
+	ContextSource string `yaml:"-" mapstructure:"-"`
+}
+
+// Context holds the server settings for a named PeerDB deployment
+type Context struct {
+	PeerDBHost  string   `yaml:"peerdb_host" mapstructure:"peerdb_host"`
+	PeerDBPort  int      `yaml:"peerdb_port" mapstructure:"peerdb_port"`
+	PeerDBHosts []string `yaml:"peerdb_hosts,omitempty" mapstructure:"peerdb_hosts"`
+	TLS         bool     `yaml:"tls" mapstructure:"tls"`
+	Username    string   `yaml:"username,omitempty" mapstructure:"username"`
+	Password    string   `yaml:"password,omitempty" mapstructure:"password"`
+	DropPolicy  string   `yaml:"drop_policy,omitempty" mapstructure:"drop_policy"`
+	ReadOnly    bool     `yaml:"read_only,omitempty" mapstructure:"read_only"`
+	Environment string   `yaml:"environment,omitempty" mapstructure:"environment"`
+	ConfirmMode string   `yaml:"confirm_mode,omitempty" mapstructure:"confirm_mode"`
+	NamePrefix  string   `yaml:"name_prefix,omitempty" mapstructure:"name_prefix"`
+	PeerDBAPI   string   `yaml:"peerdb_api,omitempty" mapstructure:"peerdb_api"`
+
+	// RequireEnvironmentMatch blocks applying configs whose
+	// metadata.environment differs from Environment
+	RequireEnvironmentMatch bool `yaml:"require_environment_match,omitempty" mapstructure:"require_environment_match"`
+
+	// ExtraHeaders are added to the top-level extra_headers, overriding
+	// headers with the same name
+	ExtraHeaders map[string]string `yaml:"extra_headers,omitempty" mapstructure:"extra_headers"`
+
+	// CredentialHelper replaces the top-level credential_helper
+	CredentialHelper string `yaml:"credential_helper,omitempty" mapstructure:"credential_helper"`
+
+	// Hooks are added to the top-level hooks, overriding hooks with the
+	// same name
+	Hooks map[string]string `yaml:"hooks,omitempty" mapstructure:"hooks"`
+
+	// DefaultDestinationSchema replaces the top-level
+	// default_destination_schema
+	DefaultDestinationSchema string `yaml:"default_destination_schema,omitempty" mapstructure:"default_destination_schema"`
+
+	// TemporalUIURL replaces the top-level temporal_ui_url
+	TemporalUIURL string `yaml:"temporal_ui_url,omitempty" mapstructure:"temporal_ui_url"`
+
+	// ReadTimeout, MutateTimeout, and WaitTimeout replace the top-level
+	// timeouts
+	ReadTimeout   string `yaml:"read_timeout,omitempty" mapstructure:"read_timeout"`
+	MutateTimeout string `yaml:"mutate_timeout,omitempty" mapstructure:"mutate_timeout"`
+	WaitTimeout   string `yaml:"wait_timeout,omitempty" mapstructure:"wait_timeout"`
 }
 
 // DefaultConfig returns a config with default values
@@ -48,6 +194,21 @@ func LoadConfig() (*Config, error) {
 	// Environment variable support
 	viper.SetEnvPrefix("MIRROR_CLI")
 	viper.AutomaticEnv()
+	viper.BindEnv("policy_file")
+	viper.BindEnv("drop_policy")
+	viper.BindEnv("read_only")
+	viper.BindEnv("confirm_mode")
+	viper.BindEnv("name_prefix")
+	viper.BindEnv("environment")
+	viper.BindEnv("require_environment_match")
+	viper.BindEnv("credential_helper")
+	viper.BindEnv("default_destination_schema")
+	viper.BindEnv("temporal_ui_url")
+	viper.BindEnv("read_timeout")
+	viper.BindEnv("mutate_timeout")
+	viper.BindEnv("wait_timeout")
+	viper.BindEnv("peerdb_hosts")
+	viper.BindEnv("peerdb_api")
 
 	// Read config file if it exists
 	if err := viper.ReadInConfig(); err != nil {
@@ -64,26 +225,230 @@ func LoadConfig() (*Config, error) {
 	return config, nil
 }
 
+// ResolveContext returns a copy of the configuration with the current
+// context's server settings applied over the top-level values
+func (c *Config) ResolveContext() (*Config, error) {
+	resolved := *c
+	if err := ValidateDropPolicy(c.DropPolicy); err != nil {
+		return nil, err
+	}
+	if err := ValidateConfirmMode(c.ConfirmMode); err != nil {
+		return nil, err
+	}
+	if err := ValidateNamePrefix(c.NamePrefix); err != nil {
+		return nil, err
+	}
+	if err := ValidatePeerDBAPI(c.PeerDBAPI); err != nil {
+		return nil, err
+	}
+	if err := ValidateTemporalUIURL(c.TemporalUIURL); err != nil {
+		return nil, err
+	}
+	if err := validateTimeouts(c.ReadTimeout, c.MutateTimeout, c.WaitTimeout); err != nil {
+		return nil, err
+	}
+	if c.CurrentContext == "" {
+		return &resolved, nil
+	}
+
+	ctx, ok := c.Contexts[strings.ToLower(c.CurrentContext)]
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found in configuration", c.CurrentContext)
+	}
+
+	// A context's servers replace the top-level ones entirely
+	if len(ctx.PeerDBHosts) > 0 {
+		resolved.PeerDBHosts = ctx.PeerDBHosts
+	} else if ctx.PeerDBHost != "" {
+		resolved.PeerDBHosts = nil
+	}
+	if ctx.PeerDBHost != "" {
+		resolved.PeerDBHost = ctx.PeerDBHost
+	}
+	if ctx.PeerDBPort != 0 {
+		resolved.PeerDBPort = ctx.PeerDBPort
+	}
+	resolved.TLS = ctx.TLS
+	if ctx.Username != "" {
+		resolved.Username = ctx.Username
+	}
+	if ctx.Password != "" {
+		resolved.Password = ctx.Password
+	}
+	// A read-only context can't be made writable by top-level settings
+	resolved.ReadOnly = c.ReadOnly || ctx.ReadOnly
+	if ctx.DropPolicy != "" {
+		if err := ValidateDropPolicy(ctx.DropPolicy); err != nil {
+			return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+		}
+		resolved.DropPolicy = ctx.DropPolicy
+	}
+	if ctx.ConfirmMode != "" {
+		if err := ValidateConfirmMode(ctx.ConfirmMode); err != nil {
+			return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+		}
+		resolved.ConfirmMode = ctx.ConfirmMode
+	}
+	if ctx.NamePrefix != "" {
+		if err := ValidateNamePrefix(ctx.NamePrefix); err != nil {
+			return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+		}
+		resolved.NamePrefix = ctx.NamePrefix
+	}
+	if ctx.PeerDBAPI != "" {
+		if err := ValidatePeerDBAPI(ctx.PeerDBAPI); err != nil {
+			return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+		}
+		resolved.PeerDBAPI = ctx.PeerDBAPI
+	}
+	if ctx.Environment != "" {
+		resolved.Environment = ctx.Environment
+	}
+	if ctx.CredentialHelper != "" {
+		resolved.CredentialHelper = ctx.CredentialHelper
+	}
+	if ctx.DefaultDestinationSchema != "" {
+		resolved.DefaultDestinationSchema = ctx.DefaultDestinationSchema
+	}
+	if ctx.TemporalUIURL != "" {
+		if err := ValidateTemporalUIURL(ctx.TemporalUIURL); err != nil {
+			return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+		}
+		resolved.TemporalUIURL = ctx.TemporalUIURL
+	}
+	if err := validateTimeouts(ctx.ReadTimeout, ctx.MutateTimeout, ctx.WaitTimeout); err != nil {
+		return nil, fmt.Errorf("context %q: %w", c.CurrentContext, err)
+	}
+	if ctx.ReadTimeout != "" {
+		resolved.ReadTimeout = ctx.ReadTimeout
+	}
+	if ctx.MutateTimeout != "" {
+		resolved.MutateTimeout = ctx.MutateTimeout
+	}
+	if ctx.WaitTimeout != "" {
+		resolved.WaitTimeout = ctx.WaitTimeout
+	}
+	// Like read_only, the gate can only be turned on by a context
+	resolved.RequireEnvironmentMatch = c.RequireEnvironmentMatch || ctx.RequireEnvironmentMatch
+	if len(ctx.ExtraHeaders) > 0 {
+		resolved.ExtraHeaders = make(map[string]string, len(c.ExtraHeaders)+len(ctx.ExtraHeaders))
+		for name, value := range c.ExtraHeaders {
+			resolved.ExtraHeaders[name] = value
+		}
+		for name, value := range ctx.ExtraHeaders {
+			resolved.ExtraHeaders[name] = value
+		}
+	}
+	if len(ctx.Hooks) > 0 {
+		resolved.Hooks = make(map[string]string, len(c.Hooks)+len(ctx.Hooks))
+		for name, program := range c.Hooks {
+			resolved.Hooks[name] = program
+		}
+		for name, program := range ctx.Hooks {
+			resolved.Hooks[name] = program
+		}
+	}
+
+	return &resolved, nil
+}
+
+// ValidateDropPolicy returns an error for unknown drop_policy values
+func ValidateDropPolicy(policy string) error {
+	switch policy {
+	case "", DropPolicyKeepDestination, DropPolicyDropDestination:
+		return nil
+	default:
+		return fmt.Errorf("invalid drop_policy %q: must be %s or %s", policy, DropPolicyKeepDestination, DropPolicyDropDestination)
+	}
+}
+
+// KeepDestination reports whether the drop policy keeps destination tables
+// when mirrors are dropped
+func (c *Config) KeepDestination() bool {
+	return c.DropPolicy == DropPolicyKeepDestination
+}
+
+// ValidateConfirmMode returns an error for unknown confirm_mode values
+func ValidateConfirmMode(mode string) error {
+	switch mode {
+	case "", ConfirmModeSimple, ConfirmModeTyped, ConfirmModeOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid confirm_mode %q: must be %s, %s, or %s", mode, ConfirmModeSimple, ConfirmModeTyped, ConfirmModeOff)
+	}
+}
+
+// ValidatePeerDBAPI returns an error for unknown peerdb_api values
+func ValidatePeerDBAPI(api string) error {
+	switch api {
+	case "", PeerDBAPIAuto, PeerDBAPICurrent, PeerDBAPILegacy:
+		return nil
+	default:
+		return fmt.Errorf("invalid peerdb_api %q: must be %s, %s, or %s", api, PeerDBAPIAuto, PeerDBAPICurrent, PeerDBAPILegacy)
+	}
+}
+
+// Defaults returns the settings config files fall back to
+func (c *Config) Defaults() Defaults {
+	return Defaults{DestinationSchema: c.DefaultDestinationSchema}
+}
+
+// Confirmation returns the confirm mode for destructive commands: the
+// configured one, or typed for production environments and simple
+// otherwise
+func (c *Config) Confirmation() string {
+	if c.ConfirmMode != "" {
+		return c.ConfirmMode
+	}
+	if strings.EqualFold(c.Environment, "production") {
+		return ConfirmModeTyped
+	}
+	return ConfirmModeSimple
+}
+
+// SetContext adds or replaces a named context. Names are stored lowercase
+// since configuration keys are case-insensitive.
+func (c *Config) SetContext(name string, ctx *Context) {
+	if c.Contexts == nil {
+		c.Contexts = make(map[string]*Context)
+	}
+	c.Contexts[strings.ToLower(name)] = ctx
+}
+
 // SaveConfig saves the configuration to a file
 func SaveConfig(config *Config) error {
-	homeDir, err := os.UserHomeDir()
+	configFile, err := ConfigPath()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
-
-	configDir := filepath.Join(homeDir, ".mirror_cli")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	configFile := filepath.Join(configDir, "config.yaml")
-
 	data, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configFile, data, 0644); err != nil {
+	// Update an existing file in place so that its comments and key order
+	// survive
+	if _, doc, err := readDocument(configFile); err == nil && doc.Content[0].Kind == yaml.MappingNode {
+		var updated yaml.Node
+		if err := yaml.Unmarshal(data, &updated); err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		syncNode(doc.Content[0], updated.Content[0])
+		if data, err = encodeDocument(doc); err != nil {
+			return err
+		}
+	}
+
+	// The file can hold passwords. WriteFile keeps the mode of an existing
+	// file, so tighten it too.
+	if err := os.WriteFile(configFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(configFile, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -94,3 +459,23 @@ func SaveConfig(config *Config) error {
 func (c *Config) Address() string {
 	return fmt.Sprintf("%s:%d", c.PeerDBHost, c.PeerDBPort)
 }
+
+// Endpoints returns the server addresses to connect to, in order of
+// preference. Entries of peerdb_hosts without a port use peerdb_port.
+func (c *Config) Endpoints() []string {
+	var endpoints []string
+	for _, host := range c.PeerDBHosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(c.PeerDBPort))
+		}
+		endpoints = append(endpoints, host)
+	}
+	if len(endpoints) == 0 {
+		return []string{c.Address()}
+	}
+	return endpoints
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContextEnv names the environment variable that selects a context for
+// one shell, e.g. from direnv, overriding current_context
+const ContextEnv = "MIRROR_CLI_CONTEXT"
+
+// ContextFileName is the file that selects a context for a directory tree,
+// found in the working directory or the nearest parent that has one
+const ContextFileName = ".mirror_cli_context"
+
+// ContextOverride returns the context selected by MIRROR_CLI_CONTEXT or the
+// nearest .mirror_cli_context file above dir, and where it came from. The
+// name is empty when neither selects one.
+func ContextOverride(dir string) (name, source string, err error) {
+	if name := strings.TrimSpace(os.Getenv(ContextEnv)); name != "" {
+		return name, "$" + ContextEnv, nil
+	}
+
+	path, err := findContextFile(dir)
+	if err != nil || path == "" {
+		return "", "", err
+	}
+	name, err = readContextFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return name, path, nil
+}
+
+// ApplyContextOverride makes the context selected by MIRROR_CLI_CONTEXT or
+// a .mirror_cli_context file current, recording its source in
+// ContextSource. The configuration file is left unchanged.
+func (c *Config) ApplyContextOverride(dir string) error {
+	name, source, err := ContextOverride(dir)
+	if err != nil || name == "" {
+		return err
+	}
+	if _, ok := c.Contexts[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("context %q selected by %s not found; import it with 'mirror_cli config import-context -f <file>'", name, source)
+	}
+	c.CurrentContext = strings.ToLower(name)
+	c.ContextSource = source
+	return nil
+}
+
+// findContextFile returns the .mirror_cli_context file in dir or its
+// nearest ancestor, or "" when there is none
+func findContextFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, ContextFileName)
+		info, err := os.Stat(path)
+		switch {
+		case err == nil && !info.IsDir():
+			return path, nil
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readContextFile returns the context named by the first line of a context
+// file that isn't blank or a # comment
+func readContextFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return "", fmt.Errorf("%s doesn't name a context", path)
+}
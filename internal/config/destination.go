@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defaults are the settings of the CLI configuration that config files
+// fall back to when they don't set them
+type Defaults struct {
+	// DestinationSchema is the default_destination_schema of the CLI
+	// configuration or current context, used for mirrors that don't set
+	// spec.default_destination_schema
+	DestinationSchema string
+}
+
+// destinationSchema returns the schema that qualifies table mappings
+// without a destination
+func (fc *FileConfig) destinationSchema(defaults Defaults) string {
+	if fc.Spec.DefaultDestinationSchema != "" {
+		return fc.Spec.DefaultDestinationSchema
+	}
+	return defaults.DestinationSchema
+}
+
+// tableDestination returns the destination of a table mapping. Without
+// one, it is the source table name qualified by the default destination
+// schema, e.g. public.users becomes ANALYTICS.PUBLIC.USERS. The table name
+// follows the schema's case when the schema is all upper or lower case, as
+// Snowflake folds unquoted names to upper case.
+func (fc *FileConfig) tableDestination(index int, table TableConfig, defaults Defaults) (string, error) {
+	if table.Destination != "" {
+		return table.Destination, nil
+	}
+
+	schema := strings.TrimSuffix(fc.destinationSchema(defaults), ".")
+	if schema == "" {
+		return "", fieldErrorf(fmt.Sprintf("spec.tables[%d]", index), "table '%s' has no destination; set its destination, spec.default_destination_schema, or the CLI's default_destination_schema", table.Source)
+	}
+	return DestinationTable(schema, table.Source), nil
+}
+
+// DestinationTable returns the destination of a source table in a default
+// destination schema, e.g. public.users in ANALYTICS.PUBLIC becomes
+// ANALYTICS.PUBLIC.USERS
+func DestinationTable(schema, source string) string {
+	schema = strings.TrimSuffix(schema, ".")
+	name := source
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	switch schema {
+	case strings.ToUpper(schema):
+		name = strings.ToUpper(name)
+	case strings.ToLower(schema):
+		name = strings.ToLower(name)
+	}
+	return schema + "." + name
+}
@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestTableDestination(t *testing.T) {
+	tests := []struct {
+		name       string
+		specSchema string
+		defaults   Defaults
+		table      TableConfig
+		want       string
+		wantErr    bool
+	}{
+		{name: "explicit", table: TableConfig{Source: "public.users", Destination: "raw.users"}, defaults: Defaults{DestinationSchema: "ANALYTICS"}, want: "raw.users"},
+		{name: "CLI default", table: TableConfig{Source: "public.users"}, defaults: Defaults{DestinationSchema: "ANALYTICS.PUBLIC"}, want: "ANALYTICS.PUBLIC.USERS"},
+		{name: "spec over CLI default", specSchema: "staging.", table: TableConfig{Source: "public.Users"}, defaults: Defaults{DestinationSchema: "ANALYTICS"}, want: "staging.users"},
+		{name: "mixed case schema", specSchema: "Raw", table: TableConfig{Source: "public.Users"}, want: "Raw.Users"},
+		{name: "no default", table: TableConfig{Source: "public.users"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &FileConfig{Spec: Spec{DefaultDestinationSchema: tt.specSchema}}
+			got, err := fc.tableDestination(0, tt.table, tt.defaults)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// DestinationOptions holds settings that only apply to one type of
+// destination peer, keyed by that type. Set in spec.destination_options
+// they apply to every table; a table's own destination_options override
+// them field by field.
+type DestinationOptions struct {
+	ClickHouse *ClickHouseOptions `yaml:"clickhouse,omitempty"`
+	Snowflake  *SnowflakeOptions  `yaml:"snowflake,omitempty"`
+	BigQuery   *BigQueryOptions   `yaml:"bigquery,omitempty"`
+}
+
+// ClickHouseOptions shape the tables a mirror creates in ClickHouse
+type ClickHouseOptions struct {
+	// Engine is replacing_merge_tree (default), merge_tree, or null
+	Engine string `yaml:"engine,omitempty"`
+	// OrderingKeys are the source columns of the table's ORDER BY, in
+	// order. PeerDB orders by the primary key by default.
+	OrderingKeys []string `yaml:"ordering_keys,omitempty"`
+}
+
+// SnowflakeOptions tune mirrors into Snowflake
+type SnowflakeOptions struct {
+	// TransformationScript names the script PeerDB runs on each row before
+	// writing it. It applies to the whole mirror.
+	TransformationScript string `yaml:"transformation_script,omitempty"`
+}
+
+// BigQueryOptions shape the tables a mirror creates in BigQuery
+type BigQueryOptions struct {
+	// PartitionColumn is the column destination tables are partitioned by
+	PartitionColumn string `yaml:"partition_column,omitempty"`
+}
+
+// clickHouseEngines lists the supported ClickHouse table engines
+var clickHouseEngines = []struct {
+	name   string
+	engine pb.TableEngine
+}{
+	{name: "replacing_merge_tree", engine: pb.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE},
+	{name: "merge_tree", engine: pb.TableEngine_CH_ENGINE_MERGE_TREE},
+	{name: "null", engine: pb.TableEngine_CH_ENGINE_NULL},
+}
+
+// destinationTypes returns the destination peer types options are set for
+func (o *DestinationOptions) destinationTypes() []string {
+	var types []string
+	if o == nil {
+		return types
+	}
+	if o.ClickHouse != nil {
+		types = append(types, "clickhouse")
+	}
+	if o.Snowflake != nil {
+		types = append(types, "snowflake")
+	}
+	if o.BigQuery != nil {
+		types = append(types, "bigquery")
+	}
+	return types
+}
+
+// destinationOptionFields returns the spec.destination_options field and
+// those of the tables that set options
+func (fc *FileConfig) destinationOptionFields() map[string]*DestinationOptions {
+	fields := make(map[string]*DestinationOptions)
+	if fc.Spec.DestinationOptions != nil {
+		fields["spec.destination_options"] = fc.Spec.DestinationOptions
+	}
+	for i, table := range fc.Spec.Tables {
+		if table.DestinationOptions != nil {
+			fields[fmt.Sprintf("spec.tables[%d].destination_options", i)] = table.DestinationOptions
+		}
+	}
+	return fields
+}
+
+// validateDestinationOptions checks destination_options without knowing
+// the destination's type: they must all be for one type, and their values
+// must be valid
+func (fc *FileConfig) validateDestinationOptions() error {
+	fields := fc.destinationOptionFields()
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	var destinationType, typeField string
+	for _, field := range names {
+		options := fields[field]
+		for _, t := range options.destinationTypes() {
+			if destinationType != "" && t != destinationType {
+				return fieldErrorf(field, "destination_options are set for both %s (%s) and %s; a mirror has one destination, so set only its options", destinationType, typeField, t)
+			}
+			destinationType, typeField = t, field
+		}
+
+		if options.ClickHouse != nil {
+			if _, err := clickHouseEngine(field, options.ClickHouse.Engine); err != nil {
+				return err
+			}
+			seen := make(map[string]bool)
+			for _, column := range options.ClickHouse.OrderingKeys {
+				switch {
+				case strings.TrimSpace(column) == "":
+					return fieldErrorf(field+".clickhouse.ordering_keys", "clickhouse.ordering_keys contains an empty column name")
+				case seen[column]:
+					return fieldErrorf(field+".clickhouse.ordering_keys", "clickhouse.ordering_keys lists %s twice", column)
+				}
+				seen[column] = true
+			}
+		}
+		if options.Snowflake != nil && field != "spec.destination_options" && options.Snowflake.TransformationScript != "" {
+			return fieldErrorf(field+".snowflake.transformation_script", "snowflake.transformation_script applies to the whole mirror; set it in spec.destination_options")
+		}
+	}
+	return nil
+}
+
+// clickHouseEngine returns the table engine named by clickhouse.engine
+func clickHouseEngine(field, name string) (pb.TableEngine, error) {
+	if name == "" {
+		return pb.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE, nil
+	}
+	names := make([]string, len(clickHouseEngines))
+	for i, e := range clickHouseEngines {
+		if e.name == strings.ToLower(name) {
+			return e.engine, nil
+		}
+		names[i] = e.name
+	}
+	return 0, fieldErrorf(field+".clickhouse.engine", "invalid clickhouse.engine %q: must be %s, or %s", name, strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+// CheckDestinationOptions returns an error if destination_options are set
+// for another type of peer than the destination's
+func (fc *FileConfig) CheckDestinationOptions(destinationType string) error {
+	fields := fc.destinationOptionFields()
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		for _, t := range fields[field].destinationTypes() {
+			if t != destinationType {
+				return fieldErrorf(field+"."+t, "destination_options.%s doesn't apply to %s destinations", t, destinationType)
+			}
+		}
+	}
+	return nil
+}
+
+// tableDestinationOptions returns a table's destination options: its own
+// over the mirror's
+func (fc *FileConfig) tableDestinationOptions(table TableConfig) DestinationOptions {
+	var options DestinationOptions
+	if mirror := fc.Spec.DestinationOptions; mirror != nil {
+		options = *mirror
+	}
+	own := table.DestinationOptions
+	if own == nil {
+		return options
+	}
+
+	if own.ClickHouse != nil {
+		clickHouse := ClickHouseOptions{}
+		if options.ClickHouse != nil {
+			clickHouse = *options.ClickHouse
+		}
+		if own.ClickHouse.Engine != "" {
+			clickHouse.Engine = own.ClickHouse.Engine
+		}
+		if len(own.ClickHouse.OrderingKeys) > 0 {
+			clickHouse.OrderingKeys = own.ClickHouse.OrderingKeys
+		}
+		options.ClickHouse = &clickHouse
+	}
+	if own.BigQuery != nil && own.BigQuery.PartitionColumn != "" {
+		options.BigQuery = &BigQueryOptions{PartitionColumn: own.BigQuery.PartitionColumn}
+	}
+	return options
+}
+
+// applyDestinationOptions sets a table mapping's per-destination settings
+// from the table's destination options
+func (fc *FileConfig) applyDestinationOptions(index int, table TableConfig, mapping *pb.TableMapping) error {
+	options := fc.tableDestinationOptions(table)
+
+	if options.ClickHouse != nil {
+		engine, err := clickHouseEngine(fmt.Sprintf("spec.tables[%d].destination_options", index), options.ClickHouse.Engine)
+		if err != nil {
+			return err
+		}
+		mapping.Engine = engine
+		for i, column := range options.ClickHouse.OrderingKeys {
+			mapping.Columns = append(mapping.Columns, &pb.ColumnSetting{SourceName: column, Ordering: int32(i + 1)})
+		}
+	}
+
+	// A table's partition_key wins over the mirror's partition column, but
+	// not over its own
+	if options.BigQuery != nil && options.BigQuery.PartitionColumn != "" {
+		column := options.BigQuery.PartitionColumn
+		own := table.DestinationOptions != nil && table.DestinationOptions.BigQuery != nil && table.DestinationOptions.BigQuery.PartitionColumn != ""
+		switch {
+		case mapping.PartitionKey == "":
+			mapping.PartitionKey = column
+		case own && mapping.PartitionKey != column:
+			return fieldErrorf(fmt.Sprintf("spec.tables[%d]", index), "partition_key %s and destination_options.bigquery.partition_column %s differ; set one", mapping.PartitionKey, column)
+		}
+	}
+	return nil
+}
+
+// transformationScript returns the mirror's Snowflake transformation script
+func (fc *FileConfig) transformationScript() string {
+	if options := fc.Spec.DestinationOptions; options != nil && options.Snowflake != nil {
+		return options.Snowflake.TransformationScript
+	}
+	return ""
+}
+
+// destinationOptionsFromProto returns the ClickHouse options of a table
+// mapping, or nil when it uses the defaults
+func destinationOptionsFromProto(mapping *pb.TableMapping) *DestinationOptions {
+	var clickHouse ClickHouseOptions
+	if mapping.Engine != pb.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE {
+		for _, e := range clickHouseEngines {
+			if e.engine == mapping.Engine {
+				clickHouse.Engine = e.name
+			}
+		}
+	}
+
+	var ordered []*pb.ColumnSetting
+	for _, column := range mapping.Columns {
+		if column.Ordering > 0 {
+			ordered = append(ordered, column)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Ordering < ordered[j].Ordering
+	})
+	for _, column := range ordered {
+		clickHouse.OrderingKeys = append(clickHouse.OrderingKeys, column.SourceName)
+	}
+
+	if clickHouse.Engine == "" && len(clickHouse.OrderingKeys) == 0 {
+		return nil
+	}
+	return &DestinationOptions{ClickHouse: &clickHouse}
+}
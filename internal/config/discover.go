@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// KnownKinds lists the configuration kinds understood by the CLI
+var KnownKinds = []string{"Peer", "Mirror", "MirrorTemplate", "Context"}
+
+// DiscoverOptions controls which files are picked up from a directory
+type DiscoverOptions struct {
+	// Include, if set, limits files to those matching at least one pattern
+	Include []string
+	// Exclude drops files matching any pattern
+	Exclude []string
+}
+
+// IsKnownKind reports whether kind is a configuration kind understood by the CLI
+func IsKnownKind(kind string) bool {
+	for _, known := range KnownKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// FindConfigFiles returns the configuration files at path, which may be a
+// single file or a directory that is searched recursively. Symlinked
+// directories are followed once; cycles are skipped. Include and exclude
+// patterns are matched against paths relative to the directory and support
+// "**" to match any number of path segments.
+func FindConfigFiles(path string, opts DiscoverOptions) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access path %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	visited := make(map[string]bool)
+	if err := walkDir(path, path, visited, opts, &files); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func walkDir(root, dir string, visited map[string]bool, opts DiscoverOptions, files *[]string) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if visited[realDir] {
+		return nil
+	}
+	visited[realDir] = true
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		// Stat follows symlinks so linked directories are descended into
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to access %s: %w", path, err)
+		}
+
+		if info.IsDir() {
+			if err := walkDir(root, path, visited, opts, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isConfigFile(path) {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		if opts.matches(filepath.ToSlash(rel)) {
+			*files = append(*files, path)
+		}
+	}
+
+	return nil
+}
+
+func (o DiscoverOptions) matches(rel string) bool {
+	if len(o.Include) > 0 {
+		included := false
+		for _, pattern := range o.Include {
+			if matchGlob(pattern, rel) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, pattern := range o.Exclude {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchGlob matches a slash-separated path against a pattern where "**"
+// matches zero or more path segments and other segments use filepath.Match
+func matchGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(rest, path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+func isConfigFile(path string) bool {
+	return isYAMLFile(strings.TrimSuffix(path, ".gotmpl"))
+}
+
+func isYAMLFile(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".yaml") || strings.HasSuffix(lower, ".yml")
+}
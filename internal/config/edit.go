@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetSpecValues sets values in the spec of a configuration file, keyed by
+// dotted path below spec (e.g. "snapshot.max_parallel_workers"), and
+// returns the new content. Comments and unknown fields are preserved.
+func SetSpecValues(filename string, values map[string]interface{}) ([]byte, error) {
+	_, doc, err := readDocument(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := mappingValue(doc.Content[0], "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration has no spec")
+	}
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		var value yaml.Node
+		if err := value.Encode(values[path]); err != nil {
+			return nil, fmt.Errorf("failed to encode spec.%s: %w", path, err)
+		}
+		if err := setPath(spec, strings.Split(path, "."), &value); err != nil {
+			return nil, fmt.Errorf("failed to set spec.%s: %w", path, err)
+		}
+	}
+
+	return encodeDocument(doc)
+}
+
+// setPath sets the value at path below a mapping node, adding mappings
+// along the way as needed
+func setPath(node *yaml.Node, path []string, value *yaml.Node) error {
+	key := path[0]
+	existing := mappingValue(node, key)
+
+	if len(path) == 1 {
+		if existing != nil {
+			// Keep comments attached to the old value
+			value.HeadComment, value.LineComment, value.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+			*existing = *value
+			return nil
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		return nil
+	}
+
+	if existing == nil {
+		existing = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, existing)
+	}
+	if existing.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", key)
+	}
+	return setPath(existing, path[1:], value)
+}
+
+// syncNode updates dst in place to hold the values of src, keeping the
+// comments, key order, and scalar styles of dst wherever values are
+// unchanged. Keys missing from src are removed; new keys are appended.
+func syncNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		wanted := make(map[string]bool, len(src.Content)/2)
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, value := src.Content[i], src.Content[i+1]
+			wanted[key.Value] = true
+			if existing := mappingValue(dst, key.Value); existing != nil {
+				syncNode(existing, value)
+			} else {
+				dst.Content = append(dst.Content, key, value)
+			}
+		}
+		for i := 0; i+1 < len(dst.Content); {
+			if !wanted[dst.Content[i].Value] {
+				dst.Content = append(dst.Content[:i], dst.Content[i+2:]...)
+				continue
+			}
+			i += 2
+		}
+
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode && dst.Value == src.Value && dst.Tag == src.Tag:
+		// Unchanged; keep the original quoting
+
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// readDocument reads a single-document YAML file, returning the raw content
+// and its document node
+func readDocument(filename string) ([]byte, *yaml.Node, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil, fmt.Errorf("file does not contain a YAML document")
+	}
+	return data, &doc, nil
+}
+
+// encodeDocument marshals a document node with the indentation used by
+// configuration files
+func encodeDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	encoder.Close()
+	return buf.Bytes(), nil
+}
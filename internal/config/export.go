@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// FromPeerProto converts a Peer protobuf to a FileConfig. Secrets are
+// replaced with ${VAR} placeholders derived from the peer name.
+func FromPeerProto(peer *pb.Peer, environment string) (*FileConfig, error) {
+	fc := &FileConfig{
+		APIVersion: "v1",
+		Kind:       "Peer",
+		Metadata: Metadata{
+			Name:        peer.Name,
+			Environment: environment,
+		},
+	}
+
+	switch c := peer.Config.(type) {
+	case *pb.Peer_PostgresConfig:
+		pg := c.PostgresConfig
+		fc.Spec.Type = "postgres"
+		fc.Spec.Config = PostgresConfig{
+			Host:           pg.Host,
+			Port:           int(pg.Port),
+			User:           pg.User,
+			Password:       secretPlaceholder(peer.Name, "PASSWORD"),
+			Database:       pg.Database,
+			TLSHost:        pg.TlsHost,
+			MetadataSchema: pg.GetMetadataSchema(),
+			RequireTLS:     pg.RequireTls,
+			RootCA:         pg.GetRootCa(),
+		}
+
+	case *pb.Peer_SnowflakeConfig:
+		sf := c.SnowflakeConfig
+		sfConfig := SnowflakeConfig{
+			AccountID:      sf.AccountId,
+			Username:       sf.Username,
+			Database:       sf.Database,
+			Warehouse:      sf.Warehouse,
+			Role:           sf.Role,
+			QueryTimeout:   sf.QueryTimeout,
+			S3Integration:  sf.S3Integration,
+			MetadataSchema: sf.GetMetadataSchema(),
+		}
+		if sf.PrivateKey != "" {
+			sfConfig.PrivateKey = secretPlaceholder(peer.Name, "PRIVATE_KEY")
+		}
+		if sf.Password != nil {
+			sfConfig.Password = secretPlaceholder(peer.Name, "PASSWORD")
+		}
+		fc.Spec.Type = "snowflake"
+		fc.Spec.Config = sfConfig
+
+	case *pb.Peer_BigqueryConfig:
+		bq := c.BigqueryConfig
+		fc.Spec.Type = "bigquery"
+		fc.Spec.Config = BigQueryConfig{
+			AuthType:     bq.AuthType,
+			ProjectID:    bq.ProjectId,
+			DatasetID:    bq.DatasetId,
+			PrivateKeyID: bq.PrivateKeyId,
+			PrivateKey:   secretPlaceholder(peer.Name, "PRIVATE_KEY"),
+			ClientEmail:  bq.ClientEmail,
+			ClientID:     bq.ClientId,
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported peer type: %s", peer.Type.String())
+	}
+
+	return fc, nil
+}
+
+// FromMirrorProto converts a mirror's connection configs to a FileConfig
+func FromMirrorProto(cfg *pb.FlowConnectionConfigs, environment string) *FileConfig {
+	fc := &FileConfig{
+		APIVersion: "v1",
+		Kind:       "Mirror",
+		Metadata: Metadata{
+			Name:        cfg.FlowJobName,
+			Environment: environment,
+		},
+		Spec: Spec{
+			Type:        "cdc",
+			Source:      cfg.SourceName,
+			Destination: cfg.DestinationName,
+			CDC: &CDCConfig{
+				BatchSize:           cfg.MaxBatchSize,
+				IdleTimeoutSeconds:  cfg.IdleTimeoutSeconds,
+				InitialSnapshot:     cfg.DoInitialSnapshot,
+				PublicationName:     cfg.PublicationName,
+				ReplicationSlotName: cfg.ReplicationSlotName,
+			},
+		},
+	}
+
+	for _, table := range cfg.TableMappings {
+		fc.Spec.Tables = append(fc.Spec.Tables, TableConfig{
+			Source:         table.SourceTableIdentifier,
+			Destination:    table.DestinationTableIdentifier,
+			PartitionKey:   table.PartitionKey,
+			ExcludeColumns: table.Exclude,
+
+			DestinationOptions: destinationOptionsFromProto(table),
+		})
+	}
+	sort.Slice(fc.Spec.Tables, func(i, j int) bool {
+		return fc.Spec.Tables[i].Source < fc.Spec.Tables[j].Source
+	})
+
+	if cfg.SnapshotNumRowsPerPartition != 0 || cfg.SnapshotMaxParallelWorkers != 0 || cfg.SnapshotNumTablesInParallel != 0 {
+		fc.Spec.Snapshot = &SnapshotConfig{
+			NumRowsPerPartition: cfg.SnapshotNumRowsPerPartition,
+			MaxParallelWorkers:  cfg.SnapshotMaxParallelWorkers,
+			NumTablesInParallel: cfg.SnapshotNumTablesInParallel,
+		}
+	}
+
+	// The CLI only sets a script through destination_options.snowflake
+	if cfg.Script != "" {
+		fc.Spec.DestinationOptions = &DestinationOptions{
+			Snowflake: &SnowflakeOptions{TransformationScript: cfg.Script},
+		}
+	}
+
+	if cfg.SoftDeleteColName != "" || cfg.SyncedAtColName != "" {
+		fc.Spec.Columns = &ColumnsConfig{
+			SoftDeleteColumn: cfg.SoftDeleteColName,
+			SyncedAtColumn:   cfg.SyncedAtColName,
+		}
+	}
+
+	// Provenance annotations are stamped again on apply
+	typeMapping, env := TypeMappingFromEnv(cfg.Env)
+	fc.Spec.TypeMapping = typeMapping
+	for key, value := range env {
+		if strings.HasPrefix(key, provenance.Prefix) {
+			continue
+		}
+		if fc.Spec.Env == nil {
+			fc.Spec.Env = make(map[string]string)
+		}
+		fc.Spec.Env[key] = value
+	}
+
+	return fc
+}
+
+// secretPlaceholder returns an environment variable placeholder for a
+// secret field of the named resource, e.g. ${MY_PEER_PASSWORD}
+func secretPlaceholder(name, field string) string {
+	upper := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	return fmt.Sprintf("${%s_%s}", upper, field)
+}
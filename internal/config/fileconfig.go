@@ -1,13 +1,17 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"gopkg.in/yaml.v3"
+
 	pb "github.com/janakos/mirror_cli/proto/gen"
 )
 
@@ -17,6 +21,12 @@ type FileConfig struct {
 	Kind       string   `yaml:"kind"`
 	Metadata   Metadata `yaml:"metadata"`
 	Spec       Spec     `yaml:"spec"`
+
+	// Path is the file the configuration was loaded from, if any
+	Path string `yaml:"-"`
+
+	// node is the parsed document, used to merge mirror templates
+	node *yaml.Node
 }
 
 // Metadata contains configuration metadata
@@ -34,13 +44,26 @@ type Spec struct {
 	Validation *Validation `yaml:"validation,omitempty"`
 
 	// For Mirror configurations
-	Source      string        `yaml:"source,omitempty"`
-	Destination string        `yaml:"destination,omitempty"`
-	Tables      []TableConfig `yaml:"tables,omitempty"`
-	CDC         *CDCConfig    `yaml:"cdc,omitempty"`
-	Snapshot    *SnapshotConfig `yaml:"snapshot,omitempty"`
-	Columns     *ColumnsConfig  `yaml:"columns,omitempty"`
-	Env         map[string]string `yaml:"env,omitempty"`
+	Source      string             `yaml:"source,omitempty"`
+	Destination string             `yaml:"destination,omitempty"`
+	Template    string             `yaml:"template,omitempty"`
+	Tables      []TableConfig      `yaml:"tables,omitempty"`
+	CDC         *CDCConfig         `yaml:"cdc,omitempty"`
+	Snapshot    *SnapshotConfig    `yaml:"snapshot,omitempty"`
+	Columns     *ColumnsConfig     `yaml:"columns,omitempty"`
+	TypeMapping *TypeMappingConfig `yaml:"type_mapping,omitempty"`
+	Env         map[string]string  `yaml:"env,omitempty"`
+
+	// DestinationOptions are settings specific to the destination's type,
+	// e.g. ClickHouse table engines
+	DestinationOptions *DestinationOptions `yaml:"destination_options,omitempty"`
+
+	// DefaultDestinationSchema qualifies the source table name of table
+	// mappings without a destination, e.g. ANALYTICS.PUBLIC
+	DefaultDestinationSchema string `yaml:"default_destination_schema,omitempty"`
+
+	// For query replication mirrors (spec.type qrep)
+	QRep *QRepConfig `yaml:"qrep,omitempty"`
 }
 
 // Validation contains validation settings
@@ -51,26 +74,34 @@ type Validation struct {
 
 // TableConfig represents table mapping configuration
 type TableConfig struct {
-	Source           string   `yaml:"source"`
-	Destination      string   `yaml:"destination"`
-	PartitionKey     string   `yaml:"partition_key,omitempty"`
-	ExcludeColumns   []string `yaml:"exclude_columns,omitempty"`
+	Source         string   `yaml:"source"`
+	Destination    string   `yaml:"destination,omitempty"`
+	PartitionKey   string   `yaml:"partition_key,omitempty"`
+	ExcludeColumns []string `yaml:"exclude_columns,omitempty"`
+
+	// Overrides of spec.columns for this table
+	SoftDelete       *bool  `yaml:"soft_delete,omitempty"`
+	SoftDeleteColumn string `yaml:"soft_delete_column,omitempty"`
+	SyncedAtColumn   string `yaml:"synced_at_column,omitempty"`
+
+	// Overrides of spec.destination_options for this table
+	DestinationOptions *DestinationOptions `yaml:"destination_options,omitempty"`
 }
 
 // CDCConfig contains CDC-specific configuration
 type CDCConfig struct {
-	BatchSize             uint32 `yaml:"batch_size,omitempty"`
-	IdleTimeoutSeconds    uint64 `yaml:"idle_timeout_seconds,omitempty"`
-	InitialSnapshot       bool   `yaml:"initial_snapshot,omitempty"`
-	PublicationName       string `yaml:"publication_name,omitempty"`
-	ReplicationSlotName   string `yaml:"replication_slot_name,omitempty"`
+	BatchSize           uint32 `yaml:"batch_size,omitempty"`
+	IdleTimeoutSeconds  uint64 `yaml:"idle_timeout_seconds,omitempty"`
+	InitialSnapshot     bool   `yaml:"initial_snapshot,omitempty"`
+	PublicationName     string `yaml:"publication_name,omitempty"`
+	ReplicationSlotName string `yaml:"replication_slot_name,omitempty"`
 }
 
 // SnapshotConfig contains snapshot-specific configuration
 type SnapshotConfig struct {
-	NumRowsPerPartition    uint32 `yaml:"num_rows_per_partition,omitempty"`
-	MaxParallelWorkers     uint32 `yaml:"max_parallel_workers,omitempty"`
-	NumTablesInParallel    uint32 `yaml:"num_tables_in_parallel,omitempty"`
+	NumRowsPerPartition uint32 `yaml:"num_rows_per_partition,omitempty"`
+	MaxParallelWorkers  uint32 `yaml:"max_parallel_workers,omitempty"`
+	NumTablesInParallel uint32 `yaml:"num_tables_in_parallel,omitempty"`
 }
 
 // ColumnsConfig contains column-specific configuration
@@ -88,6 +119,9 @@ type PostgresConfig struct {
 	Database       string `yaml:"database"`
 	TLSHost        string `yaml:"tls_host,omitempty"`
 	MetadataSchema string `yaml:"metadata_schema,omitempty"`
+	RequireTLS     bool   `yaml:"require_tls,omitempty"`
+	RootCA         string `yaml:"root_ca,omitempty"`
+	RootCAFile     string `yaml:"root_ca_file,omitempty"`
 }
 
 // SnowflakeConfig represents Snowflake configuration
@@ -100,9 +134,95 @@ type SnowflakeConfig struct {
 	Warehouse      string `yaml:"warehouse"`
 	Role           string `yaml:"role,omitempty"`
 	QueryTimeout   uint64 `yaml:"query_timeout,omitempty"`
+	S3Integration  string `yaml:"s3_integration,omitempty"`
 	MetadataSchema string `yaml:"metadata_schema,omitempty"`
 }
 
+// BigQueryConfig represents BigQuery configuration
+type BigQueryConfig struct {
+	AuthType                string `yaml:"auth_type,omitempty"`
+	ProjectID               string `yaml:"project_id"`
+	DatasetID               string `yaml:"dataset_id"`
+	PrivateKeyID            string `yaml:"private_key_id,omitempty"`
+	PrivateKey              string `yaml:"private_key,omitempty"`
+	ClientEmail             string `yaml:"client_email,omitempty"`
+	ClientID                string `yaml:"client_id,omitempty"`
+	AuthURI                 string `yaml:"auth_uri,omitempty"`
+	TokenURI                string `yaml:"token_uri,omitempty"`
+	AuthProviderX509CertURL string `yaml:"auth_provider_x509_cert_url,omitempty"`
+	ClientX509CertURL       string `yaml:"client_x509_cert_url,omitempty"`
+	CredentialsFile         string `yaml:"credentials_file,omitempty"`
+}
+
+// ServiceAccountKey represents a GCP service account JSON key file
+type ServiceAccountKey struct {
+	Type                    string `json:"type"`
+	ProjectID               string `json:"project_id"`
+	PrivateKeyID            string `json:"private_key_id"`
+	PrivateKey              string `json:"private_key"`
+	ClientEmail             string `json:"client_email"`
+	ClientID                string `json:"client_id"`
+	AuthURI                 string `json:"auth_uri"`
+	TokenURI                string `json:"token_uri"`
+	AuthProviderX509CertURL string `json:"auth_provider_x509_cert_url"`
+	ClientX509CertURL       string `json:"client_x509_cert_url"`
+}
+
+// ContextConfig represents PeerDB server connection settings
+type ContextConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port,omitempty"`
+	TLS      bool   `yaml:"tls,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// Hosts lists servers to try in order, as host or host:port
+	Hosts []string `yaml:"hosts,omitempty"`
+
+	// DropPolicy is keep-destination or drop-destination
+	DropPolicy string `yaml:"drop_policy,omitempty"`
+
+	// ReadOnly blocks commands that change server state
+	ReadOnly bool `yaml:"read_only,omitempty"`
+
+	// ConfirmMode is simple, typed, or off
+	ConfirmMode string `yaml:"confirm_mode,omitempty"`
+
+	// NamePrefix is prepended to created peer and mirror names
+	NamePrefix string `yaml:"name_prefix,omitempty"`
+
+	// PeerDBAPI is auto, current, or legacy
+	PeerDBAPI string `yaml:"peerdb_api,omitempty"`
+
+	// Environment defaults to the context file's metadata.environment
+	Environment string `yaml:"environment,omitempty"`
+
+	// RequireEnvironmentMatch blocks applies for other environments
+	RequireEnvironmentMatch bool `yaml:"require_environment_match,omitempty"`
+
+	// CredentialHelper supplies credentials and secrets for the context
+	CredentialHelper string `yaml:"credential_helper,omitempty"`
+
+	// DefaultDestinationSchema qualifies table mappings without a
+	// destination
+	DefaultDestinationSchema string `yaml:"default_destination_schema,omitempty"`
+
+	// TemporalUIURL links to mirror workflows in the Temporal UI
+	TemporalUIURL string `yaml:"temporal_ui_url,omitempty"`
+
+	// ReadTimeout, MutateTimeout, and WaitTimeout bound lookups, changes,
+	// and waits against the deployment, e.g. "2m"
+	ReadTimeout   string `yaml:"read_timeout,omitempty"`
+	MutateTimeout string `yaml:"mutate_timeout,omitempty"`
+	WaitTimeout   string `yaml:"wait_timeout,omitempty"`
+
+	// ExtraHeaders are sent as gRPC metadata with every RPC
+	ExtraHeaders map[string]string `yaml:"extra_headers,omitempty"`
+
+	// Hooks run before and after commands that change server state
+	Hooks map[string]string `yaml:"hooks,omitempty"`
+}
+
 // LoadConfigFile loads a configuration file from disk
 func LoadConfigFile(filename string) (*FileConfig, error) {
 	data, err := ioutil.ReadFile(filename)
@@ -110,30 +230,121 @@ func LoadConfigFile(filename string) (*FileConfig, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	// Decrypt sops-encrypted files in memory before anything else
+	data, err = decryptSops(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Render Go templates before expanding variables
+	if strings.HasSuffix(filename, ".gotmpl") {
+		data, err = renderTemplate(filename, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Expand environment variables
-	content := os.ExpandEnv(string(data))
+	content, err := expandVariables(string(data))
+	if err != nil {
+		return nil, err
+	}
 
-	var config FileConfig
-	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	config, err := decodeConfig(&doc)
+	if err != nil {
+		return nil, err
+	}
+	config.Path = filename
+
+	return config, nil
+}
+
+// decodeConfig decodes a parsed YAML document, normalizing v2 documents to
+// the v1 layout used internally
+func decodeConfig(doc *yaml.Node) (*FileConfig, error) {
+	var config FileConfig
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return &config, nil
+	}
+
+	root := doc.Content[0]
+	switch version := scalarValue(root, "apiVersion"); version {
+	case "", APIVersionV1:
+	case APIVersionV2:
+		// v2 files are normalized to the v1 layout used internally
+		if err := normalizeToV1(root); err != nil {
+			return nil, err
+		}
+	default:
+		// Leave other tools' documents (e.g. Kubernetes manifests) to
+		// the unknown kind handling
+		if IsKnownKind(scalarValue(root, "kind")) || IsKnownV2Kind(scalarValue(root, "kind")) {
+			return nil, fmt.Errorf("unsupported apiVersion: %s", version)
+		}
+	}
+
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	config.node = root
 	return &config, nil
 }
 
+// renderTemplate renders a .gotmpl configuration file. The template data is
+// the process environment, and the env function looks up a single variable.
+func renderTemplate(filename string, data []byte) ([]byte, error) {
+	environ := make(map[string]string)
+	for key, value := range EnvFileValues {
+		environ[key] = value
+	}
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			environ[key] = value
+		}
+	}
+	env := func(name string) string {
+		value, _ := lookupVariable(name)
+		return value
+	}
+
+	tmpl, err := template.New(filepath.Base(filename)).
+		Funcs(template.FuncMap{"env": env}).
+		Option("missingkey=zero").
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, environ); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // SaveConfigFile saves a configuration to disk
 func SaveConfigFile(config *FileConfig, filename string) error {
+	return SaveConfigFileAs(config, filename, FormatYAML)
+}
+
+// SaveConfigFileAs saves a configuration to disk in the given format
+func SaveConfigFileAs(config *FileConfig, filename, format string) error {
+	data, err := MarshalFileConfig(config, format)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", strings.ToUpper(format), err)
+	}
+
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to marshal YAML: %w", err)
-	}
-
 	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
@@ -160,6 +371,14 @@ func (fc *FileConfig) ToPeerProto() (*pb.Peer, error) {
 		}
 		peer.Config = &pb.Peer_PostgresConfig{PostgresConfig: pgConfig}
 
+	case "bigquery", "bq":
+		peer.Type = pb.DBType_BIGQUERY
+		bqConfig, err := convertToBigQueryConfig(fc.Spec.Config)
+		if err != nil {
+			return nil, err
+		}
+		peer.Config = &pb.Peer_BigqueryConfig{BigqueryConfig: bqConfig}
+
 	case "snowflake":
 		peer.Type = pb.DBType_SNOWFLAKE
 		sfConfig, err := convertToSnowflakeConfig(fc.Spec.Config)
@@ -169,36 +388,128 @@ func (fc *FileConfig) ToPeerProto() (*pb.Peer, error) {
 		peer.Config = &pb.Peer_SnowflakeConfig{SnowflakeConfig: sfConfig}
 
 	default:
-		return nil, fmt.Errorf("unsupported peer type: %s", fc.Spec.Type)
+		return nil, fieldErrorf("spec.type", "unsupported peer type: %s", fc.Spec.Type)
 	}
 
 	return peer, nil
 }
 
-// ToMirrorProto converts a FileConfig to mirror creation request
-func (fc *FileConfig) ToMirrorProto() (*pb.CreateCDCFlowRequest, error) {
+// ToContext converts a FileConfig to a CLI context
+func (fc *FileConfig) ToContext() (*Context, error) {
+	if fc.Kind != "Context" {
+		return nil, fmt.Errorf("config is not a Context, got: %s", fc.Kind)
+	}
+
+	data, err := yaml.Marshal(fc.Spec.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var ctxConfig ContextConfig
+	if err := yaml.Unmarshal(data, &ctxConfig); err != nil {
+		return nil, err
+	}
+
+	if ctxConfig.Host == "" && len(ctxConfig.Hosts) == 0 {
+		return nil, fieldErrorf("spec.config", "context requires a host or hosts")
+	}
+	if ctxConfig.Port == 0 {
+		ctxConfig.Port = DefaultConfig().PeerDBPort
+	}
+	if err := ValidateDropPolicy(ctxConfig.DropPolicy); err != nil {
+		return nil, err
+	}
+	if err := ValidateConfirmMode(ctxConfig.ConfirmMode); err != nil {
+		return nil, err
+	}
+	if err := ValidateNamePrefix(ctxConfig.NamePrefix); err != nil {
+		return nil, err
+	}
+	if err := ValidatePeerDBAPI(ctxConfig.PeerDBAPI); err != nil {
+		return nil, err
+	}
+	if err := ValidateTemporalUIURL(ctxConfig.TemporalUIURL); err != nil {
+		return nil, err
+	}
+	if err := validateTimeouts(ctxConfig.ReadTimeout, ctxConfig.MutateTimeout, ctxConfig.WaitTimeout); err != nil {
+		return nil, err
+	}
+	if ctxConfig.Environment == "" {
+		ctxConfig.Environment = fc.Metadata.Environment
+	}
+
+	return &Context{
+		PeerDBHost:   ctxConfig.Host,
+		PeerDBPort:   ctxConfig.Port,
+		PeerDBHosts:  ctxConfig.Hosts,
+		TLS:          ctxConfig.TLS,
+		Username:     ctxConfig.Username,
+		Password:     ctxConfig.Password,
+		DropPolicy:   ctxConfig.DropPolicy,
+		ReadOnly:     ctxConfig.ReadOnly,
+		Environment:  ctxConfig.Environment,
+		ConfirmMode:  ctxConfig.ConfirmMode,
+		NamePrefix:   ctxConfig.NamePrefix,
+		PeerDBAPI:    ctxConfig.PeerDBAPI,
+		ExtraHeaders: ctxConfig.ExtraHeaders,
+		Hooks:        ctxConfig.Hooks,
+
+		RequireEnvironmentMatch:  ctxConfig.RequireEnvironmentMatch,
+		CredentialHelper:         ctxConfig.CredentialHelper,
+		DefaultDestinationSchema: ctxConfig.DefaultDestinationSchema,
+		TemporalUIURL:            ctxConfig.TemporalUIURL,
+		ReadTimeout:              ctxConfig.ReadTimeout,
+		MutateTimeout:            ctxConfig.MutateTimeout,
+		WaitTimeout:              ctxConfig.WaitTimeout,
+	}, nil
+}
+
+// ToMirrorProto converts a FileConfig to mirror creation request. Tables
+// without a destination fall back to the default destination schema.
+func (fc *FileConfig) ToMirrorProto(defaults Defaults) (*pb.CreateCDCFlowRequest, error) {
 	if fc.Kind != "Mirror" {
 		return nil, fmt.Errorf("config is not a Mirror, got: %s", fc.Kind)
 	}
+	if fc.Spec.Template != "" {
+		return nil, fieldErrorf("spec.template", "mirror template '%s' not found; load it together with the mirror", fc.Spec.Template)
+	}
+	if fc.IsQRep() {
+		return nil, fmt.Errorf("mirror '%s' is a query replication mirror, not a CDC mirror", fc.Metadata.Name)
+	}
+	if fc.Spec.QRep != nil {
+		return nil, fieldErrorf("spec.qrep", "spec.qrep only applies to query replication mirrors (spec.type qrep)")
+	}
+
+	if err := fc.validateDestinationOptions(); err != nil {
+		return nil, err
+	}
 
 	// Convert table mappings
 	tableMappings := make([]*pb.TableMapping, len(fc.Spec.Tables))
 	for i, table := range fc.Spec.Tables {
+		destination, err := fc.tableDestination(i, table, defaults)
+		if err != nil {
+			return nil, err
+		}
 		tableMappings[i] = &pb.TableMapping{
 			SourceTableIdentifier:      table.Source,
-			DestinationTableIdentifier: table.Destination,
+			DestinationTableIdentifier: destination,
 			PartitionKey:               table.PartitionKey,
 			Exclude:                    table.ExcludeColumns,
 		}
+		if err := fc.applyDestinationOptions(i, table, tableMappings[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	// Build connection config
 	connectionConfig := &pb.FlowConnectionConfigs{
-		FlowJobName:         fc.Metadata.Name,
-		SourceName:          fc.Spec.Source,
-		DestinationName:     fc.Spec.Destination,
-		TableMappings:       tableMappings,
-		Env:                 fc.Spec.Env,
+		FlowJobName:     fc.Metadata.Name,
+		SourceName:      fc.Spec.Source,
+		DestinationName: fc.Spec.Destination,
+		TableMappings:   tableMappings,
+		Script:          fc.transformationScript(),
+		Env:             fc.Spec.Env,
 	}
 
 	// Add CDC configuration
@@ -218,9 +529,16 @@ func (fc *FileConfig) ToMirrorProto() (*pb.CreateCDCFlowRequest, error) {
 	}
 
 	// Add column configuration
-	if fc.Spec.Columns != nil {
-		connectionConfig.SoftDeleteColName = fc.Spec.Columns.SoftDeleteColumn
-		connectionConfig.SyncedAtColName = fc.Spec.Columns.SyncedAtColumn
+	columns, err := fc.EffectiveColumns()
+	if err != nil {
+		return nil, err
+	}
+	connectionConfig.SoftDeleteColName = columns.SoftDeleteColumn
+	connectionConfig.SyncedAtColName = columns.SyncedAtColumn
+
+	connectionConfig.Env, err = fc.mirrorEnv()
+	if err != nil {
+		return nil, err
 	}
 
 	return &pb.CreateCDCFlowRequest{
@@ -228,6 +546,26 @@ func (fc *FileConfig) ToMirrorProto() (*pb.CreateCDCFlowRequest, error) {
 	}, nil
 }
 
+// mirrorEnv returns a mirror's env with its type mappings, which are sent
+// as env settings
+func (fc *FileConfig) mirrorEnv() (map[string]string, error) {
+	if fc.Spec.TypeMapping == nil {
+		return fc.Spec.Env, nil
+	}
+	typeEnv, err := fc.Spec.TypeMapping.Env(fc.Spec.Env)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(fc.Spec.Env)+len(typeEnv))
+	for key, value := range fc.Spec.Env {
+		env[key] = value
+	}
+	for key, value := range typeEnv {
+		env[key] = value
+	}
+	return env, nil
+}
+
 // convertToPostgresConfig converts interface{} to PostgresConfig
 func convertToPostgresConfig(config interface{}) (*pb.PostgresConfig, error) {
 	data, err := yaml.Marshal(config)
@@ -241,18 +579,33 @@ func convertToPostgresConfig(config interface{}) (*pb.PostgresConfig, error) {
 	}
 
 	pbConfig := &pb.PostgresConfig{
-		Host:     pgConfig.Host,
-		Port:     uint32(pgConfig.Port),
-		User:     pgConfig.User,
-		Password: pgConfig.Password,
-		Database: pgConfig.Database,
-		TlsHost:  pgConfig.TLSHost,
+		Host:       pgConfig.Host,
+		Port:       uint32(pgConfig.Port),
+		User:       pgConfig.User,
+		Password:   pgConfig.Password,
+		Database:   pgConfig.Database,
+		TlsHost:    pgConfig.TLSHost,
+		RequireTls: pgConfig.RequireTLS,
 	}
 
 	if pgConfig.MetadataSchema != "" {
 		pbConfig.MetadataSchema = &pgConfig.MetadataSchema
 	}
 
+	if pgConfig.RootCAFile != "" {
+		if pgConfig.RootCA != "" {
+			return nil, fieldErrorf("spec.config.root_ca_file", "root_ca and root_ca_file are mutually exclusive")
+		}
+		data, err := ioutil.ReadFile(pgConfig.RootCAFile)
+		if err != nil {
+			return nil, &FieldError{Field: "spec.config.root_ca_file", Err: fmt.Errorf("failed to read root CA file: %w", err)}
+		}
+		pgConfig.RootCA = string(data)
+	}
+	if pgConfig.RootCA != "" {
+		pbConfig.RootCa = &pgConfig.RootCA
+	}
+
 	return pbConfig, nil
 }
 
@@ -269,12 +622,13 @@ func convertToSnowflakeConfig(config interface{}) (*pb.SnowflakeConfig, error) {
 	}
 
 	pbConfig := &pb.SnowflakeConfig{
-		AccountId:    sfConfig.AccountID,
-		Username:     sfConfig.Username,
-		Database:     sfConfig.Database,
-		Warehouse:    sfConfig.Warehouse,
-		Role:         sfConfig.Role,
-		QueryTimeout: sfConfig.QueryTimeout,
+		AccountId:     sfConfig.AccountID,
+		Username:      sfConfig.Username,
+		Database:      sfConfig.Database,
+		Warehouse:     sfConfig.Warehouse,
+		Role:          sfConfig.Role,
+		QueryTimeout:  sfConfig.QueryTimeout,
+		S3Integration: sfConfig.S3Integration,
 	}
 
 	if sfConfig.PrivateKey != "" {
@@ -290,29 +644,129 @@ func convertToSnowflakeConfig(config interface{}) (*pb.SnowflakeConfig, error) {
 	return pbConfig, nil
 }
 
-// LoadConfigsFromDirectory loads all config files from a directory
-func LoadConfigsFromDirectory(dirPath string) ([]*FileConfig, error) {
-	var configs []*FileConfig
+// convertToBigQueryConfig converts interface{} to BigqueryConfig
+func convertToBigQueryConfig(config interface{}) (*pb.BigqueryConfig, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var bqConfig BigQueryConfig
+	if err := yaml.Unmarshal(data, &bqConfig); err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	// Fill in credentials from the service account key file, keeping any
+	// values that were set explicitly
+	if bqConfig.CredentialsFile != "" {
+		key, err := LoadServiceAccountKey(bqConfig.CredentialsFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		if info.IsDir() {
-			return nil
+		if bqConfig.ProjectID == "" {
+			bqConfig.ProjectID = key.ProjectID
 		}
-
-		if strings.HasSuffix(strings.ToLower(path), ".yaml") || strings.HasSuffix(strings.ToLower(path), ".yml") {
-			config, err := LoadConfigFile(path)
-			if err != nil {
-				return fmt.Errorf("failed to load %s: %w", path, err)
-			}
-			configs = append(configs, config)
+		if bqConfig.PrivateKeyID == "" {
+			bqConfig.PrivateKeyID = key.PrivateKeyID
+		}
+		if bqConfig.PrivateKey == "" {
+			bqConfig.PrivateKey = key.PrivateKey
+		}
+		if bqConfig.ClientEmail == "" {
+			bqConfig.ClientEmail = key.ClientEmail
+		}
+		if bqConfig.ClientID == "" {
+			bqConfig.ClientID = key.ClientID
+		}
+		if bqConfig.AuthURI == "" {
+			bqConfig.AuthURI = key.AuthURI
 		}
+		if bqConfig.TokenURI == "" {
+			bqConfig.TokenURI = key.TokenURI
+		}
+		if bqConfig.AuthProviderX509CertURL == "" {
+			bqConfig.AuthProviderX509CertURL = key.AuthProviderX509CertURL
+		}
+		if bqConfig.ClientX509CertURL == "" {
+			bqConfig.ClientX509CertURL = key.ClientX509CertURL
+		}
+	}
+
+	if bqConfig.ProjectID == "" || bqConfig.DatasetID == "" {
+		return nil, fieldErrorf("spec.config", "bigquery peer requires project_id and dataset_id")
+	}
+
+	pbConfig := &pb.BigqueryConfig{
+		AuthType:                bqConfig.AuthType,
+		ProjectId:               bqConfig.ProjectID,
+		PrivateKeyId:            bqConfig.PrivateKeyID,
+		PrivateKey:              bqConfig.PrivateKey,
+		ClientEmail:             bqConfig.ClientEmail,
+		ClientId:                bqConfig.ClientID,
+		AuthUri:                 bqConfig.AuthURI,
+		TokenUri:                bqConfig.TokenURI,
+		AuthProviderX509CertUrl: bqConfig.AuthProviderX509CertURL,
+		ClientX509CertUrl:       bqConfig.ClientX509CertURL,
+		DatasetId:               bqConfig.DatasetID,
+	}
+
+	if pbConfig.AuthType == "" {
+		pbConfig.AuthType = "service_account"
+	}
+	if pbConfig.AuthUri == "" {
+		pbConfig.AuthUri = "https://accounts.google.com/o/oauth2/auth"
+	}
+	if pbConfig.TokenUri == "" {
+		pbConfig.TokenUri = "https://oauth2.googleapis.com/token"
+	}
+	if pbConfig.AuthProviderX509CertUrl == "" {
+		pbConfig.AuthProviderX509CertUrl = "https://www.googleapis.com/oauth2/v1/certs"
+	}
+
+	return pbConfig, nil
+}
+
+// LoadServiceAccountKey reads a GCP service account JSON key file
+func LoadServiceAccountKey(filename string) (*ServiceAccountKey, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var key ServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", filename, err)
+	}
+
+	if key.Type != "" && key.Type != "service_account" {
+		return nil, fmt.Errorf("credentials file %s is not a service account key (type: %s)", filename, key.Type)
+	}
+	if key.PrivateKey == "" || key.ClientEmail == "" {
+		return nil, fmt.Errorf("credentials file %s is missing private_key or client_email", filename)
+	}
+
+	return &key, nil
+}
+
+// LoadConfigsFromDirectory loads all config files from a directory,
+// skipping YAML files that are not CLI configuration kinds
+func LoadConfigsFromDirectory(dirPath string, opts DiscoverOptions) ([]*FileConfig, error) {
+	files, err := FindConfigFiles(dirPath, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
-	})
+	var configs []*FileConfig
+	for _, path := range files {
+		config, err := LoadConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		if !IsKnownKind(config.Kind) {
+			continue
+		}
+		configs = append(configs, config)
+	}
 
-	return configs, err
+	return configs, nil
 }
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter selects configs by kind or name, as given to config apply's
+// --only and --skip flags
+type Filter struct {
+	// Field is "kind" or "name"
+	Field string
+	// Pattern is a glob such as "orders_*"; kinds match case-insensitively
+	Pattern string
+}
+
+// ParseFilter parses a filter of the form kind=Mirror or name=orders_*
+func ParseFilter(s string) (Filter, error) {
+	field, pattern, ok := strings.Cut(s, "=")
+	field = strings.ToLower(strings.TrimSpace(field))
+	pattern = strings.TrimSpace(pattern)
+	if !ok || pattern == "" || (field != "kind" && field != "name") {
+		return Filter{}, fmt.Errorf("invalid filter: %s (expected kind=<kind> or name=<pattern>)", s)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return Filter{}, fmt.Errorf("invalid filter pattern %q: %w", pattern, err)
+	}
+	return Filter{Field: field, Pattern: pattern}, nil
+}
+
+// ParseFilters parses a list of filters
+func ParseFilters(specs []string) ([]Filter, error) {
+	filters := make([]Filter, 0, len(specs))
+	for _, spec := range specs {
+		filter, err := ParseFilter(spec)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// Matches reports whether a config matches the filter
+func (f Filter) Matches(fc *FileConfig) bool {
+	if f.Field == "kind" {
+		matched, _ := path.Match(strings.ToLower(f.Pattern), strings.ToLower(fc.Kind))
+		return matched
+	}
+	matched, _ := path.Match(f.Pattern, fc.Metadata.Name)
+	return matched
+}
+
+// FilterConfigs returns the configs selected by only and not excluded by
+// skip. A config is selected if, for each field used in only, it matches
+// at least one of that field's filters, so --only kind=Mirror --only
+// name=orders_* selects mirrors named orders_*. It is excluded if it
+// matches any skip filter.
+func FilterConfigs(configs []*FileConfig, only, skip []Filter) []*FileConfig {
+	var result []*FileConfig
+	for _, fc := range configs {
+		if selected(fc, only) && !matchesAny(fc, skip) {
+			result = append(result, fc)
+		}
+	}
+	return result
+}
+
+func selected(fc *FileConfig, only []Filter) bool {
+	byField := make(map[string][]Filter)
+	for _, filter := range only {
+		byField[filter.Field] = append(byField[filter.Field], filter)
+	}
+	for _, filters := range byField {
+		if !matchesAny(fc, filters) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(fc *FileConfig, filters []Filter) bool {
+	for _, filter := range filters {
+		if filter.Matches(fc) {
+			return true
+		}
+	}
+	return false
+}
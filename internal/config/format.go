@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported file formats for exported configurations
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatHCL  = "hcl"
+)
+
+// ValidateFormat checks that a format name is supported
+func ValidateFormat(format string) error {
+	switch strings.ToLower(format) {
+	case FormatYAML, FormatJSON, FormatHCL:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format: %s (expected yaml, json, or hcl)", format)
+	}
+}
+
+// FormatExtension returns the conventional file extension for a format
+func FormatExtension(format string) string {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return "json"
+	case FormatHCL:
+		return "tf"
+	default:
+		return "yaml"
+	}
+}
+
+// MarshalFileConfig serializes a configuration in the requested format
+func MarshalFileConfig(config *FileConfig, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", FormatYAML:
+		return MarshalCanonical(config)
+	case FormatJSON:
+		doc, err := toGeneric(config)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatHCL:
+		return marshalHCL(config)
+	default:
+		return nil, ValidateFormat(format)
+	}
+}
+
+// toGeneric round-trips a configuration through canonical YAML so that the
+// yaml field names are used by the other encoders
+func toGeneric(config *FileConfig) (map[string]interface{}, error) {
+	data, err := MarshalCanonical(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// marshalHCL renders a configuration as a Terraform resource block
+func marshalHCL(config *FileConfig) ([]byte, error) {
+	doc, err := toGeneric(config)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"name": config.Metadata.Name,
+	}
+	if spec, ok := doc["spec"].(map[string]interface{}); ok {
+		for key, value := range spec {
+			body[key] = value
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "resource %q %q {\n", "peerdb_"+strings.ToLower(config.Kind), config.Metadata.Name)
+	writeHCLBody(&b, body, 1)
+	b.WriteString("}\n")
+
+	return []byte(b.String()), nil
+}
+
+func writeHCLBody(b *strings.Builder, body map[string]interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := body[key].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(b, "%s%s {\n", indent, key)
+			writeHCLBody(b, value, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		case []interface{}:
+			if len(value) > 0 {
+				if _, isBlock := value[0].(map[string]interface{}); isBlock {
+					for _, item := range value {
+						block, _ := item.(map[string]interface{})
+						fmt.Fprintf(b, "%s%s {\n", indent, key)
+						writeHCLBody(b, block, depth+1)
+						fmt.Fprintf(b, "%s}\n", indent)
+					}
+					continue
+				}
+			}
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = hclValue(item)
+			}
+			fmt.Fprintf(b, "%s%s = [%s]\n", indent, key, strings.Join(items, ", "))
+		default:
+			fmt.Fprintf(b, "%s%s = %s\n", indent, key, hclValue(value))
+		}
+	}
+}
+
+func hclValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		// Escape template sequences so ${VAR} placeholders survive as literals
+		quoted := strconv.Quote(v)
+		quoted = strings.ReplaceAll(quoted, "${", "$${")
+		return strings.ReplaceAll(quoted, "%{", "%%{")
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
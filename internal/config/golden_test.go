@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/golden"
+)
+
+// exampleDir holds the documented example configs used as fixtures
+const exampleDir = "../../configs"
+
+var variablePattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// TestRequestGoldens converts every example peer and mirror config into the
+// request config apply sends and compares it with testdata/golden
+func TestRequestGoldens(t *testing.T) {
+	files, err := FindConfigFiles(exampleDir, DiscoverOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(exampleDir, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(rel, func(t *testing.T) {
+			setPlaceholderVariables(t, file)
+
+			base := filepath.Join("testdata", "golden", strings.TrimSuffix(rel, filepath.Ext(rel)))
+			cfg, err := LoadConfigFile(file)
+			if err != nil {
+				golden.AssertText(t, base+".error", err.Error())
+				return
+			}
+
+			switch cfg.Kind {
+			case "Peer":
+				peer, err := cfg.ToPeerProto()
+				if err != nil {
+					golden.AssertText(t, base+".error", err.Error())
+					return
+				}
+				golden.Assert(t, base+".textproto", peer)
+			case "Mirror":
+				var req proto.Message
+				if cfg.IsQRep() {
+					req, err = cfg.ToQRepProto()
+				} else {
+					req, err = cfg.ToMirrorProto(Defaults{})
+				}
+				if err != nil {
+					golden.AssertText(t, base+".error", err.Error())
+					return
+				}
+				golden.Assert(t, base+".textproto", req)
+			default:
+				t.Skipf("%s configs aren't sent to PeerDB", cfg.Kind)
+			}
+		})
+	}
+}
+
+// setPlaceholderVariables sets each ${VAR} used by file to a fixed value, so
+// the goldens don't depend on the environment the tests run in
+func setPlaceholderVariables(t *testing.T, file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range variablePattern.FindAllStringSubmatch(string(data), -1) {
+		t.Setenv(match[1], "<"+match[1]+">")
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/youmark/pkcs8"
+)
+
+// LoadPrivateKeyFile reads a PEM private key, decrypting it with the
+// passphrase when needed, and returns it as an unencrypted PKCS#8 PEM string
+// as expected by PeerDB's Snowflake peers.
+func LoadPrivateKeyFile(filename string, passphrase []byte) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", fmt.Errorf("private key file %s does not contain a PEM block", filename)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		if len(passphrase) == 0 {
+			return "", fmt.Errorf("private key file %s is encrypted; a passphrase is required", filename)
+		}
+		key, err = pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt private key: %w", err)
+		}
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return "", fmt.Errorf("unsupported private key type: %s", block.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
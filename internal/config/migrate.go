@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported configuration file API versions
+const (
+	APIVersionV1 = "v1"
+	APIVersionV2 = "v2"
+)
+
+// ErrUnknownKind is returned when migrating a file whose kind is not a CLI
+// configuration kind
+var ErrUnknownKind = errors.New("unsupported configuration kind")
+
+// peerTypeAliases maps accepted v1 peer type names to their v2 spec keys
+var peerTypeAliases = map[string]string{
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"snowflake":  "snowflake",
+	"sf":         "snowflake",
+	"bigquery":   "bigquery",
+	"bq":         "bigquery",
+}
+
+// KnownV2Kinds lists the configuration kinds accepted in v2 files
+var KnownV2Kinds = []string{"Peer", "CDCMirror", "QRepMirror", "MirrorTemplate", "Context"}
+
+// IsKnownV2Kind reports whether kind is a v2 configuration kind
+func IsKnownV2Kind(kind string) bool {
+	for _, known := range KnownV2Kinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateFile rewrites a v1 configuration file to v2 in memory, returning
+// the new content and whether anything changed. Comments and unknown fields
+// are preserved.
+func MigrateFile(filename string) ([]byte, bool, error) {
+	data, doc, err := readDocument(filename)
+	if err != nil {
+		return nil, false, err
+	}
+
+	root := doc.Content[0]
+	switch version := scalarValue(root, "apiVersion"); version {
+	case APIVersionV2:
+		return data, false, nil
+	case "", APIVersionV1:
+	default:
+		return nil, false, fmt.Errorf("unsupported apiVersion: %s", version)
+	}
+
+	if err := migrateToV2(root); err != nil {
+		return nil, false, err
+	}
+
+	out, err := encodeDocument(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+// migrateToV2 rewrites a v1 document's root mapping to the v2 layout:
+// mirrors become CDCMirror or QRepMirror kinds without spec.type, peers
+// nest their settings under a key named for the peer type, and contexts
+// drop the spec.config indirection.
+func migrateToV2(root *yaml.Node) error {
+	spec := mappingValue(root, "spec")
+	if spec == nil {
+		return fmt.Errorf("configuration has no spec")
+	}
+
+	switch kind := scalarValue(root, "kind"); kind {
+	case "Peer":
+		typ, ok := peerTypeAliases[strings.ToLower(scalarValue(spec, "type"))]
+		if !ok {
+			return fmt.Errorf("unsupported peer type: %s", scalarValue(spec, "type"))
+		}
+		config := mappingValue(spec, "config")
+		if config == nil {
+			config = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		deleteKey(spec, "type")
+		renameKey(spec, "config", typ, config)
+
+	case "Mirror":
+		switch typ := strings.ToLower(scalarValue(spec, "type")); typ {
+		case "", "cdc":
+			setScalar(root, "kind", "CDCMirror")
+		case "qrep":
+			setScalar(root, "kind", "QRepMirror")
+		default:
+			return fmt.Errorf("unsupported mirror type: %s", typ)
+		}
+		deleteKey(spec, "type")
+
+	case "Context":
+		if config := mappingValue(spec, "config"); config != nil {
+			*spec = *config
+		}
+
+	case MirrorTemplateKind:
+		// Templates have the same layout in both versions
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	setScalar(root, "apiVersion", APIVersionV2)
+	return nil
+}
+
+// normalizeToV1 rewrites a v2 document's root mapping to the v1 layout
+// used internally, so the rest of the CLI only deals with one schema
+func normalizeToV1(root *yaml.Node) error {
+	kind := scalarValue(root, "kind")
+	spec := mappingValue(root, "spec")
+	if spec == nil {
+		if !IsKnownV2Kind(kind) {
+			return nil
+		}
+		return fmt.Errorf("configuration has no spec")
+	}
+
+	switch kind {
+	case "Peer":
+		var found []string
+		for _, typ := range []string{"postgres", "snowflake", "bigquery"} {
+			if mappingValue(spec, typ) != nil {
+				found = append(found, typ)
+			}
+		}
+		if len(found) != 1 {
+			return fmt.Errorf("v2 peer spec must contain exactly one of postgres, snowflake, or bigquery")
+		}
+		renameKey(spec, found[0], "config", mappingValue(spec, found[0]))
+		prependScalar(spec, "type", found[0])
+
+	case "CDCMirror", "QRepMirror":
+		typ := "cdc"
+		if kind == "QRepMirror" {
+			typ = "qrep"
+		}
+		setScalar(root, "kind", "Mirror")
+		prependScalar(spec, "type", typ)
+
+	case "Context":
+		config := *spec
+		*spec = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		spec.Content = []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "config"},
+			&config,
+		}
+
+	default:
+		// Unknown kinds are left as-is so callers can skip them
+	}
+
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the scalar value for key in a mapping node
+func scalarValue(node *yaml.Node, key string) string {
+	value := mappingValue(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return value.Value
+}
+
+// setScalar sets key to a string scalar, adding it if missing
+func setScalar(node *yaml.Node, key, value string) {
+	if existing := mappingValue(node, key); existing != nil {
+		existing.Kind = yaml.ScalarNode
+		existing.Tag = "!!str"
+		existing.Value = value
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+// prependScalar adds key with a string scalar value at the start of a mapping
+func prependScalar(node *yaml.Node, key, value string) {
+	deleteKey(node, key)
+	node.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	}, node.Content...)
+}
+
+// renameKey replaces key with newKey holding value, keeping its position,
+// or appends newKey if key is missing
+func renameKey(node *yaml.Node, key, newKey string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i].Value = newKey
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: newKey},
+		value,
+	)
+}
+
+// deleteKey removes key from a mapping node
+func deleteKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
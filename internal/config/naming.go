@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prefixes of the publication and replication slot PeerDB creates for a
+// mirror
+const (
+	PublicationPrefix     = "peerflow_pub_"
+	ReplicationSlotPrefix = "peerflow_slot_"
+)
+
+// maxIdentifierLength is PostgreSQL's identifier limit (NAMEDATALEN - 1)
+const maxIdentifierLength = 63
+
+// invalidNameChars matches characters PeerDB doesn't allow in peer and
+// mirror names
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_]`)
+
+// ValidateResourceName checks a peer or mirror name against PeerDB's
+// naming rules: lowercase letters, digits, and underscores, at most 63
+// characters
+func ValidateResourceName(kind, name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%s name is required", strings.ToLower(kind))
+	case len(name) > maxIdentifierLength:
+		return fmt.Errorf("%s name '%s' is longer than %d characters", strings.ToLower(kind), name, maxIdentifierLength)
+	case invalidNameChars.MatchString(name):
+		return fmt.Errorf("%s name '%s' may only contain lowercase letters, digits, and underscores", strings.ToLower(kind), name)
+	}
+	return nil
+}
+
+// NormalizeResourceName turns a name into one PeerDB accepts: it is
+// lowercased, other invalid characters such as dashes become underscores,
+// and it is cut to 63 characters
+func NormalizeResourceName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "_")
+	return truncateIdentifier(name, maxIdentifierLength)
+}
+
+// NormalizeNames normalizes the name of a peer or mirror, and the peers a
+// mirror references, returning a description of each change
+func (fc *FileConfig) NormalizeNames() []string {
+	var changes []string
+	normalize := func(what string, name *string) {
+		if normalized := NormalizeResourceName(*name); normalized != *name {
+			changes = append(changes, fmt.Sprintf("%s '%s' -> '%s'", what, *name, normalized))
+			*name = normalized
+		}
+	}
+
+	switch fc.Kind {
+	case "Peer":
+		normalize("peer", &fc.Metadata.Name)
+	case "Mirror":
+		normalize("mirror", &fc.Metadata.Name)
+		normalize("source peer", &fc.Spec.Source)
+		normalize("destination peer", &fc.Spec.Destination)
+	}
+	return changes
+}
+
+// CheckNames checks the names of a peer or mirror and the peers a mirror
+// references
+func (fc *FileConfig) CheckNames() error {
+	switch fc.Kind {
+	case "Peer":
+		return checkNameField("peer", "metadata.name", fc.Metadata.Name)
+	case "Mirror":
+		if err := checkNameField("mirror", "metadata.name", fc.Metadata.Name); err != nil {
+			return err
+		}
+		if err := checkNameField("source peer", "spec.source", fc.Spec.Source); err != nil {
+			return err
+		}
+		return checkNameField("destination peer", "spec.destination", fc.Spec.Destination)
+	}
+	return nil
+}
+
+// checkNameField validates the name in a field, reporting the field
+func checkNameField(kind, field, name string) error {
+	if err := ValidateResourceName(kind, name); err != nil {
+		return &FieldError{Field: field, Err: err}
+	}
+	return nil
+}
+
+// ValidateNamePrefix checks a name_prefix: it follows the naming rules and
+// leaves room for a name after it
+func ValidateNamePrefix(prefix string) error {
+	switch {
+	case prefix == "":
+		return nil
+	case len(prefix) >= maxIdentifierLength:
+		return fmt.Errorf("invalid name_prefix %q: must be shorter than %d characters", prefix, maxIdentifierLength)
+	case invalidNameChars.MatchString(prefix):
+		return fmt.Errorf("invalid name_prefix %q: may only contain lowercase letters, digits, and underscores", prefix)
+	}
+	return nil
+}
+
+// PrefixName prepends prefix to name unless it already starts with it or
+// is empty
+func PrefixName(prefix, name string) string {
+	if name == "" || strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// PrefixNames prepends prefix to the name of a peer or mirror and the
+// peers a mirror references, returning a description of each change
+func (fc *FileConfig) PrefixNames(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	var changes []string
+	apply := func(what string, name *string) {
+		if prefixed := PrefixName(prefix, *name); prefixed != *name {
+			changes = append(changes, fmt.Sprintf("%s '%s' -> '%s'", what, *name, prefixed))
+			*name = prefixed
+		}
+	}
+
+	switch fc.Kind {
+	case "Peer":
+		apply("peer", &fc.Metadata.Name)
+	case "Mirror":
+		apply("mirror", &fc.Metadata.Name)
+		apply("source peer", &fc.Spec.Source)
+		apply("destination peer", &fc.Spec.Destination)
+	}
+	return changes
+}
+
+// DefaultPublicationName returns the name of the publication PeerDB
+// creates for a mirror that doesn't name one
+func DefaultPublicationName(mirror string) string {
+	return PublicationPrefix + mirror
+}
+
+// DefaultReplicationSlotName returns the name of the replication slot
+// PeerDB creates for a mirror that doesn't name one
+func DefaultReplicationSlotName(mirror string) string {
+	return ReplicationSlotPrefix + mirror
+}
+
+func truncateIdentifier(name string, length int) string {
+	if len(name) <= length {
+		return name
+	}
+	return name[:length]
+}
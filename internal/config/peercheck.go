@@ -0,0 +1,170 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// bigQueryDatasetPattern matches valid BigQuery dataset IDs
+var bigQueryDatasetPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// checkPeer runs structural checks on a converted peer that don't need the
+// server: required fields, port ranges, conflicting auth settings, and that
+// keys and certificates parse. Required fields only have to be present, since
+// ${VAR} placeholders expand to empty values where secrets aren't available,
+// such as in pre-commit hooks.
+func (fc *FileConfig) checkPeer(peer *pb.Peer) error {
+	keys := make(map[string]bool)
+	if config, ok := fc.Spec.Config.(map[string]interface{}); ok {
+		for key := range config {
+			keys[key] = true
+		}
+	}
+
+	// The error is located at the field of the first problem
+	var problems []string
+	var field string
+	problem := func(key, format string, args ...interface{}) {
+		if len(problems) == 0 {
+			field = "spec.config." + key
+		}
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	require := func(fields ...string) {
+		for _, name := range fields {
+			if !keys[name] {
+				problem(name, "missing required field %s", name)
+			}
+		}
+	}
+
+	switch config := peer.Config.(type) {
+	case *pb.Peer_PostgresConfig:
+		pg := config.PostgresConfig
+		require("host", "user", "database")
+
+		var raw PostgresConfig
+		if err := decodeSpecConfig(fc.Spec.Config, &raw); err == nil && keys["port"] && (raw.Port < 1 || raw.Port > 65535) {
+			problem("port", "port %d is out of range (1-65535)", raw.Port)
+		}
+		if strings.Contains(pg.Host, "://") {
+			problem("host", "host '%s' must be a hostname or IP address, not a URL", pg.Host)
+		} else if _, port, ok := strings.Cut(pg.Host, ":"); ok && !strings.Contains(port, ":") {
+			problem("host", "host '%s' must not include a port (use the port field)", pg.Host)
+		}
+		if pg.RootCa != nil && *pg.RootCa != "" {
+			if err := checkCertificate(*pg.RootCa); err != nil {
+				rootCAKey := "root_ca"
+				if keys["root_ca_file"] {
+					rootCAKey = "root_ca_file"
+				}
+				problem(rootCAKey, "root CA: %v", err)
+			}
+		}
+
+	case *pb.Peer_SnowflakeConfig:
+		sf := config.SnowflakeConfig
+		require("account_id", "username", "database", "warehouse")
+
+		if !keys["private_key"] && !keys["password"] {
+			problem("", "requires private_key or password")
+		}
+		// With a private key, password is the key's passphrase
+		if sf.PrivateKey != "" {
+			if err := checkPrivateKey(sf.PrivateKey, sf.Password != nil && *sf.Password != ""); err != nil {
+				problem("private_key", "private_key: %v", err)
+			}
+		}
+
+	case *pb.Peer_BigqueryConfig:
+		bq := config.BigqueryConfig
+		if !keys["credentials_file"] {
+			require("private_key", "client_email")
+		}
+
+		if !bigQueryDatasetPattern.MatchString(bq.DatasetId) {
+			problem("dataset_id", "dataset_id '%s' may only contain letters, numbers, and underscores", bq.DatasetId)
+		}
+		if bq.ClientEmail != "" && !strings.Contains(bq.ClientEmail, "@") {
+			problem("client_email", "client_email '%s' is not an email address", bq.ClientEmail)
+		}
+		if bq.PrivateKey != "" {
+			if err := checkPrivateKey(bq.PrivateKey, false); err != nil {
+				problem("private_key", "private_key: %v", err)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fieldErrorf(strings.TrimSuffix(field, "."), "invalid %s peer: %s", strings.ToLower(peer.Type.String()), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// decodeSpecConfig decodes a spec's free-form config into a typed struct
+func decodeSpecConfig(config interface{}, out interface{}) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// checkPrivateKey checks that a value is a PEM private key. An encrypted
+// key is only accepted with a passphrase, which isn't checked here.
+func checkPrivateKey(value string, passphrase bool) error {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return fmt.Errorf("not a PEM-encoded key")
+	}
+
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		_, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED PRIVATE KEY":
+		if !passphrase {
+			return fmt.Errorf("key is encrypted; set password to its passphrase")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse key: %w", err)
+	}
+	return nil
+}
+
+// checkCertificate checks that a value holds PEM-encoded certificates
+func checkCertificate(value string) error {
+	rest := []byte(value)
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no PEM-encoded certificate found")
+	}
+	return nil
+}
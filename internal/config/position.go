@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is an error about one field of a configuration, named by its
+// dotted path, e.g. spec.type or spec.tables[1].destination
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldErrorf returns a FieldError for field with a formatted message
+func fieldErrorf(field, format string, args ...interface{}) error {
+	return &FieldError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+// PositionError is an error located in a configuration file
+type PositionError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// Locate returns err located at the line and column of the field it is
+// about, or err unchanged when the field's position isn't known, such as
+// for configurations that weren't loaded from a file. A field missing from
+// the file is located at its nearest parent.
+func (fc *FileConfig) Locate(err error) error {
+	var fieldErr *FieldError
+	var posErr *PositionError
+	if err == nil || fc.node == nil || fc.Path == "" || errors.As(err, &posErr) || !errors.As(err, &fieldErr) {
+		return err
+	}
+
+	node := findField(fc.node, fieldErr.Field)
+	if node == nil {
+		return err
+	}
+	file := fc.Path
+	if file == StdinPath {
+		file = stdinName
+	}
+	return &PositionError{File: file, Line: node.Line, Column: node.Column, Err: err}
+}
+
+// findField returns the node of a dotted field path, or of its deepest
+// parent present in the document. A mapping value is located at its key.
+func findField(root *yaml.Node, path string) *yaml.Node {
+	node, found := root, (*yaml.Node)(nil)
+	for _, part := range strings.Split(path, ".") {
+		key, index := part, -1
+		if open := strings.Index(part, "["); open >= 0 && strings.HasSuffix(part, "]") {
+			key = part[:open]
+			index, _ = strconv.Atoi(part[open+1 : len(part)-1])
+		}
+
+		keyNode, value := mappingEntry(node, key)
+		if value == nil {
+			break
+		}
+		node, found = value, keyNode
+		if index >= 0 {
+			if value.Kind != yaml.SequenceNode || index >= len(value.Content) {
+				break
+			}
+			node = value.Content[index]
+			found = node
+		}
+	}
+	return found
+}
+
+// mappingEntry returns the key and value nodes for key in a mapping node
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// MirrorTypeQRep is the spec.type of query replication mirrors (kind
+// QRepMirror in v2 files)
+const MirrorTypeQRep = "qrep"
+
+// QRep write modes, set with qrep.write_mode
+const (
+	WriteModeAppend    = "append"
+	WriteModeUpsert    = "upsert"
+	WriteModeOverwrite = "overwrite"
+)
+
+// QRepConfig contains query replication settings
+type QRepConfig struct {
+	WatermarkTable   string `yaml:"watermark_table"`
+	WatermarkColumn  string `yaml:"watermark_column"`
+	DestinationTable string `yaml:"destination_table"`
+
+	// Query selects the rows of one partition, between the {{.start}} and
+	// {{.end}} placeholders the server fills in with watermark values. It
+	// defaults to every column of the watermark table.
+	Query string `yaml:"query,omitempty"`
+
+	// WriteMode is append (default), upsert, or overwrite
+	WriteMode string `yaml:"write_mode,omitempty"`
+	// UniqueKeyColumns identify the rows upsert replaces
+	UniqueKeyColumns []string `yaml:"unique_key_columns,omitempty"`
+
+	NumRowsPerPartition       uint32 `yaml:"num_rows_per_partition,omitempty"`
+	MaxParallelWorkers        uint32 `yaml:"max_parallel_workers,omitempty"`
+	WaitBetweenBatchesSeconds uint32 `yaml:"wait_between_batches_seconds,omitempty"`
+	InitialCopyOnly           bool   `yaml:"initial_copy_only,omitempty"`
+	StagingPath               string `yaml:"staging_path,omitempty"`
+}
+
+// writeMode describes a QRep write mode and the destinations that support it
+type writeMode struct {
+	name         string
+	writeType    pb.QRepWriteType
+	destinations []string
+}
+
+// writeModes lists the supported QRep write modes. Append works with every
+// destination; nil means no restriction.
+var writeModes = []writeMode{
+	{name: WriteModeAppend, writeType: pb.QRepWriteType_QREP_WRITE_MODE_APPEND},
+	{name: WriteModeUpsert, writeType: pb.QRepWriteType_QREP_WRITE_MODE_UPSERT, destinations: []string{"postgres", "snowflake"}},
+	{name: WriteModeOverwrite, writeType: pb.QRepWriteType_QREP_WRITE_MODE_OVERWRITE, destinations: []string{"snowflake", "clickhouse"}},
+}
+
+// queryPlaceholders match the partition range placeholders of a query
+var (
+	startPlaceholder = regexp.MustCompile(`\{\{\s*\.start\s*\}\}`)
+	endPlaceholder   = regexp.MustCompile(`\{\{\s*\.end\s*\}\}`)
+)
+
+// IsQRep reports whether the config is a query replication mirror
+func (fc *FileConfig) IsQRep() bool {
+	return fc.Kind == "Mirror" && strings.EqualFold(fc.Spec.Type, MirrorTypeQRep)
+}
+
+// lookupWriteMode returns the write mode named by qrep.write_mode
+func (q *QRepConfig) lookupWriteMode() (writeMode, error) {
+	name := strings.ToLower(q.WriteMode)
+	if name == "" {
+		name = WriteModeAppend
+	}
+	for _, mode := range writeModes {
+		if mode.name == name {
+			return mode, nil
+		}
+	}
+	return writeMode{}, fieldErrorf("spec.qrep.write_mode", "invalid qrep.write_mode %q: must be %s, %s, or %s", q.WriteMode, WriteModeAppend, WriteModeUpsert, WriteModeOverwrite)
+}
+
+// toWriteMode checks the write mode settings and converts them
+func (q *QRepConfig) toWriteMode() (*pb.QRepWriteMode, error) {
+	mode, err := q.lookupWriteMode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case mode.name == WriteModeUpsert && len(q.UniqueKeyColumns) == 0:
+		return nil, fieldErrorf("spec.qrep.write_mode", "qrep.write_mode upsert requires qrep.unique_key_columns")
+	case mode.name != WriteModeUpsert && len(q.UniqueKeyColumns) > 0:
+		return nil, fieldErrorf("spec.qrep.unique_key_columns", "qrep.unique_key_columns is only used with qrep.write_mode upsert")
+	case mode.name == WriteModeOverwrite && !q.InitialCopyOnly:
+		return nil, fieldErrorf("spec.qrep.write_mode", "qrep.write_mode overwrite requires qrep.initial_copy_only, since each run would replace the table")
+	}
+	for _, column := range q.UniqueKeyColumns {
+		if strings.TrimSpace(column) == "" {
+			return nil, fieldErrorf("spec.qrep.unique_key_columns", "qrep.unique_key_columns contains an empty column name")
+		}
+	}
+
+	return &pb.QRepWriteMode{
+		WriteType:        mode.writeType,
+		UpsertKeyColumns: q.UniqueKeyColumns,
+	}, nil
+}
+
+// partitionQuery returns the query each partition runs, checking that a
+// custom query is bounded by both range placeholders
+func (q *QRepConfig) partitionQuery() (string, error) {
+	query := strings.TrimSpace(q.Query)
+	if query == "" {
+		return fmt.Sprintf("SELECT * FROM %s WHERE %s BETWEEN {{.start}} AND {{.end}}", q.WatermarkTable, q.WatermarkColumn), nil
+	}
+
+	var missing []string
+	if !startPlaceholder.MatchString(query) {
+		missing = append(missing, "{{.start}}")
+	}
+	if !endPlaceholder.MatchString(query) {
+		missing = append(missing, "{{.end}}")
+	}
+	if len(missing) > 0 {
+		return "", fieldErrorf("spec.qrep.query", "qrep.query must filter %s on the partition range; missing %s", q.WatermarkColumn, strings.Join(missing, " and "))
+	}
+	return query, nil
+}
+
+// WriteModeName returns the qrep.write_mode name of a write mode
+func WriteModeName(mode *pb.QRepWriteMode) string {
+	for _, m := range writeModes {
+		if m.writeType == mode.GetWriteType() {
+			return m.name
+		}
+	}
+	return strings.ToLower(mode.GetWriteType().String())
+}
+
+// CheckDestination returns an error if the write mode isn't supported by
+// the destination peer type
+func (q *QRepConfig) CheckDestination(destinationType string) error {
+	mode, err := q.lookupWriteMode()
+	if err != nil {
+		return err
+	}
+	if mode.destinations != nil && !containsString(mode.destinations, destinationType) {
+		return fieldErrorf("spec.qrep.write_mode", "qrep.write_mode %s is not supported for %s destinations (supported: %s)",
+			mode.name, destinationType, strings.Join(mode.destinations, ", "))
+	}
+	return nil
+}
+
+// ToQRepProto converts a query replication mirror config to its creation
+// request
+func (fc *FileConfig) ToQRepProto() (*pb.CreateQRepFlowRequest, error) {
+	if !fc.IsQRep() {
+		return nil, fmt.Errorf("config is not a query replication mirror")
+	}
+	if fc.Spec.Template != "" {
+		return nil, fieldErrorf("spec.template", "mirror template '%s' not found; load it together with the mirror", fc.Spec.Template)
+	}
+
+	q := fc.Spec.QRep
+	switch {
+	case q == nil:
+		return nil, fieldErrorf("spec", "query replication mirrors require a qrep section")
+	case len(fc.Spec.Tables) > 0:
+		return nil, fieldErrorf("spec.tables", "query replication mirrors copy qrep.watermark_table; remove spec.tables")
+	case fc.Spec.CDC != nil:
+		return nil, fieldErrorf("spec.cdc", "spec.cdc doesn't apply to query replication mirrors")
+	case fc.Spec.DestinationOptions != nil:
+		return nil, fieldErrorf("spec.destination_options", "destination_options only apply to CDC mirrors")
+	case q.WatermarkTable == "":
+		return nil, fieldErrorf("spec.qrep", "qrep.watermark_table is required")
+	case q.WatermarkColumn == "":
+		return nil, fieldErrorf("spec.qrep", "qrep.watermark_column is required")
+	case q.DestinationTable == "":
+		return nil, fieldErrorf("spec.qrep", "qrep.destination_table is required")
+	}
+
+	mode, err := q.toWriteMode()
+	if err != nil {
+		return nil, err
+	}
+	query, err := q.partitionQuery()
+	if err != nil {
+		return nil, err
+	}
+	columns, err := fc.EffectiveColumns()
+	if err != nil {
+		return nil, err
+	}
+	env, err := fc.mirrorEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateQRepFlowRequest{
+		QrepConfig: &pb.QRepConfig{
+			FlowJobName:                fc.Metadata.Name,
+			SourceName:                 fc.Spec.Source,
+			DestinationName:            fc.Spec.Destination,
+			WatermarkTable:             q.WatermarkTable,
+			WatermarkColumn:            q.WatermarkColumn,
+			DestinationTableIdentifier: q.DestinationTable,
+			Query:                      query,
+			WriteMode:                  mode,
+			NumRowsPerPartition:        q.NumRowsPerPartition,
+			MaxParallelWorkers:         q.MaxParallelWorkers,
+			WaitBetweenBatchesSeconds:  q.WaitBetweenBatchesSeconds,
+			InitialCopyOnly:            q.InitialCopyOnly,
+			StagingPath:                q.StagingPath,
+			SoftDeleteColName:          columns.SoftDeleteColumn,
+			SyncedAtColName:            columns.SyncedAtColumn,
+			Env:                        env,
+		},
+		CreateCatalogEntry: true,
+	}, nil
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// scaffoldTemplates holds commented example configurations keyed by
+// kind and type. The {{name}} placeholder is replaced with the resource name.
+var scaffoldTemplates = map[string]map[string]string{
+	"peer": {
+		"postgres": `apiVersion: v1
+kind: Peer
+metadata:
+  # Peer names must be unique within a PeerDB instance
+  name: {{name}}
+  # Optional: environment this peer belongs to (production, staging, ...)
+  environment: development
+  description: PostgreSQL source database
+spec:
+  type: postgres
+  config:
+    host: localhost
+    port: 5432
+    user: peerdb_user
+    # Use ${VAR} placeholders to keep secrets out of version control
+    password: ${POSTGRES_PASSWORD}
+    database: mydb
+    # Optional: hostname to verify the server certificate against
+    # tls_host: postgres.company.com
+    # Optional: schema PeerDB uses for its internal metadata tables
+    metadata_schema: _peerdb_internal
+`,
+		"snowflake": `apiVersion: v1
+kind: Peer
+metadata:
+  name: {{name}}
+  environment: development
+  description: Snowflake data warehouse
+spec:
+  type: snowflake
+  config:
+    # Account identifier, e.g. myorg-myaccount or xy12345.us-east-1
+    account_id: ${SNOWFLAKE_ACCOUNT}
+    username: peerdb_user
+    # Key-pair authentication (recommended): unencrypted PKCS#8 PEM key
+    private_key: ${SNOWFLAKE_PRIVATE_KEY}
+    # Alternatively, password authentication
+    # password: ${SNOWFLAKE_PASSWORD}
+    database: ANALYTICS_DB
+    warehouse: COMPUTE_WH
+    # Optional: role used for all PeerDB queries
+    role: PEERDB_ROLE
+    # Optional: query timeout in seconds
+    query_timeout: 300
+    metadata_schema: _PEERDB_INTERNAL
+`,
+		"bigquery": `apiVersion: v1
+kind: Peer
+metadata:
+  name: {{name}}
+  environment: development
+  description: BigQuery data warehouse
+spec:
+  type: bigquery
+  config:
+    project_id: my-gcp-project
+    dataset_id: analytics
+    # Service account JSON key; populates private_key, client_email, etc.
+    credentials_file: ${GOOGLE_APPLICATION_CREDENTIALS}
+    # Alternatively, set the key fields individually
+    # private_key_id: ...
+    # private_key: ${BIGQUERY_PRIVATE_KEY}
+    # client_email: peerdb@my-gcp-project.iam.gserviceaccount.com
+    # client_id: ...
+`,
+	},
+	"mirror": {
+		"cdc": `apiVersion: v1
+kind: Mirror
+metadata:
+  # Mirror names must be unique within a PeerDB instance
+  name: {{name}}
+  environment: development
+  description: Replicate changes from PostgreSQL to the warehouse
+spec:
+  type: cdc
+  # Optional: MirrorTemplate whose settings this mirror inherits
+  # template: standard-cdc
+  # Names of existing peers
+  source: postgres_source
+  destination: snowflake_warehouse
+
+  # Table mappings from source to destination identifiers
+  tables:
+    - source: public.users
+      destination: ANALYTICS_DB.PUBLIC.USERS
+      # Optional: column used to partition the initial snapshot
+      # partition_key: id
+      # Optional: columns that are not replicated
+      # exclude_columns:
+      #   - password_hash
+
+  cdc:
+    # Maximum number of records per batch
+    batch_size: 1000
+    # Seconds to wait for new changes before syncing a partial batch
+    idle_timeout_seconds: 60
+    # Copy existing rows before streaming changes
+    initial_snapshot: true
+    # Optional: existing publication and replication slot on the source
+    # publication_name: peerdb_pub
+    # replication_slot_name: peerdb_slot
+
+  # Optional: initial snapshot tuning
+  snapshot:
+    num_rows_per_partition: 250000
+    max_parallel_workers: 4
+    num_tables_in_parallel: 1
+
+  # Optional: bookkeeping columns added to destination tables
+  # columns:
+  #   soft_delete_column: _PEERDB_IS_DELETED
+  #   synced_at_column: _PEERDB_SYNCED_AT
+
+  # Optional: settings for the destination's type (clickhouse, snowflake,
+  # or bigquery); tables can override them with their own destination_options
+  # destination_options:
+  #   snowflake:
+  #     transformation_script: mask_pii
+
+  # Optional: additional PeerDB settings
+  # env:
+  #   PEERDB_SOME_SETTING: "value"
+`,
+	},
+	"mirrortemplate": {
+		"cdc": `apiVersion: v1
+kind: MirrorTemplate
+metadata:
+  # Mirrors reference the template with spec.template: {{name}}
+  name: {{name}}
+  description: Shared settings for CDC mirrors
+spec:
+  # Settings in a mirror override the template's, key by key
+  cdc:
+    batch_size: 1000
+    idle_timeout_seconds: 60
+    initial_snapshot: true
+
+  snapshot:
+    num_rows_per_partition: 250000
+    max_parallel_workers: 4
+    num_tables_in_parallel: 1
+
+  # Optional: bookkeeping columns added to destination tables
+  # columns:
+  #   soft_delete_column: _PEERDB_IS_DELETED
+  #   synced_at_column: _PEERDB_SYNCED_AT
+
+  # Optional: additional PeerDB settings, merged with the mirror's env
+  # env:
+  #   PEERDB_SOME_SETTING: "value"
+`,
+	},
+	"context": {
+		"server": `apiVersion: v1
+kind: Context
+metadata:
+  # Import with: mirror_cli config import-context -f <file>
+  name: {{name}}
+  environment: development
+  description: PeerDB deployment
+spec:
+  config:
+    host: localhost
+    port: 8112
+    tls: false
+    # Optional: credentials for the PeerDB server
+    # username: ${PEERDB_USERNAME}
+    # password: ${PEERDB_PASSWORD}
+`,
+	},
+}
+
+// Scaffold returns a commented example configuration for the given kind
+// and type, using name as the resource name
+func Scaffold(kind, typ, name string) (string, error) {
+	kind = strings.ToLower(kind)
+	types, ok := scaffoldTemplates[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown kind: %s (expected one of: %s)", kind, strings.Join(ScaffoldKinds(), ", "))
+	}
+
+	typ = strings.ToLower(typ)
+	if typ == "" && len(types) == 1 {
+		for only := range types {
+			typ = only
+		}
+	}
+
+	switch typ {
+	case "postgresql":
+		typ = "postgres"
+	case "bq":
+		typ = "bigquery"
+	case "sf":
+		typ = "snowflake"
+	}
+
+	tmpl, ok := types[typ]
+	if !ok {
+		return "", fmt.Errorf("unknown %s type: %s (expected one of: %s)", kind, typ, strings.Join(ScaffoldTypes(kind), ", "))
+	}
+
+	return strings.ReplaceAll(tmpl, "{{name}}", name), nil
+}
+
+// ScaffoldKinds returns the kinds that can be scaffolded
+func ScaffoldKinds() []string {
+	kinds := make([]string, 0, len(scaffoldTemplates))
+	for kind := range scaffoldTemplates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// ScaffoldTypes returns the types that can be scaffolded for a kind
+func ScaffoldTypes(kind string) []string {
+	types := make([]string, 0, len(scaffoldTemplates[kind]))
+	for typ := range scaffoldTemplates[kind] {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SopsBinary is the sops executable used to decrypt encrypted config files
+var SopsBinary = "sops"
+
+// ForceSops decrypts every config file with sops, not only those with a
+// sops metadata block, e.g. so a file that lost its metadata fails loudly
+// instead of applying ciphertext
+var ForceSops bool
+
+// sopsTimeout bounds a decryption, which may call out to a KMS
+const sopsTimeout = 30 * time.Second
+
+// IsSopsEncrypted reports whether data is a YAML document encrypted by sops,
+// which adds a top-level sops mapping holding the data key and MAC
+func IsSopsEncrypted(data []byte) bool {
+	var doc struct {
+		Sops struct {
+			MAC          string `yaml:"mac"`
+			LastModified string `yaml:"lastmodified"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	return doc.Sops.MAC != "" || doc.Sops.LastModified != ""
+}
+
+// decryptSops decrypts sops-encrypted YAML when it is encrypted or
+// ForceSops is set, returning other data unchanged. The plaintext is only
+// held in memory: sops reads the file from stdin and writes to stdout.
+func decryptSops(name string, data []byte) ([]byte, error) {
+	if !ForceSops && !IsSopsEncrypted(data) {
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sopsTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, SopsBinary, "--decrypt", "--input-type", "yaml", "--output-type", "yaml", "/dev/stdin")
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("%s is encrypted with sops, but the sops binary wasn't found in PATH", name)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("failed to decrypt %s with sops: %s", name, message)
+		}
+		return nil, fmt.Errorf("failed to decrypt %s with sops: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/janakos/mirror_cli/internal/provenance"
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// SpecHashKey is the env key holding the hash of the spec a mirror was
+// last applied from
+const SpecHashKey = provenance.Prefix + "spec_hash"
+
+// SpecHash returns a hash of a mirror's configuration in canonical form, so
+// the same configuration always hashes the same whether it was built from a
+// file or read back from the server. Provenance annotations, including the
+// hash itself, are left out.
+func SpecHash(cfg *pb.FlowConnectionConfigs) (string, error) {
+	canonical, err := MarshalCanonical(FromMirrorProto(cfg, ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash mirror spec: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SecretHash returns a hash of a peer's configuration, secrets included.
+// PeerDB masks secrets when a peer is read back, so comparing with the hash
+// recorded when the peer was last applied is the only way to tell that a
+// password changed.
+func SecretHash(peer *pb.Peer) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(peer)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash peer secrets: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,653 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// SQLImport is the result of converting PeerDB SQL statements
+type SQLImport struct {
+	Configs []*FileConfig
+
+	// Warnings lists statements and options that were skipped
+	Warnings []string
+}
+
+// ImportSQL converts the CREATE PEER and CREATE MIRROR statements of
+// PeerDB's SQL interface into configurations. Secrets are replaced with
+// ${VAR} placeholders, as in exports. Other statements are skipped with a
+// warning.
+func ImportSQL(sql, environment string) (*SQLImport, error) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sqlParser{tokens: tokens}
+	result := &SQLImport{}
+	seen := make(map[string]int)
+
+	for !p.done() {
+		if p.symbol(";") {
+			continue
+		}
+
+		start := p.peek()
+		var fc *FileConfig
+		switch {
+		case p.keywords("CREATE", "PEER"):
+			fc, err = p.parsePeer(environment, &result.Warnings)
+		case p.keywords("CREATE", "MIRROR"):
+			fc, err = p.parseMirror(environment, &result.Warnings)
+		default:
+			p.skipStatement()
+			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: skipped unsupported statement starting with '%s'", start.line, start.text))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !p.done() && !p.symbol(";") {
+			return nil, p.errorf("expected ';' after statement")
+		}
+		if fc == nil {
+			continue
+		}
+
+		key := fc.Kind + "/" + fc.Metadata.Name
+		if line, ok := seen[key]; ok {
+			return nil, fmt.Errorf("line %d: %s '%s' is already defined on line %d", start.line, strings.ToLower(fc.Kind), fc.Metadata.Name, line)
+		}
+		seen[key] = start.line
+		result.Configs = append(result.Configs, fc)
+	}
+
+	return result, nil
+}
+
+// parsePeer parses the rest of a CREATE PEER statement:
+//
+//	CREATE PEER [IF NOT EXISTS] name FROM type WITH (option = value, ...)
+func (p *sqlParser) parsePeer(environment string, warnings *[]string) (*FileConfig, error) {
+	p.keywords("IF", "NOT", "EXISTS")
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keywords("FROM") {
+		return nil, p.errorf("expected FROM after peer name")
+	}
+	peerType, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keywords("WITH") {
+		return nil, p.errorf("expected WITH after peer type")
+	}
+	options, err := p.options()
+	if err != nil {
+		return nil, err
+	}
+
+	peer := &pb.Peer{Name: name}
+	values := sqlOptions{name: "peer " + name, options: options, warnings: warnings}
+	switch strings.ToUpper(peerType) {
+	case "POSTGRES", "POSTGRESQL":
+		pg := &pb.PostgresConfig{
+			Host:       values.str("host"),
+			Port:       uint32(values.uint("port", 32)),
+			User:       values.str("user"),
+			Password:   values.str("password"),
+			Database:   values.str("database"),
+			TlsHost:    values.str("tls_host"),
+			RequireTls: values.bool("require_tls"),
+		}
+		if schema := values.str("metadata_schema"); schema != "" {
+			pg.MetadataSchema = &schema
+		}
+		if rootCA := values.str("root_ca"); rootCA != "" {
+			pg.RootCa = &rootCA
+		}
+		peer.Type = pb.DBType_POSTGRES
+		peer.Config = &pb.Peer_PostgresConfig{PostgresConfig: pg}
+
+	case "SNOWFLAKE":
+		sf := &pb.SnowflakeConfig{
+			AccountId:     values.str("account_id"),
+			Username:      values.str("username"),
+			PrivateKey:    values.str("private_key"),
+			Database:      values.str("database"),
+			Warehouse:     values.str("warehouse"),
+			Role:          values.str("role"),
+			QueryTimeout:  values.uint("query_timeout", 64),
+			S3Integration: values.str("s3_integration"),
+		}
+		if password := values.str("password"); password != "" {
+			sf.Password = &password
+		}
+		if schema := values.str("metadata_schema"); schema != "" {
+			sf.MetadataSchema = &schema
+		}
+		peer.Type = pb.DBType_SNOWFLAKE
+		peer.Config = &pb.Peer_SnowflakeConfig{SnowflakeConfig: sf}
+
+	case "BIGQUERY":
+		peer.Type = pb.DBType_BIGQUERY
+		peer.Config = &pb.Peer_BigqueryConfig{BigqueryConfig: &pb.BigqueryConfig{
+			AuthType:                values.str("type"),
+			ProjectId:               values.str("project_id"),
+			PrivateKeyId:            values.str("private_key_id"),
+			PrivateKey:              values.str("private_key"),
+			ClientEmail:             values.str("client_email"),
+			ClientId:                values.str("client_id"),
+			AuthUri:                 values.str("auth_uri"),
+			TokenUri:                values.str("token_uri"),
+			AuthProviderX509CertUrl: values.str("auth_provider_x509_cert_url"),
+			ClientX509CertUrl:       values.str("client_x509_cert_url"),
+			DatasetId:               values.str("dataset_id"),
+		}}
+
+	default:
+		*warnings = append(*warnings, fmt.Sprintf("peer %s: skipped unsupported peer type %s", name, peerType))
+		return nil, nil
+	}
+	if err := values.finish(); err != nil {
+		return nil, err
+	}
+
+	return FromPeerProto(peer, environment)
+}
+
+// parseMirror parses the rest of a CREATE MIRROR statement:
+//
+//	CREATE MIRROR [IF NOT EXISTS] name FROM source TO destination
+//	  WITH TABLE MAPPING (source:destination, {from: ..., to: ...}, ...)
+//	  [WITH (option = value, ...)]
+//
+// Query replication mirrors (FOR $$query$$) are skipped.
+func (p *sqlParser) parseMirror(environment string, warnings *[]string) (*FileConfig, error) {
+	p.keywords("IF", "NOT", "EXISTS")
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keywords("FROM") {
+		return nil, p.errorf("expected FROM after mirror name")
+	}
+	source, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keywords("TO") {
+		return nil, p.errorf("expected TO after source peer")
+	}
+	destination, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keywords("FOR") {
+		p.skipStatement()
+		*warnings = append(*warnings, fmt.Sprintf("mirror %s: skipped query replication mirror; only CDC mirrors can be imported", name))
+		return nil, nil
+	}
+
+	if !p.keywords("WITH", "TABLE", "MAPPING") {
+		return nil, p.errorf("expected WITH TABLE MAPPING after destination peer")
+	}
+	mappings, err := p.tableMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	var options []sqlOption
+	if p.keywords("WITH") {
+		if options, err = p.options(); err != nil {
+			return nil, err
+		}
+	}
+
+	values := sqlOptions{name: "mirror " + name, options: options, warnings: warnings}
+	cfg := &pb.FlowConnectionConfigs{
+		FlowJobName:                 name,
+		SourceName:                  source,
+		DestinationName:             destination,
+		TableMappings:               mappings,
+		DoInitialSnapshot:           values.bool("do_initial_copy"),
+		MaxBatchSize:                uint32(values.uint("max_batch_size", 32)),
+		IdleTimeoutSeconds:          values.uint("sync_interval", 64),
+		PublicationName:             values.str("publication_name"),
+		ReplicationSlotName:         values.str("replication_slot_name"),
+		SnapshotNumRowsPerPartition: uint32(values.uint("snapshot_num_rows_per_partition", 32)),
+		SnapshotMaxParallelWorkers:  uint32(values.uint("snapshot_max_parallel_workers", 32)),
+		SnapshotNumTablesInParallel: uint32(values.uint("snapshot_num_tables_in_parallel", 32)),
+		SoftDeleteColName:           values.str("soft_delete_col_name"),
+		SyncedAtColName:             values.str("synced_at_col_name"),
+	}
+	if err := values.finish(); err != nil {
+		return nil, err
+	}
+
+	return FromMirrorProto(cfg, environment), nil
+}
+
+// tableMappings parses a parenthesized list of table mappings, each either
+// source:destination or {from: ..., to: ..., key: ..., exclude: [...]}
+func (p *sqlParser) tableMappings() ([]*pb.TableMapping, error) {
+	if !p.symbol("(") {
+		return nil, p.errorf("expected '(' to start table mapping")
+	}
+
+	var mappings []*pb.TableMapping
+	for {
+		mapping := &pb.TableMapping{}
+		if p.symbol("{") {
+			for !p.symbol("}") {
+				key, err := p.identifier()
+				if err != nil {
+					return nil, err
+				}
+				if !p.symbol(":") {
+					return nil, p.errorf("expected ':' after '%s'", key)
+				}
+				switch strings.ToLower(key) {
+				case "from":
+					mapping.SourceTableIdentifier, err = p.identifier()
+				case "to":
+					mapping.DestinationTableIdentifier, err = p.identifier()
+				case "key":
+					mapping.PartitionKey, err = p.identifier()
+				case "exclude":
+					mapping.Exclude, err = p.identifierList()
+				default:
+					return nil, p.errorf("unknown table mapping field '%s'", key)
+				}
+				if err != nil {
+					return nil, err
+				}
+				if !p.symbol(",") && !p.at("}") {
+					return nil, p.errorf("expected ',' or '}' in table mapping")
+				}
+			}
+			if mapping.SourceTableIdentifier == "" || mapping.DestinationTableIdentifier == "" {
+				return nil, p.errorf("table mapping requires from and to")
+			}
+		} else {
+			var err error
+			if mapping.SourceTableIdentifier, err = p.identifier(); err != nil {
+				return nil, err
+			}
+			if !p.symbol(":") {
+				return nil, p.errorf("expected ':' between source and destination table")
+			}
+			if mapping.DestinationTableIdentifier, err = p.identifier(); err != nil {
+				return nil, err
+			}
+		}
+		mappings = append(mappings, mapping)
+
+		if p.symbol(")") {
+			return mappings, nil
+		}
+		if !p.symbol(",") {
+			return nil, p.errorf("expected ',' or ')' in table mapping")
+		}
+	}
+}
+
+// sqlOption is one option of a WITH (...) list
+type sqlOption struct {
+	key   string
+	value string
+	line  int
+}
+
+// options parses a parenthesized list of key = value options
+func (p *sqlParser) options() ([]sqlOption, error) {
+	if !p.symbol("(") {
+		return nil, p.errorf("expected '(' after WITH")
+	}
+
+	var options []sqlOption
+	for !p.symbol(")") {
+		key, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol("=") {
+			return nil, p.errorf("expected '=' after '%s'", key)
+		}
+		value := p.next()
+		if value.kind != sqlString && value.kind != sqlWord && value.kind != sqlNumber && value.kind != sqlDollar {
+			return nil, fmt.Errorf("line %d: expected a value for '%s'", value.line, key)
+		}
+		options = append(options, sqlOption{key: strings.ToLower(key), value: value.text, line: value.line})
+
+		if !p.symbol(",") && !p.at(")") {
+			return nil, p.errorf("expected ',' or ')' in options")
+		}
+	}
+	return options, nil
+}
+
+// sqlOptions looks up the options of a statement, recording the first
+// conversion error and warning about options that are never looked up
+type sqlOptions struct {
+	name     string
+	options  []sqlOption
+	used     map[string]bool
+	err      error
+	warnings *[]string
+}
+
+func (o *sqlOptions) lookup(key string) (sqlOption, bool) {
+	if o.used == nil {
+		o.used = make(map[string]bool)
+	}
+	o.used[key] = true
+	for _, option := range o.options {
+		if option.key == key {
+			return option, true
+		}
+	}
+	return sqlOption{}, false
+}
+
+func (o *sqlOptions) str(key string) string {
+	option, _ := o.lookup(key)
+	return option.value
+}
+
+func (o *sqlOptions) uint(key string, bits int) uint64 {
+	option, ok := o.lookup(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseUint(option.value, 10, bits)
+	if err != nil && o.err == nil {
+		o.err = fmt.Errorf("line %d: %s: %s must be a non-negative integer, got '%s'", option.line, o.name, key, option.value)
+	}
+	return n
+}
+
+func (o *sqlOptions) bool(key string) bool {
+	option, ok := o.lookup(key)
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(strings.ToLower(option.value))
+	if err != nil && o.err == nil {
+		o.err = fmt.Errorf("line %d: %s: %s must be true or false, got '%s'", option.line, o.name, key, option.value)
+	}
+	return b
+}
+
+// finish returns the first conversion error and warns about unknown options
+func (o *sqlOptions) finish() error {
+	if o.err != nil {
+		return o.err
+	}
+	for _, option := range o.options {
+		if !o.used[option.key] {
+			*o.warnings = append(*o.warnings, fmt.Sprintf("line %d: %s: ignored unsupported option %s", option.line, o.name, option.key))
+		}
+	}
+	return nil
+}
+
+// SQL token kinds
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlString
+	sqlNumber
+	sqlDollar
+	sqlSymbol
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	line int
+
+	// quoted is set for "double quoted" identifiers, which keep their case
+	quoted bool
+}
+
+// tokenizeSQL splits SQL into words, strings, numbers, dollar-quoted
+// strings, and symbols, dropping comments
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	runes := []rune(sql)
+	line := 1
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := line
+			for i += 2; ; i++ {
+				if i+1 >= len(runes) {
+					return nil, fmt.Errorf("line %d: unterminated comment", start)
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				if runes[i] == '*' && runes[i+1] == '/' {
+					i += 2
+					break
+				}
+			}
+
+		case r == '\'' || r == '"':
+			start := line
+			var value strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("line %d: unterminated string", start)
+				}
+				if runes[i] == r {
+					// A doubled quote is an escaped quote
+					if i+1 < len(runes) && runes[i+1] == r {
+						value.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if r == '"' {
+				tokens = append(tokens, sqlToken{kind: sqlWord, text: value.String(), line: start, quoted: true})
+			} else {
+				tokens = append(tokens, sqlToken{kind: sqlString, text: value.String(), line: start})
+			}
+
+		case r == '$':
+			// Dollar-quoted string: $tag$ ... $tag$
+			end := i + 1
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			if end >= len(runes) || runes[end] != '$' {
+				return nil, fmt.Errorf("line %d: unexpected character '$'", line)
+			}
+			tag := string(runes[i : end+1])
+			body := string(runes[end+1:])
+			bodyEnd := strings.Index(body, tag)
+			if bodyEnd < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s string", line, tag)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlDollar, text: body[:bodyEnd], line: line})
+			line += strings.Count(body[:bodyEnd], "\n")
+			i = end + 1 + len([]rune(body[:bodyEnd])) + len([]rune(tag))
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: string(runes[start:i]), line: line})
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: string(runes[start:i]), line: line})
+
+		case strings.ContainsRune("(),=:;{}[].", r):
+			tokens = append(tokens, sqlToken{kind: sqlSymbol, text: string(r), line: line})
+			i++
+
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character '%c'", line, r)
+		}
+	}
+	return tokens, nil
+}
+
+// sqlParser walks a token stream
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// peek returns the next token, or an empty symbol at the end of input
+func (p *sqlParser) peek() sqlToken {
+	if p.done() {
+		line := 1
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].line
+		}
+		return sqlToken{kind: sqlSymbol, line: line}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	token := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return token
+}
+
+// keywords consumes a sequence of unquoted words if all of them match,
+// ignoring case
+func (p *sqlParser) keywords(words ...string) bool {
+	if p.pos+len(words) > len(p.tokens) {
+		return false
+	}
+	for i, word := range words {
+		token := p.tokens[p.pos+i]
+		if token.kind != sqlWord || token.quoted || !strings.EqualFold(token.text, word) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+// at reports whether the next token is the given symbol
+func (p *sqlParser) at(s string) bool {
+	token := p.peek()
+	return !p.done() && token.kind == sqlSymbol && token.text == s
+}
+
+// symbol consumes the next token if it is the given symbol
+func (p *sqlParser) symbol(s string) bool {
+	if p.at(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// name parses a peer or mirror name. Unquoted names are lowercased, as
+// PeerDB does.
+func (p *sqlParser) name() (string, error) {
+	return p.dotted(true)
+}
+
+// identifier parses a possibly dotted identifier such as public.users,
+// keeping its case
+func (p *sqlParser) identifier() (string, error) {
+	return p.dotted(false)
+}
+
+func (p *sqlParser) dotted(fold bool) (string, error) {
+	var parts []string
+	for {
+		token := p.next()
+		if token.kind != sqlWord && token.kind != sqlNumber {
+			return "", fmt.Errorf("line %d: expected a name, got '%s'", token.line, token.text)
+		}
+		if fold && !token.quoted {
+			parts = append(parts, strings.ToLower(token.text))
+		} else {
+			parts = append(parts, token.text)
+		}
+		if !p.symbol(".") {
+			return strings.Join(parts, "."), nil
+		}
+	}
+}
+
+// identifierList parses [name, name, ...]
+func (p *sqlParser) identifierList() ([]string, error) {
+	if !p.symbol("[") {
+		return nil, p.errorf("expected '['")
+	}
+	var names []string
+	for !p.symbol("]") {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.symbol(",") && !p.at("]") {
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+	return names, nil
+}
+
+// skipStatement skips tokens up to the next ';'
+func (p *sqlParser) skipStatement() {
+	for !p.done() && !p.at(";") {
+		p.pos++
+	}
+}
+
+// errorf returns an error at the line of the next token
+func (p *sqlParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.peek().line, fmt.Sprintf(format, a...))
+}
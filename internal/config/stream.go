@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StdinPath is the -f value that reads configurations from standard input
+const StdinPath = "-"
+
+// stdinName identifies standard input in messages and validation reports
+const stdinName = "stdin"
+
+// streamDocument is one document of a multi-document YAML stream
+type streamDocument struct {
+	Index  int
+	Line   int
+	Config *FileConfig
+	Err    error
+}
+
+// readStream parses every document in a YAML stream, such as the output of
+// helm template. Environment variables are expanded across the whole
+// stream and empty documents are ignored. Syntax errors stop parsing, since
+// the rest of the stream can't be located reliably.
+func readStream(r io.Reader) ([]streamDocument, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", stdinName, err)
+	}
+	data, err = decryptSops(stdinName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := expandVariables(string(data))
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+
+	var docs []streamDocument
+	for index := 1; ; index++ {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return docs, fmt.Errorf("failed to parse YAML document %d: %w", index, err)
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		config, err := decodeConfig(&node)
+		if config != nil {
+			config.Path = StdinPath
+		}
+		docs = append(docs, streamDocument{Index: index, Line: node.Content[0].Line, Config: config, Err: err})
+	}
+}
+
+// LoadConfigStream loads every configuration in a multi-document YAML
+// stream. Documents of unknown kinds are skipped.
+func LoadConfigStream(r io.Reader) ([]*FileConfig, error) {
+	docs, err := readStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []*FileConfig
+	for _, doc := range docs {
+		if doc.Err != nil {
+			return nil, fmt.Errorf("failed to load document %d: %w", doc.Index, doc.Err)
+		}
+		if !IsKnownKind(doc.Config.Kind) {
+			continue
+		}
+		configs = append(configs, doc.Config)
+	}
+
+	return configs, nil
+}
+
+// ValidateStream validates every document in a multi-document YAML stream,
+// returning one result per document in stream order. Documents of unknown
+// kinds are marked as skipped.
+func ValidateStream(r io.Reader, defaults Defaults) []ValidationResult {
+	docs, err := readStream(r)
+
+	results := make([]ValidationResult, 0, len(docs)+1)
+	for _, doc := range docs {
+		result := ValidationResult{File: fmt.Sprintf("%s[%d]", stdinName, doc.Index)}
+
+		switch {
+		case doc.Err != nil:
+			result.Error = doc.Err.Error()
+			result.Line = doc.Line
+		case !IsKnownKind(doc.Config.Kind):
+			result.Kind = doc.Config.Kind
+			result.Name = doc.Config.Metadata.Name
+			result.Skipped = true
+		default:
+			result.Kind = doc.Config.Kind
+			result.Name = doc.Config.Metadata.Name
+			result.Config = doc.Config
+			if err := doc.Config.Validate(defaults); err != nil {
+				result.Line = doc.Line
+				result.SetError(err)
+			}
+		}
+		results = append(results, result)
+	}
+
+	resolveTemplateResults(results, defaults)
+
+	if err != nil {
+		result := ValidationResult{File: stdinName, Error: err.Error()}
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			result.Line, _ = strconv.Atoi(match[1])
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
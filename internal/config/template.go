@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MirrorTemplateKind is the kind of configurations holding mirror settings
+// shared by mirrors that reference them with spec.template
+const MirrorTemplateKind = "MirrorTemplate"
+
+// validateTemplate checks that a MirrorTemplate only sets shared settings
+func (fc *FileConfig) validateTemplate() error {
+	if fc.Metadata.Name == "" {
+		return fmt.Errorf("mirror template requires metadata.name")
+	}
+
+	spec := fc.Spec
+	switch {
+	case spec.Type != "", spec.Config != nil, spec.Validation != nil:
+		return fmt.Errorf("mirror templates only hold cdc, snapshot, columns, type_mapping, destination_options, and env settings")
+	case spec.Source != "", spec.Destination != "", len(spec.Tables) > 0:
+		return fmt.Errorf("mirror templates can't set source, destination, or tables")
+	case spec.Template != "":
+		return fmt.Errorf("mirror templates can't reference other templates")
+	}
+	return nil
+}
+
+// ResolveTemplates merges each mirror's template into its spec and returns
+// the configurations without the templates themselves. Settings in the
+// mirror take precedence; nested sections are merged key by key.
+func ResolveTemplates(configs []*FileConfig) ([]*FileConfig, error) {
+	templates, err := collectTemplates(configs)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]*FileConfig, 0, len(configs))
+	for _, fc := range configs {
+		if fc.Kind == MirrorTemplateKind {
+			continue
+		}
+		if fc.Kind == "Mirror" && fc.Spec.Template != "" {
+			merged, err := applyTemplate(fc, templates)
+			if err != nil {
+				return nil, fmt.Errorf("mirror '%s': %w", fc.Metadata.Name, err)
+			}
+			fc = merged
+		}
+		resolved = append(resolved, fc)
+	}
+	return resolved, nil
+}
+
+// resolveTemplateResults re-validates mirrors that use templates once
+// their template has been merged in
+func resolveTemplateResults(results []ValidationResult, defaults Defaults) {
+	var configs []*FileConfig
+	for _, result := range results {
+		if result.Config != nil && result.Error == "" && result.Config.Kind == MirrorTemplateKind {
+			configs = append(configs, result.Config)
+		}
+	}
+
+	templates, err := collectTemplates(configs)
+	for i := range results {
+		result := &results[i]
+		if result.Config == nil || result.Config.Kind != "Mirror" || result.Config.Spec.Template == "" {
+			continue
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		merged, mergeErr := applyTemplate(result.Config, templates)
+		if mergeErr != nil {
+			result.Error = mergeErr.Error()
+			continue
+		}
+		result.Config = merged
+		result.Error = ""
+		if err := merged.Validate(defaults); err != nil {
+			result.SetError(err)
+		}
+	}
+}
+
+func collectTemplates(configs []*FileConfig) (map[string]*FileConfig, error) {
+	templates := make(map[string]*FileConfig)
+	for _, fc := range configs {
+		if fc.Kind != MirrorTemplateKind {
+			continue
+		}
+		if existing, ok := templates[fc.Metadata.Name]; ok {
+			return nil, fmt.Errorf("mirror template '%s' is defined in both %s and %s", fc.Metadata.Name, existing.Path, fc.Path)
+		}
+		templates[fc.Metadata.Name] = fc
+	}
+	return templates, nil
+}
+
+// applyTemplate returns a copy of a mirror with its template merged in
+func applyTemplate(fc *FileConfig, templates map[string]*FileConfig) (*FileConfig, error) {
+	tmpl, ok := templates[fc.Spec.Template]
+	if !ok {
+		return nil, fmt.Errorf("mirror template '%s' not found; load it together with the mirror", fc.Spec.Template)
+	}
+	if fc.node == nil || tmpl.node == nil {
+		return nil, fmt.Errorf("mirror template '%s' can only be applied to configurations loaded from YAML", fc.Spec.Template)
+	}
+
+	// The merge works on YAML nodes so that explicit false and zero values
+	// in the mirror still override the template
+	base := mappingValue(tmpl.node, "spec")
+	if base == nil {
+		base = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	spec := mergeNodes(base, mappingValue(fc.node, "spec"))
+	deleteKey(spec, "template")
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(fc.node.Content); i += 2 {
+		value := fc.node.Content[i+1]
+		if fc.node.Content[i].Value == "spec" {
+			value = spec
+		}
+		root.Content = append(root.Content, fc.node.Content[i], value)
+	}
+
+	var merged FileConfig
+	if err := root.Decode(&merged); err != nil {
+		return nil, fmt.Errorf("failed to merge mirror template '%s': %w", fc.Spec.Template, err)
+	}
+	merged.Path = fc.Path
+	merged.node = root
+	return &merged, nil
+}
+
+// mergeNodes returns override merged over base. Mappings are merged key by
+// key; any other override value replaces the base value.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	merged.Content = append(merged.Content, base.Content...)
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
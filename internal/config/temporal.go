@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// WorkflowIDPlaceholder marks where temporal_ui_url takes a workflow ID
+const WorkflowIDPlaceholder = "{workflow_id}"
+
+// temporalUIPort is where PeerDB's Docker Compose setup serves the Temporal
+// UI, next to the PeerDB server
+const temporalUIPort = "8085"
+
+// ValidateTemporalUIURL returns an error unless url is empty or an http(s)
+// URL with the workflow ID placeholder
+func ValidateTemporalUIURL(template string) error {
+	if template == "" {
+		return nil
+	}
+	if !strings.Contains(template, WorkflowIDPlaceholder) {
+		return fmt.Errorf("invalid temporal_ui_url %q: must contain %s", template, WorkflowIDPlaceholder)
+	}
+	u, err := url.Parse(strings.ReplaceAll(template, WorkflowIDPlaceholder, "id"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid temporal_ui_url %q: must be an http or https URL", template)
+	}
+	return nil
+}
+
+// WorkflowURL returns the Temporal UI page of a workflow. Without
+// temporal_ui_url, it guesses the UI of PeerDB's Docker Compose setup, on
+// port 8085 of the PeerDB host in the default namespace, and returns false.
+func (c *Config) WorkflowURL(workflowID string) (string, bool) {
+	template, configured := c.TemporalUIURL, c.TemporalUIURL != ""
+	if !configured {
+		host := c.PeerDBHost
+		if endpoints := c.Endpoints(); len(endpoints) > 0 {
+			if h, _, err := net.SplitHostPort(endpoints[0]); err == nil {
+				host = h
+			}
+		}
+		template = "http://" + net.JoinHostPort(host, temporalUIPort) + "/namespaces/default/workflows/" + WorkflowIDPlaceholder
+	}
+	return strings.ReplaceAll(template, WorkflowIDPlaceholder, url.PathEscape(workflowID)), configured
+}
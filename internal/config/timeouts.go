@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default timeouts. Lookups are quick; creating a mirror can take the
+// server a while to validate peers and set up replication; waiting for a
+// snapshot or cutover takes longest.
+const (
+	DefaultReadTimeout   = 30 * time.Second
+	DefaultMutateTimeout = 2 * time.Minute
+	DefaultWaitTimeout   = 30 * time.Minute
+)
+
+// Timeouts bounds the CLI's calls to PeerDB. Zero means no limit.
+type Timeouts struct {
+	// Read bounds each lookup RPC, e.g. ListMirrors or MirrorStatus
+	Read time.Duration
+	// Mutate bounds each RPC that changes server state, e.g. CreateCDCFlow
+	Mutate time.Duration
+	// Wait bounds loops that poll until a mirror gets somewhere, e.g.
+	// mirror create --wait and mirror cutover
+	Wait time.Duration
+}
+
+// ValidateTimeout returns an error unless value is empty or a duration
+// such as 30s or 2m, with 0 meaning no limit. name is the setting, e.g.
+// read_timeout.
+func ValidateTimeout(name, value string) error {
+	_, err := parseTimeout(name, value, 0)
+	return err
+}
+
+// validateTimeouts validates the read, mutate, and wait timeouts of a
+// configuration or context
+func validateTimeouts(read, mutate, wait string) error {
+	if err := ValidateTimeout("read_timeout", read); err != nil {
+		return err
+	}
+	if err := ValidateTimeout("mutate_timeout", mutate); err != nil {
+		return err
+	}
+	return ValidateTimeout("wait_timeout", wait)
+}
+
+// Timeouts returns the configured timeouts, with defaults for those not
+// set. Call it on a configuration ResolveContext validated.
+func (c *Config) Timeouts() Timeouts {
+	read, _ := parseTimeout("read_timeout", c.ReadTimeout, DefaultReadTimeout)
+	mutate, _ := parseTimeout("mutate_timeout", c.MutateTimeout, DefaultMutateTimeout)
+	wait, _ := parseTimeout("wait_timeout", c.WaitTimeout, DefaultWaitTimeout)
+	return Timeouts{Read: read, Mutate: mutate, Wait: wait}
+}
+
+func parseTimeout(name, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fallback, fmt.Errorf("invalid %s %q: must be a duration such as 30s or 5m, or 0 for no limit", name, value)
+	}
+	return d, nil
+}
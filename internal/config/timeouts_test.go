@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 5 * time.Second, false},
+		{"30s", 30 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"0", 0, false},
+		{"30", 5 * time.Second, true},
+		{"soon", 5 * time.Second, true},
+		{"-1m", 5 * time.Second, true},
+		{"1 minute", 5 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeout("read_timeout", tt.value, 5*time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeoutsDefaults(t *testing.T) {
+	cfg := &Config{ReadTimeout: "1m", WaitTimeout: "0"}
+	got := cfg.Timeouts()
+	want := Timeouts{Read: time.Minute, Mutate: DefaultMutateTimeout, Wait: 0}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TypeMappingConfig exposes PeerDB's type-mapping settings, which the
+// server reads from a mirror's env, as structured fields
+type TypeMappingConfig struct {
+	// Nullable propagates source column nullability to destination tables
+	Nullable *bool `yaml:"nullable,omitempty"`
+
+	// UnboundedNumeric controls how NUMERIC columns without a declared
+	// precision are created: "decimal" or "string"
+	UnboundedNumeric string `yaml:"unbounded_numeric,omitempty"`
+}
+
+// typeMappingSetting describes one type_mapping field and the env setting
+// it is sent as
+type typeMappingSetting struct {
+	field        string
+	env          string
+	destinations []string
+	get          func(*TypeMappingConfig) (string, bool, error)
+	set          func(*TypeMappingConfig, string) error
+}
+
+// typeMappingSettings lists the supported type_mapping fields
+var typeMappingSettings = []typeMappingSetting{
+	{
+		field:        "nullable",
+		env:          "PEERDB_NULLABLE",
+		destinations: []string{"snowflake", "bigquery", "clickhouse"},
+		get: func(t *TypeMappingConfig) (string, bool, error) {
+			if t.Nullable == nil {
+				return "", false, nil
+			}
+			return strconv.FormatBool(*t.Nullable), true, nil
+		},
+		set: func(t *TypeMappingConfig, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			t.Nullable = &b
+			return nil
+		},
+	},
+	{
+		field:        "unbounded_numeric",
+		env:          "PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING",
+		destinations: []string{"clickhouse"},
+		get: func(t *TypeMappingConfig) (string, bool, error) {
+			switch t.UnboundedNumeric {
+			case "":
+				return "", false, nil
+			case "decimal":
+				return "false", true, nil
+			case "string":
+				return "true", true, nil
+			default:
+				return "", false, fmt.Errorf("unbounded_numeric must be decimal or string, got %q", t.UnboundedNumeric)
+			}
+		},
+		set: func(t *TypeMappingConfig, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			t.UnboundedNumeric = "decimal"
+			if b {
+				t.UnboundedNumeric = "string"
+			}
+			return nil
+		},
+	},
+}
+
+// Env returns the env settings for the configured type mappings. Setting
+// the same knob in both type_mapping and env is an error.
+func (t *TypeMappingConfig) Env(existing map[string]string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, setting := range typeMappingSettings {
+		value, ok, err := setting.get(t)
+		if err != nil {
+			return nil, &FieldError{Field: "spec.type_mapping." + setting.field, Err: fmt.Errorf("invalid type_mapping: %w", err)}
+		}
+		if !ok {
+			continue
+		}
+		if _, dup := existing[setting.env]; dup {
+			return nil, fieldErrorf("spec.type_mapping."+setting.field, "type_mapping.%s and env.%s set the same option; remove one", setting.field, setting.env)
+		}
+		env[setting.env] = value
+	}
+	return env, nil
+}
+
+// CheckDestination returns an error if a configured type mapping doesn't
+// apply to the destination peer type
+func (t *TypeMappingConfig) CheckDestination(destinationType string) error {
+	for _, setting := range typeMappingSettings {
+		if _, ok, _ := setting.get(t); !ok {
+			continue
+		}
+		if !containsString(setting.destinations, destinationType) {
+			return fieldErrorf("spec.type_mapping."+setting.field, "type_mapping.%s is not supported for %s destinations (supported: %s)",
+				setting.field, destinationType, strings.Join(setting.destinations, ", "))
+		}
+	}
+	return nil
+}
+
+// TypeMappingFromEnv moves type-mapping settings out of a mirror's env,
+// returning the structured form (nil if none are set) and the rest of env
+func TypeMappingFromEnv(env map[string]string) (*TypeMappingConfig, map[string]string) {
+	var mapping *TypeMappingConfig
+	rest := make(map[string]string, len(env))
+	for key, value := range env {
+		rest[key] = value
+	}
+
+	for _, setting := range typeMappingSettings {
+		value, ok := rest[setting.env]
+		if !ok {
+			continue
+		}
+
+		// Values the structured form can't express stay in env
+		parsed := TypeMappingConfig{}
+		if mapping != nil {
+			parsed = *mapping
+		}
+		if err := setting.set(&parsed, value); err != nil {
+			continue
+		}
+		mapping = &parsed
+		delete(rest, setting.env)
+	}
+
+	if len(rest) == 0 {
+		rest = nil
+	}
+	return mapping, rest
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// ValidationResult is the outcome of validating one configuration file
+type ValidationResult struct {
+	File   string `json:"file"`
+	Kind   string `json:"kind,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Violations lists the policy rules the configuration breaks
+	Violations []string `json:"violations,omitempty"`
+
+	// Skipped is set for files that parsed but are not CLI configurations
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Config is the loaded configuration, if the file could be parsed
+	Config *FileConfig `json:"-"`
+}
+
+// Valid reports whether the file passed validation
+func (r ValidationResult) Valid() bool {
+	return r.Error == "" && len(r.Violations) == 0
+}
+
+// SetError records err on the result, with the line and column of the
+// field it is about when the configuration knows them
+func (r *ValidationResult) SetError(err error) {
+	r.Error = err.Error()
+	if r.Config == nil {
+		return
+	}
+	var posErr *PositionError
+	if errors.As(r.Config.Locate(err), &posErr) {
+		r.Line, r.Column = posErr.Line, posErr.Column
+	}
+}
+
+// yamlLinePattern extracts line numbers from yaml.v3 error messages
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// Validate checks that a configuration converts to its protobuf form, with
+// defaults for what it doesn't set. Peers are also checked structurally;
+// nothing is sent to the server.
+func (fc *FileConfig) Validate(defaults Defaults) error {
+	if err := fc.CheckNames(); err != nil {
+		return err
+	}
+
+	var err error
+	switch fc.Kind {
+	case "Peer":
+		var peer *pb.Peer
+		if peer, err = fc.ToPeerProto(); err == nil {
+			err = fc.checkPeer(peer)
+		}
+	case "Mirror":
+		if fc.IsQRep() {
+			_, err = fc.ToQRepProto()
+		} else {
+			_, err = fc.ToMirrorProto(defaults)
+		}
+	case "Context":
+		_, err = fc.ToContext()
+	case MirrorTemplateKind:
+		err = fc.validateTemplate()
+	default:
+		err = fmt.Errorf("unsupported configuration kind: %s", fc.Kind)
+	}
+	return err
+}
+
+// ValidateFiles loads and validates files concurrently using up to workers
+// goroutines, returning results sorted by file path. When skipUnknown is
+// set, files of unknown kinds are marked as skipped rather than invalid.
+func ValidateFiles(files []string, workers int, skipUnknown bool, defaults Defaults) []ValidationResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]ValidationResult, len(files))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = validateFile(files[i], skipUnknown, defaults)
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	resolveTemplateResults(results, defaults)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].File < results[j].File
+	})
+	return results
+}
+
+func validateFile(path string, skipUnknown bool, defaults Defaults) ValidationResult {
+	result := ValidationResult{File: path}
+
+	fc, err := LoadConfigFile(path)
+	if err != nil {
+		result.Error = err.Error()
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			result.Line, _ = strconv.Atoi(match[1])
+		}
+		return result
+	}
+
+	result.Kind = fc.Kind
+	result.Name = fc.Metadata.Name
+	result.Config = fc
+	if skipUnknown && !IsKnownKind(fc.Kind) {
+		result.Skipped = true
+		return result
+	}
+
+	if err := fc.Validate(defaults); err != nil {
+		result.SetError(err)
+	}
+
+	return result
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretSource, when set, is consulted for ${VAR} references in config
+// files that aren't set in the environment, e.g. by a credential helper
+var SecretSource func(name string) (string, bool)
+
+// EnvFileValues are variables from --env-file files, used for references
+// that aren't set in the environment
+var EnvFileValues map[string]string
+
+// AllowMissingVariables expands references to unset variables to empty
+// strings instead of failing to load the file
+var AllowMissingVariables bool
+
+// MissingVariablesError lists the variables a config file references
+// that aren't set anywhere
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	refs := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		refs[i] = "${" + name + "}"
+	}
+	return fmt.Sprintf("unset variables: %s (set them, pass --env-file, or use --allow-missing-env to expand them to empty values)", strings.Join(refs, ", "))
+}
+
+// variableRef matches a ${VAR} or $VAR reference. Other uses of $, such
+// as $1 in a regular expression or a $ in a password, are left alone.
+var variableRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// expandVariables replaces ${VAR} and $VAR references with environment
+// variables, falling back to EnvFileValues and then SecretSource. Unset
+// variables are an error unless AllowMissingVariables is set.
+func expandVariables(s string) (string, error) {
+	missing := make(map[string]bool)
+	var expanded strings.Builder
+	last := 0
+	for _, match := range variableRef.FindAllStringSubmatchIndex(s, -1) {
+		expanded.WriteString(s[last:match[0]])
+		last = match[1]
+
+		// The first group is ${VAR}, the second $VAR
+		var name string
+		if match[2] >= 0 {
+			name = s[match[2]:match[3]]
+		} else {
+			name = s[match[4]:match[5]]
+		}
+		if value, ok := lookupVariable(name); ok {
+			expanded.WriteString(value)
+		} else {
+			missing[name] = true
+		}
+	}
+	expanded.WriteString(s[last:])
+	if len(missing) == 0 || AllowMissingVariables {
+		return expanded.String(), nil
+	}
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "", &MissingVariablesError{Names: names}
+}
+
+func lookupVariable(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	if value, ok := EnvFileValues[name]; ok {
+		return value, true
+	}
+	if SecretSource != nil {
+		if value, ok := SecretSource(name); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// ExpandCredentials replaces a username, password, or extra header value
+// that is a ${VAR} reference with the variable's value. config
+// import-context keeps such references so config.yaml doesn't hold the
+// secrets. Other values are left as they are, so passwords containing $
+// keep working.
+func (c *Config) ExpandCredentials() error {
+	var missing []string
+	expand := func(value string) string {
+		if !isPlaceholder(value) {
+			return value
+		}
+		name := value[2 : len(value)-1]
+		resolved, ok := lookupVariable(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return resolved
+	}
+
+	c.Username = expand(c.Username)
+	c.Password = expand(c.Password)
+	if len(c.ExtraHeaders) > 0 {
+		headers := make(map[string]string, len(c.ExtraHeaders))
+		for name, value := range c.ExtraHeaders {
+			headers[name] = expand(value)
+		}
+		c.ExtraHeaders = headers
+	}
+	if len(missing) > 0 {
+		refs := make([]string, len(missing))
+		for i, name := range missing {
+			refs[i] = "${" + name + "}"
+		}
+		return fmt.Errorf("unset variables: %s (set them, or supply them with a credential helper)", strings.Join(refs, ", "))
+	}
+	return nil
+}
+
+// KeepContextReferences puts back the ${VAR} references that the username,
+// password, and extra headers of the Context file at path were written
+// with, which loading the file expanded
+func KeepContextReferences(path string, ctx *Context) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	settings := mappingValue(mappingValue(doc.Content[0], "spec"), "config")
+
+	if username := mappingValue(settings, "username"); username != nil && isPlaceholder(username.Value) {
+		ctx.Username = username.Value
+	}
+	if password := mappingValue(settings, "password"); password != nil && isPlaceholder(password.Value) {
+		ctx.Password = password.Value
+	}
+	headers := mappingValue(settings, "extra_headers")
+	if headers == nil || headers.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(headers.Content); i += 2 {
+		name, value := headers.Content[i].Value, headers.Content[i+1].Value
+		if _, ok := ctx.ExtraHeaders[name]; ok && isPlaceholder(value) {
+			ctx.ExtraHeaders[name] = value
+		}
+	}
+	return nil
+}
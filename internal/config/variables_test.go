@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	t.Setenv("MIRROR_CLI_TEST_HOST", "db.internal")
+
+	tests := []struct {
+		in      string
+		want    string
+		missing []string
+	}{
+		{"host: ${MIRROR_CLI_TEST_HOST}", "host: db.internal", nil},
+		{"host: $MIRROR_CLI_TEST_HOST:5432", "host: db.internal:5432", nil},
+		{`pattern: "^(\w+)_$1$"`, `pattern: "^(\w+)_$1$"`, nil},
+		{"password: p@$5w0rd$", "password: p@$5w0rd$", nil},
+		{"cost: $5 ${} ${1X}", "cost: $5 ${} ${1X}", nil},
+		{"user: ${MIRROR_CLI_TEST_UNSET}", "", []string{"MIRROR_CLI_TEST_UNSET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := expandVariables(tt.in)
+			var missingErr *MissingVariablesError
+			if errors.As(err, &missingErr) {
+				if !slices.Equal(missingErr.Names, tt.missing) {
+					t.Errorf("got missing %v, want %v", missingErr.Names, tt.missing)
+				}
+				return
+			}
+			if err != nil || tt.missing != nil {
+				t.Fatalf("got error %v, want missing %v", err, tt.missing)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
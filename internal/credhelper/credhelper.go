@@ -0,0 +1,122 @@
+// Package credhelper runs external credential helpers, programs that look
+// up server credentials and secrets in an organization's own secret store.
+//
+// The CLI runs "<helper> get" with a JSON Request on stdin and reads a JSON
+// Response from stdout, like git and docker credential helpers. A helper
+// that has nothing for a request prints {} and exits 0; a non-zero exit is
+// an error.
+package credhelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request kinds
+const (
+	// KindServer asks for credentials for the PeerDB server
+	KindServer = "server"
+	// KindSecret asks for a secret referenced as ${NAME} in a config file
+	KindSecret = "secret"
+)
+
+// timeout bounds each helper invocation
+const timeout = 30 * time.Second
+
+// Request is sent to the helper on stdin
+type Request struct {
+	Kind string `json:"kind"`
+
+	// Context and Endpoints identify the server for server requests
+	Context   string   `json:"context,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// Name is the variable name for secret requests
+	Name string `json:"name,omitempty"`
+}
+
+// Response is read from the helper's stdout. Fields the helper doesn't
+// know are left empty.
+type Response struct {
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Secret   *string           `json:"secret,omitempty"`
+}
+
+// Helper runs a credential helper, caching its answers for the life of the
+// process
+type Helper struct {
+	path string
+
+	mu    sync.Mutex
+	cache map[string]*Response
+}
+
+// New returns a helper running the program at path. The path may include
+// arguments, separated by spaces.
+func New(path string) *Helper {
+	return &Helper{path: path, cache: make(map[string]*Response)}
+}
+
+// Server returns the credentials for a PeerDB server
+func (h *Helper) Server(context string, endpoints []string) (*Response, error) {
+	return h.get(Request{Kind: KindServer, Context: context, Endpoints: endpoints})
+}
+
+// Secret returns the value of a secret, and whether the helper had one
+func (h *Helper) Secret(name string) (string, bool, error) {
+	resp, err := h.get(Request{Kind: KindSecret, Name: name})
+	if err != nil || resp.Secret == nil {
+		return "", false, err
+	}
+	return *resp.Secret, true, nil
+}
+
+func (h *Helper) get(req Request) (*Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if resp, ok := h.cache[string(input)]; ok {
+		return resp, nil
+	}
+
+	args := strings.Fields(h.path)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("credential helper is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], "get")...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %s failed: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s failed: %w", args[0], err)
+	}
+
+	resp := &Response{}
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		if err := json.Unmarshal(out, resp); err != nil {
+			return nil, fmt.Errorf("credential helper %s returned invalid JSON: %w", args[0], err)
+		}
+	}
+	h.cache[string(input)] = resp
+	return resp, nil
+}
@@ -0,0 +1,171 @@
+// Package gitsource fetches config files from a git repository, so apply
+// and reconcile can deploy straight from a git URL. It shells out to git,
+// which handles SSH keys, credential helpers, and proxies as usual.
+package gitsource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables with credentials for HTTPS repositories. They are
+// passed to git as an Authorization header through its environment, so
+// they never appear in URLs, command lines, or .git/config. Credentials
+// in a repository URL are passed the same way and left out of the URL git
+// stores.
+const (
+	UsernameEnv = "MIRROR_CLI_GIT_USERNAME"
+	TokenEnv    = "MIRROR_CLI_GIT_TOKEN"
+	SSHKeyEnv   = "MIRROR_CLI_GIT_SSH_KEY"
+)
+
+// Source is a repository and ref to fetch
+type Source struct {
+	Repo string
+	// Ref is a branch, tag, or commit; empty means the remote's default
+	// branch
+	Ref string
+}
+
+// Checkout is a fetched revision on disk
+type Checkout struct {
+	Dir string
+	SHA string
+}
+
+// Dir returns the directory repositories are cached in
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mirror_cli", "git"), nil
+}
+
+// Fetch fetches the source's ref into a cached working tree and checks it
+// out, discarding any local changes
+func Fetch(ctx context.Context, src Source) (*Checkout, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(src.Repo))
+	dir := filepath.Join(root, hex.EncodeToString(sum[:])[:16])
+	git := func(args ...string) (string, error) {
+		return runGit(ctx, dir, src.Repo, args...)
+	}
+
+	// The remote is stored without credentials; setting it again also
+	// removes them from caches made by earlier versions
+	remote := Redact(src.Repo)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create git cache directory: %w", err)
+		}
+		if _, err := git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+		if _, err := git("remote", "add", "origin", remote); err != nil {
+			return nil, err
+		}
+	} else if _, err := git("remote", "set-url", "origin", remote); err != nil {
+		return nil, err
+	}
+
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := git("fetch", "--quiet", "--depth", "1", "origin", ref); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s from %s: %w", ref, Redact(src.Repo), err)
+	}
+	if _, err := git("checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return nil, err
+	}
+	if _, err := git("clean", "--quiet", "-fdx"); err != nil {
+		return nil, err
+	}
+	sha, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return &Checkout{Dir: dir, SHA: sha}, nil
+}
+
+// Path returns path inside the checkout, refusing paths that leave it
+func (c *Checkout) Path(path string) (string, error) {
+	full := filepath.Join(c.Dir, path)
+	if rel, err := filepath.Rel(c.Dir, full); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("path %s is outside the repository", path)
+	}
+	return full, nil
+}
+
+// Redact removes credentials from a repository URL, for output and
+// provenance annotations
+func Redact(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.User == nil {
+		return repo
+	}
+	u.User = nil
+	return u.String()
+}
+
+// credentials returns the username and token to authenticate to repo
+// with: those in its URL, or else those in the environment
+func credentials(repo string) (string, string) {
+	username, token := os.Getenv(UsernameEnv), os.Getenv(TokenEnv)
+	if u, err := url.Parse(repo); err == nil && u.User != nil {
+		username = u.User.Username()
+		token, _ = u.User.Password()
+		if token == "" {
+			// A bare token, as in https://TOKEN@github.com/org/repo
+			username, token = "", username
+		}
+	}
+	if username == "" {
+		username = "x-access-token"
+	}
+	return username, token
+}
+
+// runGit runs git in dir with credentials for repo and returns its trimmed
+// output
+func runGit(ctx context.Context, dir, repo string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if username, token := credentials(repo); token != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	if key := os.Getenv(SSHKeyEnv); key != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o IdentitiesOnly=yes", key))
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// The first line has the cause; the rest is generic advice
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,75 @@
+// Package golden compares protobuf messages built by the CLI with golden
+// files checked in next to the tests, so changes to what is sent over the
+// wire show up in review. Run the tests with -update to rewrite the goldens.
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// Assert compares msg with the prototext golden file at path. Messages are
+// compared after parsing the golden, since prototext output isn't stable
+// byte for byte across protobuf versions.
+func Assert(t testing.TB, path string, msg proto.Message) {
+	t.Helper()
+
+	got, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("failed to marshal %T: %v", msg, err)
+	}
+	if *update {
+		write(t, path, got)
+		return
+	}
+
+	data := read(t, path)
+	want := msg.ProtoReflect().New().Interface()
+	if err := prototext.Unmarshal(data, want); err != nil {
+		t.Fatalf("failed to parse golden file %s: %v", path, err)
+	}
+	if !proto.Equal(want, msg) {
+		t.Errorf("%s: request differs from golden file (run with -update if the change is intended)\n--- want\n%s\n--- got\n%s", path, data, got)
+	}
+}
+
+// AssertText compares text, such as an error message, with the golden file
+// at path
+func AssertText(t testing.TB, path, text string) {
+	t.Helper()
+
+	if *update {
+		write(t, path, []byte(text+"\n"))
+		return
+	}
+	if want := strings.TrimSuffix(string(read(t, path)), "\n"); want != text {
+		t.Errorf("%s: output differs from golden file (run with -update if the change is intended)\n--- want\n%s\n--- got\n%s", path, want, text)
+	}
+}
+
+func read(t testing.TB, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	return data
+}
+
+func write(t testing.TB, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+// Package hooks runs the programs configured to run before and after
+// commands that change server state, e.g. to check for an approved change
+// ticket or to update a CMDB.
+//
+// A hook named pre_<operation> or post_<operation> runs with a JSON
+// Payload describing the operation on stdin. A pre hook that exits
+// non-zero stops the command before it changes anything; a failing post
+// hook is only reported, as the change was already made.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Hook stages
+const (
+	Pre  = "pre"
+	Post = "post"
+)
+
+// timeout bounds each hook invocation
+const timeout = 2 * time.Minute
+
+// Payload describes the operation to a hook
+type Payload struct {
+	// Hook is the hook's name, e.g. pre_drop
+	Hook string `json:"hook"`
+	// Operation is the hook's name without the stage, e.g. drop
+	Operation string `json:"operation"`
+	// Command is the command path without the binary, e.g. "mirror drop"
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Flags holds the command's flags that were set on the command line
+	Flags map[string]string `json:"flags,omitempty"`
+
+	Context     string   `json:"context,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	Endpoints   []string `json:"endpoints,omitempty"`
+	RequestID   string   `json:"request_id,omitempty"`
+	User        string   `json:"user,omitempty"`
+	Time        string   `json:"time"`
+
+	// Succeeded and Error report the outcome to post hooks
+	Succeeded *bool  `json:"succeeded,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Name returns the name of the hook for a stage of an operation
+func Name(stage, operation string) string {
+	return stage + "_" + operation
+}
+
+// ValidateNames returns an error for hook names that aren't a stage of
+// one of operations
+func ValidateNames(hooks map[string]string, operations []string) error {
+	for name := range hooks {
+		valid := false
+		for _, operation := range operations {
+			if name == Name(Pre, operation) || name == Name(Post, operation) {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown hook %q: expected pre_ or post_ followed by one of %s", name, strings.Join(operations, ", "))
+		}
+	}
+	return nil
+}
+
+// Run runs the hook program at path with payload on stdin. The path may
+// include arguments, separated by spaces. The hook's output goes to out,
+// and MIRROR_CLI_HOOK is set to the hook's name.
+func Run(ctx context.Context, path string, payload Payload, out io.Writer) error {
+	args := strings.Fields(path)
+	if len(args) == 0 {
+		return fmt.Errorf("hook %s is empty", payload.Hook)
+	}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.Env = append(os.Environ(), "MIRROR_CLI_HOOK="+payload.Hook)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", args[0], err)
+	}
+	return nil
+}
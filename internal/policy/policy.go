@@ -0,0 +1,173 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/janakos/mirror_cli/internal/config"
+)
+
+// Policy holds guardrails that platform teams distribute to constrain
+// self-service peers and mirrors. Unset rules are not enforced.
+type Policy struct {
+	// MaxTablesPerMirror limits the number of table mappings in a mirror
+	MaxTablesPerMirror int `yaml:"max_tables_per_mirror,omitempty"`
+
+	// Naming holds regular expressions resource names must match
+	Naming NamingRules `yaml:"naming,omitempty"`
+
+	// RequireSoftDeleteColumn requires mirrors to set columns.soft_delete_column
+	RequireSoftDeleteColumn bool `yaml:"require_soft_delete_column,omitempty"`
+
+	// AllowedDestinationTypes maps an environment to the peer types mirrors
+	// in it may replicate to. The "*" entry applies to environments that
+	// aren't listed.
+	AllowedDestinationTypes map[string][]string `yaml:"allowed_destination_types,omitempty"`
+
+	peerName   *regexp.Regexp
+	mirrorName *regexp.Regexp
+}
+
+// NamingRules holds name patterns per resource kind
+type NamingRules struct {
+	Peer   string `yaml:"peer,omitempty"`
+	Mirror string `yaml:"mirror,omitempty"`
+}
+
+// Violation is a single policy rule a configuration breaks
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// PeerTypeResolver returns the type of a named peer (postgres, snowflake,
+// bigquery, ...) and whether it is known
+type PeerTypeResolver func(name string) (string, bool)
+
+// Load reads and compiles a policy file
+func Load(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", filename, err)
+	}
+
+	if p.Naming.Peer != "" {
+		if p.peerName, err = regexp.Compile(p.Naming.Peer); err != nil {
+			return nil, fmt.Errorf("invalid naming.peer pattern: %w", err)
+		}
+	}
+	if p.Naming.Mirror != "" {
+		if p.mirrorName, err = regexp.Compile(p.Naming.Mirror); err != nil {
+			return nil, fmt.Errorf("invalid naming.mirror pattern: %w", err)
+		}
+	}
+
+	return &p, nil
+}
+
+// Check returns the rules a configuration violates. Destination types are
+// looked up with resolve; mirrors whose destination can't be resolved are
+// not checked against allowed_destination_types.
+func (p *Policy) Check(fc *config.FileConfig, resolve PeerTypeResolver) []Violation {
+	var violations []Violation
+
+	switch fc.Kind {
+	case "Peer":
+		if p.peerName != nil && !p.peerName.MatchString(fc.Metadata.Name) {
+			violations = append(violations, Violation{
+				Rule:    "naming.peer",
+				Message: fmt.Sprintf("peer name %q does not match %s", fc.Metadata.Name, p.Naming.Peer),
+			})
+		}
+
+	case "Mirror":
+		if p.mirrorName != nil && !p.mirrorName.MatchString(fc.Metadata.Name) {
+			violations = append(violations, Violation{
+				Rule:    "naming.mirror",
+				Message: fmt.Sprintf("mirror name %q does not match %s", fc.Metadata.Name, p.Naming.Mirror),
+			})
+		}
+
+		if p.MaxTablesPerMirror > 0 && len(fc.Spec.Tables) > p.MaxTablesPerMirror {
+			violations = append(violations, Violation{
+				Rule:    "max_tables_per_mirror",
+				Message: fmt.Sprintf("mirror has %d tables; at most %d are allowed", len(fc.Spec.Tables), p.MaxTablesPerMirror),
+			})
+		}
+
+		if p.RequireSoftDeleteColumn {
+			if columns, err := fc.EffectiveColumns(); err == nil && columns.SoftDeleteColumn == "" {
+				violations = append(violations, Violation{
+					Rule:    "require_soft_delete_column",
+					Message: "mirror must set columns.soft_delete_column",
+				})
+			}
+		}
+
+		if allowed, ok := p.allowedDestinations(fc.Metadata.Environment); ok && resolve != nil {
+			if destType, known := resolve(fc.Spec.Destination); known && !contains(allowed, destType) {
+				violations = append(violations, Violation{
+					Rule: "allowed_destination_types",
+					Message: fmt.Sprintf("destination %q is a %s peer; environment %q allows %s",
+						fc.Spec.Destination, destType, environmentLabel(fc.Metadata.Environment), strings.Join(allowed, ", ")),
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// allowedDestinations returns the destination types allowed in an
+// environment and whether the policy restricts it at all
+func (p *Policy) allowedDestinations(environment string) ([]string, bool) {
+	if allowed, ok := p.AllowedDestinationTypes[environment]; ok && environment != "" {
+		return allowed, true
+	}
+	allowed, ok := p.AllowedDestinationTypes["*"]
+	return allowed, ok
+}
+
+// NormalizePeerType maps peer type spellings used in files and by the
+// server (postgresql, POSTGRES, bq, ...) to a single lowercase name
+func NormalizePeerType(peerType string) string {
+	switch t := strings.ToLower(peerType); t {
+	case "postgresql":
+		return "postgres"
+	case "bq":
+		return "bigquery"
+	case "sf":
+		return "snowflake"
+	default:
+		return t
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if NormalizePeerType(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func environmentLabel(environment string) string {
+	if environment == "" {
+		return "(none)"
+	}
+	return environment
+}
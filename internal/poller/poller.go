@@ -0,0 +1,164 @@
+// Package poller fetches the status of many resources concurrently and
+// repeatedly without stampeding the PeerDB API: requests are bounded by a
+// worker pool, poll intervals are jittered so clients drift apart, and
+// resources whose requests keep failing are backed off.
+package poller
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency bounds concurrent requests when Options leaves it unset
+const DefaultConcurrency = 8
+
+// DefaultJitter is the fraction intervals are randomized by when Options
+// leaves it unset
+const DefaultJitter = 0.1
+
+// Options tune a Poller
+type Options struct {
+	// Concurrency is the maximum number of requests in flight
+	Concurrency int
+	// Jitter randomizes each wait by up to this fraction of the interval,
+	// e.g. 0.1 for ±10%
+	Jitter float64
+	// MaxBackoff caps how long a failing resource is skipped; the default
+	// is ten intervals
+	MaxBackoff time.Duration
+}
+
+// Poller polls a set of keys, such as mirror names, every interval
+type Poller struct {
+	interval time.Duration
+	opts     Options
+
+	mu       sync.Mutex
+	failures map[string]int
+	retryAt  map[string]time.Time
+}
+
+// New returns a poller that polls every interval
+func New(interval time.Duration, opts Options) *Poller {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Jitter <= 0 {
+		opts.Jitter = DefaultJitter
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 10 * interval
+	}
+	return &Poller{
+		interval: interval,
+		opts:     opts,
+		failures: make(map[string]int),
+		retryAt:  make(map[string]time.Time),
+	}
+}
+
+// Poll calls fetch for every key that isn't backing off, with at most
+// Concurrency calls at once, and returns the keys that were skipped. A key
+// whose fetch fails is skipped for twice as long after each consecutive
+// failure, up to MaxBackoff; a success clears its backoff.
+func (p *Poller) Poll(ctx context.Context, keys []string, fetch func(ctx context.Context, key string) error) []string {
+	now := time.Now()
+	var due, skipped []string
+	p.mu.Lock()
+	for _, key := range keys {
+		if now.Before(p.retryAt[key]) {
+			skipped = append(skipped, key)
+		} else {
+			due = append(due, key)
+		}
+	}
+	p.mu.Unlock()
+
+	Map(ctx, due, p.opts.Concurrency, func(ctx context.Context, key string) struct{} {
+		err := fetch(ctx, key)
+		p.record(key, err)
+		return struct{}{}
+	})
+	return skipped
+}
+
+// record updates a key's backoff after a fetch
+func (p *Poller) record(key string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		delete(p.failures, key)
+		delete(p.retryAt, key)
+		return
+	}
+
+	// After n consecutive failures the key is fetched again about
+	// interval*2^(n-1) later, so the first failure is retried on the next
+	// poll as usual. The poll after this one is an interval away already.
+	p.failures[key]++
+	backoff := p.interval
+	for i := 1; i < p.failures[key] && backoff < p.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, p.opts.MaxBackoff)
+	p.retryAt[key] = time.Now().Add(Jitter(backoff-p.interval, p.opts.Jitter))
+}
+
+// Wait waits for the interval, with jitter, or until ctx is done
+func (p *Poller) Wait(ctx context.Context) error {
+	return Sleep(ctx, Jitter(p.interval, p.opts.Jitter))
+}
+
+// Map calls fn for every item with at most concurrency calls at once and
+// returns the results in item order
+func Map[T, R any](ctx context.Context, items []T, concurrency int, fn func(ctx context.Context, item T) R) []R {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	results := make([]R, len(items))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fn(ctx, items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Jitter randomizes d by up to ±fraction of it, so clients polling on the
+// same interval spread out instead of calling the API in lockstep
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 || fraction <= 0 {
+		return d
+	}
+	spread := float64(d) * fraction
+	return d + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// Sleep waits for d or until ctx is done, returning ctx's error if it is
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
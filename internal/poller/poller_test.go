@@ -0,0 +1,194 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		items       int
+		concurrency int
+		wantMax     int
+	}{
+		{0, 4, 4},
+		{1, 4, 1},
+		{10, 1, 1},
+		{10, 3, 3},
+		{20, 0, DefaultConcurrency},
+		{5, 100, 5},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d items, concurrency %d", tt.items, tt.concurrency), func(t *testing.T) {
+			items := make([]int, tt.items)
+			for i := range items {
+				items[i] = i
+			}
+
+			var inFlight, peak atomic.Int32
+			got := Map(context.Background(), items, tt.concurrency, func(ctx context.Context, item int) int {
+				n := inFlight.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				inFlight.Add(-1)
+				return item * 2
+			})
+
+			if len(got) != tt.items {
+				t.Fatalf("got %d results, want %d", len(got), tt.items)
+			}
+			for i, result := range got {
+				if result != i*2 {
+					t.Errorf("result %d is %d, want %d", i, result, i*2)
+				}
+			}
+			if int(peak.Load()) > tt.wantMax {
+				t.Errorf("%d calls ran at once, want at most %d", peak.Load(), tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		fraction float64
+		min, max time.Duration
+	}{
+		{10 * time.Second, 0.1, 9 * time.Second, 11 * time.Second},
+		{time.Minute, 0.5, 30 * time.Second, 90 * time.Second},
+		{10 * time.Second, 0, 10 * time.Second, 10 * time.Second},
+		{0, 0.1, 0, 0},
+		{-time.Second, 0.1, -time.Second, -time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s ±%g", tt.d, tt.fraction), func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if got := Jitter(tt.d, tt.fraction); got < tt.min || got > tt.max {
+					t.Fatalf("got %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestPollBacksOffFailingKeys(t *testing.T) {
+	p := New(time.Hour, Options{})
+	failing := map[string]bool{"broken": true}
+	var fetched []string
+	poll := func() []string {
+		fetched = nil
+		results := make(chan string, 10)
+		skipped := p.Poll(context.Background(), []string{"broken", "healthy"}, func(ctx context.Context, key string) error {
+			results <- key
+			if failing[key] {
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+		close(results)
+		for key := range results {
+			fetched = append(fetched, key)
+		}
+		slices.Sort(fetched)
+		return skipped
+	}
+
+	// The first failure is retried on the next poll as usual
+	if skipped := poll(); len(skipped) != 0 {
+		t.Fatalf("first poll skipped %v", skipped)
+	}
+	if skipped := poll(); len(skipped) != 0 {
+		t.Fatalf("poll after one failure skipped %v", skipped)
+	}
+
+	// After the second, it is skipped for about an interval
+	if skipped := poll(); !slices.Equal(skipped, []string{"broken"}) {
+		t.Fatalf("poll after two failures skipped %v, want [broken]", skipped)
+	}
+	if !slices.Equal(fetched, []string{"healthy"}) {
+		t.Errorf("fetched %v, want [healthy]", fetched)
+	}
+
+	// A success clears the backoff
+	p.record("broken", nil)
+	failing["broken"] = false
+	if skipped := poll(); len(skipped) != 0 {
+		t.Fatalf("poll after a success skipped %v", skipped)
+	}
+	if !slices.Equal(fetched, []string{"broken", "healthy"}) {
+		t.Errorf("fetched %v, want [broken healthy]", fetched)
+	}
+}
+
+func TestRecordBackoff(t *testing.T) {
+	interval := time.Minute
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{2, time.Minute},
+		{3, 3 * time.Minute},
+		// Capped at MaxBackoff, less the interval until the next poll
+		{4, 4 * time.Minute},
+		{10, 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			p := New(interval, Options{MaxBackoff: 5 * time.Minute})
+			for i := 0; i < tt.failures; i++ {
+				p.record("broken", errors.New("unavailable"))
+			}
+
+			got := time.Until(p.retryAt["broken"])
+			spread := time.Duration(float64(tt.want)*DefaultJitter) + time.Second
+			if got < tt.want-spread || got > tt.want+spread {
+				t.Errorf("retried in %s, want %s ±%s", got.Round(time.Second), tt.want, spread)
+			}
+			if p.failures["broken"] != tt.failures {
+				t.Errorf("counted %d failures, want %d", p.failures["broken"], tt.failures)
+			}
+		})
+	}
+}
+
+func TestSleep(t *testing.T) {
+	t.Run("elapsed", func(t *testing.T) {
+		if err := Sleep(context.Background(), time.Millisecond); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := Sleep(ctx, time.Hour)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("returned after %s, want right after cancellation", elapsed)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := Sleep(ctx, time.Hour); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
@@ -0,0 +1,396 @@
+// Package printer writes tabular command output as an aligned table, JSON,
+// YAML, CSV, a Go template, or kubectl-style custom columns. Commands
+// build a Table once and let the user's --output choose how it is printed.
+package printer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatCSV      = "csv"
+	FormatTemplate = "template"
+	// FormatCustomColumns is followed by =HEADER:.key pairs, e.g.
+	// custom-columns=NAME:.name,STATE:.state
+	FormatCustomColumns = "custom-columns"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTemplate, FormatCustomColumns}
+
+// Column describes one column of a Table
+type Column struct {
+	// Header is shown by the table and CSV printers
+	Header string
+	// Key names the value in JSON, YAML, and templates
+	Key string
+	// Right aligns the column right in tables, e.g. for numbers
+	Right bool
+	// Format renders a value for tables, e.g. "1.2M" for a row count.
+	// Other formats print the value itself.
+	Format func(value interface{}) string
+	// Aliases are other names Select accepts for the column, e.g. "status"
+	Aliases []string
+}
+
+// Matches reports whether name selects the column: its key, its header
+// with words joined by underscores or hyphens, or an alias, in any case
+func (c Column) Matches(name string) bool {
+	name = columnName(name)
+	if name == columnName(c.Key) || name == columnName(c.Header) {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if name == columnName(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnName normalizes a column name, e.g. "ROWS SYNCED" to rows_synced
+func columnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// Table is tabular output. Each row holds one value per column: a string,
+// number, bool, time.Time, or nil when the value is unknown.
+type Table struct {
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// AddRow appends a row of values in column order
+func (t *Table) AddRow(values ...interface{}) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Select returns a table with only the named columns, in the order given.
+// It fails on a name that matches no column, listing the available ones.
+func (t *Table) Select(names []string) (*Table, error) {
+	selected := &Table{Rows: make([][]interface{}, len(t.Rows))}
+	var indexes []int
+	for _, name := range names {
+		index := -1
+		for i, column := range t.Columns {
+			if column.Matches(name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			available := make([]string, len(t.Columns))
+			for i, column := range t.Columns {
+				available[i] = column.Key
+			}
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(available, ", "))
+		}
+		if slices.Contains(indexes, index) {
+			return nil, fmt.Errorf("column %q is listed twice", name)
+		}
+		indexes = append(indexes, index)
+		selected.Columns = append(selected.Columns, t.Columns[index])
+	}
+
+	for r, row := range t.Rows {
+		selected.Rows[r] = make([]interface{}, len(indexes))
+		for i, index := range indexes {
+			selected.Rows[r][i] = row[index]
+		}
+	}
+	return selected, nil
+}
+
+// Printer writes a Table in one output format
+type Printer interface {
+	Print(w io.Writer, t *Table) error
+}
+
+// New returns the printer for a format. The template format executes text
+// once per row.
+func New(format, text string) (Printer, error) {
+	if name, spec, ok := strings.Cut(format, "="); ok && strings.EqualFold(name, FormatCustomColumns) {
+		return parseCustomColumns(spec)
+	}
+	switch strings.ToLower(format) {
+	case "", FormatTable:
+		return TablePrinter{}, nil
+	case FormatJSON:
+		return JSONPrinter{}, nil
+	case FormatYAML:
+		return YAMLPrinter{}, nil
+	case FormatCSV:
+		return CSVPrinter{}, nil
+	case FormatCustomColumns:
+		return nil, fmt.Errorf("-o custom-columns requires columns, e.g. -o custom-columns=NAME:.name,STATE:.state")
+	case FormatTemplate:
+		if text == "" {
+			return nil, fmt.Errorf("-o template requires --template")
+		}
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %w", err)
+		}
+		return TemplatePrinter{Template: tmpl}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// TablePrinter prints an aligned table with a header and a rule
+type TablePrinter struct{}
+
+// Print implements Printer
+func (TablePrinter) Print(w io.Writer, t *Table) error {
+	cells := make([][]string, len(t.Rows))
+	widths := make([]int, len(t.Columns))
+	for i, column := range t.Columns {
+		widths[i] = utf8.RuneCountInString(column.Header)
+	}
+	for r, row := range t.Rows {
+		cells[r] = make([]string, len(t.Columns))
+		for i, column := range t.Columns {
+			cells[r][i] = displayValue(column, row[i])
+			widths[i] = max(widths[i], utf8.RuneCountInString(cells[r][i]))
+		}
+	}
+
+	headers := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		headers[i] = column.Header
+	}
+	header := formatLine(t.Columns, widths, headers)
+	if _, err := fmt.Fprintln(w, header); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, strings.Repeat("-", utf8.RuneCountInString(header)))
+	for _, row := range cells {
+		if _, err := fmt.Fprintln(w, formatLine(t.Columns, widths, row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatLine pads cells to their column widths. The last column isn't
+// padded unless it is right-aligned.
+func formatLine(columns []Column, widths []int, cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteString("  ")
+		}
+		pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		switch {
+		case columns[i].Right:
+			b.WriteString(pad + cell)
+		case i == len(cells)-1:
+			b.WriteString(cell)
+		default:
+			b.WriteString(cell + pad)
+		}
+	}
+	return b.String()
+}
+
+func displayValue(column Column, value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	if column.Format != nil {
+		return column.Format(value)
+	}
+	return plainValue(value)
+}
+
+// plainValue renders a value without display formatting, for CSV
+func plainValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// CSVPrinter prints the header and rows as CSV, with unformatted values so
+// spreadsheets can compute with them
+type CSVPrinter struct{}
+
+// Print implements Printer
+func (CSVPrinter) Print(w io.Writer, t *Table) error {
+	out := csv.NewWriter(w)
+	headers := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		headers[i] = column.Header
+	}
+	if err := out.Write(headers); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i := range t.Columns {
+			record[i] = plainValue(row[i])
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// JSONPrinter prints the rows as an array of objects keyed by column, in
+// column order
+type JSONPrinter struct{}
+
+// Print implements Printer
+func (JSONPrinter) Print(w io.Writer, t *Table) error {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for r, row := range t.Rows {
+		if r > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, column := range t.Columns {
+			value, err := json.Marshal(row[i])
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", column.Key, err)
+			}
+			key, _ := json.Marshal(column.Key)
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "\n    %s: %s", key, value)
+		}
+		b.WriteString("\n  }")
+	}
+	if len(t.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// YAMLPrinter prints the rows as a list of mappings keyed by column, in
+// column order
+type YAMLPrinter struct{}
+
+// Print implements Printer
+func (YAMLPrinter) Print(w io.Writer, t *Table) error {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range t.Rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for i, column := range t.Columns {
+			value := &yaml.Node{}
+			if err := value.Encode(row[i]); err != nil {
+				return fmt.Errorf("failed to encode %s: %w", column.Key, err)
+			}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: column.Key}, value)
+		}
+		list.Content = append(list.Content, mapping)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(list); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// TemplatePrinter executes a Go template once per row, with the row's
+// values keyed by column, e.g. {{.name}}. A newline follows each row
+// unless the template ends with one.
+type TemplatePrinter struct {
+	Template *template.Template
+}
+
+// Print implements Printer
+func (p TemplatePrinter) Print(w io.Writer, t *Table) error {
+	newline := !strings.HasSuffix(p.Template.Root.String(), "\n")
+	for _, row := range t.Rows {
+		data := make(map[string]interface{}, len(t.Columns))
+		for i, column := range t.Columns {
+			data[column.Key] = row[i]
+		}
+		if err := p.Template.Execute(w, data); err != nil {
+			return fmt.Errorf("failed to execute --template: %w", err)
+		}
+		if newline {
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// CustomColumn is a column of -o custom-columns: a header of the user's
+// choosing over the column selected by Field
+type CustomColumn struct {
+	Header string
+	Field  string
+}
+
+// CustomColumnsPrinter prints an aligned table of the columns chosen with
+// -o custom-columns, like kubectl's
+type CustomColumnsPrinter struct {
+	Columns []CustomColumn
+}
+
+// parseCustomColumns parses HEADER:.key pairs separated by commas. A field
+// may be written kubectl's way, as {.key}.
+func parseCustomColumns(spec string) (CustomColumnsPrinter, error) {
+	var p CustomColumnsPrinter
+	if strings.TrimSpace(spec) == "" {
+		return p, fmt.Errorf("-o custom-columns requires columns, e.g. -o custom-columns=NAME:.name,STATE:.state")
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		header, field, ok := strings.Cut(pair, ":")
+		header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+		field = strings.TrimSuffix(strings.TrimPrefix(field, "{"), "}")
+		if !ok || header == "" || !strings.HasPrefix(field, ".") || len(field) == 1 {
+			return p, fmt.Errorf("invalid custom column %q: expected HEADER:.key, e.g. NAME:.name", pair)
+		}
+		p.Columns = append(p.Columns, CustomColumn{Header: header, Field: field[1:]})
+	}
+	return p, nil
+}
+
+// Print implements Printer. Fields select columns like Select does, by
+// key, header, or alias.
+func (p CustomColumnsPrinter) Print(w io.Writer, t *Table) error {
+	fields := make([]string, len(p.Columns))
+	for i, column := range p.Columns {
+		fields[i] = column.Field
+	}
+	selected, err := t.Select(fields)
+	if err != nil {
+		return fmt.Errorf("invalid custom columns: %w", err)
+	}
+	for i, column := range p.Columns {
+		selected.Columns[i].Header = column.Header
+	}
+	return TablePrinter{}.Print(w, selected)
+}
@@ -0,0 +1,339 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleTable has a formatted right-aligned column, a time, an alias, and
+// a missing value
+func sampleTable() *Table {
+	t := &Table{Columns: []Column{
+		{Header: "NAME", Key: "name"},
+		{Header: "ROWS SYNCED", Key: "rows_synced", Right: true, Format: func(v interface{}) string {
+			return fmt.Sprintf("%dK", v.(int64)/1000)
+		}},
+		{Header: "STARTED", Key: "started", Aliases: []string{"since"}},
+	}}
+	t.AddRow("orders", int64(12000), time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	t.AddRow("users", int64(3000), nil)
+	return t
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		format   string
+		template string
+		want     string
+	}{
+		{FormatTable, "", `
+NAME    ROWS SYNCED  STARTED
+----------------------------
+orders          12K  2024-05-01T12:00:00Z
+users            3K  -
+`},
+		{"", "", `
+NAME    ROWS SYNCED  STARTED
+----------------------------
+orders          12K  2024-05-01T12:00:00Z
+users            3K  -
+`},
+		{FormatJSON, "", `
+[
+  {
+    "name": "orders",
+    "rows_synced": 12000,
+    "started": "2024-05-01T12:00:00Z"
+  },
+  {
+    "name": "users",
+    "rows_synced": 3000,
+    "started": null
+  }
+]
+`},
+		{"JSON", "", `
+[
+  {
+    "name": "orders",
+    "rows_synced": 12000,
+    "started": "2024-05-01T12:00:00Z"
+  },
+  {
+    "name": "users",
+    "rows_synced": 3000,
+    "started": null
+  }
+]
+`},
+		{FormatYAML, "", `
+- name: orders
+  rows_synced: 12000
+  started: 2024-05-01T12:00:00Z
+- name: users
+  rows_synced: 3000
+  started: null
+`},
+		{FormatCSV, "", `
+NAME,ROWS SYNCED,STARTED
+orders,12000,2024-05-01T12:00:00Z
+users,3000,
+`},
+		{FormatTemplate, "{{.name}}={{.rows_synced}}", `
+orders=12000
+users=3000
+`},
+		{FormatTemplate, "{{.name}}\n", `
+orders
+users
+`},
+		{"custom-columns=MIRROR:.name,ROWS:{.rows_synced}", "", `
+MIRROR  ROWS
+------------
+orders   12K
+users     3K
+`},
+		{"custom-columns=NAME:.name,SINCE:.since", "", `
+NAME    SINCE
+-------------
+orders  2024-05-01T12:00:00Z
+users   -
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+tt.template, func(t *testing.T) {
+			p, err := New(tt.format, tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := p.Print(&out, sampleTable()); err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.TrimPrefix(tt.want, "\n"); out.String() != want {
+				t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestPrintEmpty(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatTable, "NAME  ROWS SYNCED  STARTED\n--------------------------\n"},
+		{FormatJSON, "[]\n"},
+		{FormatYAML, "[]\n"},
+		{FormatCSV, "NAME,ROWS SYNCED,STARTED\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			p, err := New(tt.format, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			table := sampleTable()
+			table.Rows = nil
+			var out bytes.Buffer
+			if err := p.Print(&out, table); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		format   string
+		template string
+		wantErr  string
+	}{
+		{"xml", "", "unsupported output format: xml"},
+		{FormatTemplate, "", "-o template requires --template"},
+		{FormatTemplate, "{{.name", "invalid --template"},
+		{FormatCustomColumns, "", "-o custom-columns requires columns"},
+		{"custom-columns=", "", "-o custom-columns requires columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+tt.template, func(t *testing.T) {
+			_, err := New(tt.format, tt.template)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplateMissingKey(t *testing.T) {
+	p, err := New(FormatTemplate, "{{.lag}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Print(&bytes.Buffer{}, sampleTable()); err == nil {
+		t.Error("got no error for a key that isn't a column")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []string
+		wantErr string
+	}{
+		{names: []string{"name"}, want: []string{"name"}},
+		{names: []string{"started", "NAME"}, want: []string{"started", "name"}},
+		{names: []string{"ROWS SYNCED"}, want: []string{"rows_synced"}},
+		{names: []string{"rows-synced"}, want: []string{"rows_synced"}},
+		{names: []string{" Since "}, want: []string{"started"}},
+		{names: []string{"lag"}, wantErr: `unknown column "lag" (available: name, rows_synced, started)`},
+		{names: []string{"name", "rows_synced", "NAME"}, wantErr: `column "NAME" is listed twice`},
+		{names: []string{"started", "since"}, wantErr: `column "since" is listed twice`},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.names, ","), func(t *testing.T) {
+			got, err := sampleTable().Select(tt.names)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var keys []string
+			for _, column := range got.Columns {
+				keys = append(keys, column.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got columns %v, want %v", keys, tt.want)
+			}
+			for r, row := range got.Rows {
+				if len(row) != len(tt.want) {
+					t.Errorf("row %d has %d values, want %d", r, len(row), len(tt.want))
+				}
+			}
+			if got.Rows[0][0] != sampleTable().Rows[0][columnIndex(t, tt.want[0])] {
+				t.Errorf("got %v in the first cell", got.Rows[0][0])
+			}
+		})
+	}
+}
+
+// columnIndex returns the index of a column of sampleTable by key
+func columnIndex(t *testing.T, key string) int {
+	t.Helper()
+	for i, column := range sampleTable().Columns {
+		if column.Key == key {
+			return i
+		}
+	}
+	t.Fatalf("no column %s", key)
+	return -1
+}
+
+func TestParseCustomColumns(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []CustomColumn
+		wantErr bool
+	}{
+		{spec: "NAME:.name", want: []CustomColumn{{"NAME", "name"}}},
+		{spec: "NAME:.name,STATE:.state", want: []CustomColumn{{"NAME", "name"}, {"STATE", "state"}}},
+		{spec: " NAME : .name , ROWS:{.rows_synced}", want: []CustomColumn{{"NAME", "name"}, {"ROWS", "rows_synced"}}},
+		{spec: "", wantErr: true},
+		{spec: "NAME", wantErr: true},
+		{spec: ":.name", wantErr: true},
+		{spec: "NAME:name", wantErr: true},
+		{spec: "NAME:.", wantErr: true},
+		{spec: "NAME:.name,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseCustomColumns(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if fmt.Sprint(got.Columns) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got.Columns, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomColumnsUnknownField(t *testing.T) {
+	p, err := New("custom-columns=NAME:.name,LAG:.lag", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Print(&bytes.Buffer{}, sampleTable())
+	if err == nil || !strings.Contains(err.Error(), `unknown column "lag"`) {
+		t.Errorf("got error %v, want an unknown column", err)
+	}
+}
+
+func TestReportFormat(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"JSON", FormatJSON, false},
+		{"yaml", FormatYAML, false},
+		{"csv", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := ReportFormat(tt.output, FormatJSON, FormatYAML)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintReport(t *testing.T) {
+	report := struct {
+		Name   string   `json:"name"`
+		Tables []string `json:"tables"`
+		Lag    *int     `json:"lag,omitempty"`
+	}{Name: "orders", Tables: []string{"public.orders"}}
+
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{FormatJSON, "{\n  \"name\": \"orders\",\n  \"tables\": [\n    \"public.orders\"\n  ]\n}\n", false},
+		{FormatYAML, "name: orders\ntables:\n  - public.orders\n", false},
+		{FormatText, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			err := PrintReport(&out, tt.format, report)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
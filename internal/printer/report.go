@@ -0,0 +1,68 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatText is the human-readable output of commands that print a report,
+// such as a status, rather than a table
+const FormatText = "text"
+
+// ReportFormat returns the report format selected by --output: text when
+// it is empty, or one of formats
+func ReportFormat(output string, formats ...string) (string, error) {
+	output = strings.ToLower(output)
+	if output == "" || output == FormatText {
+		return FormatText, nil
+	}
+	for _, format := range formats {
+		if output == format {
+			return output, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format: %s (expected %s)", output, strings.Join(append([]string{FormatText}, formats...), ", "))
+}
+
+// PrintReport writes v as indented JSON, or as YAML with the same field
+// names and order. Commands print the text format themselves.
+func PrintReport(w io.Writer, format string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatJSON:
+		_, err := fmt.Fprintln(w, string(data))
+		return err
+	case FormatYAML:
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+
+	// JSON is YAML in flow style; switch to block style to print it
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle clears the flow and quoting styles of a node and its children,
+// so the encoder picks the plainest style for each
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
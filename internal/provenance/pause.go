@@ -0,0 +1,59 @@
+package provenance
+
+import (
+	"slices"
+	"time"
+)
+
+// Pause annotations record why a mirror was paused. Resuming clears them.
+const (
+	PauseReasonKey = Prefix + "pause_reason"
+	PausedByKey    = Prefix + "paused_by"
+	PausedAtKey    = Prefix + "paused_at"
+)
+
+var pauseKeys = []string{PauseReasonKey, PausedByKey, PausedAtKey}
+
+// PauseNote is why, by whom, and when a mirror was paused
+type PauseNote struct {
+	Reason string
+	By     string
+	At     time.Time
+}
+
+// PauseAnnotations returns the env entries recording a pause for reason
+func PauseAnnotations(reason string, at time.Time) map[string]string {
+	return map[string]string{
+		PauseReasonKey: reason,
+		PausedByKey:    CurrentUser(),
+		PausedAtKey:    at.UTC().Format(time.RFC3339),
+	}
+}
+
+// ClearedPauseAnnotations returns the env entries clearing a pause note.
+// PeerDB can update a mirror's env but not remove entries, so they are
+// set to empty values.
+func ClearedPauseAnnotations() map[string]string {
+	cleared := make(map[string]string, len(pauseKeys))
+	for _, key := range pauseKeys {
+		cleared[key] = ""
+	}
+	return cleared
+}
+
+// ExtractPauseNote returns the pause note recorded in env, if any
+func ExtractPauseNote(env map[string]string) (PauseNote, bool) {
+	reason := env[PauseReasonKey]
+	if reason == "" {
+		return PauseNote{}, false
+	}
+	note := PauseNote{Reason: reason, By: env[PausedByKey]}
+	note.At, _ = time.Parse(time.RFC3339, env[PausedAtKey])
+	return note, true
+}
+
+// isPauseKey reports whether key belongs to a pause note, which status
+// shows on its own rather than with the other annotations
+func isPauseKey(key string) bool {
+	return slices.Contains(pauseKeys, key)
+}
@@ -0,0 +1,107 @@
+package provenance
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prefix namespaces provenance annotations within a mirror's env
+const Prefix = "mirror_cli."
+
+// Collect returns provenance annotations for a resource applied from dir,
+// auto-detecting the user and git revision. Explicit annotations override
+// detected values.
+func Collect(dir string, annotations map[string]string) map[string]string {
+	result := map[string]string{
+		Prefix + "applied_at": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if appliedBy := CurrentUser(); appliedBy != "" {
+		result[Prefix+"applied_by"] = appliedBy
+	}
+	if sha := gitOutput(dir, "rev-parse", "HEAD"); sha != "" {
+		result[Prefix+"git_sha"] = sha
+	}
+
+	for key, value := range annotations {
+		if !strings.HasPrefix(key, Prefix) {
+			key = Prefix + key
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+// RevisionChanged reports whether annotations record a git commit other
+// than the one recorded in env
+func RevisionChanged(env, annotations map[string]string) bool {
+	sha := annotations[Prefix+"git_sha"]
+	return sha != "" && env[Prefix+"git_sha"] != sha
+}
+
+// ParseAnnotations parses key=value pairs as passed to --annotate
+func ParseAnnotations(pairs []string) (map[string]string, error) {
+	annotations := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid annotation: %s (expected: key=value)", pair)
+		}
+		annotations[strings.TrimSpace(key)] = value
+	}
+	return annotations, nil
+}
+
+// Merge adds annotations to env, returning the combined map
+func Merge(env, annotations map[string]string) map[string]string {
+	merged := make(map[string]string, len(env)+len(annotations))
+	for key, value := range env {
+		merged[key] = value
+	}
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Extract returns the provenance annotations found in env as sorted
+// "key=value" strings with the prefix removed. Cleared annotations and
+// pause notes are left out.
+func Extract(env map[string]string) []string {
+	var entries []string
+	for key, value := range env {
+		if strings.HasPrefix(key, Prefix) && value != "" && !isPauseKey(key) {
+			entries = append(entries, fmt.Sprintf("%s=%s", strings.TrimPrefix(key, Prefix), value))
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// CurrentUser returns the user running the CLI as user@host
+func CurrentUser() string {
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil && name != "" {
+		return name + "@" + host
+	}
+	return name
+}
+
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,368 @@
+// Package reconcile holds the pieces of the reconcile daemon that don't
+// depend on the CLI: leader election locks and Prometheus metrics.
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Lock elects a single leader among reconcile processes. Acquire takes the
+// lock or renews it if it is already held, and reports whether this process
+// is the leader. A lock that isn't renewed within its TTL expires, so
+// another process can take over from one that died.
+type Lock interface {
+	Acquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// Identity returns a holder identity for this process, e.g. host-1234
+func Identity() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// NoLock is used when a single reconcile process runs; it always leads
+type NoLock struct{}
+
+// Acquire always succeeds
+func (NoLock) Acquire(context.Context) (bool, error) { return true, nil }
+
+// Release does nothing
+func (NoLock) Release(context.Context) error { return nil }
+
+// FileLock is a lease kept in a file, for processes sharing a filesystem
+// such as a volume mounted into several pods
+type FileLock struct {
+	Path     string
+	Identity string
+	TTL      time.Duration
+}
+
+// fileLease is the content of a FileLock file
+type fileLease struct {
+	Holder    string    `json:"holder"`
+	RenewedAt time.Time `json:"renewed_at"`
+}
+
+// Acquire takes the lock if it is free, expired, or already ours
+func (l *FileLock) Acquire(context.Context) (bool, error) {
+	now := time.Now()
+	// Create the file exclusively, so two processes can't both take a free
+	// lock
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		defer f.Close()
+		if err := json.NewEncoder(f).Encode(fileLease{Holder: l.Identity, RenewedAt: now}); err != nil {
+			return false, fmt.Errorf("failed to write lock file: %w", err)
+		}
+		return true, nil
+	}
+	if !errors.Is(err, os.ErrExist) {
+		return false, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	if ok, err := l.replaceable(now); !ok || err != nil {
+		return false, err
+	}
+
+	// Renew or take over while holding the guard, checking the lease again
+	// under it, so two processes can't both replace an expired lease
+	release, ok, err := l.guard(now)
+	if !ok || err != nil {
+		return false, err
+	}
+	defer release()
+	if ok, err := l.replaceable(now); !ok || err != nil {
+		return false, err
+	}
+
+	data, err := json.Marshal(fileLease{Holder: l.Identity, RenewedAt: now})
+	if err != nil {
+		return false, err
+	}
+	tmp := fmt.Sprintf("%s.%s.tmp", l.Path, l.Identity)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmp, l.Path); err != nil {
+		return false, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return true, nil
+}
+
+// replaceable reports whether the lease is expired or ours. A lease
+// released meanwhile isn't; the next pass creates it again.
+func (l *FileLock) replaceable(now time.Time) (bool, error) {
+	data, err := os.ReadFile(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var lease fileLease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return false, fmt.Errorf("failed to parse lock file %s: %w", l.Path, err)
+	}
+	return lease.Holder == l.Identity || now.Sub(lease.RenewedAt) >= l.TTL, nil
+}
+
+// guard takes the guard file next to the lock by creating it exclusively,
+// returning a func that removes it. It reports false while another process
+// holds it. A guard left behind by a process that died holding it is
+// removed once older than the TTL, for the next pass to take.
+func (l *FileLock) guard(now time.Time) (func(), bool, error) {
+	path := l.Path + ".guard"
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) > l.TTL {
+			os.Remove(path)
+		}
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create lock guard: %w", err)
+	}
+	f.Close()
+	return func() { os.Remove(path) }, true, nil
+}
+
+// Release removes the lock file if this process holds it
+func (l *FileLock) Release(context.Context) error {
+	release, ok, err := l.guard(time.Now())
+	if !ok || err != nil {
+		// Left to expire
+		return err
+	}
+	defer release()
+	data, err := os.ReadFile(l.Path)
+	if err != nil {
+		return nil
+	}
+	var lease fileLease
+	if json.Unmarshal(data, &lease) == nil && lease.Holder == l.Identity {
+		return os.Remove(l.Path)
+	}
+	return nil
+}
+
+// serviceAccountDir holds the credentials Kubernetes mounts into pods
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubeLease is a Kubernetes coordination.k8s.io Lease, for reconcile
+// running as a Deployment with several replicas. Updates are conditional on
+// the lease's resourceVersion, so two replicas can't both take it.
+type KubeLease struct {
+	Name      string
+	Namespace string
+	Identity  string
+	TTL       time.Duration
+
+	server string
+	token  string
+	http   *http.Client
+}
+
+// NewKubeLease returns a lease using the pod's in-cluster service account.
+// The namespace defaults to the pod's own.
+func NewKubeLease(name, namespace, identity string, ttl time.Duration) (*KubeLease, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("--lease needs to run inside Kubernetes (KUBERNETES_SERVICE_HOST is not set)")
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse service account CA")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pod namespace; set --lease-namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	return &KubeLease{
+		Name:      name,
+		Namespace: namespace,
+		Identity:  identity,
+		TTL:       ttl,
+		server:    "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// lease is the subset of a coordination.k8s.io/v1 Lease used here
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+}
+
+// microTime is the timestamp format of Lease times
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// Acquire creates the lease, renews it, or takes it over once expired
+func (l *KubeLease) Acquire(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+	current, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil {
+		desired := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: l.Name, Namespace: l.Namespace},
+			Spec:       l.spec(now, now),
+		}
+		status, err := l.do(ctx, http.MethodPost, l.collectionURL(), desired, nil)
+		if status == http.StatusConflict {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	acquired := now
+	if current.Spec.HolderIdentity == l.Identity {
+		if t, err := time.Parse(microTime, current.Spec.AcquireTime); err == nil {
+			acquired = t
+		}
+	} else if renewed, err := time.Parse(microTime, current.Spec.RenewTime); err == nil {
+		ttl := time.Duration(current.Spec.LeaseDurationSeconds) * time.Second
+		if now.Sub(renewed) < ttl {
+			return false, nil
+		}
+	}
+
+	current.Spec = l.spec(acquired, now)
+	status, err := l.do(ctx, http.MethodPut, l.collectionURL()+"/"+l.Name, current, nil)
+	if status == http.StatusConflict {
+		// Someone else updated the lease first
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release gives up the lease by clearing its holder, if this process holds it
+func (l *KubeLease) Release(ctx context.Context) error {
+	current, err := l.get(ctx)
+	if err != nil || current == nil || current.Spec.HolderIdentity != l.Identity {
+		return err
+	}
+	current.Spec = leaseSpec{}
+	_, err = l.do(ctx, http.MethodPut, l.collectionURL()+"/"+l.Name, current, nil)
+	return err
+}
+
+func (l *KubeLease) spec(acquired, renewed time.Time) leaseSpec {
+	return leaseSpec{
+		HolderIdentity:       l.Identity,
+		LeaseDurationSeconds: int(l.TTL.Seconds()),
+		AcquireTime:          acquired.Format(microTime),
+		RenewTime:            renewed.Format(microTime),
+	}
+}
+
+func (l *KubeLease) collectionURL() string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", l.server, l.Namespace)
+}
+
+func (l *KubeLease) get(ctx context.Context) (*lease, error) {
+	var current lease
+	status, err := l.do(ctx, http.MethodGet, l.collectionURL()+"/"+l.Name, nil, &current)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// do sends a request to the Kubernetes API, returning the HTTP status and
+// an error for non-2xx responses
+func (l *KubeLease) do(ctx context.Context, method, url string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach the Kubernetes API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("lease %s/%s: %s %s", l.Namespace, l.Name, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse lease: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
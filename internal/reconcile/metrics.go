@@ -0,0 +1,159 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics are the reconcile daemon's Prometheus metrics, served in the
+// text exposition format
+type Metrics struct {
+	mu sync.Mutex
+
+	leader      bool
+	runs        map[string]int64 // by result
+	changes     map[[2]string]int64
+	lastRun     time.Time
+	lastSuccess time.Time
+	duration    time.Duration
+	configs     int
+	drifted     int
+	failed      int
+	repo        string
+	sha         string
+}
+
+// NewMetrics returns empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{
+		runs:    make(map[string]int64),
+		changes: make(map[[2]string]int64),
+	}
+}
+
+// SetLeader records whether this process holds the lock
+func (m *Metrics) SetLeader(leader bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leader = leader
+}
+
+// Change counts an action (create, update, edit) taken on a kind
+func (m *Metrics) Change(kind, action string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changes[[2]string{kind, action}]++
+}
+
+// SetRevision records the git commit configs were last fetched at, and
+// reports whether it changed
+func (m *Metrics) SetRevision(repo, sha string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := m.repo != repo || m.sha != sha
+	m.repo, m.sha = repo, sha
+	return changed
+}
+
+// RunResult summarizes one reconcile pass
+type RunResult struct {
+	Started  time.Time
+	Duration time.Duration
+	Configs  int
+	// Drifted counts resources that differ from their config in ways
+	// reconcile can't change
+	Drifted int
+	// Failed counts configs that couldn't be applied
+	Failed int
+	Err    error
+}
+
+// Run records a reconcile pass
+func (m *Metrics) Run(result RunResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastRun = result.Started
+	m.duration = result.Duration
+	m.configs = result.Configs
+	m.drifted = result.Drifted
+	m.failed = result.Failed
+	if result.Err != nil || result.Failed > 0 {
+		m.runs["error"]++
+		return
+	}
+	m.runs["success"]++
+	m.lastSuccess = result.Started
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+	timestamp := func(t time.Time) float64 {
+		if t.IsZero() {
+			return 0
+		}
+		return float64(t.UnixMilli()) / 1000
+	}
+
+	leader := 0.0
+	if m.leader {
+		leader = 1
+	}
+	gauge("mirror_cli_reconcile_leader", "Whether this process holds the reconcile lock.", leader)
+
+	b.WriteString("# HELP mirror_cli_reconcile_runs_total Reconcile passes by result.\n# TYPE mirror_cli_reconcile_runs_total counter\n")
+	for _, result := range []string{"success", "error"} {
+		fmt.Fprintf(&b, "mirror_cli_reconcile_runs_total{result=%q} %d\n", result, m.runs[result])
+	}
+
+	b.WriteString("# HELP mirror_cli_reconcile_changes_total Changes applied to PeerDB by kind and action.\n# TYPE mirror_cli_reconcile_changes_total counter\n")
+	keys := make([][2]string, 0, len(m.changes))
+	for key := range m.changes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "mirror_cli_reconcile_changes_total{kind=%q,action=%q} %d\n", key[0], key[1], m.changes[key])
+	}
+
+	gauge("mirror_cli_reconcile_last_run_timestamp_seconds", "Start time of the last reconcile pass.", timestamp(m.lastRun))
+	gauge("mirror_cli_reconcile_last_success_timestamp_seconds", "Start time of the last pass without errors.", timestamp(m.lastSuccess))
+	gauge("mirror_cli_reconcile_duration_seconds", "Duration of the last reconcile pass.", m.duration.Seconds())
+	gauge("mirror_cli_reconcile_configs", "Peer and mirror configs found in the last pass.", float64(m.configs))
+	gauge("mirror_cli_reconcile_drifted_resources", "Resources that differ from their config in ways reconcile can't change.", float64(m.drifted))
+	gauge("mirror_cli_reconcile_failed_configs", "Configs that failed to apply in the last pass.", float64(m.failed))
+	if m.sha != "" {
+		b.WriteString("# HELP mirror_cli_reconcile_git_info Git commit configs were last fetched at.\n# TYPE mirror_cli_reconcile_git_info gauge\n")
+		fmt.Fprintf(&b, "mirror_cli_reconcile_git_info{repo=%q,sha=%q} 1\n", m.repo, m.sha)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the metrics on /metrics and a liveness check on /healthz
+func (m *Metrics) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.WriteTo(w)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok\n")
+	})
+	return mux
+}
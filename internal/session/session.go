@@ -0,0 +1,270 @@
+// Package session records CLI invocations, with the RPCs they sent and the
+// server's responses, to a YAML file that can be reviewed and replayed,
+// e.g. to have a change approved before running it again in production
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secrets in recorded arguments and messages
+const Redacted = "<redacted>"
+
+// Session is a recorded sequence of commands
+type Session struct {
+	Commands []Command `yaml:"commands"`
+}
+
+// Command is one recorded CLI invocation
+type Command struct {
+	// Args are the arguments after the program name, with --record
+	// removed and secrets redacted
+	Args     []string  `yaml:"args"`
+	User     string    `yaml:"user,omitempty"`
+	Context  string    `yaml:"context,omitempty"`
+	Endpoint string    `yaml:"endpoint,omitempty"`
+	Started  time.Time `yaml:"started"`
+	Duration string    `yaml:"duration"`
+	Error    string    `yaml:"error,omitempty"`
+	Calls    []Call    `yaml:"calls,omitempty"`
+}
+
+// Call is one RPC a command sent
+type Call struct {
+	Method   string      `yaml:"method"`
+	Mutating bool        `yaml:"mutating,omitempty"`
+	Request  interface{} `yaml:"request,omitempty"`
+	Response interface{} `yaml:"response,omitempty"`
+	Error    string      `yaml:"error,omitempty"`
+}
+
+// Redacts reports whether a command has redacted arguments, which can't
+// be replayed as recorded
+func (c Command) Redacts() bool {
+	for _, arg := range c.Args {
+		if strings.Contains(arg, Redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// Recorder collects the RPCs of the running command
+type Recorder struct {
+	isLookup func(method string) bool
+
+	mu      sync.Mutex
+	command Command
+}
+
+// NewRecorder starts recording a command with args, the arguments after
+// the program name. isLookup tells read-only RPCs from mutating ones.
+func NewRecorder(args []string, isLookup func(method string) bool) *Recorder {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	return &Recorder{
+		isLookup: isLookup,
+		command: Command{
+			Args:    RedactArgs(StripRecordFlag(args)),
+			User:    user,
+			Started: time.Now().UTC(),
+		},
+	}
+}
+
+// Record adds an RPC with its response, or the error it failed with
+func (r *Recorder) Record(method string, req, reply interface{}, err error) {
+	call := Call{
+		Method:   strings.TrimPrefix(method, "/"),
+		Mutating: !r.isLookup(method),
+		Request:  messageValue(req),
+	}
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.Response = messageValue(reply)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.command.Calls = append(r.command.Calls, call)
+}
+
+// Finish completes the recorded command and appends it to the session at
+// path, creating the file if needed
+func (r *Recorder) Finish(path, context, endpoint string, err error) error {
+	r.mu.Lock()
+	command := r.command
+	r.mu.Unlock()
+
+	command.Context = context
+	command.Endpoint = endpoint
+	command.Duration = time.Since(command.Started).Round(time.Millisecond).String()
+	if err != nil {
+		command.Error = err.Error()
+	}
+
+	s, loadErr := Load(path)
+	if errors.Is(loadErr, os.ErrNotExist) {
+		s, loadErr = &Session{}, nil
+	}
+	if loadErr != nil {
+		return loadErr
+	}
+	s.Commands = append(s.Commands, command)
+	return Save(s, path)
+}
+
+// Load reads a session file
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes a session file, replacing the previous one
+func Save(s *Session, path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+	// Write and rename, so a failed save never loses recorded commands
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// StripRecordFlag removes --record and its value from args
+func StripRecordFlag(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--record":
+			i++
+		case strings.HasPrefix(args[i], "--record="):
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// RedactArgs replaces the values of flags that hold secrets, such as
+// --password and --sf-private-key, and of --header values that look like
+// credentials
+func RedactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i := 0; i < len(out); i++ {
+		name, value, hasValue := strings.Cut(out[i], "=")
+		if !strings.HasPrefix(name, "--") {
+			continue
+		}
+		name = strings.TrimPrefix(name, "--")
+		if !secretFlag(name) {
+			continue
+		}
+		switch {
+		case hasValue:
+			out[i] = "--" + name + "=" + redactFlagValue(name, value)
+		case i+1 < len(out):
+			i++
+			out[i] = redactFlagValue(name, out[i])
+		}
+	}
+	return out
+}
+
+func secretFlag(name string) bool {
+	return name == "password" || name == "header" ||
+		strings.HasSuffix(name, "-password") || strings.HasSuffix(name, "-private-key")
+}
+
+// redactFlagValue redacts a secret flag's value; headers keep their name
+// and are only redacted when it looks like a credential
+func redactFlagValue(flag, value string) string {
+	if flag != "header" {
+		return Redacted
+	}
+	key, _, _ := strings.Cut(value, "=")
+	if secretKey(key) {
+		return key + "=" + Redacted
+	}
+	return value
+}
+
+// secretKey reports whether a field or header name holds a secret
+func secretKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, word := range []string{"password", "privatekey", "secret", "token", "authorization", "passphrase", "credential"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageValue converts a protobuf message to plain values that read well
+// as YAML, with secret fields redacted
+func messageValue(m interface{}) interface{} {
+	msg, ok := m.(proto.Message)
+	if !ok || msg == nil {
+		return nil
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	return redactValue(value)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, isString := field.(string); isString && secretKey(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return value
+}
@@ -0,0 +1,167 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/janakos/mirror_cli/proto/gen"
+)
+
+// Snapshot is the last-known state of a PeerDB deployment, kept on disk so
+// it can be inspected while the server is unreachable
+type Snapshot struct {
+	SavedAt  time.Time
+	Endpoint string
+	Context  string
+
+	Peers    *pb.ListPeersResponse
+	Mirrors  *pb.ListMirrorsResponse
+	Statuses map[string]*pb.MirrorStatusResponse
+
+	// Errors holds the mirrors whose status couldn't be fetched
+	Errors map[string]string
+}
+
+// file is the on-disk layout; responses are stored as protobuf JSON
+type file struct {
+	SavedAt  time.Time                  `json:"saved_at"`
+	Endpoint string                     `json:"endpoint"`
+	Context  string                     `json:"context,omitempty"`
+	Peers    json.RawMessage            `json:"peers"`
+	Mirrors  json.RawMessage            `json:"mirrors"`
+	Statuses map[string]json.RawMessage `json:"statuses,omitempty"`
+	Errors   map[string]string          `json:"errors,omitempty"`
+}
+
+// Dir returns the directory snapshots are saved in
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mirror_cli", "snapshots"), nil
+}
+
+// Path returns the default snapshot file for a deployment, named after the
+// context or, without one, the server endpoints
+func Path(context string, endpoints []string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	name := context
+	if name == "" {
+		name = strings.Join(endpoints, ",")
+	}
+	replacer := strings.NewReplacer(":", "_", "/", "_", "\\", "_", ",", "+")
+	return filepath.Join(dir, replacer.Replace(name)+".json"), nil
+}
+
+// Save writes a snapshot to path, replacing any previous one
+func Save(s *Snapshot, path string) error {
+	out := file{
+		SavedAt:  s.SavedAt,
+		Endpoint: s.Endpoint,
+		Context:  s.Context,
+		Errors:   s.Errors,
+	}
+
+	var err error
+	if out.Peers, err = marshal(s.Peers); err != nil {
+		return err
+	}
+	if out.Mirrors, err = marshal(s.Mirrors); err != nil {
+		return err
+	}
+	if len(s.Statuses) > 0 {
+		out.Statuses = make(map[string]json.RawMessage, len(s.Statuses))
+		for name, status := range s.Statuses {
+			if out.Statuses[name], err = marshal(status); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	// Write and rename, so a failed save never leaves a truncated snapshot
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot from path
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var in file
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	s := &Snapshot{
+		SavedAt:  in.SavedAt,
+		Endpoint: in.Endpoint,
+		Context:  in.Context,
+		Peers:    &pb.ListPeersResponse{},
+		Mirrors:  &pb.ListMirrorsResponse{},
+		Statuses: make(map[string]*pb.MirrorStatusResponse, len(in.Statuses)),
+		Errors:   in.Errors,
+	}
+	if err := unmarshal(in.Peers, s.Peers); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot peers: %w", err)
+	}
+	if err := unmarshal(in.Mirrors, s.Mirrors); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot mirrors: %w", err)
+	}
+	for name, raw := range in.Statuses {
+		status := &pb.MirrorStatusResponse{}
+		if err := unmarshal(raw, status); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot status of '%s': %w", name, err)
+		}
+		s.Statuses[name] = status
+	}
+
+	sort.Slice(s.Mirrors.Mirrors, func(i, j int) bool {
+		return s.Mirrors.Mirrors[i].Name < s.Mirrors.Mirrors[j].Name
+	})
+	return s, nil
+}
+
+func marshal(m proto.Message) (json.RawMessage, error) {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return data, nil
+}
+
+func unmarshal(data json.RawMessage, m proto.Message) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return protojson.Unmarshal(data, m)
+}
@@ -0,0 +1,189 @@
+// Package state records which PeerDB resources are managed from
+// configuration files, per context, so resources created by hand can be
+// told apart from those the CLI owns
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Resource is one managed peer or mirror
+type Resource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// File is the configuration file the resource is managed from
+	File string `json:"file,omitempty"`
+	// Adopted is set when the resource was created outside the CLI and
+	// adopted later
+	Adopted      bool       `json:"adopted,omitempty"`
+	ManagedSince time.Time  `json:"managed_since"`
+	LastApplied  *time.Time `json:"last_applied,omitempty"`
+	// SecretHash is the hash of a peer's configuration, secrets included,
+	// when it was last applied
+	SecretHash string `json:"secret_hash,omitempty"`
+}
+
+// Workflow is the Temporal workflow PeerDB started for a mirror the CLI
+// created
+type Workflow struct {
+	Mirror     string    `json:"mirror"`
+	WorkflowID string    `json:"workflow_id"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// State is the set of managed resources of one PeerDB deployment
+type State struct {
+	Endpoint  string     `json:"endpoint"`
+	Context   string     `json:"context,omitempty"`
+	Resources []Resource `json:"resources"`
+	// Workflows are kept apart from Resources: creating a mirror doesn't
+	// make it managed
+	Workflows []Workflow `json:"workflows,omitempty"`
+}
+
+// Dir returns the directory state files are kept in
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mirror_cli", "state"), nil
+}
+
+// Path returns the state file of a deployment, named after the context or,
+// without one, the server endpoints
+func Path(context string, endpoints []string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	name := context
+	if name == "" {
+		name = strings.Join(endpoints, ",")
+	}
+	replacer := strings.NewReplacer(":", "_", "/", "_", "\\", "_", ",", "+")
+	return filepath.Join(dir, replacer.Replace(name)+".json"), nil
+}
+
+// Load reads the state at path; a missing file is an empty state
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state to path, replacing the previous one
+func Save(s *State, path string) error {
+	sort.Slice(s.Resources, func(i, j int) bool {
+		if s.Resources[i].Kind != s.Resources[j].Kind {
+			return s.Resources[i].Kind < s.Resources[j].Kind
+		}
+		return s.Resources[i].Name < s.Resources[j].Name
+	})
+	sort.Slice(s.Workflows, func(i, j int) bool {
+		return s.Workflows[i].Mirror < s.Workflows[j].Mirror
+	})
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	// Write and rename, so a failed save never leaves a truncated state
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// Get returns the managed resource of a kind and name
+func (s *State) Get(kind, name string) (Resource, bool) {
+	for _, r := range s.Resources {
+		if r.Kind == kind && r.Name == name {
+			return r, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Manage records a resource as managed, keeping when it was first managed
+// and whether it was adopted
+func (s *State) Manage(r Resource) {
+	for i, existing := range s.Resources {
+		if existing.Kind == r.Kind && existing.Name == r.Name {
+			r.ManagedSince = existing.ManagedSince
+			r.Adopted = r.Adopted || existing.Adopted
+			if r.File == "" {
+				r.File = existing.File
+			}
+			if r.LastApplied == nil {
+				r.LastApplied = existing.LastApplied
+			}
+			if r.SecretHash == "" {
+				r.SecretHash = existing.SecretHash
+			}
+			s.Resources[i] = r
+			return
+		}
+	}
+	if r.ManagedSince.IsZero() {
+		r.ManagedSince = time.Now().UTC()
+	}
+	s.Resources = append(s.Resources, r)
+}
+
+// Forget removes a resource from the managed ones
+func (s *State) Forget(kind, name string) {
+	s.Resources = slices.DeleteFunc(s.Resources, func(r Resource) bool {
+		return r.Kind == kind && r.Name == name
+	})
+}
+
+// Workflow returns the workflow recorded for a mirror
+func (s *State) Workflow(mirror string) (Workflow, bool) {
+	for _, w := range s.Workflows {
+		if w.Mirror == mirror {
+			return w, true
+		}
+	}
+	return Workflow{}, false
+}
+
+// RecordWorkflow records the workflow of a mirror, replacing the one of a
+// previous mirror with the same name
+func (s *State) RecordWorkflow(w Workflow) {
+	if w.RecordedAt.IsZero() {
+		w.RecordedAt = time.Now().UTC()
+	}
+	for i, existing := range s.Workflows {
+		if existing.Mirror == w.Mirror {
+			s.Workflows[i] = w
+			return
+		}
+	}
+	s.Workflows = append(s.Workflows, w)
+}
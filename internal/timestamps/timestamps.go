@@ -0,0 +1,52 @@
+// Package timestamps converts the timestamps PeerDB reports to time.Time.
+//
+// Most messages use google.protobuf.Timestamp, which is unset for events
+// that haven't happened yet; AsTime turns that into the Unix epoch. The
+// mirror list reports creation times as a double of Unix milliseconds
+// instead, which some PeerDB versions fill with seconds.
+package timestamps
+
+import (
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Thresholds telling the units of a Unix time apart. A value below
+// msThreshold is in seconds: 1e11 seconds is in the year 5138, while 1e11
+// milliseconds is in 1973.
+const (
+	msThreshold = 1e11
+	usThreshold = 1e14
+)
+
+// FromProto returns the time of ts, or false when it is unset or invalid
+func FromProto(ts *timestamppb.Timestamp) (time.Time, bool) {
+	if ts == nil || ts.CheckValid() != nil || (ts.Seconds == 0 && ts.Nanos == 0) {
+		return time.Time{}, false
+	}
+	return ts.AsTime(), true
+}
+
+// FromUnix returns the time of a Unix timestamp in seconds, milliseconds,
+// or microseconds, telling them apart by magnitude. It returns false for
+// zero and values that aren't a time.
+func FromUnix(value float64) (time.Time, bool) {
+	if value <= 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return time.Time{}, false
+	}
+	var nanos float64
+	switch {
+	case value < msThreshold:
+		nanos = value * float64(time.Second)
+	case value < usThreshold:
+		nanos = value * float64(time.Millisecond)
+	default:
+		nanos = value * float64(time.Microsecond)
+	}
+	if nanos > math.MaxInt64 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(nanos)), true
+}
@@ -0,0 +1,184 @@
+package tuning
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	kb = int64(1) << 10
+	mb = kb << 10
+	gb = mb << 10
+	tb = gb << 10
+
+	// DefaultRowBytes is the average row size assumed when estimating row
+	// counts from table sizes
+	DefaultRowBytes = 200
+
+	// targetPartitionBytes is the amount of data each snapshot partition
+	// should hold
+	targetPartitionBytes = 256 * mb
+
+	minRowsPerPartition = 50_000
+	maxRowsPerPartition = 5_000_000
+)
+
+// Table is a source table and its on-disk size
+type Table struct {
+	Name  string
+	Bytes int64
+}
+
+// Settings are the tunable snapshot and CDC settings of a mirror. Zero
+// means unset (the server default).
+type Settings struct {
+	NumRowsPerPartition uint32
+	MaxParallelWorkers  uint32
+	NumTablesInParallel uint32
+	BatchSize           uint32
+}
+
+// Recommendation holds suggested settings and the reasoning behind them
+type Recommendation struct {
+	Settings
+	Reasons []string
+}
+
+// Recommend suggests settings for replicating tables, estimating row counts
+// as size divided by rowBytes
+func Recommend(tables []Table, rowBytes int64) Recommendation {
+	if rowBytes <= 0 {
+		rowBytes = DefaultRowBytes
+	}
+
+	var total, largest int64
+	for _, table := range tables {
+		total += table.Bytes
+		if table.Bytes > largest {
+			largest = table.Bytes
+		}
+	}
+
+	var rec Recommendation
+
+	// Workers split the largest table, so size them by it
+	switch {
+	case largest < 10*gb:
+		rec.MaxParallelWorkers = 4
+	case largest < 100*gb:
+		rec.MaxParallelWorkers = 8
+	default:
+		rec.MaxParallelWorkers = 16
+	}
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf("max_parallel_workers: largest table is %s", FormatSize(largest)))
+
+	// Partitions of about targetPartitionBytes, but small enough that the
+	// largest table still spreads across every worker
+	rows := targetPartitionBytes / rowBytes
+	if perWorker := largest / rowBytes / int64(rec.MaxParallelWorkers); perWorker < rows {
+		rows = perWorker
+	}
+	rec.NumRowsPerPartition = uint32(roundDown(clamp(rows, minRowsPerPartition, maxRowsPerPartition)))
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf("num_rows_per_partition: about %s per partition at %d bytes per row",
+		FormatSize(int64(rec.NumRowsPerPartition)*rowBytes), rowBytes))
+
+	// Many small tables snapshot faster side by side
+	tablesInParallel := 4
+	if largest < gb {
+		tablesInParallel = 8
+	}
+	if len(tables) < tablesInParallel {
+		tablesInParallel = len(tables)
+	}
+	if tablesInParallel < 1 {
+		tablesInParallel = 1
+	}
+	rec.NumTablesInParallel = uint32(tablesInParallel)
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf("num_tables_in_parallel: %d source table(s), largest %s", len(tables), FormatSize(largest)))
+
+	switch {
+	case total < 10*gb:
+		rec.BatchSize = 100_000
+	case total < 100*gb:
+		rec.BatchSize = 250_000
+	default:
+		rec.BatchSize = 1_000_000
+	}
+	rec.Reasons = append(rec.Reasons, fmt.Sprintf("batch_size: %s of source data in total", FormatSize(total)))
+
+	return rec
+}
+
+// ParseSize parses a size as formatted by PostgreSQL's pg_size_pretty,
+// e.g. "8192 bytes", "96 kB" or "42 GB"
+func ParseSize(s string) (int64, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	var unit int64
+	switch strings.ToLower(fields[1]) {
+	case "bytes", "byte", "b":
+		unit = 1
+	case "kb":
+		unit = kb
+	case "mb":
+		unit = mb
+	case "gb":
+		unit = gb
+	case "tb":
+		unit = tb
+	case "pb":
+		unit = tb << 10
+	default:
+		return 0, fmt.Errorf("invalid size unit in %q", s)
+	}
+
+	return int64(value * float64(unit)), nil
+}
+
+// FormatSize formats a byte count with a binary unit, e.g. "1.5 GB"
+func FormatSize(bytes int64) string {
+	units := []struct {
+		size int64
+		name string
+	}{{tb, "TB"}, {gb, "GB"}, {mb, "MB"}, {kb, "kB"}}
+
+	for _, unit := range units {
+		if bytes >= unit.size {
+			return strconv.FormatFloat(float64(bytes)/float64(unit.size), 'f', 1, 64) + " " + unit.name
+		}
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
+
+func clamp(n, min, max int64) int64 {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// roundDown rounds n down to 1, 2 or 5 times a power of ten
+func roundDown(n int64) int64 {
+	scale := int64(1)
+	for n >= scale*10 {
+		scale *= 10
+	}
+	for _, step := range []int64{5, 2, 1} {
+		if n >= step*scale {
+			return step * scale
+		}
+	}
+	return n
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
@@ -10,6 +11,11 @@ import (
 func main() {
 	if err := cmd.Execute(); err != nil {
 		log.Printf("Error: %v", err)
+
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }